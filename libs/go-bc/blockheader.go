@@ -51,6 +51,16 @@ func (bh *BlockHeader) HashMerkleRootStr() string {
 	return hex.EncodeToString(bh.HashMerkleRoot)
 }
 
+// Hash returns the hash of the Block Header, which is the block hash.
+func (bh *BlockHeader) Hash() []byte {
+	return bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))
+}
+
+// HashStr returns the hash of the Block Header encoded as hex string.
+func (bh *BlockHeader) HashStr() string {
+	return hex.EncodeToString(bh.Hash())
+}
+
 // BitsStr returns the Block Header encoded as hex string.
 func (bh *BlockHeader) BitsStr() string {
 	return hex.EncodeToString(bh.Bits)
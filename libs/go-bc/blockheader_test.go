@@ -130,6 +130,13 @@ func TestVerifyBlockHeader(t *testing.T) {
 	assert.False(t, genesisInvalid.Valid())
 }
 
+func TestBlockHeader_Hash(t *testing.T) {
+	genesis, err := bc.NewBlockHeaderFromStr("0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c")
+	assert.NoError(t, err)
+	assert.Equal(t, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", genesis.HashStr())
+	assert.Equal(t, genesis.HashStr(), hex.EncodeToString(genesis.Hash()))
+}
+
 func TestBlockHeader_MarshalJSON(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,334 @@
+package bc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/libsv/go-bt/v2"
+)
+
+// Sentinel errors returned when streaming a block.
+var (
+	// ErrMerkleRootMismatch is returned when the merkle root of the txs read
+	// does not match the one in the block header.
+	ErrMerkleRootMismatch = errors.New("merkle root of txs does not match block header")
+	// ErrBlockUnread is returned when verifying a block before all of its txs
+	// have been read.
+	ErrBlockUnread = errors.New("block has unread txs")
+)
+
+// TxInfo locates a tx within a block.
+type TxInfo struct {
+	// Index is the position of the tx in the block, the coinbase being 0.
+	Index uint64
+	// Offset is the position of the tx's first byte from the start of the block.
+	Offset uint64
+	// Size is the length of the tx in bytes.
+	Size uint64
+	// TxID is the id of the tx, in the byte order of bt.Tx.TxIDBytes.
+	TxID []byte
+}
+
+// A BlockReader decodes a block from a stream, one tx at a time, so that
+// blocks of any size can be processed without holding them in memory. At most
+// one tx is held at a time, and none when only TxInfo is read.
+//
+// The merkle root is built as txs are read, so that once the last tx has been
+// read Verify can check the txs against the block header.
+type BlockReader struct {
+	r       *bufio.Reader
+	header  *BlockHeader
+	txCount uint64
+	offset  uint64
+	merkle  *MerkleRootBuilder
+	err     error
+}
+
+// NewBlockReader reads the block header and tx count from r, returning a
+// BlockReader positioned at the first tx.
+func NewBlockReader(r io.Reader) (*BlockReader, error) {
+	br := &BlockReader{
+		r:      bufio.NewReader(r),
+		merkle: NewMerkleRootBuilder(),
+	}
+
+	b := make([]byte, 80)
+	if _, err := io.ReadFull(br.r, b); err != nil {
+		return nil, fmt.Errorf("failed to read block header: %w", err)
+	}
+	bh, err := NewBlockHeaderFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	br.header = bh
+
+	sr := &streamReader{r: br.r, w: ioutil.Discard}
+	if br.txCount, err = sr.readVarInt(); err != nil {
+		return nil, fmt.Errorf("failed to read tx count: %w", err)
+	}
+	br.offset = 80 + sr.n
+
+	return br, nil
+}
+
+// Header returns the block header.
+func (br *BlockReader) Header() *BlockHeader {
+	return br.header
+}
+
+// TxCount returns the number of txs in the block.
+func (br *BlockReader) TxCount() uint64 {
+	return br.txCount
+}
+
+// Next reads and decodes the next tx. io.EOF is returned once all txs have
+// been read.
+func (br *BlockReader) Next() (*bt.Tx, *TxInfo, error) {
+	var buf bytes.Buffer
+	info, err := br.next(&buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := bt.NewTxFromBytes(buf.Bytes())
+	if err != nil {
+		br.err = fmt.Errorf("failed to decode tx %d: %w", info.Index, err)
+		return nil, nil, br.err
+	}
+
+	return tx, info, nil
+}
+
+// NextTxInfo reads the next tx, returning only its id and location without
+// decoding or holding it. io.EOF is returned once all txs have been read.
+func (br *BlockReader) NextTxInfo() (*TxInfo, error) {
+	return br.next(ioutil.Discard)
+}
+
+// Verify checks that the merkle root of the txs read matches the block
+// header. It must be called after all txs have been read.
+func (br *BlockReader) Verify() error {
+	if br.err != nil {
+		return br.err
+	}
+	if br.merkle.Count() != br.txCount {
+		return fmt.Errorf("%w: read %d of %d", ErrBlockUnread, br.merkle.Count(), br.txCount)
+	}
+	if !bytes.Equal(br.merkle.Root(), br.header.HashMerkleRoot) {
+		return ErrMerkleRootMismatch
+	}
+
+	return nil
+}
+
+// next reads the next tx, writing its bytes to w.
+func (br *BlockReader) next(w io.Writer) (*TxInfo, error) {
+	if br.err != nil {
+		return nil, br.err
+	}
+	index := br.merkle.Count()
+	if index == br.txCount {
+		return nil, io.EOF
+	}
+
+	h := sha256.New()
+	sr := &streamReader{r: br.r, w: io.MultiWriter(h, w)}
+	if err := sr.readTx(); err != nil {
+		br.err = fmt.Errorf("failed to read tx %d: %w", index, err)
+		return nil, br.err
+	}
+
+	id := sha256.Sum256(h.Sum(nil))
+	br.merkle.add(id[:])
+	info := &TxInfo{
+		Index:  index,
+		Offset: br.offset,
+		Size:   sr.n,
+		TxID:   bt.ReverseBytes(id[:]),
+	}
+	br.offset += sr.n
+
+	return info, nil
+}
+
+// ReadBlockParams are the callbacks ReadBlock makes as it reads a block. Any
+// may be nil, and an error returned by any stops the read.
+type ReadBlockParams struct {
+	// Header is called with the block header and tx count before any txs.
+	Header func(bh *BlockHeader, txCount uint64) error
+	// Tx is called with each decoded tx.
+	Tx func(tx *bt.Tx, info *TxInfo) error
+	// TxInfo is called with the id and location of each tx. It is only used
+	// when Tx is nil, in which case txs are not decoded at all.
+	TxInfo func(info *TxInfo) error
+}
+
+// ReadBlock streams a block from r, calling back with the header and then
+// each tx in turn, before verifying the merkle root of the block.
+func ReadBlock(r io.Reader, params ReadBlockParams) (*BlockHeader, error) {
+	br, err := NewBlockReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if params.Header != nil {
+		if err = params.Header(br.Header(), br.TxCount()); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if params.Tx != nil {
+			var tx *bt.Tx
+			var info *TxInfo
+			if tx, info, err = br.Next(); err == nil {
+				err = params.Tx(tx, info)
+			}
+		} else {
+			var info *TxInfo
+			if info, err = br.NextTxInfo(); err == nil && params.TxInfo != nil {
+				err = params.TxInfo(info)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = br.Verify(); err != nil {
+		return nil, err
+	}
+
+	return br.Header(), nil
+}
+
+/*
+Field 											Purpose 																			Size (Bytes)
+----------------------------------------------------------------------------------------------------
+Version 								Tx version number 																			4
+In-counter 							Number of inputs 																			1 - 9 (VarInt)
+Inputs 									Previous outpoint (36), script length (VarInt), script, sequence (4)	 	-
+Out-counter 						Number of outputs 																			1 - 9 (VarInt)
+Outputs 								Satoshis (8), script length (VarInt), script 										-
+Locktime 								Block height or timestamp 																4
+*/
+
+// streamReader copies the fields of a tx from r to w, counting the bytes.
+type streamReader struct {
+	r   *bufio.Reader
+	w   io.Writer
+	n   uint64
+	buf [9]byte
+}
+
+func (sr *streamReader) readTx() error {
+	if err := sr.read(4); err != nil {
+		return err
+	}
+
+	inputs, err := sr.readVarInt()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < inputs; i++ {
+		if err = sr.read(36); err != nil {
+			return err
+		}
+		if err = sr.readScript(); err != nil {
+			return err
+		}
+		if err = sr.read(4); err != nil {
+			return err
+		}
+	}
+
+	outputs, err := sr.readVarInt()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < outputs; i++ {
+		if err = sr.read(8); err != nil {
+			return err
+		}
+		if err = sr.readScript(); err != nil {
+			return err
+		}
+	}
+
+	return sr.read(4)
+}
+
+func (sr *streamReader) readScript() error {
+	l, err := sr.readVarInt()
+	if err != nil {
+		return err
+	}
+
+	return sr.read(l)
+}
+
+// read copies n bytes. Copying rather than reading into a buffer of size n
+// means a corrupt length fails on EOF instead of allocating.
+func (sr *streamReader) read(n uint64) error {
+	if n > math.MaxInt64 {
+		return fmt.Errorf("invalid length %d", n)
+	}
+	c, err := io.CopyN(sr.w, sr.r, int64(n))
+	sr.n += uint64(c)
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+func (sr *streamReader) readVarInt() (uint64, error) {
+	b, err := sr.r.ReadByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	sr.buf[0] = b
+	size := 1
+	switch b {
+	case 0xfd:
+		size = 3
+	case 0xfe:
+		size = 5
+	case 0xff:
+		size = 9
+	}
+	if _, err = io.ReadFull(sr.r, sr.buf[1:size]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	if _, err = sr.w.Write(sr.buf[:size]); err != nil {
+		return 0, err
+	}
+	sr.n += uint64(size)
+
+	switch size {
+	case 3:
+		return uint64(binary.LittleEndian.Uint16(sr.buf[1:3])), nil
+	case 5:
+		return uint64(binary.LittleEndian.Uint32(sr.buf[1:5])), nil
+	case 9:
+		return binary.LittleEndian.Uint64(sr.buf[1:9]), nil
+	}
+
+	return uint64(b), nil
+}
@@ -0,0 +1,291 @@
+package bc
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+)
+
+// Sentinel errors returned when adding headers to a HeaderChain.
+var (
+	// ErrHeaderInvalidPoW is returned when a header's hash does not meet the target in its bits.
+	ErrHeaderInvalidPoW = errors.New("header hash does not meet its target")
+	// ErrHeaderOrphan is returned when a header's previous block is not in the chain.
+	ErrHeaderOrphan = errors.New("header does not follow a known header")
+)
+
+// maxTarget is 2^256, used to find the work represented by a target.
+var maxTarget = new(big.Int).Lsh(big.NewInt(1), 256)
+
+type chainEntry struct {
+	header *BlockHeader
+	prev   *chainEntry
+	height uint64
+	// work is the total work of the chain ending at this header.
+	work *big.Int
+}
+
+// A HeaderChain is a BlockHeaderChain built from raw 80 byte headers. Each
+// header added must meet its proof of work target and follow a header
+// already in the chain, except the first which is the root of the chain,
+// normally the genesis block. Forks are kept, and the chain with the most
+// work is the longest chain.
+//
+// The bits of each header are checked only against the header's own hash,
+// not against the difficulty adjustment rules.
+//
+// Heights are counted from the root of the chain.
+type HeaderChain struct {
+	mu      sync.RWMutex
+	entries map[string]*chainEntry
+	// order holds the entries in the order added, so that every header
+	// follows its previous header.
+	order []*chainEntry
+	// longest holds the entries of the longest chain by height.
+	longest []*chainEntry
+	f       *os.File
+}
+
+var _ BlockHeaderChain = (*HeaderChain)(nil)
+
+// NewHeaderChain creates an empty in memory HeaderChain.
+func NewHeaderChain() *HeaderChain {
+	return &HeaderChain{
+		entries: make(map[string]*chainEntry),
+	}
+}
+
+// OpenHeaderChain opens a HeaderChain stored in a file of raw 80 byte headers,
+// creating the file if needed. Headers added are appended to the file, so a
+// headers snapshot can be used as is and kept up to date.
+//
+// A partly written header at the end of the file is discarded.
+func OpenHeaderChain(path string) (*HeaderChain, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewHeaderChain()
+	n, err := c.ReadFrom(f)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err = f.Truncate(n); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if _, err = f.Seek(n, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	c.f = f
+
+	return c, nil
+}
+
+// Close closes the file backing the chain, if any.
+func (c *HeaderChain) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.f == nil {
+		return nil
+	}
+	if err := c.f.Sync(); err != nil {
+		_ = c.f.Close()
+		return err
+	}
+	err := c.f.Close()
+	c.f = nil
+
+	return err
+}
+
+// Add adds a header to the chain, switching the longest chain to it if
+// it is now the tip of the chain with the most work. Adding a header which
+// is already in the chain does nothing.
+func (c *HeaderChain) Add(bh *BlockHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := bh.HashStr()
+	if _, ok := c.entries[hash]; ok {
+		return nil
+	}
+	if !bh.Valid() {
+		return fmt.Errorf("%w: %s", ErrHeaderInvalidPoW, hash)
+	}
+
+	e := &chainEntry{header: bh}
+	if len(c.entries) > 0 {
+		prev, ok := c.entries[bh.HashPrevBlockStr()]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrHeaderOrphan, hash)
+		}
+		e.prev = prev
+		e.height = prev.height + 1
+	}
+	work, err := headerWork(bh)
+	if err != nil {
+		return err
+	}
+	e.work = work
+	if e.prev != nil {
+		e.work.Add(e.work, e.prev.work)
+	}
+
+	if c.f != nil {
+		if _, err = c.f.Write(bh.Bytes()); err != nil {
+			return err
+		}
+	}
+	c.entries[hash] = e
+	c.order = append(c.order, e)
+	if len(c.longest) == 0 || e.work.Cmp(c.longest[len(c.longest)-1].work) > 0 {
+		c.setTip(e)
+	}
+
+	return nil
+}
+
+// ReadFrom adds the raw 80 byte headers read from r until EOF, returning the
+// number of bytes of the headers added.
+func (c *HeaderChain) ReadFrom(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	for {
+		b := make([]byte, 80)
+		if _, err := io.ReadFull(br, b); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, err
+		}
+		bh, err := NewBlockHeaderFromBytes(b)
+		if err != nil {
+			return n, err
+		}
+		if err = c.Add(bh); err != nil {
+			return n, err
+		}
+		n += 80
+	}
+}
+
+// WriteTo writes every header in the chain, including those on forks, as
+// raw 80 byte headers which can be read back with ReadFrom.
+func (c *HeaderChain) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var n int64
+	for _, e := range c.order {
+		l, err := w.Write(e.header.Bytes())
+		n += int64(l)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// BlockHeader returns the header with the block hash given.
+// ErrHeaderNotFound is returned if the header isn't in the chain, and
+// ErrNotOnLongestChain if it is only on a fork.
+func (c *HeaderChain) BlockHeader(ctx context.Context, blockHash string) (*BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[blockHash]
+	if !ok {
+		return nil, ErrHeaderNotFound
+	}
+	if !c.onLongest(e) {
+		return nil, ErrNotOnLongestChain
+	}
+
+	return e.header, nil
+}
+
+// HeaderByHeight returns the header at a height of the longest chain.
+func (c *HeaderChain) HeaderByHeight(height uint64) (*BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if height >= uint64(len(c.longest)) {
+		return nil, ErrHeaderNotFound
+	}
+
+	return c.longest[height].header, nil
+}
+
+// Tip returns the last header of the longest chain and its height, or nil
+// if the chain is empty.
+func (c *HeaderChain) Tip() (*BlockHeader, uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.longest) == 0 {
+		return nil, 0
+	}
+	e := c.longest[len(c.longest)-1]
+
+	return e.header, e.height
+}
+
+// Work returns the total work of the longest chain.
+func (c *HeaderChain) Work() *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.longest) == 0 {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Set(c.longest[len(c.longest)-1].work)
+}
+
+func (c *HeaderChain) onLongest(e *chainEntry) bool {
+	return e.height < uint64(len(c.longest)) && c.longest[e.height] == e
+}
+
+// setTip makes e the tip of the longest chain, replacing the headers from
+// where its branch forks from the current longest chain.
+func (c *HeaderChain) setTip(e *chainEntry) {
+	var branch []*chainEntry
+	for ; e != nil && !c.onLongest(e); e = e.prev {
+		branch = append(branch, e)
+	}
+
+	if e == nil {
+		c.longest = c.longest[:0]
+	} else {
+		c.longest = c.longest[:e.height+1]
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		c.longest = append(c.longest, branch[i])
+	}
+}
+
+// headerWork returns the expected number of hashes needed to meet the
+// header's target, being 2^256 / (target+1).
+func headerWork(bh *BlockHeader) (*big.Int, error) {
+	target, err := ExpandTargetFromAsInt(hex.EncodeToString(bh.Bits))
+	if err != nil {
+		return nil, err
+	}
+	if target.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrHeaderInvalidPoW, bh.BitsStr())
+	}
+
+	return new(big.Int).Div(maxTarget, target.Add(target, big.NewInt(1))), nil
+}
@@ -0,0 +1,215 @@
+package bc_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libsv/go-bc"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	regtestBits = []byte{0x20, 0x7f, 0xff, 0xff}
+	// harderBits needs around 256 times the work of regtestBits.
+	harderBits = []byte{0x1f, 0x7f, 0xff, 0xff}
+)
+
+// mineHeader creates a header following prev, or a root header if prev is
+// nil, with a nonce meeting the bits. The time distinguishes siblings.
+func mineHeader(prev *bc.BlockHeader, time uint32, bits []byte) *bc.BlockHeader {
+	bh := &bc.BlockHeader{
+		Version:        1,
+		Time:           time,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: make([]byte, 32),
+		Bits:           bits,
+	}
+	if prev != nil {
+		bh.HashPrevBlock = prev.Hash()
+	}
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+
+	return bh
+}
+
+// mineHeaders mines n headers following prev.
+func mineHeaders(prev *bc.BlockHeader, n int, time uint32) []*bc.BlockHeader {
+	var hh []*bc.BlockHeader
+	for i := 0; i < n; i++ {
+		prev = mineHeader(prev, time, regtestBits)
+		hh = append(hh, prev)
+	}
+
+	return hh
+}
+
+func TestHeaderChain_Longest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := bc.NewHeaderChain()
+	tip, _ := c.Tip()
+	assert.Nil(t, tip)
+
+	main := mineHeaders(nil, 4, 1)
+	for _, bh := range main {
+		assert.NoError(t, c.Add(bh))
+	}
+	tip, height := c.Tip()
+	assert.Equal(t, main[3], tip)
+	assert.Equal(t, uint64(3), height)
+
+	// A longer fork from the root takes over.
+	fork := mineHeaders(main[0], 4, 2)
+	for _, bh := range fork {
+		assert.NoError(t, c.Add(bh))
+	}
+	tip, height = c.Tip()
+	assert.Equal(t, fork[3], tip)
+	assert.Equal(t, uint64(4), height)
+
+	bh, err := c.BlockHeader(ctx, main[0].HashStr())
+	assert.NoError(t, err)
+	assert.Equal(t, main[0], bh)
+	_, err = c.BlockHeader(ctx, main[1].HashStr())
+	assert.ErrorIs(t, err, bc.ErrNotOnLongestChain)
+	bh, err = c.BlockHeader(ctx, fork[1].HashStr())
+	assert.NoError(t, err)
+	assert.Equal(t, fork[1], bh)
+	_, err = c.BlockHeader(ctx, mineHeader(nil, 3, regtestBits).HashStr())
+	assert.ErrorIs(t, err, bc.ErrHeaderNotFound)
+
+	bh, err = c.HeaderByHeight(2)
+	assert.NoError(t, err)
+	assert.Equal(t, fork[1], bh)
+	_, err = c.HeaderByHeight(5)
+	assert.ErrorIs(t, err, bc.ErrHeaderNotFound)
+
+	// Extending the first chain switches back to it.
+	ext := mineHeaders(main[3], 2, 1)
+	for _, bh := range ext {
+		assert.NoError(t, c.Add(bh))
+	}
+	tip, height = c.Tip()
+	assert.Equal(t, ext[1], tip)
+	assert.Equal(t, uint64(5), height)
+	_, err = c.BlockHeader(ctx, main[1].HashStr())
+	assert.NoError(t, err)
+	_, err = c.BlockHeader(ctx, fork[0].HashStr())
+	assert.ErrorIs(t, err, bc.ErrNotOnLongestChain)
+}
+
+func TestHeaderChain_MostWork(t *testing.T) {
+	t.Parallel()
+
+	c := bc.NewHeaderChain()
+	main := mineHeaders(nil, 5, 1)
+	for _, bh := range main {
+		assert.NoError(t, c.Add(bh))
+	}
+	work := c.Work()
+
+	// One header with a harder target has more work than several easy ones.
+	hard := mineHeader(main[0], 2, harderBits)
+	assert.NoError(t, c.Add(hard))
+	tip, height := c.Tip()
+	assert.Equal(t, hard, tip)
+	assert.Equal(t, uint64(1), height)
+	assert.Equal(t, 1, c.Work().Cmp(work))
+
+	// Adding a header again does nothing.
+	assert.NoError(t, c.Add(main[4]))
+	tip, _ = c.Tip()
+	assert.Equal(t, hard, tip)
+}
+
+func TestHeaderChain_Invalid(t *testing.T) {
+	t.Parallel()
+
+	c := bc.NewHeaderChain()
+	root := mineHeader(nil, 1, regtestBits)
+	assert.NoError(t, c.Add(root))
+
+	bh := mineHeader(root, 1, harderBits)
+	for bh.Valid() {
+		bh.Nonce++
+	}
+	assert.ErrorIs(t, c.Add(bh), bc.ErrHeaderInvalidPoW)
+
+	orphan := mineHeaders(root, 2, 2)[1]
+	assert.ErrorIs(t, c.Add(orphan), bc.ErrHeaderOrphan)
+
+	_, height := c.Tip()
+	assert.Equal(t, uint64(0), height)
+}
+
+func TestHeaderChain_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	c := bc.NewHeaderChain()
+	main := mineHeaders(nil, 3, 1)
+	fork := mineHeaders(main[0], 3, 2)
+	for _, bh := range append(main, fork...) {
+		assert.NoError(t, c.Add(bh))
+	}
+
+	var buf bytes.Buffer
+	n, err := c.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6*80), n)
+
+	loaded := bc.NewHeaderChain()
+	n, err = loaded.ReadFrom(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6*80), n)
+	tip, height := loaded.Tip()
+	assert.Equal(t, fork[2], tip)
+	assert.Equal(t, uint64(3), height)
+	_, err = loaded.BlockHeader(context.Background(), main[2].HashStr())
+	assert.ErrorIs(t, err, bc.ErrNotOnLongestChain)
+}
+
+func TestOpenHeaderChain(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "headers")
+	hh := mineHeaders(nil, 4, 1)
+
+	c, err := bc.OpenHeaderChain(path)
+	assert.NoError(t, err)
+	for _, bh := range hh[:3] {
+		assert.NoError(t, c.Add(bh))
+	}
+	assert.NoError(t, c.Close())
+
+	// Simulate a crash part way through writing a header.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NoError(t, err)
+	_, err = f.Write(hh[3].Bytes()[:40])
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	c, err = bc.OpenHeaderChain(path)
+	assert.NoError(t, err)
+	tip, height := c.Tip()
+	assert.Equal(t, hh[2], tip)
+	assert.Equal(t, uint64(2), height)
+	assert.NoError(t, c.Add(hh[3]))
+	assert.NoError(t, c.Close())
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, b, 4*80)
+
+	c, err = bc.OpenHeaderChain(path)
+	assert.NoError(t, err)
+	tip, _ = c.Tip()
+	assert.Equal(t, hh[3], tip)
+	assert.NoError(t, c.Close())
+}
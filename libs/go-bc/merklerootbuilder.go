@@ -0,0 +1,89 @@
+package bc
+
+import (
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// MerkleRootBuilder calculates a merkle root from txids added one at a time,
+// keeping only one pending hash per level of the tree rather than the whole
+// tree, so the root of a block can be found while the block is streamed.
+//
+// The root is the same as that given by BuildMerkleRoot, in that a node with
+// no right sibling is hashed with itself.
+type MerkleRootBuilder struct {
+	// levels holds the pending left node of each level, in the internal
+	// (little endian) byte order, or nil where the level has no pending node.
+	levels [][]byte
+	count  uint64
+}
+
+// NewMerkleRootBuilder creates an empty MerkleRootBuilder.
+func NewMerkleRootBuilder() *MerkleRootBuilder {
+	return &MerkleRootBuilder{}
+}
+
+// Add adds the next txid, in the big endian byte order of bt.Tx.TxIDBytes.
+func (m *MerkleRootBuilder) Add(txID []byte) {
+	m.add(bt.ReverseBytes(txID))
+}
+
+// add adds the next txid, in the internal byte order.
+func (m *MerkleRootBuilder) add(h []byte) {
+	m.count++
+	for i := 0; ; i++ {
+		if i == len(m.levels) {
+			m.levels = append(m.levels, h)
+			return
+		}
+		if m.levels[i] == nil {
+			m.levels[i] = h
+			return
+		}
+		h = merkleParent(m.levels[i], h)
+		m.levels[i] = nil
+	}
+}
+
+// Count returns the number of txids added.
+func (m *MerkleRootBuilder) Count() uint64 {
+	return m.count
+}
+
+// Root returns the merkle root of the txids added so far, in the big endian
+// byte order of BlockHeader.HashMerkleRoot. Nil is returned if no txids have
+// been added.
+func (m *MerkleRootBuilder) Root() []byte {
+	top := len(m.levels) - 1
+	var h []byte
+	for i, l := range m.levels {
+		switch {
+		case h == nil && l == nil:
+			continue
+		case h == nil && i == top:
+			h = l
+		case h == nil:
+			// The first pending node has no right sibling.
+			h = merkleParent(l, l)
+		case l == nil:
+			// Nodes carried up from below have no right sibling either.
+			h = merkleParent(h, h)
+		default:
+			h = merkleParent(l, h)
+		}
+	}
+	if h == nil {
+		return nil
+	}
+
+	return bt.ReverseBytes(h)
+}
+
+// merkleParent hashes two child nodes given in the internal byte order.
+func merkleParent(l, r []byte) []byte {
+	concat := make([]byte, 0, len(l)+len(r))
+	concat = append(concat, l...)
+	concat = append(concat, r...)
+
+	return crypto.Sha256d(concat)
+}
@@ -2,7 +2,8 @@ package interpreter
 
 import "math"
 
-type config interface {
+// Config defines the script limits applied by the interpreter.
+type Config interface {
 	MaxOps() int
 	MaxStackSize() int
 	MaxScriptSize() int
@@ -22,6 +23,16 @@ const (
 type beforeGenesisConfig struct{}
 type afterGenesisConfig struct{}
 
+// BeforeGenesisConfig returns the limits applied to utxos created before genesis.
+func BeforeGenesisConfig() Config {
+	return &beforeGenesisConfig{}
+}
+
+// AfterGenesisConfig returns the limits applied to utxos created after genesis.
+func AfterGenesisConfig() Config {
+	return &afterGenesisConfig{}
+}
+
 func (a *afterGenesisConfig) MaxStackSize() int {
 	return math.MaxInt32
 }
@@ -0,0 +1,148 @@
+package interpreter
+
+// State is a snapshot of a thread at a single point of execution. The stacks
+// are copies, so a State remains valid after the thread moves on.
+type State struct {
+	// ScriptIdx is the index of the script being executed, where 0 is the
+	// unlocking script, 1 is the locking script and 2 is the redeem script
+	// of a P2SH execution. See ScriptRole.
+	ScriptIdx int
+	// OpcodeIdx is the index of Opcode within the script at ScriptIdx.
+	OpcodeIdx int
+	Opcode    ParsedOp
+	// DataStack and AltStack hold the stack contents bottom up, where the
+	// last item is the top of the stack.
+	DataStack [][]byte
+	AltStack  [][]byte
+	// CondStack holds the conditional stack, made of OpCondFalse, OpCondTrue
+	// and OpCondSkip values.
+	CondStack []int
+	NumOps    int
+	// Err is the error returned from executing Opcode. It is only ever set
+	// on the State passed to AfterExecuteOpcode.
+	Err error
+}
+
+// Debugger is notified before and after every opcode is executed. It can be
+// supplied through ExecutionParams to observe an execution of Engine or
+// Stepper.
+type Debugger interface {
+	BeforeExecuteOpcode(State)
+	AfterExecuteOpcode(State)
+}
+
+// Stepper executes a script pair one opcode at a time, allowing the state of
+// the thread to be inspected between opcodes.
+type Stepper struct {
+	th          *thread
+	breakpoints map[breakpoint]struct{}
+
+	// started is set once execution has moved past the first opcode, so a
+	// breakpoint on the first opcode only stops the first Continue.
+	started bool
+	done    bool
+	err     error
+}
+
+type breakpoint struct {
+	scriptIdx int
+	opcodeIdx int
+}
+
+// NewStepper returns a new Stepper for the provided execution params, ready
+// to execute the first opcode.
+func NewStepper(params ExecutionParams) (*Stepper, error) {
+	th, err := newThread(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stepper{
+		th:          th,
+		breakpoints: make(map[breakpoint]struct{}),
+	}, nil
+}
+
+// SetBreakpoint sets a breakpoint on the opcode at opcodeIdx of the script
+// at scriptIdx. Continue will stop before the opcode is executed.
+func (s *Stepper) SetBreakpoint(scriptIdx, opcodeIdx int) {
+	s.breakpoints[breakpoint{scriptIdx: scriptIdx, opcodeIdx: opcodeIdx}] = struct{}{}
+}
+
+// ClearBreakpoint removes a breakpoint previously set with SetBreakpoint.
+func (s *Stepper) ClearBreakpoint(scriptIdx, opcodeIdx int) {
+	delete(s.breakpoints, breakpoint{scriptIdx: scriptIdx, opcodeIdx: opcodeIdx})
+}
+
+// Step executes the next opcode. It returns true once execution has finished,
+// at which point the final stack has been checked and any resulting error is
+// returned. Calling Step after execution has finished returns the same result.
+func (s *Stepper) Step() (bool, error) {
+	if s.done {
+		return true, s.err
+	}
+
+	s.started = true
+	done, err := s.th.Step()
+	if err == nil && done {
+		err = s.th.CheckErrorCondition(true)
+	}
+	if err != nil || done {
+		s.done, s.err = true, err
+	}
+
+	return s.done, err
+}
+
+// Continue executes opcodes until the next opcode to be executed has a
+// breakpoint set, or until execution has finished.
+func (s *Stepper) Continue() (bool, error) {
+	if !s.started && !s.done {
+		s.started = true
+		if s.atBreakpoint() {
+			return false, nil
+		}
+	}
+	for {
+		done, err := s.Step()
+		if done || err != nil {
+			return done, err
+		}
+		if s.atBreakpoint() {
+			return false, nil
+		}
+	}
+}
+
+// atBreakpoint returns true if the next opcode to be executed has a breakpoint.
+func (s *Stepper) atBreakpoint() bool {
+	_, ok := s.breakpoints[breakpoint{scriptIdx: s.th.scriptIdx, opcodeIdx: s.th.scriptOff}]
+	return ok
+}
+
+// State returns the current state of the thread, where Opcode is the next
+// opcode to be executed. Opcode is empty once execution has finished.
+func (s *Stepper) State() State {
+	var pop ParsedOp
+	if !s.done && s.th.validPC() == nil {
+		pop = s.th.scripts[s.th.scriptIdx][s.th.scriptOff]
+	}
+
+	return s.th.state(s.th.scriptIdx, s.th.scriptOff, pop)
+}
+
+// state returns a snapshot of the thread positioned at the given opcode.
+func (t *thread) state(scriptIdx, opcodeIdx int, pop ParsedOp) State {
+	condStack := make([]int, len(t.condStack))
+	copy(condStack, t.condStack)
+
+	return State{
+		ScriptIdx: scriptIdx,
+		OpcodeIdx: opcodeIdx,
+		Opcode:    pop,
+		DataStack: getStack(&t.dstack),
+		AltStack:  getStack(&t.astack),
+		CondStack: condStack,
+		NumOps:    t.numOps,
+	}
+}
@@ -0,0 +1,199 @@
+package interpreter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+type recordingDebugger struct {
+	before []State
+	after  []State
+}
+
+func (r *recordingDebugger) BeforeExecuteOpcode(s State) {
+	r.before = append(r.before, s)
+}
+
+func (r *recordingDebugger) AfterExecuteOpcode(s State) {
+	r.after = append(r.after, s)
+}
+
+func debugTestParams(t *testing.T, unlocking, locking string) ExecutionParams {
+	uls, err := bscript.NewFromASM(unlocking)
+	if err != nil {
+		t.Fatalf("failed to create unlocking script: %v", err)
+	}
+	ls, err := bscript.NewFromASM(locking)
+	if err != nil {
+		t.Fatalf("failed to create locking script: %v", err)
+	}
+
+	return ExecutionParams{
+		Tx: &bt.Tx{
+			Version: 1,
+			Inputs: []*bt.Input{{
+				UnlockingScript: uls,
+				SequenceNumber:  4294967295,
+			}},
+			Outputs: []*bt.Output{{Satoshis: 1000}},
+		},
+		PreviousTxOut: &bt.Output{LockingScript: ls},
+		Flags:         ScriptUTXOAfterGenesis,
+	}
+}
+
+func TestStepper_Step(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewStepper(debugTestParams(t, "OP_2 OP_3", "OP_ADD OP_5 OP_EQUAL"))
+	if err != nil {
+		t.Fatalf("failed to create stepper: %v", err)
+	}
+
+	tests := []struct {
+		scriptIdx int
+		opcodeIdx int
+		opcode    byte
+		depth     int
+	}{
+		{scriptIdx: 0, opcodeIdx: 0, opcode: bscript.Op2, depth: 0},
+		{scriptIdx: 0, opcodeIdx: 1, opcode: bscript.Op3, depth: 1},
+		{scriptIdx: 1, opcodeIdx: 0, opcode: bscript.OpADD, depth: 2},
+		{scriptIdx: 1, opcodeIdx: 1, opcode: bscript.Op5, depth: 1},
+		{scriptIdx: 1, opcodeIdx: 2, opcode: bscript.OpEQUAL, depth: 2},
+	}
+
+	for i, test := range tests {
+		state := s.State()
+		if state.ScriptIdx != test.scriptIdx || state.OpcodeIdx != test.opcodeIdx {
+			t.Fatalf("step %d: expected pc %d:%d, got %d:%d", i,
+				test.scriptIdx, test.opcodeIdx, state.ScriptIdx, state.OpcodeIdx)
+		}
+		if state.Opcode.Op.val != test.opcode {
+			t.Fatalf("step %d: expected opcode %x, got %x", i, test.opcode, state.Opcode.Op.val)
+		}
+		if len(state.DataStack) != test.depth {
+			t.Fatalf("step %d: expected stack depth %d, got %d", i, test.depth, len(state.DataStack))
+		}
+
+		done, err := s.Step()
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if done != (i == len(tests)-1) {
+			t.Fatalf("step %d: unexpected done %t", i, done)
+		}
+	}
+
+	done, err := s.Step()
+	if !done || err != nil {
+		t.Fatalf("expected finished stepper to stay done, got %t %v", done, err)
+	}
+}
+
+func TestStepper_Continue(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewStepper(debugTestParams(t,
+		"OP_1 OP_2",
+		"OP_TOALTSTACK OP_IF OP_FROMALTSTACK OP_ENDIF OP_2 OP_EQUAL",
+	))
+	if err != nil {
+		t.Fatalf("failed to create stepper: %v", err)
+	}
+	s.SetBreakpoint(1, 2)
+	s.SetBreakpoint(1, 4)
+	s.ClearBreakpoint(1, 4)
+
+	done, err := s.Continue()
+	if done || err != nil {
+		t.Fatalf("expected to stop at breakpoint, got %t %v", done, err)
+	}
+
+	state := s.State()
+	if state.ScriptIdx != 1 || state.OpcodeIdx != 2 {
+		t.Fatalf("expected pc 1:2, got %d:%d", state.ScriptIdx, state.OpcodeIdx)
+	}
+	if len(state.AltStack) != 1 || !bytes.Equal(state.AltStack[0], []byte{2}) {
+		t.Fatalf("unexpected alt stack %x", state.AltStack)
+	}
+	if len(state.CondStack) != 1 || state.CondStack[0] != OpCondTrue {
+		t.Fatalf("unexpected cond stack %v", state.CondStack)
+	}
+
+	done, err = s.Continue()
+	if !done || err != nil {
+		t.Fatalf("expected successful completion, got %t %v", done, err)
+	}
+}
+
+func TestStepper_ContinueFirstOpcode(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewStepper(debugTestParams(t, "OP_2 OP_3", "OP_ADD OP_5 OP_EQUAL"))
+	if err != nil {
+		t.Fatalf("failed to create stepper: %v", err)
+	}
+	s.SetBreakpoint(0, 0)
+
+	done, err := s.Continue()
+	if done || err != nil {
+		t.Fatalf("expected to stop at breakpoint, got %t %v", done, err)
+	}
+
+	state := s.State()
+	if state.ScriptIdx != 0 || state.OpcodeIdx != 0 || len(state.DataStack) != 0 {
+		t.Fatalf("expected pc 0:0 before execution, got %d:%d with stack %x",
+			state.ScriptIdx, state.OpcodeIdx, state.DataStack)
+	}
+
+	done, err = s.Continue()
+	if !done || err != nil {
+		t.Fatalf("expected successful completion, got %t %v", done, err)
+	}
+}
+
+func TestStepper_Error(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewStepper(debugTestParams(t, "OP_1", "OP_2 OP_EQUALVERIFY OP_1"))
+	if err != nil {
+		t.Fatalf("failed to create stepper: %v", err)
+	}
+
+	done, err := s.Continue()
+	if !done || !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("expected ErrEqualVerify, got %t %v", done, err)
+	}
+
+	if _, err = s.Step(); !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("expected error to be retained, got %v", err)
+	}
+}
+
+func TestEngine_Debugger(t *testing.T) {
+	t.Parallel()
+
+	dbg := &recordingDebugger{}
+	params := debugTestParams(t, "OP_1", "OP_2 OP_EQUALVERIFY OP_1")
+	params.Debugger = dbg
+
+	if err := NewEngine().Execute(params); !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("expected ErrEqualVerify, got %v", err)
+	}
+
+	if len(dbg.before) != 3 || len(dbg.after) != 3 {
+		t.Fatalf("expected 3 hook calls each, got %d and %d", len(dbg.before), len(dbg.after))
+	}
+
+	last := dbg.after[2]
+	if last.Opcode.Op.val != bscript.OpEQUALVERIFY || !IsErrorCode(last.Err, ErrEqualVerify) {
+		t.Fatalf("unexpected final state %+v", last)
+	}
+	if dbg.before[2].ScriptIdx != 1 || dbg.before[2].OpcodeIdx != 1 || len(dbg.before[2].DataStack) != 2 {
+		t.Fatalf("unexpected state before OP_EQUALVERIFY %+v", dbg.before[2])
+	}
+}
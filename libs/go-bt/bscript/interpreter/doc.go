@@ -38,5 +38,12 @@ ErrorCode field of the type asserted interpreter.Error while still providing ric
 error messages with contextual information.  A convenience function named
 IsErrorCode is also provided to allow callers to easily check for a specific
 error code.  See ErrorCode in the package documentation for a full list.
+
+Debugging
+
+A Debugger can be supplied through ExecutionParams to be notified of the
+thread State before and after every opcode is executed.  For interactive
+debugging, a Stepper executes a script pair one opcode at a time, stopping at
+any breakpoints set by script and opcode index.
 */
 package interpreter
@@ -121,14 +121,25 @@ func NewEngine() Engine {
 // Execute will execute all scripts in the script engine and return either nil
 // for successful validation or an error if one occurred.
 func (e *engine) Execute(params ExecutionParams) error {
+	th, err := newThread(params)
+	if err != nil {
+		return err
+	}
+
+	return th.execute()
+}
+
+// newThread returns a thread configured with the provided params, ready to
+// execute the first opcode.
+func newThread(params ExecutionParams) (*thread, error) {
 	th := &thread{
 		scriptParser: &parser{},
 		cfg:          &beforeGenesisConfig{},
 	}
 
 	if err := th.apply(params); err != nil {
-		return err
+		return nil, err
 	}
 
-	return th.execute()
+	return th, nil
 }
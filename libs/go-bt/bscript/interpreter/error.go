@@ -322,6 +322,11 @@ const (
 	// set, but the ScriptEnableSighashForkID flag is not set.
 	ErrIllegalForkID
 
+	// ErrInvalidParams is returned when the params passed to the engine are
+	// incomplete, such as when the previous output being spent is unknown.
+	// It is appended so the codes before it keep their values.
+	ErrInvalidParams
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -390,6 +395,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrNegativeLockTime:         "ErrNegativeLockTime",
 	ErrUnsatisfiedLockTime:      "ErrUnsatisfiedLockTime",
 	ErrIllegalForkID:            "ErrIllegalForkID",
+	ErrInvalidParams:            "ErrInvalidParams",
 }
 
 // String returns the ErrorCode as a human-readable name.
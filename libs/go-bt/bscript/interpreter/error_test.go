@@ -77,6 +77,7 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrNegativeLockTime, "ErrNegativeLockTime"},
 		{ErrUnsatisfiedLockTime, "ErrUnsatisfiedLockTime"},
 		{ErrIllegalForkID, "ErrIllegalForkID"},
+		{ErrInvalidParams, "ErrInvalidParams"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
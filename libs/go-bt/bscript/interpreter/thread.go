@@ -14,7 +14,7 @@ type thread struct {
 
 	elseStack boolStack
 
-	cfg config
+	cfg Config
 
 	scripts         []ParsedScript
 	condStack       []int
@@ -36,6 +36,8 @@ type thread struct {
 
 	afterGenesis            bool
 	earlyReturnAfterGenesis bool
+
+	debug Debugger
 }
 
 // ExecutionParams are the params required for building an Engine
@@ -44,6 +46,10 @@ type ExecutionParams struct {
 	Tx            *bt.Tx
 	InputIdx      int
 	Flags         ScriptFlags
+
+	// Debugger is optional, and is notified before and after each opcode
+	// is executed.
+	Debugger Debugger
 }
 
 // hasFlag returns whether the script engine instance has the passed flag set.
@@ -170,13 +176,24 @@ func (t *thread) Step() (bool, error) {
 		return true, err
 	}
 
-	opcode := t.scripts[t.scriptIdx][t.scriptOff]
+	scriptIdx, opcodeIdx := t.scriptIdx, t.scriptOff
+	opcode := t.scripts[scriptIdx][opcodeIdx]
 	t.scriptOff++
 
+	if t.debug != nil {
+		t.debug.BeforeExecuteOpcode(t.state(scriptIdx, opcodeIdx, opcode))
+	}
+
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per
 	// script, maximum script element sizes, and conditionals.
-	if err := t.executeOpcode(opcode); err != nil {
+	err := t.executeOpcode(opcode)
+	if t.debug != nil {
+		state := t.state(scriptIdx, opcodeIdx, opcode)
+		state.Err = err
+		t.debug.AfterExecuteOpcode(state)
+	}
+	if err != nil {
 		if ok := IsErrorCode(err, ErrOK); ok {
 			// If returned early, move onto the next script
 			t.shiftScript()
@@ -252,6 +269,7 @@ func (t *thread) apply(params ExecutionParams) error {
 	t.flags = params.Flags
 	t.inputIdx = params.InputIdx
 	t.prevOutput = params.PreviousTxOut
+	t.debug = params.Debugger
 
 	// The clean stack flag (ScriptVerifyCleanStack) is not allowed without
 	// the pay-to-script-hash (P2SH) evaluation (ScriptBip16).
@@ -280,6 +298,18 @@ func (t *thread) apply(params ExecutionParams) error {
 		)
 	}
 
+	// Txs in the extended format carry the previous output on each input.
+	if t.prevOutput == nil {
+		in := t.tx.Inputs[t.inputIdx]
+		if in.PreviousTxScript == nil {
+			return scriptError(ErrInvalidParams, "previous output of input %d is unknown", t.inputIdx)
+		}
+		t.prevOutput = &bt.Output{
+			Satoshis:      in.PreviousTxSatoshis,
+			LockingScript: in.PreviousTxScript,
+		}
+	}
+
 	uls := t.tx.Inputs[params.InputIdx].UnlockingScript
 	ls := t.prevOutput.LockingScript
 
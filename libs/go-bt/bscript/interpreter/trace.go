@@ -0,0 +1,208 @@
+package interpreter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ScriptRole identifies the role of a script within an execution, and is
+// the ScriptIdx of a State.
+type ScriptRole int
+
+// The scripts executed by a thread, in order of execution.
+const (
+	UnlockingScript ScriptRole = iota
+	LockingScript
+	P2SHScript
+)
+
+// NoScript is the role reported when execution fails before any opcode is
+// executed, such as when a script can't be parsed.
+const NoScript ScriptRole = -1
+
+// String returns the ScriptRole as a human-readable name.
+func (s ScriptRole) String() string {
+	switch s {
+	case UnlockingScript:
+		return "unlocking"
+	case LockingScript:
+		return "locking"
+	case P2SHScript:
+		return "p2sh"
+	case NoScript:
+		return "none"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(s))
+	}
+}
+
+// MarshalText marshals the ScriptRole as its human-readable name.
+func (s ScriptRole) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// TraceStep is a single opcode executed by a thread, with the data stack as it
+// was left after the opcode was executed.
+type TraceStep struct {
+	Script    ScriptRole `json:"script"`
+	OpcodeIdx int        `json:"opcodeIdx"`
+	Opcode    string     `json:"opcode"`
+	Data      string     `json:"data,omitempty"`
+	Stack     []string   `json:"stack"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Tracer is a Debugger which records every opcode executed by a thread.
+type Tracer struct {
+	steps []TraceStep
+
+	// lastBefore is the state before the most recently executed opcode,
+	// kept so that a failure can be reported against the stack the
+	// failing opcode was given.
+	lastBefore State
+	lastAfter  State
+}
+
+// NewTracer returns a new, empty Tracer.
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// BeforeExecuteOpcode records the state before an opcode is executed.
+func (t *Tracer) BeforeExecuteOpcode(s State) {
+	t.lastBefore = s
+}
+
+// AfterExecuteOpcode records the opcode which has been executed.
+func (t *Tracer) AfterExecuteOpcode(s State) {
+	t.lastAfter = s
+
+	step := TraceStep{
+		Script:    ScriptRole(s.ScriptIdx),
+		OpcodeIdx: s.OpcodeIdx,
+		Opcode:    s.Opcode.Name(),
+		Data:      hex.EncodeToString(s.Opcode.Data),
+		Stack:     make([]string, len(s.DataStack)),
+	}
+	for i, item := range s.DataStack {
+		step.Stack[i] = hex.EncodeToString(item)
+	}
+	if s.Err != nil && !IsErrorCode(s.Err, ErrOK) {
+		step.Error = s.Err.Error()
+	}
+
+	t.steps = append(t.steps, step)
+}
+
+// Steps returns the opcodes recorded so far, in order of execution.
+func (t *Tracer) Steps() []TraceStep {
+	return t.steps
+}
+
+// MarshalJSON marshals the recorded steps as a JSON array.
+func (t *Tracer) MarshalJSON() ([]byte, error) {
+	if t.steps == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t.steps)
+}
+
+// ExecutionError is returned by Trace when execution fails. It describes
+// where in the scripts the failure happened, and wraps the underlying Error.
+// Script is NoScript, and the opcode is left empty, if execution failed before
+// any opcode was executed.
+type ExecutionError struct {
+	InputIdx  int
+	Script    ScriptRole
+	OpcodeIdx int
+	Opcode    string
+	// StackTop is the top of the data stack given to the failing opcode, or
+	// left by the final opcode if the failure was found after execution.
+	StackTop []byte
+	Err      error
+}
+
+// Error satisfies the error interface and prints the failure with its context.
+func (e *ExecutionError) Error() string {
+	if e.Script == NoScript {
+		return fmt.Sprintf("input %d: %s", e.InputIdx, e.Err)
+	}
+	return fmt.Sprintf("input %d: %s script opcode %d (%s) with stack top %x: %s",
+		e.InputIdx, e.Script, e.OpcodeIdx, e.Opcode, e.StackTop, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON marshals the failure and its context into a JSON object.
+func (e *ExecutionError) MarshalJSON() ([]byte, error) {
+	var code string
+	serr := &Error{}
+	if ok := errors.As(e.Err, serr); ok {
+		code = serr.ErrorCode.String()
+	}
+
+	return json.Marshal(struct {
+		InputIdx  int        `json:"inputIdx"`
+		Script    ScriptRole `json:"script"`
+		OpcodeIdx int        `json:"opcodeIdx"`
+		Opcode    string     `json:"opcode"`
+		StackTop  string     `json:"stackTop"`
+		Code      string     `json:"code,omitempty"`
+		Error     string     `json:"error"`
+	}{
+		InputIdx:  e.InputIdx,
+		Script:    e.Script,
+		OpcodeIdx: e.OpcodeIdx,
+		Opcode:    e.Opcode,
+		StackTop:  hex.EncodeToString(e.StackTop),
+		Code:      code,
+		Error:     e.Err.Error(),
+	})
+}
+
+// Trace executes the scripts in the same way as Engine.Execute, recording
+// every opcode executed with a Tracer, which replaces any Debugger in the
+// params. If execution fails, the returned error is an *ExecutionError.
+func Trace(params ExecutionParams) (*Tracer, error) {
+	tracer := NewTracer()
+	params.Debugger = tracer
+
+	if err := NewEngine().Execute(params); err != nil {
+		return tracer, tracer.failure(params.InputIdx, err)
+	}
+
+	return tracer, nil
+}
+
+// failure returns an ExecutionError for err, using the most recently
+// executed opcode as its context.
+func (t *Tracer) failure(inputIdx int, err error) *ExecutionError {
+	e := &ExecutionError{
+		InputIdx: inputIdx,
+		Script:   NoScript,
+		Err:      err,
+	}
+	if len(t.steps) == 0 {
+		return e
+	}
+
+	s := t.lastAfter
+	stack := s.DataStack
+	if s.Err != nil && !IsErrorCode(s.Err, ErrOK) {
+		stack = t.lastBefore.DataStack
+	}
+
+	e.Script = ScriptRole(s.ScriptIdx)
+	e.OpcodeIdx = s.OpcodeIdx
+	e.Opcode = s.Opcode.Name()
+	if len(stack) > 0 {
+		e.StackTop = stack[len(stack)-1]
+	}
+
+	return e
+}
@@ -0,0 +1,135 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	tracer, err := Trace(debugTestParams(t, "OP_2 OP_3", "OP_ADD OP_5 OP_EQUAL"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	steps := tracer.Steps()
+	if len(steps) != 5 {
+		t.Fatalf("expected 5 steps, got %d", len(steps))
+	}
+
+	add := steps[2]
+	if add.Script != LockingScript || add.OpcodeIdx != 0 || add.Opcode != "OP_ADD" {
+		t.Fatalf("unexpected step %+v", add)
+	}
+	if len(add.Stack) != 1 || add.Stack[0] != "05" {
+		t.Fatalf("unexpected stack after OP_ADD %v", add.Stack)
+	}
+
+	b, err := json.Marshal(tracer)
+	if err != nil {
+		t.Fatalf("failed to marshal trace: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal trace: %v", err)
+	}
+	if len(decoded) != 5 || decoded[0]["script"] != "unlocking" || decoded[2]["script"] != "locking" {
+		t.Fatalf("unexpected json trace %s", b)
+	}
+}
+
+func TestTrace_Failure(t *testing.T) {
+	t.Parallel()
+
+	params := debugTestParams(t, "OP_1 OP_7", "OP_2 OP_EQUALVERIFY OP_1")
+	tracer, err := Trace(params)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !IsErrorCode(err, ErrEqualVerify) {
+		t.Fatalf("expected ErrEqualVerify, got %v", err)
+	}
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected ExecutionError, got %T", err)
+	}
+	if execErr.InputIdx != 0 || execErr.Script != LockingScript || execErr.OpcodeIdx != 1 ||
+		execErr.Opcode != "OP_EQUALVERIFY" {
+		t.Fatalf("unexpected failure context %+v", execErr)
+	}
+	if !bytes.Equal(execErr.StackTop, []byte{2}) {
+		t.Fatalf("unexpected stack top %x", execErr.StackTop)
+	}
+
+	steps := tracer.Steps()
+	if steps[len(steps)-1].Error == "" {
+		t.Fatal("expected failing step to record the error")
+	}
+
+	b, err := json.Marshal(execErr)
+	if err != nil {
+		t.Fatalf("failed to marshal failure: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal failure: %v", err)
+	}
+	if decoded["code"] != "ErrEqualVerify" || decoded["stackTop"] != "02" {
+		t.Fatalf("unexpected json failure %s", b)
+	}
+}
+
+func TestTrace_EvalFalse(t *testing.T) {
+	t.Parallel()
+
+	_, err := Trace(debugTestParams(t, "OP_1", "OP_DROP OP_0"))
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || !IsErrorCode(err, ErrEvalFalse) {
+		t.Fatalf("expected ExecutionError with ErrEvalFalse, got %v", err)
+	}
+	if execErr.Opcode != "OP_0" || len(execErr.StackTop) != 0 {
+		t.Fatalf("unexpected failure context %+v", execErr)
+	}
+}
+
+func TestTrace_FailureBeforeExecution(t *testing.T) {
+	t.Parallel()
+
+	params := debugTestParams(t, "OP_1", "OP_1")
+	// OP_PUSHDATA1 without its data can't be parsed, so no opcode is executed.
+	params.Tx.Inputs[0].UnlockingScript = &bscript.Script{bscript.OpPUSHDATA1, 0x05}
+
+	tracer, err := Trace(params)
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected ExecutionError, got %v", err)
+	}
+	if len(tracer.Steps()) != 0 {
+		t.Fatalf("expected no steps, got %d", len(tracer.Steps()))
+	}
+	if execErr.Script != NoScript || execErr.Opcode != "" {
+		t.Fatalf("unexpected failure context %+v", execErr)
+	}
+	if execErr.Error() != "input 0: "+execErr.Err.Error() {
+		t.Fatalf("unexpected error message %q", execErr.Error())
+	}
+
+	b, err := json.Marshal(execErr)
+	if err != nil {
+		t.Fatalf("failed to marshal failure: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal failure: %v", err)
+	}
+	if decoded["script"] != "none" {
+		t.Fatalf("unexpected json failure %s", b)
+	}
+}
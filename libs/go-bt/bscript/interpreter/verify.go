@@ -0,0 +1,214 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libsv/go-bt/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// GenesisActivationHeight is the height of the first mainnet block
+	// with the genesis upgrade activated.
+	GenesisActivationHeight = 620538
+
+	// MempoolHeight is the height reported for outputs which have not yet
+	// been mined. Such outputs are always treated as created after genesis.
+	MempoolHeight = 0x7FFFFFFF
+)
+
+const (
+	flagsBeforeGenesis = ScriptBip16 | ScriptEnableSighashForkID | ScriptVerifyStrictEncoding |
+		ScriptVerifyLowS | ScriptVerifyNullFail
+	flagsAfterGenesis = ScriptEnableSighashForkID | ScriptVerifyStrictEncoding |
+		ScriptVerifyLowS | ScriptVerifyNullFail | ScriptUTXOAfterGenesis
+)
+
+// PreviousOutputProvider provides the outputs spent by the inputs of a
+// transaction being verified.
+type PreviousOutputProvider interface {
+	// PreviousOutput returns the output at index vout of the transaction
+	// with the given id, along with the height of the block the transaction
+	// was mined in, or MempoolHeight if it is unconfirmed.
+	PreviousOutput(ctx context.Context, txID []byte, vout uint32) (*bt.Output, uint32, error)
+}
+
+// VerifyTxParams are the params required for verifying every input of a
+// transaction.
+type VerifyTxParams struct {
+	Tx *bt.Tx
+
+	// PreviousOutputs provides the outputs spent by the tx. When nil, the
+	// previous outputs carried by the inputs of an extended format tx are
+	// used instead, and are treated as unconfirmed.
+	PreviousOutputs PreviousOutputProvider
+
+	// Flags are added to the flags chosen for each input based on the height
+	// of the output it spends.
+	Flags ScriptFlags
+
+	// GenesisActivationHeight overrides the mainnet genesis activation
+	// height, for use on other networks.
+	GenesisActivationHeight uint32
+
+	// Concurrency is the number of inputs to execute in parallel. Inputs are
+	// executed one at a time when it is less than 2.
+	Concurrency int
+
+	// Trace records the execution of every input with a Tracer, and reports
+	// input failures as *ExecutionError.
+	Trace bool
+}
+
+// InputResult is the result of verifying a single input.
+type InputResult struct {
+	InputIdx      int
+	PreviousTxOut *bt.Output
+	Height        uint32
+	Flags         ScriptFlags
+	Err           error
+
+	// Trace is only set when tracing was requested in VerifyTxParams.
+	Trace *Tracer
+
+	trace bool
+}
+
+// TxResult is the result of verifying every input of a transaction.
+type TxResult struct {
+	Inputs []InputResult
+}
+
+// Valid returns true if every input was verified successfully.
+func (r *TxResult) Valid() bool {
+	return r.Err() == nil
+}
+
+// Err returns the error of the first input that failed verification, or nil
+// if every input was verified successfully.
+func (r *TxResult) Err() error {
+	for _, in := range r.Inputs {
+		if in.Err != nil {
+			return in.Err
+		}
+	}
+	return nil
+}
+
+// VerifyTx executes the scripts of every input of a transaction against the
+// outputs they spend, choosing pre or post genesis flags by the height of each
+// output. Script failures are reported per input in the returned TxResult,
+// whereas an error is returned if the previous outputs could not be provided.
+func VerifyTx(ctx context.Context, params VerifyTxParams) (*TxResult, error) {
+	genesisHeight := params.GenesisActivationHeight
+	if genesisHeight == 0 {
+		genesisHeight = GenesisActivationHeight
+	}
+
+	tx := params.Tx
+	res := &TxResult{
+		Inputs: make([]InputResult, len(tx.Inputs)),
+	}
+
+	for i, in := range tx.Inputs {
+		out, height, err := previousOutput(ctx, params.PreviousOutputs, in)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			return nil, fmt.Errorf("previous output %s:%d not provided", in.PreviousTxIDStr(), in.PreviousTxOutIndex)
+		}
+
+		flags := flagsAfterGenesis
+		if height < genesisHeight {
+			flags = flagsBeforeGenesis
+		}
+
+		res.Inputs[i] = InputResult{
+			InputIdx:      i,
+			PreviousTxOut: out,
+			Height:        height,
+			Flags:         flags | params.Flags,
+			trace:         params.Trace,
+		}
+	}
+
+	// The engine records the previous output on the input being executed, so
+	// inputs are executed against a copy to leave the caller's tx untouched.
+	if params.Concurrency < 2 {
+		txc := tx.Clone()
+		for i := range res.Inputs {
+			verifyInput(txc, &res.Inputs[i])
+		}
+		return res, nil
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	idxs := make(chan int)
+	g.Go(func() error {
+		defer close(idxs)
+		for i := range res.Inputs {
+			select {
+			case idxs <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+
+	for w := 0; w < params.Concurrency; w++ {
+		g.Go(func() error {
+			// Each worker executes against its own copy of the tx.
+			txc := tx.Clone()
+			for i := range idxs {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				verifyInput(txc, &res.Inputs[i])
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// verifyInput executes the input against its previous output, recording the
+// outcome on the result.
+func verifyInput(tx *bt.Tx, in *InputResult) {
+	params := ExecutionParams{
+		Tx:            tx,
+		InputIdx:      in.InputIdx,
+		PreviousTxOut: in.PreviousTxOut,
+		Flags:         in.Flags,
+	}
+
+	if in.trace {
+		in.Trace, in.Err = Trace(params)
+		return
+	}
+
+	in.Err = NewEngine().Execute(params)
+}
+
+// previousOutput returns the output spent by the input from the provider, or
+// from the input itself when there is no provider.
+func previousOutput(ctx context.Context, p PreviousOutputProvider, in *bt.Input) (*bt.Output, uint32, error) {
+	if p != nil {
+		return p.PreviousOutput(ctx, in.PreviousTxID(), in.PreviousTxOutIndex)
+	}
+	if in.PreviousTxScript == nil {
+		return nil, 0, nil
+	}
+
+	return &bt.Output{
+		Satoshis:      in.PreviousTxSatoshis,
+		LockingScript: in.PreviousTxScript,
+	}, MempoolHeight, nil
+}
@@ -0,0 +1,228 @@
+package interpreter_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/libsv/go-bk/wif"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+type prevOutput struct {
+	output *bt.Output
+	height uint32
+}
+
+type mapProvider map[string]prevOutput
+
+func (m mapProvider) PreviousOutput(ctx context.Context, txID []byte, vout uint32) (*bt.Output, uint32, error) {
+	o, ok := m[fmt.Sprintf("%x:%d", txID, vout)]
+	if !ok {
+		return nil, 0, errors.New("not found")
+	}
+	return o.output, o.height, nil
+}
+
+func signedTestTx(t *testing.T) (*bt.Tx, mapProvider) {
+	const lockingScript = "76a914c0a3c167a28cabb9fbb495affa0761e6e74ac60d88ac"
+	txIDs := []string{
+		"07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		"93a35408b6068499e0d5abd799d3e827d9bfe70c9b75ebe209c91d2507232651",
+		"1dd7ad77d93879f00dcfeee50ef258775ab13fe0bcfb8f51994ec6f2d295be45",
+	}
+	heights := []uint32{interpreter.GenesisActivationHeight - 1, interpreter.GenesisActivationHeight, interpreter.MempoolHeight}
+
+	tx := bt.NewTx()
+	provider := mapProvider{}
+	for i, txID := range txIDs {
+		if err := tx.From(txID, uint32(i), lockingScript, 10000); err != nil {
+			t.Fatalf("failed to add input: %v", err)
+		}
+
+		ls, err := bscript.NewFromHexString(lockingScript)
+		if err != nil {
+			t.Fatalf("failed to parse locking script: %v", err)
+		}
+		provider[fmt.Sprintf("%s:%d", txID, i)] = prevOutput{
+			output: &bt.Output{LockingScript: ls, Satoshis: 10000},
+			height: heights[i],
+		}
+	}
+	if err := tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 29000); err != nil {
+		t.Fatalf("failed to add output: %v", err)
+	}
+
+	w, err := wif.DecodeWIF("cNGwGSc7KRrTmdLUZ54fiSXWbhLNDc2Eg5zNucgQxyQCzuQ5YRDq")
+	if err != nil {
+		t.Fatalf("failed to decode wif: %v", err)
+	}
+	if _, err := tx.SignAuto(context.Background(), &bt.LocalSigner{PrivateKey: w.PrivKey}); err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+
+	// Round trip the tx so that only the provider knows the previous outputs.
+	tx, err = bt.NewTxFromString(hex.EncodeToString(tx.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to parse tx: %v", err)
+	}
+
+	return tx, provider
+}
+
+func TestVerifyTx(t *testing.T) {
+	t.Parallel()
+
+	for _, concurrency := range []int{0, 3} {
+		concurrency := concurrency
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			t.Parallel()
+
+			tx, provider := signedTestTx(t)
+
+			res, err := interpreter.VerifyTx(context.Background(), interpreter.VerifyTxParams{
+				Tx:              tx,
+				PreviousOutputs: provider,
+				Concurrency:     concurrency,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !res.Valid() {
+				t.Fatalf("expected valid tx, got %v", res.Err())
+			}
+			if len(res.Inputs) != 3 {
+				t.Fatalf("expected 3 input results, got %d", len(res.Inputs))
+			}
+			if res.Inputs[0].Flags.HasFlag(interpreter.ScriptUTXOAfterGenesis) {
+				t.Error("expected pre genesis flags for input 0")
+			}
+			for _, i := range []int{1, 2} {
+				if !res.Inputs[i].Flags.HasFlag(interpreter.ScriptUTXOAfterGenesis) {
+					t.Errorf("expected post genesis flags for input %d", i)
+				}
+			}
+			for i, in := range tx.Inputs {
+				if in.PreviousTxScript != nil || in.PreviousTxSatoshis != 0 {
+					t.Errorf("expected input %d of the verified tx to be unchanged", i)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyTx_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	tx, provider := signedTestTx(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := interpreter.VerifyTx(ctx, interpreter.VerifyTxParams{
+		Tx:              tx,
+		PreviousOutputs: provider,
+		Concurrency:     3,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestVerifyTx_InvalidInput(t *testing.T) {
+	t.Parallel()
+
+	tx, provider := signedTestTx(t)
+
+	// The signature commits to the satoshis of the output being spent.
+	key := fmt.Sprintf("%s:%d", tx.Inputs[1].PreviousTxIDStr(), 1)
+	po := provider[key]
+	provider[key] = prevOutput{
+		output: &bt.Output{LockingScript: po.output.LockingScript, Satoshis: 20000},
+		height: po.height,
+	}
+
+	res, err := interpreter.VerifyTx(context.Background(), interpreter.VerifyTxParams{
+		Tx:              tx,
+		PreviousOutputs: provider,
+		Concurrency:     2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Valid() {
+		t.Fatal("expected invalid tx")
+	}
+	for i, in := range res.Inputs {
+		if (in.Err != nil) != (i == 1) {
+			t.Errorf("unexpected result for input %d: %v", i, in.Err)
+		}
+	}
+	if !interpreter.IsErrorCode(res.Err(), interpreter.ErrNullFail) {
+		t.Errorf("expected ErrNullFail, got %v", res.Err())
+	}
+}
+
+func TestVerifyTx_MissingOutput(t *testing.T) {
+	t.Parallel()
+
+	tx, provider := signedTestTx(t)
+	delete(provider, fmt.Sprintf("%s:%d", tx.Inputs[2].PreviousTxIDStr(), 2))
+
+	if _, err := interpreter.VerifyTx(context.Background(), interpreter.VerifyTxParams{
+		Tx:              tx,
+		PreviousOutputs: provider,
+	}); err == nil {
+		t.Fatal("expected error for missing previous output")
+	}
+}
+
+func TestVerifyTx_Extended(t *testing.T) {
+	t.Parallel()
+
+	tx, provider := signedTestTx(t)
+	if _, err := interpreter.VerifyTx(context.Background(), interpreter.VerifyTxParams{Tx: tx}); err == nil {
+		t.Fatal("expected error for unknown previous outputs")
+	}
+
+	for i, in := range tx.Inputs {
+		o := provider[fmt.Sprintf("%s:%d", in.PreviousTxIDStr(), i)].output
+		in.PreviousTxSatoshis = o.Satoshis
+		in.PreviousTxScript = o.LockingScript
+	}
+	tx, err := bt.NewTxFromString(tx.ExtendedString())
+	if err != nil {
+		t.Fatalf("failed to parse extended tx: %v", err)
+	}
+
+	res, err := interpreter.VerifyTx(context.Background(), interpreter.VerifyTxParams{Tx: tx})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Valid() {
+		t.Fatalf("expected valid tx, got %v", res.Err())
+	}
+	for _, in := range res.Inputs {
+		if in.Height != interpreter.MempoolHeight {
+			t.Errorf("input %d: expected mempool height, got %d", in.InputIdx, in.Height)
+		}
+	}
+
+	// The engine also falls back to the previous output on the input.
+	if err := interpreter.NewEngine().Execute(interpreter.ExecutionParams{
+		Tx:       tx,
+		InputIdx: 1,
+		Flags:    interpreter.ScriptEnableSighashForkID | interpreter.ScriptUTXOAfterGenesis,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tx.Inputs[1].PreviousTxScript = nil
+	err = interpreter.NewEngine().Execute(interpreter.ExecutionParams{Tx: tx, InputIdx: 1})
+	if !interpreter.IsErrorCode(err, interpreter.ErrInvalidParams) {
+		t.Fatalf("expected ErrInvalidParams, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/libsv/go-bk/bec"
@@ -16,6 +17,7 @@ var (
 	ErrInvalidOpCode = errors.New("invalid opcode data")
 	ErrEmptyScript   = errors.New("script is empty")
 	ErrNotP2PKH      = errors.New("not a P2PKH")
+	ErrNotMultiSig   = errors.New("not a multisig")
 )
 
 // ScriptKey types.
@@ -299,6 +301,69 @@ func (s *Script) IsMultiSigOut() bool {
 		parts[len(parts)-1][0] == OpCHECKMULTISIG
 }
 
+// NewHashPuzzle creates a hash puzzle locking script, which is spent by
+// revealing the preimage of hash and signing with the key of pubKeyHash.
+func NewHashPuzzle(hash, pubKeyHash []byte) (*Script, error) {
+	s := &Script{}
+	s.AppendOpCode(OpHASH160)
+	if err := s.AppendPushData(hash); err != nil {
+		return nil, err
+	}
+	s.AppendOpCode(OpEQUALVERIFY).
+		AppendOpCode(OpDUP).
+		AppendOpCode(OpHASH160)
+	if err := s.AppendPushData(pubKeyHash); err != nil {
+		return nil, err
+	}
+	s.AppendOpCode(OpEQUALVERIFY).
+		AppendOpCode(OpCHECKSIG)
+
+	return s, nil
+}
+
+// NewMultiSigOut creates a new bare multisig locking script which requires
+// m signatures from the public keys provided.
+func NewMultiSigOut(m int, pubKeys [][]byte) (*Script, error) {
+	if m < 1 || m > len(pubKeys) || len(pubKeys) > 16 {
+		return nil, fmt.Errorf("invalid %d of %d multisig", m, len(pubKeys))
+	}
+
+	s := &Script{}
+	s.AppendOpCode(OpONE + byte(m) - 1)
+	if err := s.AppendPushDataArray(pubKeys); err != nil {
+		return nil, err
+	}
+	s.AppendOpCode(OpONE + byte(len(pubKeys)) - 1)
+	s.AppendOpCode(OpCHECKMULTISIG)
+
+	return s, nil
+}
+
+// MultiSigOut returns the number of signatures required and the public keys
+// of a bare multisig locking script.
+func (s *Script) MultiSigOut() (int, [][]byte, error) {
+	if s == nil || !s.IsMultiSigOut() {
+		return 0, nil, ErrNotMultiSig
+	}
+
+	parts, err := DecodeParts(*s)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Check the small ints are opcodes rather than single byte pushes, and
+	// agree with the number of keys.
+	b := []byte(*s)
+	pubKeys := parts[1 : len(parts)-2]
+	m, n := int(b[0]-OpONE)+1, int(b[len(b)-2]-OpONE)+1
+	if b[0] < OpONE || b[0] > Op16 || b[len(b)-2] < OpONE || b[len(b)-2] > Op16 ||
+		m > n || n != len(pubKeys) {
+		return 0, nil, ErrNotMultiSig
+	}
+
+	return m, pubKeys, nil
+}
+
 func isSmallIntOp(opcode byte) bool {
 	return opcode == OpZERO || (opcode >= OpONE && opcode <= Op16)
 }
@@ -321,39 +386,45 @@ func (s *Script) PublicKeyHash() ([]byte, error) {
 	return parts[0], nil
 }
 
-// ScriptType returns the type of script this is as a string.
+// ScriptType returns the type of script this is as a string, being the type
+// of the registered template it matches.
 func (s *Script) ScriptType() string {
-	if s.IsP2PKH() {
-		return ScriptTypePubKeyHash
-	}
-	if s.IsP2PK() {
-		return ScriptTypePubKey
-	}
-	if s.IsMultiSigOut() {
-		return ScriptTypeMultiSig
-	}
-	if s.IsData() {
-		return ScriptTypeNullData
+	t, _, err := TemplateFor(s)
+	if err != nil {
+		return ScriptTypeNonStandard
 	}
-	return ScriptTypeNonStandard
+	return t.Type()
 }
 
-// Addresses will return all addresses found in the script, if any.
+// Addresses will return all addresses found in the script, if any. These are
+// the addresses of the public keys, or public key hashes, in the params of
+// the template it matches.
 func (s *Script) Addresses() ([]string, error) {
 	addresses := make([]string, 0)
-	if s.IsP2PKH() {
-		pkh, err := s.PublicKeyHash()
-		if err != nil {
-			return nil, err
-		}
+	_, params, err := TemplateFor(s)
+	if errors.Is(err, ErrUnknownTemplate) {
+		return addresses, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkhs := make([][]byte, 0, len(params.PubKeys)+1)
+	if params.PubKeyHash != nil {
+		pkhs = append(pkhs, params.PubKeyHash)
+	}
+	for _, pk := range params.PubKeys {
+		pkhs = append(pkhs, crypto.Hash160(pk))
+	}
+
+	for _, pkh := range pkhs {
 		a, err := NewAddressFromPublicKeyHash(pkh, true)
 		if err != nil {
 			return nil, err
 		}
-		addresses = []string{a.AddressString}
+		addresses = append(addresses, a.AddressString)
 	}
-	// TODO: handle multisig, and other outputs
-	// https://github.com/libsv/go-bt/issues/6
+
 	return addresses, nil
 }
 
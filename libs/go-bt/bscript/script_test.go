@@ -137,6 +137,26 @@ func TestScript_IsMultisigOut(t *testing.T) { // TODO: check this
 	assert.Equal(t, true, scriptPub.IsMultiSigOut())
 }
 
+func TestNewMultiSigOut(t *testing.T) {
+	t.Parallel()
+
+	pubKeys := [][]byte{{0x11}, {0x22}, {0x33}}
+	s, err := bscript.NewMultiSigOut(2, pubKeys)
+	assert.NoError(t, err)
+	assert.Equal(t, "5201110122013353ae", s.String())
+
+	m, pks, err := s.MultiSigOut()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m)
+	assert.Equal(t, pubKeys, pks)
+
+	_, err = bscript.NewMultiSigOut(4, pubKeys)
+	assert.Error(t, err)
+
+	_, _, err = bscript.NewFromBytes([]byte{bscript.OpTRUE}).MultiSigOut()
+	assert.ErrorIs(t, err, bscript.ErrNotMultiSig)
+}
+
 func TestScript_PublicKeyHash(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,160 @@
+package bscript
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// Sentinel errors raised by script templates.
+var (
+	ErrTemplateMismatch   = errors.New("script does not match template")
+	ErrUnknownTemplate    = errors.New("no template matches script")
+	ErrDuplicateTemplate  = errors.New("template already registered for script type")
+	ErrUnspendable        = errors.New("script cannot be spent")
+	ErrMissingUnlockParam = errors.New("missing param needed to unlock script")
+	ErrNoSigningKey       = errors.New("no key available to sign")
+)
+
+// Script types of the standard templates, in addition to those above.
+const (
+	ScriptTypeRPuzzle    = "rpuzzle"
+	ScriptTypeHashPuzzle = "hashpuzzle"
+)
+
+// Template is a standard form of locking script. It builds locking scripts
+// from params, parses the params back out of a locking script, and builds
+// unlocking scripts which spend them.
+//
+// Custom templates can be added with RegisterTemplate, after which they are
+// recognised by Script.ScriptType and Script.Addresses.
+type Template interface {
+	// Type returns the script type, as returned by Script.ScriptType.
+	Type() string
+
+	// Build creates a locking script from the params.
+	Build(params *TemplateParams) (*Script, error)
+
+	// Parse extracts the params from a locking script. ErrTemplateMismatch is
+	// returned if the script does not match the template.
+	Parse(s *Script) (*TemplateParams, error)
+
+	// Unlock creates an unlocking script spending a locking script with the
+	// params given, signing with the keys in up.
+	Unlock(params *TemplateParams, up *UnlockParams) (*Script, error)
+}
+
+// TemplateParams are the values which vary between scripts of a template.
+// Each template only uses the fields it needs.
+type TemplateParams struct {
+	// PubKeys are the keys of a P2PK or multisig script.
+	PubKeys [][]byte
+	// PubKeyHash is the key hash of a P2PKH or hash puzzle script.
+	PubKeyHash []byte
+	// Required is the number of signatures needed by a multisig script.
+	Required int
+	// RHash is the hash160 of the R value an R-puzzle signature must use.
+	RHash []byte
+	// Hash is the hash160 of the secret of a hash puzzle script.
+	Hash []byte
+	// Data is the data pushed by an OP_RETURN script.
+	Data [][]byte
+}
+
+// UnlockParams are the keys and secrets used when unlocking a script.
+type UnlockParams struct {
+	// PubKeys are the public keys which Sign can sign with.
+	PubKeys [][]byte
+	// Sign returns the DER signature, without the sighash flag, of the
+	// input being unlocked made with the private key of pubKey.
+	Sign func(pubKey []byte) ([]byte, error)
+	// SigHashFlag is appended to each signature.
+	SigHashFlag sighash.Flag
+	// Preimage is the secret revealed to unlock a hash puzzle.
+	Preimage []byte
+}
+
+type templateRegistry struct {
+	sync.RWMutex
+	templates []Template
+}
+
+var registry = &templateRegistry{
+	templates: []Template{
+		p2pkhTemplate{},
+		p2pkTemplate{},
+		multiSigTemplate{},
+		rPuzzleTemplate{},
+		hashPuzzleTemplate{},
+		dataTemplate{},
+	},
+}
+
+// RegisterTemplate adds a custom template to those recognised. Templates are
+// matched in the order registered, after the standard templates.
+func RegisterTemplate(t Template) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, tt := range registry.templates {
+		if tt.Type() == t.Type() {
+			return fmt.Errorf("%w: %s", ErrDuplicateTemplate, t.Type())
+		}
+	}
+	registry.templates = append(registry.templates, t)
+
+	return nil
+}
+
+// Templates returns the registered templates, in the order they are matched.
+func Templates() []Template {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return append([]Template{}, registry.templates...)
+}
+
+// TemplateByType returns the template for a script type.
+func TemplateByType(scriptType string) (Template, error) {
+	for _, t := range Templates() {
+		if t.Type() == scriptType {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, scriptType)
+}
+
+// TemplateFor returns the first template matching the script along with the
+// params parsed from it. ErrUnknownTemplate is returned if no template
+// matches, meaning the script is nonstandard.
+func TemplateFor(s *Script) (Template, *TemplateParams, error) {
+	if s == nil || len(*s) == 0 {
+		return nil, nil, ErrUnknownTemplate
+	}
+
+	for _, t := range Templates() {
+		params, err := t.Parse(s)
+		if err == nil {
+			return t, params, nil
+		}
+		if !errors.Is(err, ErrTemplateMismatch) {
+			return nil, nil, err
+		}
+	}
+
+	return nil, nil, ErrUnknownTemplate
+}
+
+// Unlock creates an unlocking script spending the script, using the
+// template it matches.
+func (s *Script) Unlock(up *UnlockParams) (*Script, error) {
+	t, params, err := TemplateFor(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Unlock(params, up)
+}
@@ -0,0 +1,259 @@
+package bscript_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+var (
+	testPubKey, _   = hex.DecodeString("023717efaec6761e457f55c8417815505b695209d0bbfed8c3265be425b373c2d6")
+	testPubKey2, _  = hex.DecodeString("03a3a5bd0ed6b20e5e7d6f8e20fd3c78d3a6d3b1a9e5f1d04e2c0e7a5b8d4f2c11")
+	testPubKeyHash  = crypto.Hash160(testPubKey)
+	testSecretHash  = crypto.Hash160([]byte("secret"))
+	testRHash       = crypto.Hash160([]byte("r value"))
+	testDERSig, _   = hex.DecodeString("3044022012345678901234567890123456789012345678901234567890123456789012022012345678901234567890123456789012345678901234567890123456789012")
+	errTestSigner   = errors.New("signer failed")
+	testSignerCalls = func(calls *[][]byte) func([]byte) ([]byte, error) {
+		return func(pubKey []byte) ([]byte, error) {
+			*calls = append(*calls, pubKey)
+			return testDERSig, nil
+		}
+	}
+)
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		params     *bscript.TemplateParams
+		scriptType string
+		addresses  int
+	}{
+		"p2pkh": {
+			params:     &bscript.TemplateParams{PubKeyHash: testPubKeyHash},
+			scriptType: bscript.ScriptTypePubKeyHash,
+			addresses:  1,
+		},
+		"p2pk": {
+			params:     &bscript.TemplateParams{PubKeys: [][]byte{testPubKey}},
+			scriptType: bscript.ScriptTypePubKey,
+			addresses:  1,
+		},
+		"multisig": {
+			params:     &bscript.TemplateParams{PubKeys: [][]byte{testPubKey, testPubKey2}, Required: 1},
+			scriptType: bscript.ScriptTypeMultiSig,
+			addresses:  2,
+		},
+		"r-puzzle": {
+			params:     &bscript.TemplateParams{RHash: testRHash},
+			scriptType: bscript.ScriptTypeRPuzzle,
+		},
+		"hash puzzle": {
+			params:     &bscript.TemplateParams{Hash: testSecretHash, PubKeyHash: testPubKeyHash},
+			scriptType: bscript.ScriptTypeHashPuzzle,
+			addresses:  1,
+		},
+		"data": {
+			params:     &bscript.TemplateParams{Data: [][]byte{[]byte("hello"), []byte("world")}},
+			scriptType: bscript.ScriptTypeNullData,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := bscript.TemplateByType(test.scriptType)
+			assert.NoError(t, err)
+
+			s, err := tmpl.Build(test.params)
+			assert.NoError(t, err)
+			assert.Equal(t, test.scriptType, s.ScriptType())
+
+			matched, params, err := bscript.TemplateFor(s)
+			assert.NoError(t, err)
+			assert.Equal(t, tmpl, matched)
+			assert.Equal(t, test.params, params)
+
+			addresses, err := s.Addresses()
+			assert.NoError(t, err)
+			assert.Len(t, addresses, test.addresses)
+		})
+	}
+}
+
+func TestTemplateFor_NonStandard(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []*bscript.Script{nil, {}, {bscript.OpNOP}, {bscript.Op1, bscript.OpCHECKMULTISIG}} {
+		_, _, err := bscript.TemplateFor(s)
+		assert.ErrorIs(t, err, bscript.ErrUnknownTemplate)
+	}
+	assert.Equal(t, bscript.ScriptTypeNonStandard, (&bscript.Script{}).ScriptType())
+
+	addresses, err := (&bscript.Script{bscript.OpNOP}).Addresses()
+	assert.NoError(t, err)
+	assert.Empty(t, addresses)
+}
+
+func TestScript_Addresses(t *testing.T) {
+	t.Parallel()
+
+	s, err := bscript.NewP2PKHFromPubKeyBytes(testPubKey)
+	assert.NoError(t, err)
+	p2pkh, err := s.Addresses()
+	assert.NoError(t, err)
+
+	tmpl, err := bscript.TemplateByType(bscript.ScriptTypePubKey)
+	assert.NoError(t, err)
+	s, err = tmpl.Build(&bscript.TemplateParams{PubKeys: [][]byte{testPubKey}})
+	assert.NoError(t, err)
+	p2pk, err := s.Addresses()
+	assert.NoError(t, err)
+
+	assert.Equal(t, p2pkh, p2pk)
+}
+
+func TestTemplate_Unlock(t *testing.T) {
+	t.Parallel()
+
+	build := func(t *testing.T, scriptType string, params *bscript.TemplateParams) *bscript.Script {
+		tmpl, err := bscript.TemplateByType(scriptType)
+		assert.NoError(t, err)
+		s, err := tmpl.Build(params)
+		assert.NoError(t, err)
+		return s
+	}
+	sigPush := hex.EncodeToString(append([]byte{byte(len(testDERSig) + 1)}, testDERSig...)) + "41"
+
+	t.Run("p2pkh", func(t *testing.T) {
+		var calls [][]byte
+		s := build(t, bscript.ScriptTypePubKeyHash, &bscript.TemplateParams{PubKeyHash: testPubKeyHash})
+		uls, err := s.Unlock(&bscript.UnlockParams{
+			PubKeys: [][]byte{testPubKey2, testPubKey},
+			Sign:    testSignerCalls(&calls),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]byte{testPubKey}, calls)
+		assert.Equal(t, sigPush+"21"+hex.EncodeToString(testPubKey), uls.String())
+
+		_, err = s.Unlock(&bscript.UnlockParams{PubKeys: [][]byte{testPubKey2}, Sign: testSignerCalls(&calls)})
+		assert.ErrorIs(t, err, bscript.ErrNoSigningKey)
+	})
+
+	t.Run("p2pk", func(t *testing.T) {
+		var calls [][]byte
+		s := build(t, bscript.ScriptTypePubKey, &bscript.TemplateParams{PubKeys: [][]byte{testPubKey}})
+		uls, err := s.Unlock(&bscript.UnlockParams{PubKeys: [][]byte{testPubKey}, Sign: testSignerCalls(&calls)})
+		assert.NoError(t, err)
+		assert.Equal(t, sigPush, uls.String())
+	})
+
+	t.Run("multisig signs in key order", func(t *testing.T) {
+		var calls [][]byte
+		s := build(t, bscript.ScriptTypeMultiSig, &bscript.TemplateParams{
+			PubKeys:  [][]byte{testPubKey, testPubKey2},
+			Required: 2,
+		})
+		uls, err := s.Unlock(&bscript.UnlockParams{
+			PubKeys: [][]byte{testPubKey2, testPubKey},
+			Sign:    testSignerCalls(&calls),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]byte{testPubKey, testPubKey2}, calls)
+		assert.Equal(t, "00"+sigPush+sigPush, uls.String())
+
+		_, err = s.Unlock(&bscript.UnlockParams{PubKeys: [][]byte{testPubKey2}, Sign: testSignerCalls(&calls)})
+		assert.ErrorIs(t, err, bscript.ErrNoSigningKey)
+	})
+
+	t.Run("multisig signer error", func(t *testing.T) {
+		s := build(t, bscript.ScriptTypeMultiSig, &bscript.TemplateParams{
+			PubKeys:  [][]byte{testPubKey},
+			Required: 1,
+		})
+		_, err := s.Unlock(&bscript.UnlockParams{
+			PubKeys: [][]byte{testPubKey},
+			Sign:    func([]byte) ([]byte, error) { return nil, errTestSigner },
+		})
+		assert.ErrorIs(t, err, errTestSigner)
+	})
+
+	t.Run("r-puzzle with wrong R", func(t *testing.T) {
+		var calls [][]byte
+		s := build(t, bscript.ScriptTypeRPuzzle, &bscript.TemplateParams{RHash: testRHash})
+		_, err := s.Unlock(&bscript.UnlockParams{PubKeys: [][]byte{testPubKey}, Sign: testSignerCalls(&calls)})
+		assert.Error(t, err)
+	})
+
+	t.Run("hash puzzle", func(t *testing.T) {
+		var calls [][]byte
+		s := build(t, bscript.ScriptTypeHashPuzzle, &bscript.TemplateParams{
+			Hash:       testSecretHash,
+			PubKeyHash: testPubKeyHash,
+		})
+		up := &bscript.UnlockParams{PubKeys: [][]byte{testPubKey}, Sign: testSignerCalls(&calls)}
+		_, err := s.Unlock(up)
+		assert.ErrorIs(t, err, bscript.ErrMissingUnlockParam)
+
+		up.Preimage = []byte("wrong")
+		_, err = s.Unlock(up)
+		assert.Error(t, err)
+
+		up.Preimage = []byte("secret")
+		uls, err := s.Unlock(up)
+		assert.NoError(t, err)
+		assert.Equal(t, sigPush+"21"+hex.EncodeToString(testPubKey)+"06"+hex.EncodeToString([]byte("secret")),
+			uls.String())
+	})
+
+	t.Run("data", func(t *testing.T) {
+		s := build(t, bscript.ScriptTypeNullData, &bscript.TemplateParams{Data: [][]byte{{0x01}}})
+		_, err := s.Unlock(&bscript.UnlockParams{})
+		assert.ErrorIs(t, err, bscript.ErrUnspendable)
+	})
+}
+
+type opTrueTemplate struct{}
+
+func (opTrueTemplate) Type() string { return "optrue" }
+
+func (opTrueTemplate) Build(*bscript.TemplateParams) (*bscript.Script, error) {
+	return &bscript.Script{bscript.OpTRUE}, nil
+}
+
+func (opTrueTemplate) Parse(s *bscript.Script) (*bscript.TemplateParams, error) {
+	if !s.EqualsBytes([]byte{bscript.OpTRUE}) {
+		return nil, bscript.ErrTemplateMismatch
+	}
+	return &bscript.TemplateParams{}, nil
+}
+
+func (opTrueTemplate) Unlock(*bscript.TemplateParams, *bscript.UnlockParams) (*bscript.Script, error) {
+	return &bscript.Script{}, nil
+}
+
+// registerOpTrue registers opTrueTemplate once, as templates cannot be
+// unregistered when tests are run more than once.
+var registerOpTrue sync.Once
+
+// TestRegisterTemplate is not parallel as it changes the registered templates.
+func TestRegisterTemplate(t *testing.T) {
+	s := &bscript.Script{bscript.OpTRUE}
+	registerOpTrue.Do(func() {
+		assert.Equal(t, bscript.ScriptTypeNonStandard, s.ScriptType())
+		assert.NoError(t, bscript.RegisterTemplate(opTrueTemplate{}))
+	})
+	assert.ErrorIs(t, bscript.RegisterTemplate(opTrueTemplate{}), bscript.ErrDuplicateTemplate)
+
+	assert.Equal(t, "optrue", s.ScriptType())
+	uls, err := s.Unlock(&bscript.UnlockParams{})
+	assert.NoError(t, err)
+	assert.Empty(t, *uls)
+}
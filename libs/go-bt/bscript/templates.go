@@ -0,0 +1,340 @@
+package bscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// rPuzzlePrefix extracts the R value from the signature of an R-puzzle
+// unlocking script and hashes it.
+var rPuzzlePrefix = []byte{
+	OpOVER, Op3, OpSPLIT, OpNIP, Op1, OpSPLIT, OpSWAP, OpSPLIT, OpDROP, OpHASH160,
+}
+
+// p2pkhTemplate is OP_DUP OP_HASH160 <pubKeyHash> OP_EQUALVERIFY OP_CHECKSIG.
+type p2pkhTemplate struct{}
+
+func (p2pkhTemplate) Type() string {
+	return ScriptTypePubKeyHash
+}
+
+func (p2pkhTemplate) Build(params *TemplateParams) (*Script, error) {
+	pkh := params.PubKeyHash
+	if pkh == nil && len(params.PubKeys) == 1 {
+		pkh = crypto.Hash160(params.PubKeys[0])
+	}
+	if len(pkh) != 20 {
+		return nil, fmt.Errorf("p2pkh requires a 20 byte public key hash, got %d", len(pkh))
+	}
+
+	return NewP2PKHFromPubKeyHash(pkh)
+}
+
+func (p2pkhTemplate) Parse(s *Script) (*TemplateParams, error) {
+	if !s.IsP2PKH() {
+		return nil, ErrTemplateMismatch
+	}
+
+	return &TemplateParams{PubKeyHash: []byte(*s)[3:23]}, nil
+}
+
+func (p2pkhTemplate) Unlock(params *TemplateParams, up *UnlockParams) (*Script, error) {
+	pubKey := up.keyForHash(params.PubKeyHash)
+	if pubKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	sig, err := up.sign(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewP2PKHUnlockingScript(pubKey, sig, up.sigHashFlag())
+}
+
+// p2pkTemplate is <pubKey> OP_CHECKSIG.
+type p2pkTemplate struct{}
+
+func (p2pkTemplate) Type() string {
+	return ScriptTypePubKey
+}
+
+func (p2pkTemplate) Build(params *TemplateParams) (*Script, error) {
+	if len(params.PubKeys) != 1 {
+		return nil, fmt.Errorf("p2pk requires 1 public key, got %d", len(params.PubKeys))
+	}
+
+	s := &Script{}
+	if err := s.AppendPushData(params.PubKeys[0]); err != nil {
+		return nil, err
+	}
+	s.AppendOpCode(OpCHECKSIG)
+
+	return s, nil
+}
+
+func (p2pkTemplate) Parse(s *Script) (*TemplateParams, error) {
+	if !s.IsP2PK() {
+		return nil, ErrTemplateMismatch
+	}
+	parts, err := DecodeParts(*s)
+	if err != nil {
+		return nil, ErrTemplateMismatch
+	}
+
+	return &TemplateParams{PubKeys: parts[:1]}, nil
+}
+
+func (p2pkTemplate) Unlock(params *TemplateParams, up *UnlockParams) (*Script, error) {
+	if !up.hasKey(params.PubKeys[0]) {
+		return nil, ErrNoSigningKey
+	}
+
+	return up.unlockingScript(params.PubKeys[0])
+}
+
+// multiSigTemplate is OP_m <pubKey>... OP_n OP_CHECKMULTISIG.
+type multiSigTemplate struct{}
+
+func (multiSigTemplate) Type() string {
+	return ScriptTypeMultiSig
+}
+
+func (multiSigTemplate) Build(params *TemplateParams) (*Script, error) {
+	return NewMultiSigOut(params.Required, params.PubKeys)
+}
+
+func (multiSigTemplate) Parse(s *Script) (*TemplateParams, error) {
+	m, pubKeys, err := s.MultiSigOut()
+	if err != nil {
+		return nil, ErrTemplateMismatch
+	}
+
+	return &TemplateParams{PubKeys: pubKeys, Required: m}, nil
+}
+
+func (multiSigTemplate) Unlock(params *TemplateParams, up *UnlockParams) (*Script, error) {
+	// Signatures must be in the same order as their keys.
+	sigs := make([][]byte, 0, params.Required)
+	for _, pk := range params.PubKeys {
+		if len(sigs) == params.Required {
+			break
+		}
+		if !up.hasKey(pk) {
+			continue
+		}
+		sig, err := up.sign(pk)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	if len(sigs) < params.Required {
+		return nil, fmt.Errorf("%w: have %d of %d keys", ErrNoSigningKey, len(sigs), params.Required)
+	}
+
+	return NewMultiSigUnlockingScript(sigs, up.sigHashFlag())
+}
+
+// rPuzzleTemplate is OP_OVER OP_3 OP_SPLIT OP_NIP OP_1 OP_SPLIT OP_SWAP OP_SPLIT
+// OP_DROP OP_HASH160 <rHash> OP_EQUALVERIFY OP_CHECKSIG, which is spent by any
+// key signing with the k value behind R.
+type rPuzzleTemplate struct{}
+
+func (rPuzzleTemplate) Type() string {
+	return ScriptTypeRPuzzle
+}
+
+func (rPuzzleTemplate) Build(params *TemplateParams) (*Script, error) {
+	if len(params.RHash) != 20 {
+		return nil, fmt.Errorf("r-puzzle requires a 20 byte r hash, got %d", len(params.RHash))
+	}
+
+	s := NewFromBytes(append([]byte{}, rPuzzlePrefix...))
+	if err := s.AppendPushData(params.RHash); err != nil {
+		return nil, err
+	}
+	s.AppendOpCode(OpEQUALVERIFY).AppendOpCode(OpCHECKSIG)
+
+	return s, nil
+}
+
+func (rPuzzleTemplate) Parse(s *Script) (*TemplateParams, error) {
+	b := []byte(*s)
+	l := len(rPuzzlePrefix)
+	if len(b) != l+23 || !bytes.HasPrefix(b, rPuzzlePrefix) || b[l] != OpDATA20 ||
+		b[l+21] != OpEQUALVERIFY || b[l+22] != OpCHECKSIG {
+		return nil, ErrTemplateMismatch
+	}
+
+	return &TemplateParams{RHash: b[l+1 : l+21]}, nil
+}
+
+func (rPuzzleTemplate) Unlock(params *TemplateParams, up *UnlockParams) (*Script, error) {
+	if len(up.PubKeys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	pubKey := up.PubKeys[0]
+	sig, err := up.sign(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := sigR(sig)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Hash160(r), params.RHash) {
+		return nil, errors.New("signature R value does not solve r-puzzle")
+	}
+
+	return NewP2PKHUnlockingScript(pubKey, sig, up.sigHashFlag())
+}
+
+// hashPuzzleTemplate is OP_HASH160 <hash> OP_EQUALVERIFY OP_DUP OP_HASH160
+// <pubKeyHash> OP_EQUALVERIFY OP_CHECKSIG, which is spent by revealing the
+// secret and signing with the key.
+type hashPuzzleTemplate struct{}
+
+func (hashPuzzleTemplate) Type() string {
+	return ScriptTypeHashPuzzle
+}
+
+func (hashPuzzleTemplate) Build(params *TemplateParams) (*Script, error) {
+	if len(params.Hash) != 20 || len(params.PubKeyHash) != 20 {
+		return nil, errors.New("hash puzzle requires a 20 byte hash and public key hash")
+	}
+
+	return NewHashPuzzle(params.Hash, params.PubKeyHash)
+}
+
+func (hashPuzzleTemplate) Parse(s *Script) (*TemplateParams, error) {
+	b := []byte(*s)
+	if len(b) != 48 ||
+		b[0] != OpHASH160 || b[1] != OpDATA20 || b[22] != OpEQUALVERIFY ||
+		!NewFromBytes(b[23:]).IsP2PKH() {
+		return nil, ErrTemplateMismatch
+	}
+
+	return &TemplateParams{Hash: b[2:22], PubKeyHash: b[26:46]}, nil
+}
+
+func (hashPuzzleTemplate) Unlock(params *TemplateParams, up *UnlockParams) (*Script, error) {
+	if up.Preimage == nil {
+		return nil, fmt.Errorf("%w: preimage", ErrMissingUnlockParam)
+	}
+	if !bytes.Equal(crypto.Hash160(up.Preimage), params.Hash) {
+		return nil, errors.New("preimage does not solve hash puzzle")
+	}
+
+	pubKey := up.keyForHash(params.PubKeyHash)
+	if pubKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	return up.unlockingScript(pubKey, pubKey, up.Preimage)
+}
+
+// dataTemplate is OP_FALSE OP_RETURN <data>..., or OP_RETURN <data>...
+type dataTemplate struct{}
+
+func (dataTemplate) Type() string {
+	return ScriptTypeNullData
+}
+
+func (dataTemplate) Build(params *TemplateParams) (*Script, error) {
+	s := &Script{}
+	s.AppendOpCode(OpFALSE).AppendOpCode(OpRETURN)
+	if err := s.AppendPushDataArray(params.Data); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (dataTemplate) Parse(s *Script) (*TemplateParams, error) {
+	b := []byte(*s)
+	switch {
+	case len(b) > 0 && b[0] == OpRETURN:
+		b = b[1:]
+	case len(b) > 1 && b[0] == OpFALSE && b[1] == OpRETURN:
+		b = b[2:]
+	default:
+		return nil, ErrTemplateMismatch
+	}
+
+	// Anything may follow OP_RETURN, so data which is not all pushes is
+	// still a data script.
+	data, _ := DecodeParts(b)
+
+	return &TemplateParams{Data: data}, nil
+}
+
+func (dataTemplate) Unlock(*TemplateParams, *UnlockParams) (*Script, error) {
+	return nil, ErrUnspendable
+}
+
+func (up *UnlockParams) sigHashFlag() sighash.Flag {
+	if up.SigHashFlag == 0 {
+		return sighash.AllForkID
+	}
+	return up.SigHashFlag
+}
+
+func (up *UnlockParams) hasKey(pubKey []byte) bool {
+	for _, pk := range up.PubKeys {
+		if bytes.Equal(pk, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (up *UnlockParams) keyForHash(pubKeyHash []byte) []byte {
+	for _, pk := range up.PubKeys {
+		if bytes.Equal(crypto.Hash160(pk), pubKeyHash) {
+			return pk
+		}
+	}
+	return nil
+}
+
+func (up *UnlockParams) sign(pubKey []byte) ([]byte, error) {
+	if up.Sign == nil {
+		return nil, fmt.Errorf("%w: sign", ErrMissingUnlockParam)
+	}
+	return up.Sign(pubKey)
+}
+
+// unlockingScript signs with the key and pushes the signature, followed by
+// any other data given.
+func (up *UnlockParams) unlockingScript(pubKey []byte, pushes ...[]byte) (*Script, error) {
+	sig, err := up.sign(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Script{}
+	sigBuf := make([]byte, 0, len(sig)+1)
+	sigBuf = append(sigBuf, sig...)
+	sigBuf = append(sigBuf, byte(up.sigHashFlag()))
+	if err = s.AppendPushData(sigBuf); err != nil {
+		return nil, err
+	}
+	if err = s.AppendPushDataArray(pushes); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// sigR returns the R value of a DER signature.
+func sigR(sig []byte) ([]byte, error) {
+	if len(sig) < 4 || sig[0] != 0x30 || sig[2] != 0x02 || len(sig) < 4+int(sig[3]) {
+		return nil, errors.New("malformed DER signature")
+	}
+
+	return sig[4 : 4+int(sig[3])], nil
+}
@@ -19,3 +19,24 @@ func NewP2PKHUnlockingScript(pubKey []byte, sig []byte, sigHashFlag sighash.Flag
 
 	return s, err
 }
+
+// NewMultiSigUnlockingScript creates a new unlocking script which spends a
+// bare multisig locking script from signatures, given in the same order as
+// their public keys appear in the locking script, and a SIGHASH flag.
+func NewMultiSigUnlockingScript(sigs [][]byte, sigHashFlag sighash.Flag) (*Script, error) {
+	// OP_CHECKMULTISIG pops one more item than it uses.
+	s := &Script{}
+	s.AppendOpCode(OpZERO)
+
+	for _, sig := range sigs {
+		sigBuf := make([]byte, 0, len(sig)+1)
+		sigBuf = append(sigBuf, sig...)
+		sigBuf = append(sigBuf, uint8(sigHashFlag))
+
+		if err := s.AppendPushData(sigBuf); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
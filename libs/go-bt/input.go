@@ -1,6 +1,7 @@
 package bt
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -167,3 +168,17 @@ func (i *Input) Bytes(clear bool) []byte {
 
 	return append(h, LittleEndianBytes(i.SequenceNumber, 4)...)
 }
+
+// previousOutputBytes encodes the satoshis and locking script of the output
+// spent by the input, as appended to the input in the extended format.
+func (i *Input) previousOutputBytes() []byte {
+	h := make([]byte, 8)
+	binary.LittleEndian.PutUint64(h, i.PreviousTxSatoshis)
+
+	if i.PreviousTxScript == nil {
+		return append(h, VarInt(0)...)
+	}
+	h = append(h, VarInt(uint64(len(*i.PreviousTxScript)))...)
+
+	return append(h, *i.PreviousTxScript...)
+}
@@ -56,6 +56,10 @@ func (o *Output) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	reqSigs := len(addresses)
+	if _, params, err := bscript.TemplateFor(o.LockingScript); err == nil && params.Required > 0 {
+		reqSigs = params.Required
+	}
 
 	output := &outputJSON{
 		Value:    float64(o.Satoshis) / 100000000,
@@ -69,7 +73,7 @@ func (o *Output) MarshalJSON() ([]byte, error) {
 		}{
 			Asm:     asm,
 			Hex:     o.LockingScriptHexString(),
-			ReqSigs: len(addresses),
+			ReqSigs: reqSigs,
 			Type:    o.LockingScript.ScriptType(),
 		},
 	}
@@ -0,0 +1,628 @@
+package bt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// Sentinel errors reported by a PartiallySignedTx.
+var (
+	ErrPartialTxMismatch        = errors.New("partially signed txs are for different txs")
+	ErrPartialNoPreviousOutput  = errors.New("previous output of input is unknown")
+	ErrPartialInvalidSignature  = errors.New("signature does not sign the input for the public key")
+	ErrPartialUnsupportedScript = errors.New("previous output script cannot be finalised")
+	ErrPartialMissingSignatures = errors.New("input does not have enough signatures")
+	ErrPartialInvalidFormat     = errors.New("invalid partially signed tx format")
+)
+
+// partialTxMagic prefixes the binary serialisation of a PartiallySignedTx.
+var partialTxMagic = []byte{'b', 's', 'v', 'p', 0xff}
+
+/*
+Binary format of a partially signed tx
+--------------------------------------------------------
+Field              Description                                               Size
+
+magic              0x62 0x73 0x76 0x70 0xff ("bsvp" 0xff)                    5 bytes
+
+tx length          VI = VarInt                                               1 - 9 bytes
+tx                 the unsigned tx                                           <tx length>-many bytes
+
+then for each input of the tx:
+
+has prev output    0x01 if the previous output follows, otherwise 0x00       1 byte
+prev output        the output spent by the input, as in a tx                 variable
+sighash flag       the flag the input is signed with                         1 byte
+sig count          VI = VarInt                                               1 - 9 bytes
+sigs               VI public key length, public key,                         variable
+                   VI signature length, DER signature
+derivation count   VI = VarInt                                               1 - 9 bytes
+derivations        VI public key length, public key,                         variable
+                   4 bytes master key fingerprint, VI path length, path
+--------------------------------------------------------
+*/
+
+// PartiallySignedTx carries an unsigned tx between the parties signing it,
+// along with everything they need to sign each input: the output it spends,
+// the sighash flag to sign with, and hints for deriving the keys. Signatures
+// are collected per input until there are enough to finalise the tx.
+//
+// Each party signs its own copy with Sign, the copies are merged with Combine,
+// and Finalise builds the signed tx once every input has enough signatures.
+type PartiallySignedTx struct {
+	Tx     *Tx
+	Inputs []*PartialInput
+}
+
+// PartialInput is the signing data for an input of a PartiallySignedTx.
+type PartialInput struct {
+	PreviousOutput *Output
+	SigHashFlag    sighash.Flag
+	Signatures     []*PartialSignature
+	Derivations    []*KeyDerivation
+}
+
+// PartialSignature is a DER signature, without the sighash flag, made by the
+// holder of PublicKey.
+type PartialSignature struct {
+	PublicKey []byte
+	Signature []byte
+}
+
+// KeyDerivation is a hint telling a signer how to derive the private key for
+// PublicKey from the master key with the given fingerprint.
+type KeyDerivation struct {
+	PublicKey         []byte
+	MasterFingerprint uint32
+	Path              string
+}
+
+type partialTxJSON struct {
+	Tx     string              `json:"tx"`
+	Inputs []*partialInputJSON `json:"inputs"`
+}
+
+type partialInputJSON struct {
+	PreviousOutput *Output                 `json:"previousOutput,omitempty"`
+	SigHashFlag    sighash.Flag            `json:"sigHashFlag"`
+	Signatures     []*partialSignatureJSON `json:"signatures,omitempty"`
+	Derivations    []*keyDerivationJSON    `json:"derivations,omitempty"`
+}
+
+type partialSignatureJSON struct {
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+type keyDerivationJSON struct {
+	PublicKey         string `json:"publicKey"`
+	MasterFingerprint uint32 `json:"masterFingerprint"`
+	Path              string `json:"path"`
+}
+
+// NewPartiallySignedTx creates a PartiallySignedTx for a copy of the tx with
+// any unlocking scripts removed. The previous outputs are taken from the
+// inputs where known, and every input is signed with sighash.AllForkID unless
+// changed.
+func NewPartiallySignedTx(tx *Tx) *PartiallySignedTx {
+	p := &PartiallySignedTx{
+		Tx:     tx.Clone(),
+		Inputs: make([]*PartialInput, len(tx.Inputs)),
+	}
+	for i, in := range p.Tx.Inputs {
+		in.UnlockingScript = nil
+		p.Inputs[i] = &PartialInput{SigHashFlag: sighash.AllForkID}
+		if in.PreviousTxScript != nil {
+			p.Inputs[i].PreviousOutput = &Output{
+				Satoshis:      in.PreviousTxSatoshis,
+				LockingScript: in.PreviousTxScript,
+			}
+		}
+	}
+
+	return p
+}
+
+// NewPartiallySignedTxFromString decodes a hex encoded PartiallySignedTx.
+func NewPartiallySignedTxFromString(str string) (*PartiallySignedTx, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPartiallySignedTxFromBytes(b)
+}
+
+// NewPartiallySignedTxFromBytes decodes a PartiallySignedTx from the binary
+// format written by Bytes.
+func NewPartiallySignedTxFromBytes(b []byte) (*PartiallySignedTx, error) {
+	r := &partialTxReader{b: b}
+	if !bytes.Equal(r.next(len(partialTxMagic)), partialTxMagic) {
+		return nil, fmt.Errorf("%w: missing magic bytes", ErrPartialInvalidFormat)
+	}
+
+	txBytes := r.varBytes()
+	if r.err != nil {
+		return nil, r.err
+	}
+	tx, err := NewTxFromBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPartialInvalidFormat, err)
+	}
+
+	p := &PartiallySignedTx{
+		Tx:     tx,
+		Inputs: make([]*PartialInput, len(tx.Inputs)),
+	}
+	for i := range p.Inputs {
+		in := &PartialInput{}
+		if r.byte() == 1 {
+			in.PreviousOutput = r.output()
+		}
+		in.SigHashFlag = sighash.Flag(r.byte())
+
+		for n := r.varInt(); n > 0 && r.err == nil; n-- {
+			in.Signatures = append(in.Signatures, &PartialSignature{
+				PublicKey: r.varBytes(),
+				Signature: r.varBytes(),
+			})
+		}
+		for n := r.varInt(); n > 0 && r.err == nil; n-- {
+			kd := &KeyDerivation{PublicKey: r.varBytes()}
+			if fp := r.next(4); fp != nil {
+				kd.MasterFingerprint = binary.LittleEndian.Uint32(fp)
+			}
+			kd.Path = string(r.varBytes())
+			in.Derivations = append(in.Derivations, kd)
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		p.Inputs[i] = in
+		p.applyPreviousOutput(i)
+	}
+
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrPartialInvalidFormat, len(r.b))
+	}
+
+	return p, nil
+}
+
+// Bytes encodes the PartiallySignedTx into its binary format.
+func (p *PartiallySignedTx) Bytes() []byte {
+	h := make([]byte, 0)
+	h = append(h, partialTxMagic...)
+
+	txBytes := p.unsignedTx().Bytes()
+	h = append(h, VarInt(uint64(len(txBytes)))...)
+	h = append(h, txBytes...)
+
+	for _, in := range p.Inputs {
+		if in.PreviousOutput != nil {
+			h = append(h, 1)
+			h = append(h, in.PreviousOutput.Bytes()...)
+		} else {
+			h = append(h, 0)
+		}
+		h = append(h, byte(in.SigHashFlag))
+
+		h = append(h, VarInt(uint64(len(in.Signatures)))...)
+		for _, sig := range in.Signatures {
+			h = appendVarBytes(h, sig.PublicKey)
+			h = appendVarBytes(h, sig.Signature)
+		}
+
+		h = append(h, VarInt(uint64(len(in.Derivations)))...)
+		for _, kd := range in.Derivations {
+			h = appendVarBytes(h, kd.PublicKey)
+			h = append(h, LittleEndianBytes(kd.MasterFingerprint, 4)...)
+			h = appendVarBytes(h, []byte(kd.Path))
+		}
+	}
+
+	return h
+}
+
+// String returns the binary format of the PartiallySignedTx encoded as hex.
+func (p *PartiallySignedTx) String() string {
+	return hex.EncodeToString(p.Bytes())
+}
+
+// MarshalJSON will serialise the PartiallySignedTx to json.
+func (p *PartiallySignedTx) MarshalJSON() ([]byte, error) {
+	pj := partialTxJSON{
+		Tx:     p.unsignedTx().String(),
+		Inputs: make([]*partialInputJSON, len(p.Inputs)),
+	}
+	for i, in := range p.Inputs {
+		ij := &partialInputJSON{
+			PreviousOutput: in.PreviousOutput,
+			SigHashFlag:    in.SigHashFlag,
+		}
+		for _, sig := range in.Signatures {
+			ij.Signatures = append(ij.Signatures, &partialSignatureJSON{
+				PublicKey: hex.EncodeToString(sig.PublicKey),
+				Signature: hex.EncodeToString(sig.Signature),
+			})
+		}
+		for _, kd := range in.Derivations {
+			ij.Derivations = append(ij.Derivations, &keyDerivationJSON{
+				PublicKey:         hex.EncodeToString(kd.PublicKey),
+				MasterFingerprint: kd.MasterFingerprint,
+				Path:              kd.Path,
+			})
+		}
+		pj.Inputs[i] = ij
+	}
+
+	return json.Marshal(pj)
+}
+
+// UnmarshalJSON will convert a json serialised PartiallySignedTx back into a
+// PartiallySignedTx.
+func (p *PartiallySignedTx) UnmarshalJSON(b []byte) error {
+	var pj partialTxJSON
+	if err := json.Unmarshal(b, &pj); err != nil {
+		return err
+	}
+
+	tx, err := NewTxFromString(pj.Tx)
+	if err != nil {
+		return err
+	}
+	if len(pj.Inputs) != len(tx.Inputs) {
+		return fmt.Errorf("%w: tx has %d inputs but %d were given", ErrPartialInvalidFormat,
+			len(tx.Inputs), len(pj.Inputs))
+	}
+
+	pt := PartiallySignedTx{
+		Tx:     tx,
+		Inputs: make([]*PartialInput, len(pj.Inputs)),
+	}
+	for i, ij := range pj.Inputs {
+		if ij == nil {
+			return fmt.Errorf("%w: input %d is null", ErrPartialInvalidFormat, i)
+		}
+		in := &PartialInput{
+			PreviousOutput: ij.PreviousOutput,
+			SigHashFlag:    ij.SigHashFlag,
+		}
+		for _, sj := range ij.Signatures {
+			sig := &PartialSignature{}
+			if sig.PublicKey, err = hex.DecodeString(sj.PublicKey); err != nil {
+				return err
+			}
+			if sig.Signature, err = hex.DecodeString(sj.Signature); err != nil {
+				return err
+			}
+			in.Signatures = append(in.Signatures, sig)
+		}
+		for _, kj := range ij.Derivations {
+			kd := &KeyDerivation{MasterFingerprint: kj.MasterFingerprint, Path: kj.Path}
+			if kd.PublicKey, err = hex.DecodeString(kj.PublicKey); err != nil {
+				return err
+			}
+			in.Derivations = append(in.Derivations, kd)
+		}
+		pt.Inputs[i] = in
+		pt.applyPreviousOutput(i)
+	}
+
+	*p = pt
+	return nil
+}
+
+// SetPreviousOutput sets the output spent by the input at the given index.
+func (p *PartiallySignedTx) SetPreviousOutput(index int, o *Output) error {
+	if index < 0 || index >= len(p.Inputs) {
+		return fmt.Errorf("no input at index %d", index)
+	}
+	p.Inputs[index].PreviousOutput = o
+	p.applyPreviousOutput(index)
+
+	return nil
+}
+
+// Sign signs the input at the given index with its sighash flag, adding the
+// signature to the input.
+func (p *PartiallySignedTx) Sign(ctx context.Context, s Signer, index int) error {
+	in, err := p.signableInput(index)
+	if err != nil {
+		return err
+	}
+
+	pubKey, sig, err := s.Sign(ctx, p.unsignedTx(), uint32(index), in.SigHashFlag)
+	if err != nil {
+		return err
+	}
+	in.addSignature(&PartialSignature{PublicKey: pubKey, Signature: sig})
+
+	return nil
+}
+
+// AddSignature adds a signature made elsewhere to the input at the given
+// index. The signature is checked against the input before it is added.
+func (p *PartiallySignedTx) AddSignature(index int, pubKey, sig []byte) error {
+	in, err := p.signableInput(index)
+	if err != nil {
+		return err
+	}
+
+	sh, err := p.unsignedTx().CalcInputSignatureHash(uint32(index), in.SigHashFlag)
+	if err != nil {
+		return err
+	}
+	pk, err := bec.ParsePubKey(pubKey, bec.S256())
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPartialInvalidSignature, err)
+	}
+	s, err := bec.ParseDERSignature(sig, bec.S256())
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPartialInvalidSignature, err)
+	}
+	if !s.Verify(sh, pk) {
+		return ErrPartialInvalidSignature
+	}
+	in.addSignature(&PartialSignature{PublicKey: pubKey, Signature: sig})
+
+	return nil
+}
+
+// Combine merges the previous outputs, signatures and derivations of the
+// other PartiallySignedTxs into this one. Every PartiallySignedTx must be for
+// the same unsigned tx and sign each input with the same sighash flag.
+func (p *PartiallySignedTx) Combine(others ...*PartiallySignedTx) error {
+	txID := p.unsignedTx().TxID()
+	for _, o := range others {
+		if o.unsignedTx().TxID() != txID || len(o.Inputs) != len(p.Inputs) {
+			return ErrPartialTxMismatch
+		}
+		for i, in := range o.Inputs {
+			if in.SigHashFlag != p.Inputs[i].SigHashFlag {
+				return fmt.Errorf("%w: input %d has sighash flags %#x and %#x", ErrPartialTxMismatch, i,
+					p.Inputs[i].SigHashFlag, in.SigHashFlag)
+			}
+			if in.PreviousOutput == nil {
+				continue
+			}
+			if prev := p.Inputs[i].PreviousOutput; prev != nil &&
+				(prev.Satoshis != in.PreviousOutput.Satoshis || !prev.LockingScript.Equals(in.PreviousOutput.LockingScript)) {
+				return fmt.Errorf("%w: input %d has different previous outputs", ErrPartialTxMismatch, i)
+			}
+		}
+	}
+
+	for _, o := range others {
+		for i, in := range o.Inputs {
+			pin := p.Inputs[i]
+			if pin.PreviousOutput == nil && in.PreviousOutput != nil {
+				pin.PreviousOutput = in.PreviousOutput
+				p.applyPreviousOutput(i)
+			}
+			for _, sig := range in.Signatures {
+				pin.addSignature(sig)
+			}
+			for _, kd := range in.Derivations {
+				pin.addDerivation(kd)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Finalise builds the signed tx from the collected signatures. P2PKH inputs
+// need a signature from the key hashed in the previous output, and bare
+// multisig inputs need signatures from as many of its keys as it requires.
+// The PartiallySignedTx itself is left unchanged.
+func (p *PartiallySignedTx) Finalise() (*Tx, error) {
+	tx := p.unsignedTx().Clone()
+	for i, in := range p.Inputs {
+		if in.PreviousOutput == nil {
+			return nil, fmt.Errorf("input %d: %w", i, ErrPartialNoPreviousOutput)
+		}
+
+		uls, err := in.unlockingScript()
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		tx.Inputs[i].UnlockingScript = uls
+	}
+
+	return tx, nil
+}
+
+// unsignedTx returns the tx with the previous outputs applied to its inputs,
+// ready for computing signature hashes.
+func (p *PartiallySignedTx) unsignedTx() *Tx {
+	for i := range p.Inputs {
+		p.applyPreviousOutput(i)
+	}
+	return p.Tx
+}
+
+// applyPreviousOutput copies the previous output of an input onto the input
+// of the tx.
+func (p *PartiallySignedTx) applyPreviousOutput(index int) {
+	in, o := p.Tx.Inputs[index], p.Inputs[index].PreviousOutput
+	in.UnlockingScript = nil
+	if o != nil {
+		in.PreviousTxSatoshis = o.Satoshis
+		in.PreviousTxScript = o.LockingScript
+	}
+}
+
+func (p *PartiallySignedTx) signableInput(index int) (*PartialInput, error) {
+	if index < 0 || index >= len(p.Inputs) {
+		return nil, fmt.Errorf("no input at index %d", index)
+	}
+	in := p.Inputs[index]
+	if in.PreviousOutput == nil {
+		return nil, fmt.Errorf("input %d: %w", index, ErrPartialNoPreviousOutput)
+	}
+
+	return in, nil
+}
+
+// addSignature adds the signature, replacing any existing signature from the
+// same public key.
+func (in *PartialInput) addSignature(sig *PartialSignature) {
+	for i, s := range in.Signatures {
+		if bytes.Equal(s.PublicKey, sig.PublicKey) {
+			in.Signatures[i] = sig
+			return
+		}
+	}
+	in.Signatures = append(in.Signatures, sig)
+}
+
+func (in *PartialInput) addDerivation(kd *KeyDerivation) {
+	for _, d := range in.Derivations {
+		if bytes.Equal(d.PublicKey, kd.PublicKey) {
+			return
+		}
+	}
+	in.Derivations = append(in.Derivations, kd)
+}
+
+func (in *PartialInput) signature(pubKey []byte) *PartialSignature {
+	for _, sig := range in.Signatures {
+		if bytes.Equal(sig.PublicKey, pubKey) {
+			return sig
+		}
+	}
+	return nil
+}
+
+func (in *PartialInput) unlockingScript() (*bscript.Script, error) {
+	ls := in.PreviousOutput.LockingScript
+	switch {
+	case ls.IsP2PKH():
+		pkh, err := ls.PublicKeyHash()
+		if err != nil {
+			return nil, err
+		}
+		for _, sig := range in.Signatures {
+			if bytes.Equal(crypto.Hash160(sig.PublicKey), pkh) {
+				return bscript.NewP2PKHUnlockingScript(sig.PublicKey, sig.Signature, in.SigHashFlag)
+			}
+		}
+		return nil, ErrPartialMissingSignatures
+
+	case ls.IsMultiSigOut():
+		m, pubKeys, err := ls.MultiSigOut()
+		if err != nil {
+			return nil, err
+		}
+		// Signatures must be in the same order as their keys.
+		sigs := make([][]byte, 0, m)
+		for _, pk := range pubKeys {
+			if sig := in.signature(pk); sig != nil {
+				sigs = append(sigs, sig.Signature)
+			}
+			if len(sigs) == m {
+				return bscript.NewMultiSigUnlockingScript(sigs, in.SigHashFlag)
+			}
+		}
+		return nil, fmt.Errorf("%w: have %d of %d", ErrPartialMissingSignatures, len(sigs), m)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrPartialUnsupportedScript, ls.ScriptType())
+}
+
+func appendVarBytes(h, b []byte) []byte {
+	h = append(h, VarInt(uint64(len(b)))...)
+	return append(h, b...)
+}
+
+// partialTxReader reads the fields of a PartiallySignedTx, recording the
+// first error encountered.
+type partialTxReader struct {
+	b   []byte
+	err error
+}
+
+func (r *partialTxReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.err = fmt.Errorf("%w: not enough data", ErrPartialInvalidFormat)
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *partialTxReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *partialTxReader) varInt() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) == 0 {
+		r.err = fmt.Errorf("%w: not enough data", ErrPartialInvalidFormat)
+		return 0
+	}
+	size := 1
+	switch r.b[0] {
+	case 0xff:
+		size = 9
+	case 0xfe:
+		size = 5
+	case 0xfd:
+		size = 3
+	}
+	if size > len(r.b) {
+		r.err = fmt.Errorf("%w: not enough data", ErrPartialInvalidFormat)
+		return 0
+	}
+	l, _ := DecodeVarInt(r.b)
+	r.b = r.b[size:]
+	return l
+}
+
+func (r *partialTxReader) varBytes() []byte {
+	l := r.varInt()
+	if l > uint64(len(r.b)) {
+		r.err = fmt.Errorf("%w: not enough data", ErrPartialInvalidFormat)
+		return nil
+	}
+	return r.next(int(l))
+}
+
+func (r *partialTxReader) output() *Output {
+	if r.err != nil {
+		return nil
+	}
+	// An output is at least 8 bytes of satoshis and a script length.
+	if len(r.b) < 9 {
+		r.err = fmt.Errorf("%w: not enough data", ErrPartialInvalidFormat)
+		return nil
+	}
+	o, size, err := NewOutputFromBytes(r.b)
+	if err != nil {
+		r.err = fmt.Errorf("%w: %s", ErrPartialInvalidFormat, err)
+		return nil
+	}
+	r.b = r.b[size:]
+	return o
+}
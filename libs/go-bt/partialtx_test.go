@@ -0,0 +1,216 @@
+package bt_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/libsv/go-bt/v2/sighash"
+	"github.com/stretchr/testify/assert"
+)
+
+type partialTxFixture struct {
+	keys []*bec.PrivateKey
+	tx   *bt.Tx
+}
+
+// newPartialTxFixture creates a tx spending a 2 of 3 multisig output and a
+// P2PKH output belonging to the first key.
+func newPartialTxFixture(t *testing.T) *partialTxFixture {
+	f := &partialTxFixture{}
+	pubKeys := make([][]byte, 3)
+	for i := range pubKeys {
+		k, err := bec.NewPrivateKey(bec.S256())
+		assert.NoError(t, err)
+		f.keys = append(f.keys, k)
+		pubKeys[i] = k.PubKey().SerialiseCompressed()
+	}
+
+	multiSig, err := bscript.NewMultiSigOut(2, pubKeys)
+	assert.NoError(t, err)
+	p2pkh, err := bscript.NewP2PKHFromPubKeyBytes(pubKeys[0])
+	assert.NoError(t, err)
+
+	f.tx = bt.NewTx()
+	assert.NoError(t, f.tx.From("07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		0, multiSig.String(), 10000))
+	assert.NoError(t, f.tx.From("07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		1, p2pkh.String(), 5000))
+	assert.NoError(t, f.tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 14000))
+
+	return f
+}
+
+func (f *partialTxFixture) sign(t *testing.T, p *bt.PartiallySignedTx, key, index int) {
+	assert.NoError(t, p.Sign(context.Background(), &bt.LocalSigner{PrivateKey: f.keys[key]}, index))
+}
+
+func verifyTx(t *testing.T, tx *bt.Tx) {
+	for i, in := range tx.Inputs {
+		err := interpreter.NewEngine().Execute(interpreter.ExecutionParams{
+			Tx:       tx,
+			InputIdx: i,
+			PreviousTxOut: &bt.Output{
+				Satoshis:      in.PreviousTxSatoshis,
+				LockingScript: in.PreviousTxScript,
+			},
+			Flags: interpreter.ScriptEnableSighashForkID | interpreter.ScriptUTXOAfterGenesis,
+		})
+		assert.NoError(t, err, "input %d", i)
+	}
+}
+
+func TestPartiallySignedTx_MultiParty(t *testing.T) {
+	t.Parallel()
+
+	f := newPartialTxFixture(t)
+	p := bt.NewPartiallySignedTx(f.tx)
+	p.Inputs[0].Derivations = []*bt.KeyDerivation{{
+		PublicKey:         f.keys[1].PubKey().SerialiseCompressed(),
+		MasterFingerprint: 0xdeadbeef,
+		Path:              "m/44'/236'/0'/0/7",
+	}}
+
+	// The first party receives the binary format.
+	alice, err := bt.NewPartiallySignedTxFromString(p.String())
+	assert.NoError(t, err)
+	assert.Equal(t, p.Bytes(), alice.Bytes())
+	f.sign(t, alice, 0, 0)
+	f.sign(t, alice, 0, 1)
+
+	_, err = alice.Finalise()
+	assert.ErrorIs(t, err, bt.ErrPartialMissingSignatures)
+
+	// The second party receives json.
+	b, err := json.Marshal(p)
+	assert.NoError(t, err)
+	var bob bt.PartiallySignedTx
+	assert.NoError(t, json.Unmarshal(b, &bob))
+	assert.Equal(t, p.Bytes(), bob.Bytes())
+	assert.Equal(t, "m/44'/236'/0'/0/7", bob.Inputs[0].Derivations[0].Path)
+	f.sign(t, &bob, 2, 0)
+
+	assert.NoError(t, alice.Combine(&bob))
+	assert.Len(t, alice.Inputs[0].Signatures, 2)
+	assert.Len(t, alice.Inputs[0].Derivations, 1)
+
+	tx, err := alice.Finalise()
+	assert.NoError(t, err)
+	verifyTx(t, tx)
+
+	// Signatures are only applied to the finalised tx.
+	assert.Nil(t, alice.Tx.Inputs[0].UnlockingScript)
+}
+
+func TestPartiallySignedTx_AddSignature(t *testing.T) {
+	t.Parallel()
+
+	f := newPartialTxFixture(t)
+	p := bt.NewPartiallySignedTx(f.tx)
+
+	signer := &bt.LocalSigner{PrivateKey: f.keys[1]}
+	pubKey, sig, err := signer.Sign(context.Background(), p.Tx, 0, sighash.AllForkID)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, p.AddSignature(1, pubKey, sig), bt.ErrPartialInvalidSignature)
+	assert.ErrorIs(t, p.AddSignature(0, f.keys[0].PubKey().SerialiseCompressed(), sig),
+		bt.ErrPartialInvalidSignature)
+	assert.NoError(t, p.AddSignature(0, pubKey, sig))
+	assert.Len(t, p.Inputs[0].Signatures, 1)
+
+	// Signing again with the same key replaces the signature.
+	assert.NoError(t, p.AddSignature(0, pubKey, sig))
+	assert.Len(t, p.Inputs[0].Signatures, 1)
+}
+
+func TestPartiallySignedTx_SigHashFlag(t *testing.T) {
+	t.Parallel()
+
+	f := newPartialTxFixture(t)
+	p := bt.NewPartiallySignedTx(f.tx)
+	p.Inputs[0].SigHashFlag = sighash.AllForkID | sighash.AnyOneCanPay
+
+	other, err := bt.NewPartiallySignedTxFromBytes(p.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, p.Inputs[0].SigHashFlag, other.Inputs[0].SigHashFlag)
+
+	f.sign(t, p, 0, 0)
+	f.sign(t, p, 1, 0)
+	f.sign(t, p, 0, 1)
+	tx, err := p.Finalise()
+	assert.NoError(t, err)
+	verifyTx(t, tx)
+}
+
+func TestPartiallySignedTx_Combine(t *testing.T) {
+	t.Parallel()
+
+	t.Run("different tx", func(t *testing.T) {
+		f := newPartialTxFixture(t)
+		p := bt.NewPartiallySignedTx(f.tx)
+
+		f.tx.LockTime = 100
+		other := bt.NewPartiallySignedTx(f.tx)
+		assert.ErrorIs(t, p.Combine(other), bt.ErrPartialTxMismatch)
+	})
+
+	t.Run("different sighash flag", func(t *testing.T) {
+		f := newPartialTxFixture(t)
+		p := bt.NewPartiallySignedTx(f.tx)
+		other := bt.NewPartiallySignedTx(f.tx)
+		other.Inputs[1].SigHashFlag = sighash.NoneForkID
+		assert.ErrorIs(t, p.Combine(other), bt.ErrPartialTxMismatch)
+	})
+
+	t.Run("missing previous output is filled in", func(t *testing.T) {
+		f := newPartialTxFixture(t)
+		other := bt.NewPartiallySignedTx(f.tx)
+
+		f.tx.Inputs[1].PreviousTxScript = nil
+		p := bt.NewPartiallySignedTx(f.tx)
+		assert.Nil(t, p.Inputs[1].PreviousOutput)
+		assert.ErrorIs(t, p.Sign(context.Background(), &bt.LocalSigner{PrivateKey: f.keys[0]}, 1),
+			bt.ErrPartialNoPreviousOutput)
+
+		assert.NoError(t, p.Combine(other))
+		assert.NotNil(t, p.Inputs[1].PreviousOutput)
+		f.sign(t, p, 0, 1)
+	})
+}
+
+func TestPartiallySignedTx_Finalise(t *testing.T) {
+	t.Parallel()
+
+	f := newPartialTxFixture(t)
+	p := bt.NewPartiallySignedTx(f.tx)
+	assert.NoError(t, p.SetPreviousOutput(1, &bt.Output{
+		Satoshis:      5000,
+		LockingScript: bscript.NewFromBytes([]byte{bscript.OpTRUE}),
+	}))
+	f.sign(t, p, 0, 0)
+	f.sign(t, p, 1, 0)
+
+	_, err := p.Finalise()
+	assert.ErrorIs(t, err, bt.ErrPartialUnsupportedScript)
+}
+
+func TestNewPartiallySignedTxFromBytes(t *testing.T) {
+	t.Parallel()
+
+	f := newPartialTxFixture(t)
+	p := bt.NewPartiallySignedTx(f.tx)
+	f.sign(t, p, 0, 0)
+	b := p.Bytes()
+
+	for i := 0; i < len(b); i++ {
+		_, err := bt.NewPartiallySignedTxFromBytes(b[:i])
+		assert.Error(t, err, "truncated to %d bytes", i)
+	}
+
+	_, err := bt.NewPartiallySignedTxFromBytes(append(b, 0))
+	assert.ErrorIs(t, err, bt.ErrPartialInvalidFormat)
+}
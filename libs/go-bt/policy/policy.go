@@ -0,0 +1,164 @@
+// Package policy checks transactions against the standardness policy applied
+// by miners, so that a transaction likely to be rejected can be caught before
+// it is broadcast.
+package policy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// Default policy limits, matching the defaults of the node. The dust limit
+// is the one bt uses when creating change, so change it builds is standard.
+const (
+	DefaultMaxTxSize   = 10000000
+	DefaultMaxTxSigOps = math.MaxInt32
+	DefaultDustLimit   = bt.DustLimit
+)
+
+// Rule identifies the policy rule broken by a transaction.
+type Rule string
+
+// Rules checked by Check.
+const (
+	RuleTxSize              Rule = "tx-size"
+	RuleTxSigOps            Rule = "tx-sigops"
+	RuleDust                Rule = "dust"
+	RuleScriptSize          Rule = "script-size"
+	RuleMalformedScript     Rule = "malformed-script"
+	RuleUnlockingPushOnly   Rule = "unlocking-push-only"
+	RuleP2SHOutput          Rule = "p2sh-output"
+	RuleMultipleDataOutputs Rule = "multiple-data-outputs"
+)
+
+// Violation is a single breach of policy. InputIdx and OutputIdx are -1 when
+// the violation does not relate to a particular input or output.
+type Violation struct {
+	Rule        Rule
+	InputIdx    int
+	OutputIdx   int
+	Description string
+}
+
+// String returns the violation as a human-readable message.
+func (v Violation) String() string {
+	switch {
+	case v.InputIdx >= 0:
+		return fmt.Sprintf("%s: input %d: %s", v.Rule, v.InputIdx, v.Description)
+	case v.OutputIdx >= 0:
+		return fmt.Sprintf("%s: output %d: %s", v.Rule, v.OutputIdx, v.Description)
+	default:
+		return fmt.Sprintf("%s: %s", v.Rule, v.Description)
+	}
+}
+
+// Policy is the set of limits a transaction is checked against.
+//
+// Usage setup should be calling NewPolicy, and overriding any limits which
+// differ for the miner being broadcast to.
+type Policy struct {
+	MaxTxSize     int
+	MaxTxSigOps   int
+	MaxScriptSize int
+	// DustLimit is the minimum number of satoshis an output, other than a data
+	// output, must hold.
+	DustLimit uint64
+	// AllowMultipleDataOutputs permits more than one OP_RETURN output in a
+	// single transaction.
+	AllowMultipleDataOutputs bool
+}
+
+// NewPolicy returns a Policy with the default limits applied to transactions
+// spending utxos created after genesis.
+func NewPolicy() *Policy {
+	return &Policy{
+		MaxTxSize:     DefaultMaxTxSize,
+		MaxTxSigOps:   DefaultMaxTxSigOps,
+		MaxScriptSize: interpreter.AfterGenesisConfig().MaxScriptSize(),
+		DustLimit:     DefaultDustLimit,
+	}
+}
+
+// Check checks the transaction against the policy, returning every violation
+// found. A transaction which complies with the policy returns no violations.
+func (p *Policy) Check(tx *bt.Tx) []Violation {
+	var vv []Violation
+	add := func(rule Rule, inputIdx, outputIdx int, desc string, args ...interface{}) {
+		vv = append(vv, Violation{
+			Rule:        rule,
+			InputIdx:    inputIdx,
+			OutputIdx:   outputIdx,
+			Description: fmt.Sprintf(desc, args...),
+		})
+	}
+
+	if size := tx.Size(); size > p.MaxTxSize {
+		add(RuleTxSize, -1, -1, "tx size %d exceeds max %d", size, p.MaxTxSize)
+	}
+
+	if sigOps := CountSigOps(tx); sigOps.Total > p.MaxTxSigOps {
+		add(RuleTxSigOps, -1, -1, "tx sigops %d exceeds max %d", sigOps.Total, p.MaxTxSigOps)
+	}
+
+	parser := interpreter.NewOpcodeParser()
+	for i, in := range tx.Inputs {
+		if in.UnlockingScript == nil {
+			continue
+		}
+		if l := len(*in.UnlockingScript); l > p.MaxScriptSize {
+			add(RuleScriptSize, i, -1, "unlocking script size %d exceeds max %d", l, p.MaxScriptSize)
+		}
+
+		ops, err := parser.Parse(in.UnlockingScript)
+		if err != nil {
+			add(RuleMalformedScript, i, -1, "unlocking script: %s", err)
+			continue
+		}
+		if !ops.IsPushOnly() {
+			add(RuleUnlockingPushOnly, i, -1, "unlocking script is not push only")
+		}
+	}
+
+	var dataOutputs int
+	for i, out := range tx.Outputs {
+		ls := out.LockingScript
+		if ls == nil {
+			ls = &bscript.Script{}
+		}
+		if l := len(*ls); l > p.MaxScriptSize {
+			add(RuleScriptSize, -1, i, "locking script size %d exceeds max %d", l, p.MaxScriptSize)
+		}
+		if _, err := parser.Parse(ls); err != nil {
+			add(RuleMalformedScript, -1, i, "locking script: %s", err)
+		}
+		if ls.IsP2SH() {
+			add(RuleP2SHOutput, -1, i, "p2sh outputs are not standard after genesis")
+		}
+
+		if isData(ls) {
+			dataOutputs++
+			continue
+		}
+		if out.Satoshis < p.DustLimit {
+			add(RuleDust, -1, i, "output of %d satoshis is below the dust limit of %d", out.Satoshis, p.DustLimit)
+		}
+	}
+
+	if dataOutputs > 1 && !p.AllowMultipleDataOutputs {
+		add(RuleMultipleDataOutputs, -1, -1, "tx has %d data outputs, only 1 is allowed", dataOutputs)
+	}
+
+	return vv
+}
+
+// isData returns true if the script is a data output script, guarding against
+// scripts too short for bscript.Script.IsData.
+func isData(s *bscript.Script) bool {
+	b := []byte(*s)
+	return len(b) > 0 && b[0] == bscript.OpRETURN ||
+		len(b) > 1 && b[0] == bscript.OpFALSE && b[1] == bscript.OpRETURN
+}
@@ -0,0 +1,152 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTx(t *testing.T) *bt.Tx {
+	tx := bt.NewTx()
+	assert.NoError(t, tx.From(
+		"07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		0,
+		"76a914af2590a45ae401651fdbdf59a76ad43d1862534088ac",
+		4000000))
+	// nolint:lll // p2pkh unlocking script (sig + pubkey)
+	uls, err := bscript.NewFromHexString("4830450221009c13cbcbb16f2cfedc7abf3a4af1c3fe77df1180c0e7eee30d9bcc53ebda39da02207b258005f1bc3cf9dffa06edb358d6db2bcfc87f50516fac8e3f4686fc2a03df412103107feff22788a1fc8357240bf450fd7bca4bd45d5f8bac63818c5a7b67b03876")
+	assert.NoError(t, err)
+	tx.Inputs[0].UnlockingScript = uls
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 1000))
+	return tx
+}
+
+func rules(vv []policy.Violation) []policy.Rule {
+	rr := make([]policy.Rule, 0, len(vv))
+	for _, v := range vv {
+		rr = append(rr, v.Rule)
+	}
+	return rr
+}
+
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tx       func(t *testing.T) *bt.Tx
+		policy   func(p *policy.Policy)
+		expRules []policy.Rule
+	}{
+		"standard tx has no violations": {
+			tx:       newTestTx,
+			expRules: []policy.Rule{},
+		},
+		"dust output is reported": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 10))
+				return tx
+			},
+			expRules: []policy.Rule{policy.RuleDust},
+		},
+		"output at the bt dust limit is not dust": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", bt.DustLimit))
+				return tx
+			},
+			expRules: []policy.Rule{},
+		},
+		"zero value data output is not dust": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				assert.NoError(t, tx.AddOpReturnOutput([]byte("hello")))
+				return tx
+			},
+			expRules: []policy.Rule{},
+		},
+		"multiple data outputs are reported": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				assert.NoError(t, tx.AddOpReturnOutput([]byte("hello")))
+				assert.NoError(t, tx.AddOpReturnOutput([]byte("world")))
+				return tx
+			},
+			expRules: []policy.Rule{policy.RuleMultipleDataOutputs},
+		},
+		"multiple data outputs can be allowed": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				assert.NoError(t, tx.AddOpReturnOutput([]byte("hello")))
+				assert.NoError(t, tx.AddOpReturnOutput([]byte("world")))
+				return tx
+			},
+			policy: func(p *policy.Policy) {
+				p.AllowMultipleDataOutputs = true
+			},
+			expRules: []policy.Rule{},
+		},
+		"non push only unlocking script is reported": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				tx.Inputs[0].UnlockingScript.AppendOpCode(bscript.OpDROP)
+				return tx
+			},
+			expRules: []policy.Rule{policy.RuleUnlockingPushOnly},
+		},
+		"p2sh output is reported": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				s, err := bscript.NewFromHexString("a914000000000000000000000000000000000000000087")
+				assert.NoError(t, err)
+				tx.AddOutput(&bt.Output{LockingScript: s, Satoshis: 1000})
+				return tx
+			},
+			expRules: []policy.Rule{policy.RuleP2SHOutput},
+		},
+		"malformed locking script is reported": {
+			tx: func(t *testing.T) *bt.Tx {
+				tx := newTestTx(t)
+				tx.AddOutput(&bt.Output{LockingScript: bscript.NewFromBytes([]byte{0x4c, 0x05, 0x01}), Satoshis: 1000})
+				return tx
+			},
+			expRules: []policy.Rule{policy.RuleMalformedScript},
+		},
+		"tx limits are applied": {
+			tx: newTestTx,
+			policy: func(p *policy.Policy) {
+				p.MaxTxSize = 100
+				p.MaxTxSigOps = 1
+				p.MaxScriptSize = 50
+			},
+			expRules: []policy.Rule{
+				policy.RuleTxSize,
+				policy.RuleTxSigOps,
+				policy.RuleScriptSize,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			p := policy.NewPolicy()
+			if test.policy != nil {
+				test.policy(p)
+			}
+			assert.Equal(t, test.expRules, rules(p.Check(test.tx(t))))
+		})
+	}
+}
+
+func TestViolation_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "dust: output 1: too small",
+		policy.Violation{Rule: policy.RuleDust, InputIdx: -1, OutputIdx: 1, Description: "too small"}.String())
+	assert.Equal(t, "tx-size: too big",
+		policy.Violation{Rule: policy.RuleTxSize, InputIdx: -1, OutputIdx: -1, Description: "too big"}.String())
+}
@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"encoding/binary"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// SigOps is the number of signature operations in each input and output of
+// a transaction.
+type SigOps struct {
+	Inputs  []int
+	Outputs []int
+	Total   int
+}
+
+// CountSigOps counts the signature operations in a transaction. The sigops of
+// an input include those of the locking script it spends, when the previous
+// script is known. Scripts which cannot be parsed are counted up to the point
+// of failure.
+func CountSigOps(tx *bt.Tx) *SigOps {
+	s := &SigOps{
+		Inputs:  make([]int, len(tx.Inputs)),
+		Outputs: make([]int, len(tx.Outputs)),
+	}
+
+	for i, in := range tx.Inputs {
+		s.Inputs[i] = ScriptSigOps(in.UnlockingScript, true)
+		if in.PreviousTxScript != nil {
+			s.Inputs[i] += ScriptSigOps(in.PreviousTxScript, true)
+		}
+		s.Total += s.Inputs[i]
+	}
+	for i, out := range tx.Outputs {
+		s.Outputs[i] = ScriptSigOps(out.LockingScript, false)
+		s.Total += s.Outputs[i]
+	}
+
+	return s
+}
+
+// ScriptSigOps counts the signature operations in a script. When accurate is
+// set, a multisig operation preceded by a small integer counts as that many
+// sigops, otherwise it counts as the pre-genesis maximum number of keys.
+// Counting stops at the first malformed push.
+func ScriptSigOps(s *bscript.Script, accurate bool) int {
+	if s == nil {
+		return 0
+	}
+
+	var n int
+	var prev byte = bscript.OpINVALIDOPCODE
+	b := []byte(*s)
+	for i := 0; i < len(b); {
+		op := b[i]
+		i++
+
+		// Skip over any pushed data.
+		switch {
+		case op < bscript.OpPUSHDATA1:
+			i += int(op)
+		case op == bscript.OpPUSHDATA1 && len(b[i:]) >= 1:
+			i += 1 + int(b[i])
+		case op == bscript.OpPUSHDATA2 && len(b[i:]) >= 2:
+			i += 2 + int(binary.LittleEndian.Uint16(b[i:]))
+		case op == bscript.OpPUSHDATA4 && len(b[i:]) >= 4:
+			i += 4 + int(binary.LittleEndian.Uint32(b[i:]))
+		case op <= bscript.OpPUSHDATA4:
+			return n
+		}
+		if i > len(b) {
+			return n
+		}
+
+		switch op {
+		case bscript.OpCHECKSIG, bscript.OpCHECKSIGVERIFY:
+			n++
+		case bscript.OpCHECKMULTISIG, bscript.OpCHECKMULTISIGVERIFY:
+			if accurate && prev >= bscript.Op1 && prev <= bscript.Op16 {
+				n += int(prev-bscript.Op1) + 1
+			} else {
+				n += interpreter.MaxPubKeysPerMultiSigBeforeGenesis
+			}
+		}
+		prev = op
+	}
+
+	return n
+}
@@ -0,0 +1,80 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptSigOps(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		asm       string
+		hex       string
+		accurate  bool
+		expSigOps int
+	}{
+		"p2pkh": {
+			hex:       "76a914af2590a45ae401651fdbdf59a76ad43d1862534088ac",
+			expSigOps: 1,
+		},
+		"checksigverify and checksig": {
+			asm:       "OP_CHECKSIGVERIFY OP_CHECKSIG",
+			expSigOps: 2,
+		},
+		"multisig inaccurate": {
+			asm:       "OP_1 OP_3 OP_CHECKMULTISIG",
+			expSigOps: 20,
+		},
+		"multisig accurate": {
+			asm:       "OP_1 OP_3 OP_CHECKMULTISIG",
+			accurate:  true,
+			expSigOps: 3,
+		},
+		"pushed data is not counted": {
+			hex:       "01ac4c01ac",
+			expSigOps: 0,
+		},
+		"counting stops at malformed push": {
+			hex:       "ac4c05ac",
+			expSigOps: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var s *bscript.Script
+			var err error
+			if test.asm != "" {
+				s, err = bscript.NewFromASM(test.asm)
+			} else {
+				s, err = bscript.NewFromHexString(test.hex)
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expSigOps, policy.ScriptSigOps(s, test.accurate))
+		})
+	}
+}
+
+func TestCountSigOps(t *testing.T) {
+	t.Parallel()
+
+	tx := bt.NewTx()
+	assert.NoError(t, tx.From(
+		"07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		0,
+		"76a914af2590a45ae401651fdbdf59a76ad43d1862534088ac",
+		4000000))
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 1000))
+	assert.NoError(t, tx.AddOpReturnOutput([]byte("hello")))
+
+	s := policy.CountSigOps(tx)
+	assert.Equal(t, []int{1}, s.Inputs)
+	assert.Equal(t, []int{1, 0}, s.Outputs)
+	assert.Equal(t, 2, s.Total)
+}
@@ -32,8 +32,16 @@ list of Outputs  the Outputs of the first transaction spend the mined
 lock_time        if non-zero and sequence numbers are < 0xFFFFFFFF: block height or        4 bytes
                  timestamp when transaction is final
 --------------------------------------------------------
+
+In the extended format (BIP239) the marker 0x0000000000EF follows the version
+no, and each input is followed by the satoshis and locking script of the output
+it spends, so that the transaction can be validated without looking up its
+previous outputs.
 */
 
+// extendedFormatMarker follows the version of a tx in the extended format.
+var extendedFormatMarker = []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xEF}
+
 // Sentinel errors for transactions.
 var (
 	ErrInvalidTxID = errors.New("invalid TxID")
@@ -110,7 +118,7 @@ func NewTx() *Tx {
 }
 
 // NewTxFromString takes a toBytesHelper string representation of a bitcoin transaction
-// and returns a Tx object.
+// and returns a Tx object. Txs in the extended format are detected automatically.
 func NewTxFromString(str string) (*Tx, error) {
 	bb, err := hex.DecodeString(str)
 	if err != nil {
@@ -138,6 +146,9 @@ func NewTxFromBytes(b []byte) (*Tx, error) {
 // NewTxFromStream takes an array of bytes and constructs a Tx from it, returning the Tx and the bytes used.
 // Despite the name, this is not actually reading a stream in the true sense: it is a byte slice that contains
 // many transactions one after another.
+//
+// Txs in the extended format are detected automatically, in which case the
+// previous satoshis and locking script of each input are set.
 func NewTxFromStream(b []byte) (*Tx, int, error) {
 
 	if len(b) < 10 {
@@ -150,6 +161,11 @@ func NewTxFromStream(b []byte) (*Tx, int, error) {
 	}
 	offset += 4
 
+	extended := bytes.Equal(b[offset:offset+len(extendedFormatMarker)], extendedFormatMarker)
+	if extended {
+		offset += len(extendedFormatMarker)
+	}
+
 	inputCount, size := DecodeVarInt(b[offset:])
 	offset += size
 
@@ -158,7 +174,7 @@ func NewTxFromStream(b []byte) (*Tx, int, error) {
 	var err error
 	var input *Input
 	for ; i < inputCount; i++ {
-		input, size, err = NewInputFromBytes(b[offset:])
+		input, size, err = newInputFromBytes(b[offset:], extended)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -267,13 +283,36 @@ func IsValidTxID(txid []byte) bool {
 // Bytes encodes the transaction into a byte array.
 // See https://chainquery.com/bitcoin-cli/decoderawtransaction
 func (tx *Tx) Bytes() []byte {
-	return tx.toBytesHelper(0, nil)
+	return tx.toBytesHelper(0, nil, false)
+}
+
+// ExtendedBytes encodes the transaction into a byte array in the extended
+// format, including the previous satoshis and locking script of each input.
+func (tx *Tx) ExtendedBytes() []byte {
+	return tx.toBytesHelper(0, nil, true)
+}
+
+// ExtendedString encodes the transaction into a hex string in the extended
+// format.
+func (tx *Tx) ExtendedString() string {
+	return hex.EncodeToString(tx.ExtendedBytes())
+}
+
+// IsExtended returns true if the previous locking script of every input is
+// known, so the tx can be fully encoded in the extended format.
+func (tx *Tx) IsExtended() bool {
+	for _, in := range tx.Inputs {
+		if in.PreviousTxScript == nil {
+			return false
+		}
+	}
+	return len(tx.Inputs) > 0
 }
 
 // BytesWithClearedInputs encodes the transaction into a byte array but clears its Inputs first.
 // This is used when signing transactions.
 func (tx *Tx) BytesWithClearedInputs(index int, lockingScript []byte) []byte {
-	return tx.toBytesHelper(index, lockingScript)
+	return tx.toBytesHelper(index, lockingScript, false)
 }
 
 // Clone returns a clone of the tx
@@ -289,10 +328,13 @@ func (tx *Tx) Clone() *Tx {
 	return clone
 }
 
-func (tx *Tx) toBytesHelper(index int, lockingScript []byte) []byte {
+func (tx *Tx) toBytesHelper(index int, lockingScript []byte, extended bool) []byte {
 	h := make([]byte, 0)
 
 	h = append(h, LittleEndianBytes(tx.Version, 4)...)
+	if extended {
+		h = append(h, extendedFormatMarker...)
+	}
 
 	h = append(h, VarInt(uint64(len(tx.Inputs)))...)
 
@@ -304,6 +346,9 @@ func (tx *Tx) toBytesHelper(index int, lockingScript []byte) []byte {
 		} else {
 			h = append(h, s...)
 		}
+		if extended {
+			h = append(h, in.previousOutputBytes()...)
+		}
 	}
 
 	h = append(h, VarInt(uint64(len(tx.Outputs)))...)
@@ -902,6 +902,64 @@ func TestTx_Clone(t *testing.T) {
 	})
 }
 
+func TestTx_ExtendedBytes(t *testing.T) {
+	t.Parallel()
+
+	tx := bt.NewTx()
+	assert.NoError(t, tx.From("07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+		0, "76a914af2590a45ae401651fdbdf59a76ad43d1862534088ac", 4000))
+	assert.NoError(t, tx.From("93a35408b6068499e0d5abd799d3e827d9bfe70c9b75ebe209c91d2507232651",
+		1, "76a914c0a3c167a28cabb9fbb495affa0761e6e74ac60d88ac", 6000))
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 9000))
+	assert.True(t, tx.IsExtended())
+
+	ext := tx.ExtendedBytes()
+	assert.Equal(t, "010000000000000000ef02", hex.EncodeToString(ext[:11]))
+
+	t.Run("round trip", func(t *testing.T) {
+		parsed, err := bt.NewTxFromBytes(ext)
+		assert.NoError(t, err)
+		assert.Equal(t, tx.Bytes(), parsed.Bytes())
+		assert.Equal(t, tx.TxID(), parsed.TxID())
+		assert.Equal(t, ext, parsed.ExtendedBytes())
+		for i, in := range parsed.Inputs {
+			assert.Equal(t, tx.Inputs[i].PreviousTxSatoshis, in.PreviousTxSatoshis)
+			assert.Equal(t, tx.Inputs[i].PreviousTxScript, in.PreviousTxScript)
+		}
+		assert.Equal(t, uint64(10000), parsed.TotalInputSatoshis())
+
+		ok, err := parsed.IsFeePaidEnough(bt.NewFeeQuote())
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("from string", func(t *testing.T) {
+		parsed, err := bt.NewTxFromString(tx.ExtendedString())
+		assert.NoError(t, err)
+		assert.Equal(t, ext, parsed.ExtendedBytes())
+	})
+
+	t.Run("stream of extended and standard txs", func(t *testing.T) {
+		b := append(append([]byte{}, ext...), tx.Bytes()...)
+
+		first, used, err := bt.NewTxFromStream(b)
+		assert.NoError(t, err)
+		assert.Equal(t, len(ext), used)
+		assert.True(t, first.IsExtended())
+
+		second, used, err := bt.NewTxFromStream(b[used:])
+		assert.NoError(t, err)
+		assert.Equal(t, len(tx.Bytes()), used)
+		assert.False(t, second.IsExtended())
+		assert.Equal(t, first.TxID(), second.TxID())
+	})
+
+	t.Run("truncated previous output", func(t *testing.T) {
+		_, err := bt.NewTxFromBytes(ext[:72])
+		assert.Error(t, err)
+	})
+}
+
 func Test_IsFeePaidEnough(t *testing.T) {
 	tests := map[string]struct {
 		tx         *bt.Tx
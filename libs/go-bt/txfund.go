@@ -0,0 +1,366 @@
+package bt
+
+import (
+	"container/heap"
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// Sentinel errors reported when funding a tx.
+var (
+	ErrInsufficientFunds = errors.New("utxos do not cover the outputs and fees of the tx")
+	ErrMaxInputsExceeded = errors.New("utxos cannot cover the tx within the maximum number of inputs")
+	ErrNoExactMatch      = errors.New("no combination of utxos exactly matches the tx outputs and fees")
+	ErrUnknownCoinSelect = errors.New("unknown coin selection strategy")
+	ErrFundNoFeeQuote    = errors.New("a fee quote is required to fund a tx")
+	ErrFundInvalidFee    = errors.New("fee quote must charge for a positive number of bytes")
+)
+
+const (
+	// p2pkhInputSize is the size in bytes of a signed P2PKH input:
+	// 32 bytes txid + 4 bytes vout + 1 byte script length + 107 bytes script + 4 bytes sequence.
+	p2pkhInputSize = 148
+	// p2pkhOutputSize is the size in bytes of a P2PKH output:
+	// 8 bytes satoshis + 1 byte script length + 25 bytes script.
+	p2pkhOutputSize = 34
+
+	branchAndBoundMaxTries = 100000
+)
+
+// UTXO is an unspent transaction output which can be used to fund a tx.
+type UTXO struct {
+	TxID          []byte
+	Vout          uint32
+	LockingScript *bscript.Script
+	Satoshis      uint64
+}
+
+// CoinSelection is the strategy used to choose which utxos fund a tx.
+type CoinSelection int
+
+const (
+	// CoinSelectLargestFirst spends the largest utxos first, using the
+	// fewest inputs.
+	CoinSelectLargestFirst CoinSelection = iota
+
+	// CoinSelectSmallestFirst spends the smallest utxos first, consolidating
+	// small utxos.
+	CoinSelectSmallestFirst
+
+	// CoinSelectBranchAndBound searches for a set of utxos which covers the
+	// tx without needing a change output. ErrNoExactMatch is returned if no
+	// such set is found.
+	CoinSelectBranchAndBound
+
+	// CoinSelectRandom spends utxos in a random order, so that the utxos
+	// spent together reveal less about the wallet.
+	CoinSelectRandom
+)
+
+// FundParams are the params used when funding a tx with Fund.
+type FundParams struct {
+	UTXOs    []*UTXO
+	FeeQuote *FeeQuote
+	Strategy CoinSelection
+
+	// ChangeScript receives any change left after fees. When nil, or when
+	// the change would be dust, the change is paid to the miner.
+	ChangeScript *bscript.Script
+
+	// MaxInputs limits the number of inputs added to the tx. Zero means
+	// there is no limit.
+	MaxInputs int
+
+	// DustLimit is the smallest change output which will be created. When
+	// zero DustLimit is used.
+	DustLimit uint64
+
+	// Rand is the source of randomness for CoinSelectRandom. When nil a
+	// time seeded source is used.
+	Rand *rand.Rand
+}
+
+// Fund selects utxos to cover the outputs of the tx and the fees needed for
+// the funded tx, adding them as inputs along with a change output if
+// required. Fees are re-estimated as each utxo is selected, so the tx pays
+// enough fees for its final size.
+//
+// The utxos must be P2PKH, as unsigned inputs are estimated as P2PKH when
+// calculating fees. Utxos worth less than the fee needed to spend them are
+// never selected.
+func (tx *Tx) Fund(params FundParams) error {
+	if params.FeeQuote == nil {
+		return ErrFundNoFeeQuote
+	}
+	if params.DustLimit == 0 {
+		params.DustLimit = DustLimit
+	}
+
+	for _, ft := range []FeeType{FeeTypeStandard, FeeTypeData} {
+		fee, err := params.FeeQuote.Fee(ft)
+		if err != nil {
+			return err
+		}
+		if fee.MiningFee.Bytes <= 0 {
+			return ErrFundInvalidFee
+		}
+	}
+	stdFee, err := params.FeeQuote.Fee(FeeTypeStandard)
+	if err != nil {
+		return err
+	}
+	inputFee := feeForBytes(p2pkhInputSize, stdFee)
+
+	utxos := make([]*UTXO, 0, len(params.UTXOs))
+	for _, u := range params.UTXOs {
+		if u.Satoshis > inputFee {
+			utxos = append(utxos, u)
+		}
+	}
+
+	switch params.Strategy {
+	case CoinSelectLargestFirst:
+		sort.SliceStable(utxos, func(i, j int) bool { return utxos[i].Satoshis > utxos[j].Satoshis })
+	case CoinSelectSmallestFirst:
+		sort.SliceStable(utxos, func(i, j int) bool { return utxos[i].Satoshis < utxos[j].Satoshis })
+	case CoinSelectRandom:
+		r := params.Rand
+		if r == nil {
+			r = rand.New(rand.NewSource(time.Now().UnixNano())) // nolint:gosec // not used for security
+		}
+		r.Shuffle(len(utxos), func(i, j int) { utxos[i], utxos[j] = utxos[j], utxos[i] })
+	case CoinSelectBranchAndBound:
+		return tx.fundBranchAndBound(utxos, inputFee, stdFee, params)
+	default:
+		return ErrUnknownCoinSelect
+	}
+
+	return tx.fundInOrder(utxos, params)
+}
+
+// fundInOrder selects utxos greedily in the order given until the tx is
+// funded. When the maximum number of inputs is reached, the smallest selected
+// utxo is swapped for any larger utxo which follows, so a funding set is found
+// whenever the largest utxos can cover the tx.
+func (tx *Tx) fundInOrder(utxos []*UTXO, params FundParams) error {
+	size, err := tx.EstimateSizeWithTypes()
+	if err != nil {
+		return err
+	}
+	nIn := uint64(len(tx.Inputs))
+	available, required := tx.TotalInputSatoshis(), tx.TotalOutputSatoshis()
+
+	// covers reports whether n more P2PKH inputs worth sats fund the tx,
+	// without adding them to the tx.
+	covers := func(n int, sats uint64) (bool, error) {
+		m := uint64(n)
+		fees, err := tx.feesPaid(&TxSize{
+			TotalStdBytes:  size.TotalStdBytes + m*p2pkhInputSize + uint64(len(VarInt(nIn+m))-len(VarInt(nIn))),
+			TotalDataBytes: size.TotalDataBytes,
+		}, params.FeeQuote)
+		if err != nil {
+			return false, err
+		}
+		return available+sats >= required+fees.TotalFeePaid, nil
+	}
+
+	selected := &utxoHeap{utxos: utxos}
+	var selectedSats, totalSats uint64
+	for i, u := range utxos {
+		totalSats += u.Satoshis
+		switch {
+		case params.MaxInputs == 0 || selected.Len() < params.MaxInputs:
+			heap.Push(selected, i)
+			selectedSats += u.Satoshis
+		case u.Satoshis > utxos[selected.idx[0]].Satoshis:
+			selectedSats += u.Satoshis - utxos[selected.idx[0]].Satoshis
+			selected.idx[0] = i
+			heap.Fix(selected, 0)
+		default:
+			continue
+		}
+
+		funded, err := covers(selected.Len(), selectedSats)
+		if err != nil {
+			return err
+		}
+		if funded {
+			return tx.fundSelected(selected.sorted(), params)
+		}
+	}
+
+	if params.MaxInputs > 0 && len(utxos) > params.MaxInputs {
+		// Check whether the utxos could have covered the tx at all.
+		if funded, err := covers(len(utxos), totalSats); err == nil && funded {
+			return ErrMaxInputsExceeded
+		}
+	}
+
+	return ErrInsufficientFunds
+}
+
+// fundSelected adds the selected utxos to the tx along with any change.
+func (tx *Tx) fundSelected(utxos []*UTXO, params FundParams) error {
+	funded, err := tx.fundWith(utxos, params, true)
+	if err != nil {
+		return err
+	}
+	if !funded {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// fundBranchAndBound searches for a set of utxos whose value, less the fee
+// to spend them, covers the tx without exceeding it by more than the cost of
+// a change output.
+func (tx *Tx) fundBranchAndBound(utxos []*UTXO, inputFee uint64, stdFee *Fee, params FundParams) error {
+	fees, err := tx.EstimateFeesPaid(params.FeeQuote)
+	if err != nil {
+		return err
+	}
+
+	available := tx.TotalInputSatoshis()
+	required := tx.TotalOutputSatoshis() + fees.TotalFeePaid
+	if available >= required {
+		return nil
+	}
+	target := required - available
+	tolerance := feeForBytes(p2pkhOutputSize, stdFee) + params.DustLimit
+
+	sort.SliceStable(utxos, func(i, j int) bool { return utxos[i].Satoshis > utxos[j].Satoshis })
+	values := make([]uint64, len(utxos))
+	var remaining uint64
+	for i, u := range utxos {
+		values[i] = u.Satoshis - inputFee
+		remaining += values[i]
+	}
+
+	// Depth first search, including then excluding each utxo, giving up
+	// after a bounded number of tries.
+	var tries int
+	var search func(i int, sum, remaining uint64, selected []*UTXO) []*UTXO
+	search = func(i int, sum, remaining uint64, selected []*UTXO) []*UTXO {
+		if tries++; tries > branchAndBoundMaxTries {
+			return nil
+		}
+		switch {
+		case sum >= target && sum <= target+tolerance:
+			return selected
+		case sum > target+tolerance, sum+remaining < target, i == len(utxos):
+			return nil
+		case params.MaxInputs > 0 && len(selected) == params.MaxInputs:
+			return nil
+		}
+
+		if found := search(i+1, sum+values[i], remaining-values[i], append(selected, utxos[i])); found != nil {
+			return found
+		}
+		return search(i+1, sum, remaining-values[i], selected)
+	}
+
+	selected := search(0, 0, remaining, nil)
+	if selected == nil {
+		return ErrNoExactMatch
+	}
+
+	funded, err := tx.fundWith(selected, params, false)
+	if err != nil {
+		return err
+	}
+	if !funded {
+		return ErrNoExactMatch
+	}
+
+	return nil
+}
+
+// fundWith adds the utxos as inputs and reports whether they fund the tx,
+// adding a change output if allowed and worthwhile. When the tx is not funded
+// it is left unchanged.
+func (tx *Tx) fundWith(utxos []*UTXO, params FundParams, allowChange bool) (bool, error) {
+	nIn, nOut := len(tx.Inputs), len(tx.Outputs)
+	for _, u := range utxos {
+		in := &Input{
+			PreviousTxOutIndex: u.Vout,
+			PreviousTxSatoshis: u.Satoshis,
+			PreviousTxScript:   u.LockingScript,
+			SequenceNumber:     DefaultSequenceNumber,
+		}
+		if err := in.PreviousTxIDAdd(u.TxID); err != nil {
+			tx.Inputs = tx.Inputs[:nIn]
+			return false, err
+		}
+		tx.addInput(in)
+	}
+
+	fees, err := tx.EstimateFeesPaid(params.FeeQuote)
+	if err != nil {
+		tx.Inputs = tx.Inputs[:nIn]
+		return false, err
+	}
+
+	inputs, outputs := tx.TotalInputSatoshis(), tx.TotalOutputSatoshis()
+	if inputs < outputs+fees.TotalFeePaid {
+		tx.Inputs = tx.Inputs[:nIn]
+		return false, nil
+	}
+
+	if !allowChange || params.ChangeScript == nil {
+		return true, nil
+	}
+
+	// Estimate the fees again with the change output in place, as it
+	// increases the size of the tx.
+	tx.AddOutput(&Output{LockingScript: params.ChangeScript})
+	if fees, err = tx.EstimateFeesPaid(params.FeeQuote); err != nil {
+		tx.Inputs, tx.Outputs = tx.Inputs[:nIn], tx.Outputs[:nOut]
+		return false, err
+	}
+	if inputs < outputs+fees.TotalFeePaid+params.DustLimit {
+		tx.Outputs = tx.Outputs[:nOut]
+		return true, nil
+	}
+	tx.Outputs[nOut].Satoshis = inputs - outputs - fees.TotalFeePaid
+
+	return true, nil
+}
+
+// feeForBytes returns the standard fee for the given number of bytes, rounded
+// up. The fee must have been validated by Fund.
+func feeForBytes(bytes int, fee *Fee) uint64 {
+	return uint64((bytes*fee.MiningFee.Satoshis + fee.MiningFee.Bytes - 1) / fee.MiningFee.Bytes)
+}
+
+// utxoHeap is a min-heap, by value, of indexes into utxos.
+type utxoHeap struct {
+	utxos []*UTXO
+	idx   []int
+}
+
+func (h *utxoHeap) Len() int { return len(h.idx) }
+func (h *utxoHeap) Less(i, j int) bool {
+	return h.utxos[h.idx[i]].Satoshis < h.utxos[h.idx[j]].Satoshis
+}
+func (h *utxoHeap) Swap(i, j int)      { h.idx[i], h.idx[j] = h.idx[j], h.idx[i] }
+func (h *utxoHeap) Push(x interface{}) { h.idx = append(h.idx, x.(int)) }
+func (h *utxoHeap) Pop() interface{} {
+	i := h.idx[len(h.idx)-1]
+	h.idx = h.idx[:len(h.idx)-1]
+	return i
+}
+
+// sorted returns the utxos in the heap in their original order.
+func (h *utxoHeap) sorted() []*UTXO {
+	idx := append([]int(nil), h.idx...)
+	sort.Ints(idx)
+	utxos := make([]*UTXO, len(idx))
+	for i, j := range idx {
+		utxos[i] = h.utxos[j]
+	}
+	return utxos
+}
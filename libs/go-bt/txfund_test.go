@@ -0,0 +1,235 @@
+package bt_test
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/stretchr/testify/assert"
+)
+
+func testUTXOs(t *testing.T, satoshis ...uint64) []*bt.UTXO {
+	s, err := bscript.NewFromHexString("76a914af2590a45ae401651fdbdf59a76ad43d1862534088ac")
+	assert.NoError(t, err)
+	txID, err := hex.DecodeString("07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b")
+	assert.NoError(t, err)
+
+	utxos := make([]*bt.UTXO, len(satoshis))
+	for i, sats := range satoshis {
+		utxos[i] = &bt.UTXO{
+			TxID:          txID,
+			Vout:          uint32(i),
+			LockingScript: s,
+			Satoshis:      sats,
+		}
+	}
+	return utxos
+}
+
+func inputSatoshis(tx *bt.Tx) []uint64 {
+	ss := make([]uint64, len(tx.Inputs))
+	for i, in := range tx.Inputs {
+		ss[i] = in.PreviousTxSatoshis
+	}
+	return ss
+}
+
+func TestTx_Fund(t *testing.T) {
+	t.Parallel()
+
+	changeScript, err := bscript.NewP2PKHFromAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		utxos      []uint64
+		pay        uint64
+		strategy   bt.CoinSelection
+		maxInputs  int
+		noChange   bool
+		expInputs  []uint64
+		expOutputs int
+		expErr     error
+	}{
+		"largest first uses the largest utxo": {
+			utxos:      []uint64{1000, 5000, 20000},
+			pay:        3000,
+			strategy:   bt.CoinSelectLargestFirst,
+			expInputs:  []uint64{20000},
+			expOutputs: 2,
+		},
+		"smallest first uses the smallest utxos": {
+			utxos:      []uint64{20000, 5000, 1000},
+			pay:        3000,
+			strategy:   bt.CoinSelectSmallestFirst,
+			expInputs:  []uint64{1000, 5000},
+			expOutputs: 2,
+		},
+		"smallest first with max inputs drops the smallest utxos": {
+			utxos:      []uint64{20000, 5000, 1000},
+			pay:        3000,
+			strategy:   bt.CoinSelectSmallestFirst,
+			maxInputs:  1,
+			expInputs:  []uint64{5000},
+			expOutputs: 2,
+		},
+		"utxos worth less than their fee are not spent": {
+			utxos:      []uint64{50, 5000},
+			pay:        3000,
+			strategy:   bt.CoinSelectSmallestFirst,
+			expInputs:  []uint64{5000},
+			expOutputs: 2,
+		},
+		"dust change is paid to the miner": {
+			utxos:      []uint64{3200},
+			pay:        3000,
+			strategy:   bt.CoinSelectLargestFirst,
+			expInputs:  []uint64{3200},
+			expOutputs: 1,
+		},
+		"insufficient funds": {
+			utxos:    []uint64{1000, 1000},
+			pay:      3000,
+			strategy: bt.CoinSelectLargestFirst,
+			expErr:   bt.ErrInsufficientFunds,
+		},
+		"max inputs exceeded": {
+			utxos:     []uint64{1000, 1000, 1000},
+			pay:       2500,
+			strategy:  bt.CoinSelectLargestFirst,
+			maxInputs: 2,
+			expErr:    bt.ErrMaxInputsExceeded,
+		},
+		"branch and bound finds an exact match": {
+			utxos:      []uint64{10000, 1000, 3000, 2000},
+			pay:        2900,
+			strategy:   bt.CoinSelectBranchAndBound,
+			expInputs:  []uint64{3000},
+			expOutputs: 1,
+		},
+		"branch and bound combines utxos": {
+			utxos:      []uint64{10000, 1000, 2000},
+			pay:        2750,
+			strategy:   bt.CoinSelectBranchAndBound,
+			expInputs:  []uint64{2000, 1000},
+			expOutputs: 1,
+		},
+		"branch and bound without a match": {
+			utxos:    []uint64{10000},
+			pay:      2900,
+			strategy: bt.CoinSelectBranchAndBound,
+			expErr:   bt.ErrNoExactMatch,
+		},
+		"no change script pays the change to the miner": {
+			utxos:      []uint64{20000},
+			pay:        3000,
+			strategy:   bt.CoinSelectLargestFirst,
+			noChange:   true,
+			expInputs:  []uint64{20000},
+			expOutputs: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			tx := bt.NewTx()
+			assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", test.pay))
+
+			params := bt.FundParams{
+				UTXOs:        testUTXOs(t, test.utxos...),
+				FeeQuote:     bt.NewFeeQuote(),
+				Strategy:     test.strategy,
+				ChangeScript: changeScript,
+				MaxInputs:    test.maxInputs,
+			}
+			if test.noChange {
+				params.ChangeScript = nil
+			}
+
+			err := tx.Fund(params)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				assert.Empty(t, tx.Inputs)
+				assert.Equal(t, 1, tx.OutputCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expInputs, inputSatoshis(tx))
+			assert.Equal(t, test.expOutputs, tx.OutputCount())
+
+			fees, err := tx.EstimateFeesPaid(bt.NewFeeQuote())
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, tx.TotalInputSatoshis()-tx.TotalOutputSatoshis(), fees.TotalFeePaid)
+			if test.expOutputs == 2 {
+				assert.Equal(t, fees.TotalFeePaid, tx.TotalInputSatoshis()-tx.TotalOutputSatoshis())
+			}
+		})
+	}
+}
+
+func TestTx_Fund_Random(t *testing.T) {
+	t.Parallel()
+
+	tx := bt.NewTx()
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 3000))
+
+	changeScript, err := bscript.NewP2PKHFromAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi")
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Fund(bt.FundParams{
+		UTXOs:        testUTXOs(t, 1000, 1000, 1000, 1000, 1000, 1000),
+		FeeQuote:     bt.NewFeeQuote(),
+		Strategy:     bt.CoinSelectRandom,
+		ChangeScript: changeScript,
+		Rand:         rand.New(rand.NewSource(1)), // nolint:gosec // deterministic test
+	}))
+	assert.Equal(t, 4, tx.InputCount())
+
+	fees, err := tx.EstimateFeesPaid(bt.NewFeeQuote())
+	assert.NoError(t, err)
+	assert.Equal(t, fees.TotalFeePaid, tx.TotalInputSatoshis()-tx.TotalOutputSatoshis())
+}
+
+func TestTx_Fund_MaxInputs(t *testing.T) {
+	t.Parallel()
+
+	changeScript, err := bscript.NewP2PKHFromAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi")
+	assert.NoError(t, err)
+
+	// Only the two largest utxos can cover the tx within two inputs, wherever
+	// they are shuffled to.
+	for seed := int64(0); seed < 20; seed++ {
+		tx := bt.NewTx()
+		assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 15000))
+
+		assert.NoError(t, tx.Fund(bt.FundParams{
+			UTXOs:        testUTXOs(t, 9000, 1000, 2000, 1000, 9000, 3000),
+			FeeQuote:     bt.NewFeeQuote(),
+			Strategy:     bt.CoinSelectRandom,
+			ChangeScript: changeScript,
+			MaxInputs:    2,
+			Rand:         rand.New(rand.NewSource(seed)), // nolint:gosec // deterministic test
+		}))
+		assert.Equal(t, []uint64{9000, 9000}, inputSatoshis(tx))
+	}
+}
+
+func TestTx_Fund_InvalidFeeQuote(t *testing.T) {
+	t.Parallel()
+
+	tx := bt.NewTx()
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 3000))
+
+	fq := bt.NewFeeQuote().AddQuote(bt.FeeTypeStandard, &bt.Fee{
+		FeeType:   bt.FeeTypeStandard,
+		MiningFee: bt.FeeUnit{Satoshis: 5},
+	})
+	err := tx.Fund(bt.FundParams{
+		UTXOs:    testUTXOs(t, 20000),
+		FeeQuote: fq,
+	})
+	assert.ErrorIs(t, err, bt.ErrFundInvalidFee)
+	assert.Empty(t, tx.Inputs)
+}
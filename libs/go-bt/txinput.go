@@ -12,6 +12,13 @@ import (
 
 // NewInputFromBytes returns a transaction input from the bytes provided.
 func NewInputFromBytes(bytes []byte) (*Input, int, error) {
+	return newInputFromBytes(bytes, false)
+}
+
+// newInputFromBytes returns a transaction input from the bytes provided,
+// followed by the satoshis and locking script of its previous output when
+// extended.
+func newInputFromBytes(bytes []byte, extended bool) (*Input, int, error) {
 	if len(bytes) < 36 {
 		return nil, 0, fmt.Errorf("input length too short < 36")
 	}
@@ -26,12 +33,28 @@ func NewInputFromBytes(bytes []byte) (*Input, int, error) {
 		return nil, 0, fmt.Errorf("input length too short < 36 + script + 4")
 	}
 
-	return &Input{
+	input := &Input{
 		previousTxID:       ReverseBytes(bytes[0:32]),
 		PreviousTxOutIndex: binary.LittleEndian.Uint32(bytes[32:36]),
 		SequenceNumber:     binary.LittleEndian.Uint32(bytes[offset+int(l):]),
 		UnlockingScript:    bscript.NewFromBytes(bytes[offset : offset+int(l)]),
-	}, totalLength, nil
+	}
+	if !extended {
+		return input, totalLength, nil
+	}
+
+	// The previous output is encoded the same as an output.
+	if len(bytes) < totalLength+9 {
+		return nil, 0, fmt.Errorf("input length too short < 36 + script + 4 + previous output")
+	}
+	prevOut, size, err := NewOutputFromBytes(bytes[totalLength:])
+	if err != nil {
+		return nil, 0, err
+	}
+	input.PreviousTxSatoshis = prevOut.Satoshis
+	input.PreviousTxScript = prevOut.LockingScript
+
+	return input, totalLength + size, nil
 }
 
 // TotalInputSatoshis returns the total Satoshis inputted to the transaction.
@@ -117,23 +117,10 @@ func (tx *Tx) AddHashPuzzleOutput(secret, publicKeyHash string, satoshis uint64)
 		return err
 	}
 
-	s := &bscript.Script{}
-
-	s.AppendOpCode(bscript.OpHASH160)
-	secretBytesHash := crypto.Hash160([]byte(secret))
-
-	if err = s.AppendPushData(secretBytesHash); err != nil {
-		return err
-	}
-	s.AppendOpCode(bscript.OpEQUALVERIFY).
-		AppendOpCode(bscript.OpDUP).
-		AppendOpCode(bscript.OpHASH160)
-
-	if err = s.AppendPushData(publicKeyHashBytes); err != nil {
+	s, err := bscript.NewHashPuzzle(crypto.Hash160([]byte(secret)), publicKeyHashBytes)
+	if err != nil {
 		return err
 	}
-	s.AppendOpCode(bscript.OpEQUALVERIFY).
-		AppendOpCode(bscript.OpCHECKSIG)
 
 	tx.AddOutput(&Output{
 		Satoshis:      satoshis,
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/libsv/go-bk/crypto"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/libsv/go-bt/v2"
@@ -86,6 +87,22 @@ func TestNewHashPuzzleOutput(t *testing.T) {
 			tx.Outputs[0].LockingScriptHexString(),
 		)
 	})
+
+	t.Run("matches the hash puzzle template", func(t *testing.T) {
+		addr, err := bscript.NewAddressFromString("myFhJggmsaA2S8Qe6ZQDEcVCwC4wLkvC4e")
+		assert.NoError(t, err)
+		pkh, err := hex.DecodeString(addr.PublicKeyHash)
+		assert.NoError(t, err)
+
+		tx := bt.NewTx()
+		assert.NoError(t, tx.AddHashPuzzleOutput("secret1", addr.PublicKeyHash, uint64(5000)))
+
+		tmpl, err := bscript.TemplateByType(bscript.ScriptTypeHashPuzzle)
+		assert.NoError(t, err)
+		s, err := tmpl.Build(&bscript.TemplateParams{Hash: crypto.Hash160([]byte("secret1")), PubKeyHash: pkh})
+		assert.NoError(t, err)
+		assert.Equal(t, tx.Outputs[0].LockingScript, s)
+	})
 }
 
 func TestNewOpReturnOutput(t *testing.T) {
@@ -52,6 +52,37 @@ func (tx *Tx) SignHash(ctx context.Context, s Signer, index uint32, shf sighash.
 	return tx.ApplyP2PKHUnlockingScript(index, pubKey, sig, shf)
 }
 
+// SignTemplate unlocks the input at a specific index using the registered
+// template matching the locking script it spends, signing with the
+// AutoSigner. The public key and signing function of the UnlockParams are
+// set from the AutoSigner, so only the sighash flag and any preimage need
+// to be provided.
+func (tx *Tx) SignTemplate(ctx context.Context, s AutoSigner, index uint32, up bscript.UnlockParams) error {
+	if int(index) >= len(tx.Inputs) {
+		return fmt.Errorf("no input at index %d", index)
+	}
+	if up.SigHashFlag == 0 {
+		up.SigHashFlag = sighash.AllForkID
+	}
+
+	pubKey, err := s.PublicKey(ctx)
+	if err != nil {
+		return err
+	}
+	up.PubKeys = [][]byte{pubKey}
+	up.Sign = func([]byte) ([]byte, error) {
+		_, sig, err := s.Sign(ctx, tx, index, up.SigHashFlag)
+		return sig, err
+	}
+
+	uls, err := tx.Inputs[index].PreviousTxScript.Unlock(&up)
+	if err != nil {
+		return err
+	}
+
+	return tx.ApplyUnlockingScript(index, uls)
+}
+
 // ApplyP2PKHUnlockingScript applies a script to the transaction at a specific index in
 // unlocking script field.
 func (tx *Tx) ApplyP2PKHUnlockingScript(index uint32, pubKey []byte, sig []byte, shf sighash.Flag) error {
@@ -2,10 +2,15 @@ package bt_test
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/crypto"
 	. "github.com/libsv/go-bk/wif"
 	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,3 +86,94 @@ func TestTx_SignAuto(t *testing.T) {
 		assert.Equal(t, rawTxBefore, tx.String())
 	})
 }
+
+// kSigner signs with a chosen k value, so that its signatures have a known R
+// value for solving r-puzzles.
+type kSigner struct {
+	key *bec.PrivateKey
+	k   *big.Int
+}
+
+func (s *kSigner) Sign(ctx context.Context, tx *bt.Tx, index uint32, shf sighash.Flag) ([]byte, []byte, error) {
+	sh, err := tx.CalcInputSignatureHash(index, shf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.SignHash(ctx, sh)
+}
+
+func (s *kSigner) SignHash(ctx context.Context, hash []byte) ([]byte, []byte, error) {
+	n := bec.S256().N
+	rx, _ := bec.S256().ScalarBaseMult(s.k.Bytes())
+	r := new(big.Int).Mod(rx, n)
+
+	sv := new(big.Int).Mul(r, s.key.D)
+	sv.Add(sv, new(big.Int).SetBytes(hash))
+	sv.Mul(sv, new(big.Int).ModInverse(s.k, n))
+	sv.Mod(sv, n)
+	if sv.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		sv.Sub(n, sv)
+	}
+
+	sig := &bec.Signature{R: r, S: sv}
+	return s.key.PubKey().SerialiseCompressed(), sig.Serialise(), nil
+}
+
+func (s *kSigner) PublicKey(context.Context) ([]byte, error) {
+	return s.key.PubKey().SerialiseCompressed(), nil
+}
+
+func TestTx_SignTemplate(t *testing.T) {
+	t.Parallel()
+
+	keys := make([]*bec.PrivateKey, 2)
+	for i := range keys {
+		k, err := bec.NewPrivateKey(bec.S256())
+		assert.NoError(t, err)
+		keys[i] = k
+	}
+	pubKey := keys[0].PubKey().SerialiseCompressed()
+
+	rSigner := &kSigner{key: keys[1], k: big.NewInt(0x1234567890)}
+	_, rSig, err := rSigner.SignHash(context.Background(), make([]byte, 32))
+	assert.NoError(t, err)
+	rValue := rSig[4 : 4+rSig[3]]
+
+	scripts := []struct {
+		scriptType string
+		params     *bscript.TemplateParams
+	}{
+		{bscript.ScriptTypePubKey, &bscript.TemplateParams{PubKeys: [][]byte{pubKey}}},
+		{bscript.ScriptTypeMultiSig, &bscript.TemplateParams{
+			PubKeys:  [][]byte{keys[1].PubKey().SerialiseCompressed(), pubKey},
+			Required: 1,
+		}},
+		{bscript.ScriptTypeHashPuzzle, &bscript.TemplateParams{
+			Hash:       crypto.Hash160([]byte("secret")),
+			PubKeyHash: crypto.Hash160(pubKey),
+		}},
+		{bscript.ScriptTypeRPuzzle, &bscript.TemplateParams{RHash: crypto.Hash160(rValue)}},
+	}
+
+	tx := bt.NewTx()
+	for i, s := range scripts {
+		tmpl, err := bscript.TemplateByType(s.scriptType)
+		assert.NoError(t, err)
+		ls, err := tmpl.Build(s.params)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.From("07912972e42095fe58daaf09161c5a5da57be47c2054dc2aaa52b30fefa1940b",
+			uint32(i), ls.String(), 1000))
+	}
+	assert.NoError(t, tx.PayToAddress("1GHMW7ABrFma2NSwiVe9b9bZxkMB7tuPZi", 3000))
+
+	signer := &bt.LocalSigner{PrivateKey: keys[0]}
+	ctx := context.Background()
+	assert.NoError(t, tx.SignTemplate(ctx, signer, 0, bscript.UnlockParams{}))
+	assert.NoError(t, tx.SignTemplate(ctx, signer, 1, bscript.UnlockParams{}))
+	assert.ErrorIs(t, tx.SignTemplate(ctx, signer, 2, bscript.UnlockParams{}), bscript.ErrMissingUnlockParam)
+	assert.NoError(t, tx.SignTemplate(ctx, signer, 2, bscript.UnlockParams{Preimage: []byte("secret")}))
+	assert.Error(t, tx.SignTemplate(ctx, signer, 3, bscript.UnlockParams{}))
+	assert.NoError(t, tx.SignTemplate(ctx, rSigner, 3, bscript.UnlockParams{}))
+
+	verifyTx(t, tx)
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// EnvNewPassphrase is the environment variable containing the passphrase to rotate to.
+	EnvNewPassphrase = "WALLET_NEW_PASSPHRASE"
+)
+
+var cmdKeystore = &cobra.Command{
+	Use:   "keystore",
+	Short: "Manage the contract keys in storage.",
+	Long: "Manage the contract keys in storage. Keys are encrypted with WALLET_PASSPHRASE. " +
+		"The daemon must not be running when keys are changed.",
+}
+
+var cmdKeystoreImport = &cobra.Command{
+	Use:   "import <WIF>",
+	Short: "Import a contract private key.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+		cfg := bootstrap.NewConfig(ctx)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+		defer masterDB.Close()
+
+		w := bootstrap.NewWallet(ctx, cfg)
+		if err := loadWallet(ctx, masterDB, w); err != nil {
+			return errors.Wrap(err, "load wallet")
+		}
+
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+		if err := w.Register(args[0], net); err != nil {
+			return errors.Wrap(err, "register key")
+		}
+
+		if err := saveWallet(ctx, masterDB, w); err != nil {
+			return errors.Wrap(err, "save wallet")
+		}
+
+		printKeys(w, net)
+		return nil
+	},
+}
+
+var cmdKeystoreList = &cobra.Command{
+	Use:   "list",
+	Short: "List the addresses of the contract keys.",
+	RunE: func(c *cobra.Command, args []string) error {
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+		cfg := bootstrap.NewConfig(ctx)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+		defer masterDB.Close()
+
+		w := bootstrap.NewWallet(ctx, cfg)
+		if err := loadWallet(ctx, masterDB, w); err != nil {
+			return errors.Wrap(err, "load wallet")
+		}
+
+		printKeys(w, bitcoin.NetworkFromString(cfg.Bitcoin.Network))
+		return nil
+	},
+}
+
+var cmdKeystoreRotate = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt the contract keys with a new passphrase.",
+	Long: "Re-encrypt the contract keys with the passphrase in " + EnvNewPassphrase + ". " +
+		"WALLET_PASSPHRASE is the current passphrase. It is empty when the keys are not " +
+		"encrypted yet. Set WALLET_PASSPHRASE to the new passphrase afterward.",
+	RunE: func(c *cobra.Command, args []string) error {
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+		cfg := bootstrap.NewConfig(ctx)
+
+		var current wallet.Encrypter
+		if len(cfg.Wallet.Passphrase) > 0 {
+			enc, err := wallet.NewPassphraseEncrypter(cfg.Wallet.Passphrase)
+			if err != nil {
+				return errors.Wrap(err, "current encrypter")
+			}
+			current = enc
+		}
+
+		next, err := wallet.NewPassphraseEncrypter(os.Getenv(EnvNewPassphrase))
+		if err != nil {
+			return errors.Wrap(err, EnvNewPassphrase)
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+		defer masterDB.Close()
+
+		count, err := rotateWallet(ctx, masterDB, current, next)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Re-encrypted %d keys\n", count)
+		return nil
+	},
+}
+
+// rotateWallet reads the stored keys with the current encrypter, which is nil when the keys are
+// not encrypted, and stores them encrypted with the next encrypter. It returns the key count.
+func rotateWallet(ctx context.Context, masterDB *db.DB, current,
+	next wallet.Encrypter) (int, error) {
+
+	var w *wallet.Wallet
+	if current == nil {
+		w = wallet.New()
+	} else {
+		w = wallet.NewEncrypted(current, true)
+	}
+
+	if err := loadWallet(ctx, masterDB, w); err != nil {
+		return 0, errors.Wrap(err, "load wallet")
+	}
+
+	w.SetEncrypter(next)
+
+	if err := saveWallet(ctx, masterDB, w); err != nil {
+		return 0, errors.Wrap(err, "save wallet")
+	}
+
+	return len(w.ListAll()), nil
+}
+
+// loadWallet reads the stored keys into the wallet. It is not an error if there are no keys.
+func loadWallet(ctx context.Context, masterDB *db.DB, w *wallet.Wallet) error {
+	data, err := masterDB.Fetch(ctx, listeners.WalletKey)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return w.Deserialize(bytes.NewReader(data))
+}
+
+func saveWallet(ctx context.Context, masterDB *db.DB, w *wallet.Wallet) error {
+	var buf bytes.Buffer
+	if err := w.Serialize(&buf); err != nil {
+		return err
+	}
+
+	return masterDB.Put(ctx, listeners.WalletKey, buf.Bytes())
+}
+
+func printKeys(w *wallet.Wallet, net bitcoin.Network) {
+	keys := w.ListAll()
+	fmt.Printf("%d keys\n", len(keys))
+	for _, key := range keys {
+		fmt.Printf("  %s\n", bitcoin.NewAddressFromRawAddress(key.Address, net).String())
+	}
+}
+
+func init() {
+	cmdKeystore.AddCommand(cmdKeystoreImport)
+	cmdKeystore.AddCommand(cmdKeystoreList)
+	cmdKeystore.AddCommand(cmdKeystoreRotate)
+}
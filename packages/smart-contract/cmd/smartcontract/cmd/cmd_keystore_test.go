@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+)
+
+func TestRotateWallet(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	masterDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   dir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage : %s", err)
+	}
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	plain := wallet.New()
+	if err := plain.Register(key.String(), bitcoin.MainNet); err != nil {
+		t.Fatalf("Failed to register key : %s", err)
+	}
+	if err := saveWallet(ctx, masterDB, plain); err != nil {
+		t.Fatalf("Failed to save wallet : %s", err)
+	}
+
+	first := newKeystoreEncrypter(t, "first")
+	second := newKeystoreEncrypter(t, "second")
+
+	// Encrypt the unencrypted keys, then rotate to a new passphrase.
+	if count, err := rotateWallet(ctx, masterDB, nil, first); err != nil || count != 1 {
+		t.Fatalf("Failed to encrypt wallet : %d keys : %v", count, err)
+	}
+	if count, err := rotateWallet(ctx, masterDB, first, second); err != nil || count != 1 {
+		t.Fatalf("Failed to rotate wallet : %d keys : %v", count, err)
+	}
+
+	old := wallet.NewEncrypted(first, false)
+	if err := loadWallet(ctx, masterDB, old); errors.Cause(err) != wallet.ErrDecrypt {
+		t.Errorf("Wrong error for old passphrase : got %v, want %v", err, wallet.ErrDecrypt)
+	}
+
+	w := wallet.NewEncrypted(second, false)
+	if err := loadWallet(ctx, masterDB, w); err != nil {
+		t.Fatalf("Failed to load rotated wallet : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	loadedKey, err := w.Get(ra)
+	if err != nil {
+		t.Fatalf("Failed to get key : %s", err)
+	}
+	if !bytes.Equal(loadedKey.Key.Number(), key.Number()) {
+		t.Errorf("Wrong key after rotation")
+	}
+}
+
+func newKeystoreEncrypter(t *testing.T, passphrase string) wallet.Encrypter {
+	enc, err := wallet.NewPassphraseEncrypter(passphrase)
+	if err != nil {
+		t.Fatalf("Failed to create encrypter : %s", err)
+	}
+	return enc
+}
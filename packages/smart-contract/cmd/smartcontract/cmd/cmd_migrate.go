@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/transfer"
+	"github.com/tokenized/smart-contract/internal/utxos"
+	"github.com/tokenized/smart-contract/internal/vote"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// migrateObjects are copied as they are because they don't have their own tables.
+	migrateObjects = []string{"chainstate", "tracer", "server", "wallet"}
+
+	// migrateObjectPaths contain objects that are copied as they are.
+	migrateObjectPaths = []string{"txs", "txstates", "formations"}
+)
+
+var cmdMigrate = &cobra.Command{
+	Use:   "migrate",
+	Short: "Import contract storage into a SQL database.",
+	Long: "Import contract storage into a SQL database. Data is read from the storage bucket " +
+		"(CONTRACT_STORAGE_BUCKET/CONTRACT_STORAGE_ROOT) and written to the SQL database " +
+		"(CONTRACT_STORAGE_DRIVER/CONTRACT_STORAGE_DATA_SOURCE). Existing rows are " +
+		"overwritten so it can be run again. The daemon must not be running.",
+	RunE: func(c *cobra.Command, args []string) error {
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfig(ctx)
+
+		if len(cfg.Storage.Driver) == 0 {
+			return errors.New("Missing CONTRACT_STORAGE_DRIVER")
+		}
+
+		fromDB := bootstrap.NewMasterDBFromValues(ctx, cfg.Storage.Bucket, cfg.Storage.Root,
+			cfg.AWS.MaxRetries, cfg.AWS.RetryDelay)
+		defer fromDB.Close()
+
+		toDB := bootstrap.NewMasterDB(ctx, cfg)
+		defer toDB.Close()
+
+		return migrateStorage(ctx, fromDB, toDB, cfg.Contract.IsTest)
+	},
+}
+
+// migrateStorage copies everything in one storage to another. The domain packages are used to
+// read and write so that the data is put in the tables of a SQL database.
+func migrateStorage(ctx context.Context, fromDB, toDB *db.DB, isTest bool) error {
+	// The package caches would return data from the wrong storage.
+	defer resetCaches(ctx)
+	resetCaches(ctx)
+
+	for _, key := range migrateObjects {
+		if err := migrateObject(ctx, fromDB, toDB, key); err != nil {
+			return errors.Wrap(err, key)
+		}
+	}
+
+	for _, path := range migrateObjectPaths {
+		keys, err := fromDB.List(ctx, path)
+		if err != nil {
+			return errors.Wrapf(err, "list %s", path)
+		}
+
+		for _, key := range keys {
+			if err := migrateObject(ctx, fromDB, toDB, key); err != nil {
+				return errors.Wrap(err, key)
+			}
+		}
+
+		fmt.Printf("Copied %d %s\n", len(keys), path)
+	}
+
+	us, err := utxos.Load(ctx, fromDB)
+	if err != nil {
+		return errors.Wrap(err, "load utxos")
+	}
+	if err := us.Save(ctx, toDB); err != nil {
+		return errors.Wrap(err, "save utxos")
+	}
+
+	paths, err := fromDB.List(ctx, "contracts")
+	if err != nil {
+		return errors.Wrap(err, "list contracts")
+	}
+
+	for _, path := range paths {
+		b, err := fromDB.Fetch(ctx, path+"/contract")
+		if err != nil {
+			if err == db.ErrNotFound {
+				continue
+			}
+			return errors.Wrapf(err, "fetch %s", path)
+		}
+
+		// Unmarshal directly rather than using contract.Fetch, which expands oracles.
+		c := &state.Contract{}
+		if err := json.Unmarshal(b, c); err != nil {
+			return errors.Wrapf(err, "unmarshal %s", path)
+		}
+
+		if err := migrateContract(ctx, fromDB, toDB, c, isTest); err != nil {
+			return errors.Wrap(err, path)
+		}
+
+		if err := migrateObject(ctx, fromDB, toDB, path+"/agreement"); err != nil {
+			return errors.Wrapf(err, "%s agreement", path)
+		}
+
+		fmt.Printf("Migrated contract %s\n", strings.TrimPrefix(path, "contracts/"))
+	}
+
+	return nil
+}
+
+func migrateContract(ctx context.Context, fromDB, toDB *db.DB, c *state.Contract,
+	isTest bool) error {
+
+	if err := contract.Save(ctx, toDB, c, isTest); err != nil {
+		return errors.Wrap(err, "save contract")
+	}
+
+	for _, assetCode := range c.AssetCodes {
+		a, err := asset.Fetch(ctx, fromDB, c.Address, assetCode)
+		if err != nil {
+			return errors.Wrapf(err, "fetch asset %s", assetCode)
+		}
+
+		if err := asset.Save(ctx, toDB, c.Address, a); err != nil {
+			return errors.Wrapf(err, "save asset %s", assetCode)
+		}
+
+		hs, err := holdings.FetchAll(ctx, fromDB, c.Address, assetCode)
+		if err != nil {
+			return errors.Wrapf(err, "fetch holdings %s", assetCode)
+		}
+
+		for _, h := range hs {
+			if _, err := holdings.Save(ctx, toDB, c.Address, assetCode, h); err != nil {
+				return errors.Wrapf(err, "save holding %s", assetCode)
+			}
+		}
+
+		// Holdings are saved to the cache so they have to be written.
+		if err := holdings.WriteCache(ctx, toDB); err != nil {
+			return errors.Wrapf(err, "write holdings %s", assetCode)
+		}
+	}
+
+	votes, err := vote.List(ctx, fromDB, c.Address)
+	if err != nil {
+		return errors.Wrap(err, "list votes")
+	}
+
+	for _, v := range votes {
+		// Listed votes only contain the ballot list.
+		v.Ballots = make(map[bitcoin.Hash20]state.Ballot)
+		for _, b := range v.BallotList {
+			hash, err := b.Address.Hash()
+			if err != nil {
+				return errors.Wrap(err, "ballot address hash")
+			}
+			v.Ballots[*hash] = b
+		}
+
+		if err := vote.Save(ctx, toDB, c.Address, v); err != nil {
+			return errors.Wrapf(err, "save vote %s", v.VoteTxId)
+		}
+	}
+
+	transfers, err := transfer.List(ctx, fromDB, c.Address)
+	if err != nil {
+		return errors.Wrap(err, "list transfers")
+	}
+
+	for _, t := range transfers {
+		if err := transfer.Save(ctx, toDB, c.Address, t); err != nil {
+			return errors.Wrapf(err, "save transfer %s", t.TransferTxId)
+		}
+	}
+
+	return nil
+}
+
+// migrateObject copies an object if it exists.
+func migrateObject(ctx context.Context, fromDB, toDB *db.DB, key string) error {
+	b, err := fromDB.Fetch(ctx, key)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil
+		}
+		return errors.Wrap(err, "fetch")
+	}
+
+	if err := toDB.Put(ctx, key, b); err != nil {
+		return errors.Wrap(err, "put")
+	}
+
+	return nil
+}
+
+func resetCaches(ctx context.Context) {
+	contract.Reset(ctx)
+	asset.Reset(ctx)
+	holdings.Reset(ctx)
+	vote.Reset(ctx)
+}
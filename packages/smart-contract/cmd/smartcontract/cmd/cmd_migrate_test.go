@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/transfer"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fromDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   filepath.Join(dir, "storage"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage : %s", err)
+	}
+
+	toDB, err := db.New(&db.StorageConfig{
+		Driver:     db.DriverSQLite,
+		DataSource: filepath.Join(dir, "contract.db"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create SQL DB : %s", err)
+	}
+	defer toDB.Close()
+
+	contractAddress := generateAddress(t)
+	holderAddress := generateAddress(t)
+	assetCode := &bitcoin.Hash20{1}
+	txid := &bitcoin.Hash32{2}
+	now := protocol.CurrentTimestamp()
+
+	c := &state.Contract{
+		Address:    contractAddress,
+		Revision:   3,
+		AssetCodes: []*bitcoin.Hash20{assetCode},
+	}
+	if err := contract.Save(ctx, fromDB, c, true); err != nil {
+		t.Fatalf("Failed to save contract : %s", err)
+	}
+
+	a := &state.Asset{
+		Code:               assetCode,
+		AssetType:          "SHC",
+		AuthorizedTokenQty: 1000,
+	}
+	if err := asset.Save(ctx, fromDB, contractAddress, a); err != nil {
+		t.Fatalf("Failed to save asset : %s", err)
+	}
+
+	h := &state.Holding{
+		Address:          holderAddress,
+		PendingBalance:   100,
+		FinalizedBalance: 100,
+		HoldingStatuses: map[bitcoin.Hash32]*state.HoldingStatus{
+			*txid: &state.HoldingStatus{
+				Code:    'F',
+				Amount:  50,
+				TxId:    txid,
+				Expires: now,
+			},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := holdings.Save(ctx, fromDB, contractAddress, assetCode, h); err != nil {
+		t.Fatalf("Failed to save holding : %s", err)
+	}
+	if err := holdings.WriteCache(ctx, fromDB); err != nil {
+		t.Fatalf("Failed to write holdings : %s", err)
+	}
+
+	holderHash, err := holderAddress.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash address : %s", err)
+	}
+	v := &state.Vote{
+		VoteTxId: txid,
+		Ballots: map[bitcoin.Hash20]state.Ballot{
+			*holderHash: state.Ballot{
+				Address:  holderAddress,
+				Vote:     "A",
+				Quantity: 100,
+			},
+		},
+	}
+	if err := vote.Save(ctx, fromDB, contractAddress, v); err != nil {
+		t.Fatalf("Failed to save vote : %s", err)
+	}
+
+	pt := &state.PendingTransfer{TransferTxId: txid, Timeout: now}
+	if err := transfer.Save(ctx, fromDB, contractAddress, pt); err != nil {
+		t.Fatalf("Failed to save transfer : %s", err)
+	}
+
+	if err := fromDB.Put(ctx, "txs/"+txid.String(), []byte("tx")); err != nil {
+		t.Fatalf("Failed to save tx : %s", err)
+	}
+
+	if err := migrateStorage(ctx, fromDB, toDB, true); err != nil {
+		t.Fatalf("Failed to migrate : %s", err)
+	}
+
+	// Caches were reset so everything is read from the SQL database.
+	fetchedContract, err := contract.Fetch(ctx, toDB, contractAddress, true)
+	if err != nil {
+		t.Fatalf("Failed to fetch contract : %s", err)
+	}
+	if fetchedContract.Revision != c.Revision {
+		t.Errorf("Wrong contract revision : got %d, want %d", fetchedContract.Revision,
+			c.Revision)
+	}
+
+	fetchedAsset, err := asset.Fetch(ctx, toDB, contractAddress, assetCode)
+	if err != nil {
+		t.Fatalf("Failed to fetch asset : %s", err)
+	}
+	if fetchedAsset.AuthorizedTokenQty != a.AuthorizedTokenQty {
+		t.Errorf("Wrong authorized quantity : got %d, want %d", fetchedAsset.AuthorizedTokenQty,
+			a.AuthorizedTokenQty)
+	}
+
+	fetchedHoldings, err := holdings.FetchAll(ctx, toDB, contractAddress, assetCode)
+	if err != nil {
+		t.Fatalf("Failed to fetch holdings : %s", err)
+	}
+	if len(fetchedHoldings) != 1 {
+		t.Fatalf("Wrong holding count : got %d, want 1", len(fetchedHoldings))
+	}
+	if !fetchedHoldings[0].Address.Equal(holderAddress) {
+		t.Errorf("Wrong holding address")
+	}
+	if fetchedHoldings[0].FinalizedBalance != h.FinalizedBalance {
+		t.Errorf("Wrong balance : got %d, want %d", fetchedHoldings[0].FinalizedBalance,
+			h.FinalizedBalance)
+	}
+	status, exists := fetchedHoldings[0].HoldingStatuses[*txid]
+	if !exists {
+		t.Fatalf("Missing holding status")
+	}
+	if status.Code != 'F' || status.Amount != 50 || !status.Expires.Equal(now) {
+		t.Errorf("Wrong holding status : %+v", status)
+	}
+
+	fetchedVote, err := vote.Fetch(ctx, toDB, contractAddress, txid)
+	if err != nil {
+		t.Fatalf("Failed to fetch vote : %s", err)
+	}
+	ballot, exists := fetchedVote.Ballots[*holderHash]
+	if !exists {
+		t.Fatalf("Missing ballot")
+	}
+	if ballot.Vote != "A" || ballot.Quantity != 100 {
+		t.Errorf("Wrong ballot : %+v", ballot)
+	}
+
+	fetchedTransfer, err := transfer.Fetch(ctx, toDB, contractAddress, txid)
+	if err != nil {
+		t.Fatalf("Failed to fetch transfer : %s", err)
+	}
+	if !fetchedTransfer.Timeout.Equal(now) {
+		t.Errorf("Wrong transfer timeout")
+	}
+
+	if _, err := toDB.Fetch(ctx, "txs/"+txid.String()); err != nil {
+		t.Errorf("Failed to fetch tx : %s", err)
+	}
+}
+
+func generateAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return ra
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tokenized/pkg/json"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/replay"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagKeep = "keep"
+)
+
+// replayResponse is a response tx in the replay output.
+type replayResponse struct {
+	Event  int    `json:"event"`
+	Action string `json:"action"`
+	TxID   string `json:"txid"`
+	Tx     string `json:"tx"`
+}
+
+var cmdReplay = &cobra.Command{
+	Use:   "replay <recording file>",
+	Short: "Replay recorded requests and print the responses.",
+	Long: "Replay recorded requests through the contract handlers and print the response txs " +
+		"as JSON. Nothing is broadcast. Contract state is written to scratch storage, not to " +
+		"the contract storage, and requests are processed at the recorded times. Responses " +
+		"are signed with test keys, never with the contract wallet, and are printed without " +
+		"signatures. If the replay fails, the responses before the failure are printed.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return errors.Wrap(err, "read recording")
+		}
+
+		recording := &replay.Recording{}
+		if err := json.Unmarshal(data, recording); err != nil {
+			return errors.Wrap(err, "parse recording")
+		}
+
+		dir, err := ioutil.TempDir("", "replay")
+		if err != nil {
+			return errors.Wrap(err, "scratch dir")
+		}
+
+		keep, _ := c.Flags().GetBool(FlagKeep)
+		if keep {
+			fmt.Fprintf(os.Stderr, "Scratch storage : %s\n", dir)
+		} else {
+			defer os.RemoveAll(dir)
+		}
+
+		masterDB, err := db.New(&db.StorageConfig{
+			Bucket: "standalone",
+			Root:   dir,
+		})
+		if err != nil {
+			return errors.Wrap(err, "scratch storage")
+		}
+		defer masterDB.Close()
+
+		// The responses before a failure are still printed so the failure can be located.
+		responses, replayErr := replay.Run(ctx, recording, masterDB)
+
+		output := make([]replayResponse, 0, len(responses))
+		for _, response := range responses {
+			tx, err := replay.TxHex(response.Tx)
+			if err != nil {
+				return errors.Wrap(err, "serialize response")
+			}
+
+			output = append(output, replayResponse{
+				Event:  response.Event,
+				Action: response.Action,
+				TxID:   response.Tx.TxHash().String(),
+				Tx:     tx,
+			})
+		}
+
+		js, err := json.MarshalIndent(output, "", "    ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", js)
+		return replayErr
+	},
+}
+
+func init() {
+	cmdReplay.Flags().Bool(FlagKeep, false, "keep the scratch storage to inspect the state")
+}
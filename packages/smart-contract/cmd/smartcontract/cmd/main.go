@@ -34,6 +34,9 @@ func Execute() {
 	scCmd.AddCommand(cmdState)
 	scCmd.AddCommand(cmdJSON)
 	scCmd.AddCommand(cmdFIP)
+	scCmd.AddCommand(cmdMigrate)
+	scCmd.AddCommand(cmdKeystore)
+	scCmd.AddCommand(cmdReplay)
 	scCmd.Execute()
 }
 
@@ -31,8 +31,24 @@ func NewContextWithDevelopmentLogger() context.Context {
 	return ctx
 }
 
-func NewWallet() *wallet.Wallet {
-	return wallet.New()
+// NewWallet returns a wallet that encrypts keys with WALLET_PASSPHRASE. Without a passphrase keys
+//   are stored unencrypted, which is only allowed when WALLET_ALLOW_UNENCRYPTED is set.
+func NewWallet(ctx context.Context, cfg *smartContractConfig.Config) *wallet.Wallet {
+	if len(cfg.Wallet.Passphrase) == 0 {
+		if !cfg.Wallet.AllowUnencrypted {
+			logger.Fatal(ctx, "Missing WALLET_PASSPHRASE. Set WALLET_ALLOW_UNENCRYPTED to store keys unencrypted")
+		}
+
+		logger.Warn(ctx, "Wallet keys are stored unencrypted. Set WALLET_PASSPHRASE to encrypt them")
+		return wallet.New()
+	}
+
+	enc, err := wallet.NewPassphraseEncrypter(cfg.Wallet.Passphrase)
+	if err != nil {
+		logger.Fatal(ctx, "Wallet encrypter : %s", err)
+	}
+
+	return wallet.NewEncrypted(enc, cfg.Wallet.AllowUnencrypted)
 }
 
 func NewConfig(ctx context.Context) *smartContractConfig.Config {
@@ -54,6 +70,8 @@ func NewMasterDB(ctx context.Context, cfg *smartContractConfig.Config) *db.DB {
 		Root:       cfg.Storage.Root,
 		MaxRetries: cfg.AWS.MaxRetries,
 		RetryDelay: cfg.AWS.RetryDelay,
+		Driver:     cfg.Storage.Driver,
+		DataSource: cfg.Storage.DataSource,
 	})
 	if err != nil {
 		logger.Fatal(ctx, "Register DB : %s", err)
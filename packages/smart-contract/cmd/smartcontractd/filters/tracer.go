@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"io"
+	"sync"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/wire"
@@ -21,6 +22,14 @@ const (
 //   a specified transaction if you are expecting a later UTXO spend to come back to you.
 type Tracer struct {
 	traces []*traceNode
+	lock   sync.Mutex
+}
+
+// Trace is a snapshot of a traced UTXO path. The children are the outputs of the tx that spent the
+//   outpoint.
+type Trace struct {
+	Outpoint wire.OutPoint
+	Children []*Trace
 }
 
 func NewTracer() *Tracer {
@@ -30,15 +39,36 @@ func NewTracer() *Tracer {
 
 // Count returns the number of active traces.
 func (tracer *Tracer) Count() int {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	return len(tracer.traces)
 }
 
 // Clear removes all active traces.
 func (tracer *Tracer) Clear() {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	tracer.traces = nil
 }
 
+// Traces returns a copy of the active traces.
+func (tracer *Tracer) Traces() []*Trace {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
+	result := make([]*Trace, 0, len(tracer.traces))
+	for _, trace := range tracer.traces {
+		result = append(result, trace.snapshot())
+	}
+	return result
+}
+
 func (tracer *Tracer) Save(ctx context.Context, masterDB *db.DB) error {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	// Save the cache list
 	var buf bytes.Buffer
 
@@ -60,6 +90,9 @@ func (tracer *Tracer) Save(ctx context.Context, masterDB *db.DB) error {
 }
 
 func (tracer *Tracer) Load(ctx context.Context, masterDB *db.DB) error {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	data, err := masterDB.Fetch(ctx, tracerStorageKey)
 	if err != nil {
 		if err == db.ErrNotFound {
@@ -92,6 +125,9 @@ func (tracer *Tracer) Load(ctx context.Context, masterDB *db.DB) error {
 
 // Add adds a new trace starting at the specified output.
 func (tracer *Tracer) Add(ctx context.Context, start *wire.OutPoint) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	newNode := traceNode{
 		outpoint: *start,
 	}
@@ -100,6 +136,9 @@ func (tracer *Tracer) Add(ctx context.Context, start *wire.OutPoint) {
 
 // Remove removes a trace containing the specified output.
 func (tracer *Tracer) Remove(ctx context.Context, start *wire.OutPoint) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	for i, trace := range tracer.traces {
 		if bytes.Equal(trace.outpoint.Hash[:], start.Hash[:]) &&
 			trace.outpoint.Index == start.Index {
@@ -112,6 +151,9 @@ func (tracer *Tracer) Remove(ctx context.Context, start *wire.OutPoint) {
 // AddTx adds the next step of any path in tracer if contained in the specified tx.
 // Returns true if one of the inputs matches a monitored output.
 func (tracer *Tracer) AddTx(ctx context.Context, tx *wire.MsgTx) bool {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	result := false
 	for _, trace := range tracer.traces {
 		if trace.addTx(tx) {
@@ -123,6 +165,9 @@ func (tracer *Tracer) AddTx(ctx context.Context, tx *wire.MsgTx) bool {
 
 // RevertTx reverts any outputs from any traced paths.
 func (tracer *Tracer) RevertTx(ctx context.Context, txid *bitcoin.Hash32) {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	for _, trace := range tracer.traces {
 		trace.revertTx(txid)
 	}
@@ -131,6 +176,9 @@ func (tracer *Tracer) RevertTx(ctx context.Context, txid *bitcoin.Hash32) {
 // Contains returns the hash of the output that was requested to be monitored that contains the tx
 // specified.
 func (tracer *Tracer) Contains(ctx context.Context, tx *wire.MsgTx) bool {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	for _, trace := range tracer.traces {
 		if trace.contains(tx) {
 			return true
@@ -143,6 +191,9 @@ func (tracer *Tracer) Contains(ctx context.Context, tx *wire.MsgTx) bool {
 // specified.
 // The trace is also removed.
 func (tracer *Tracer) Retrace(ctx context.Context, tx *wire.MsgTx) *bitcoin.Hash32 {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+
 	for i, trace := range tracer.traces {
 		if trace.contains(tx) {
 			tracer.traces = append(tracer.traces[:i], tracer.traces[i+1:]...)
@@ -157,6 +208,17 @@ type traceNode struct {
 	children []*traceNode
 }
 
+func (node *traceNode) snapshot() *Trace {
+	result := &Trace{
+		Outpoint: node.outpoint,
+		Children: make([]*Trace, 0, len(node.children)),
+	}
+	for _, child := range node.children {
+		result.Children = append(result.Children, child.snapshot())
+	}
+	return result
+}
+
 func (node *traceNode) addTx(tx *wire.MsgTx) bool {
 	result := false
 	if len(node.children) == 0 {
@@ -246,7 +246,7 @@ func (m *Message) ProcessRevert(ctx context.Context, w *node.ResponseWriter,
 			}
 		}
 
-		err = tx.Sign([]bitcoin.Key{rk.Key})
+		err = node.Sign(m.Config, tx, rk)
 		if err == nil {
 			break
 		}
@@ -419,7 +419,7 @@ func (m *Message) processSettlementRequest(ctx context.Context, w *node.Response
 		signed := false
 		var sigHashCache txbuilder.SigHashCache
 		for i, _ := range settleTx.Inputs {
-			err = settleTx.SignP2PKHInput(i, rk.Key, &sigHashCache)
+			err = node.SignP2PKHInput(m.Config, settleTx, i, rk, &sigHashCache)
 			if errors.Cause(err) == txbuilder.ErrWrongPrivateKey {
 				continue
 			}
@@ -603,7 +603,7 @@ func (m *Message) processSigRequestSettlement(ctx context.Context, w *node.Respo
 	signed := false
 	var hashCache txbuilder.SigHashCache
 	for i, _ := range settleTx.Inputs {
-		err = settleTx.SignP2PKHInput(i, rk.Key, &hashCache)
+		err = node.SignP2PKHInput(m.Config, settleTx, i, rk, &hashCache)
 		if errors.Cause(err) == txbuilder.ErrWrongPrivateKey {
 			continue
 		}
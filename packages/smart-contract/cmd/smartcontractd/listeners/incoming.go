@@ -85,44 +85,56 @@ func (server *Server) ProcessIncomingTxs(ctx context.Context) error {
 		txCtx := node.ContextWithLogTrace(ctx, intx.Itx.Hash.String())
 		node.Log(txCtx, "Processing incoming tx")
 
-		if err := intx.Itx.Validate(txCtx); err != nil {
+		if err := Preprocess(txCtx, server.MasterDB, server.Config, server.Headers, intx.Itx,
+			intx.Timestamp); err != nil {
 			server.abortPendingTx(txCtx, *intx.Itx.Hash)
-			return errors.Wrap(err, "validate")
+			return err
 		}
 
-		if server.Config.MinFeeRate > 0.0 && intx.Itx.IsIncomingMessageType() {
-			feeRate, err := intx.Itx.FeeRate()
-			if err != nil {
-				server.abortPendingTx(txCtx, *intx.Itx.Hash)
-				return errors.Wrap(err, "fee rate")
-			}
-			if feeRate < server.Config.MinFeeRate {
-				intx.Itx.RejectCode = actions.RejectionsInsufficientTxFeeFunding
-				node.LogWarn(txCtx, "Low tx fee rate %f", feeRate)
-			}
-		}
+		server.markPreprocessed(ctx, *intx.Itx.Hash)
+	}
 
-		if intx.Itx.RejectCode == 0 {
-			switch msg := intx.Itx.MsgProto.(type) {
-			case *actions.Transfer:
-				if err := validateOracles(txCtx, server.MasterDB, intx.Itx, msg, server.Headers,
-					server.Config.IsTest); err != nil {
-					intx.Itx.RejectCode = actions.RejectionsInvalidSignature
-					intx.Itx.RejectText = fmt.Sprintf("Invalid receiver oracle signature : %s", err)
-					node.LogWarn(txCtx, "Invalid receiver oracle signature : %s", err)
-				}
-			case *actions.ContractOffer:
-				if err := validateAdminIdentityOracleSig(txCtx, server.MasterDB, server.Config,
-					intx.Itx, msg, server.Headers, intx.Timestamp); err != nil {
-					intx.Itx.RejectCode = actions.RejectionsInvalidSignature
-					intx.Itx.RejectText = fmt.Sprintf("Invalid admin identity oracle signature : %s",
-						err)
-					node.LogWarn(txCtx, "Invalid admin identity oracle signature : %s", err)
-				}
-			}
+	return nil
+}
+
+// Preprocess validates a tx before it is processed by the handlers. The tx's reject code is set
+//   when it has a low fee rate or invalid oracle signatures.
+func Preprocess(ctx context.Context, masterDB *db.DB, config *node.Config,
+	headers node.BitcoinHeaders, itx *inspector.Transaction, timestamp protocol.Timestamp) error {
+
+	if err := itx.Validate(ctx); err != nil {
+		return errors.Wrap(err, "validate")
+	}
+
+	if config.MinFeeRate > 0.0 && itx.IsIncomingMessageType() {
+		feeRate, err := itx.FeeRate()
+		if err != nil {
+			return errors.Wrap(err, "fee rate")
+		}
+		if feeRate < config.MinFeeRate {
+			itx.RejectCode = actions.RejectionsInsufficientTxFeeFunding
+			node.LogWarn(ctx, "Low tx fee rate %f", feeRate)
 		}
+	}
 
-		server.markPreprocessed(ctx, *intx.Itx.Hash)
+	if itx.RejectCode != 0 {
+		return nil
+	}
+
+	switch msg := itx.MsgProto.(type) {
+	case *actions.Transfer:
+		if err := validateOracles(ctx, masterDB, itx, msg, headers, config.IsTest); err != nil {
+			itx.RejectCode = actions.RejectionsInvalidSignature
+			itx.RejectText = fmt.Sprintf("Invalid receiver oracle signature : %s", err)
+			node.LogWarn(ctx, "Invalid receiver oracle signature : %s", err)
+		}
+	case *actions.ContractOffer:
+		if err := validateAdminIdentityOracleSig(ctx, masterDB, config, itx, msg, headers,
+			timestamp); err != nil {
+			itx.RejectCode = actions.RejectionsInvalidSignature
+			itx.RejectText = fmt.Sprintf("Invalid admin identity oracle signature : %s", err)
+			node.LogWarn(ctx, "Invalid admin identity oracle signature : %s", err)
+		}
 	}
 
 	return nil
@@ -25,7 +25,7 @@ import (
 )
 
 const (
-	walletKey = "wallet" // storage path for wallet
+	WalletKey = "wallet" // storage path for wallet
 	serverKey = "server" // storage path for server
 )
 
@@ -119,16 +119,17 @@ func (server *Server) SaveWallet(ctx context.Context) error {
 	logger.Elapsed(ctx, start, "Serialize wallet")
 
 	defer logger.Elapsed(ctx, time.Now(), "Put wallet")
-	return server.MasterDB.Put(ctx, walletKey, buf.Bytes())
+	return server.MasterDB.Put(ctx, WalletKey, buf.Bytes())
 }
 
 func (server *Server) LoadWallet(ctx context.Context) error {
 	node.Log(ctx, "Loading wallet")
 
-	data, err := server.MasterDB.Fetch(ctx, walletKey)
+	data, err := server.MasterDB.Fetch(ctx, WalletKey)
 	if err != nil {
 		if err == db.ErrNotFound {
-			return nil // No keys yet
+			// Nothing stored yet, but there may be keys imported from the config.
+			return server.SyncWallet(ctx)
 		}
 		return errors.Wrap(err, "fetch wallet")
 	}
@@ -0,0 +1,64 @@
+package listeners
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+)
+
+// TestLoadWalletFirstBoot checks that a key imported from the config is monitored when there is
+// no stored wallet yet.
+func TestLoadWalletFirstBoot(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	masterDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   dir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage : %s", err)
+	}
+	defer masterDB.Close()
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	w := wallet.New()
+	if err := w.Register(key.String(), bitcoin.MainNet); err != nil {
+		t.Fatalf("Failed to register key : %s", err)
+	}
+
+	server := NewServer(w, nil, &node.Config{Net: bitcoin.MainNet}, masterDB, nil, nil, nil, nil,
+		nil, nil)
+	if err := server.LoadWallet(ctx); err != nil {
+		t.Fatalf("Failed to load wallet : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	if len(server.contractAddresses) != 1 {
+		t.Fatalf("Wrong contract address count : got %d, want 1", len(server.contractAddresses))
+	}
+	if !server.contractAddresses[0].Equal(ra) {
+		t.Errorf("Wrong contract address : got %s, want %s",
+			bitcoin.NewAddressFromRawAddress(server.contractAddresses[0], bitcoin.MainNet),
+			bitcoin.NewAddressFromRawAddress(ra, bitcoin.MainNet))
+	}
+}
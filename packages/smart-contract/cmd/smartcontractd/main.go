@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/handlers"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/query"
 	spynodeBootstrap "github.com/tokenized/spynode/cmd/spynoded/bootstrap"
 )
 
@@ -98,14 +100,14 @@ func main() {
 	// -------------------------------------------------------------------------
 	// Wallet
 
-	masterWallet := bootstrap.NewWallet()
-	if err := masterWallet.Register(cfg.Contract.PrivateKey, appConfig.Net); err != nil {
-		panic(err)
-	}
+	masterWallet := bootstrap.NewWallet(ctx, cfg)
 
-	contractAddress := bitcoin.NewAddressFromRawAddress(masterWallet.KeyStore.GetAddresses()[0],
-		appConfig.Net)
-	logger.Info(ctx, "Contract address : %s", contractAddress.String())
+	// Keys in the config are imported into the stored wallet.
+	if len(cfg.Contract.PrivateKey) > 0 {
+		if err := masterWallet.Register(cfg.Contract.PrivateKey, appConfig.Net); err != nil {
+			panic(err)
+		}
+	}
 
 	// -------------------------------------------------------------------------
 	// Start Database / Storage
@@ -155,10 +157,29 @@ func main() {
 		holdingsChannel,
 	)
 
-	if err := node.SyncWallet(ctx); err != nil {
+	if err := node.LoadWallet(ctx); err != nil {
 		logger.Fatal(ctx, "Load Wallet : %s", err)
 	}
 
+	// Save so that imported keys, and keys that were stored unencrypted, are stored encrypted.
+	if err := node.SaveWallet(ctx); err != nil {
+		logger.Fatal(ctx, "Save Wallet : %s", err)
+	}
+
+	if len(cfg.Contract.PrivateKey) > 0 {
+		logger.Warn(ctx, "PRIV_KEY has been imported into the wallet and can be removed from the config")
+	}
+
+	contractKeys := masterWallet.ListAll()
+	if len(contractKeys) == 0 {
+		logger.Fatal(ctx, "No contract keys. Set PRIV_KEY or use \"smartcontract keystore import\"")
+	}
+
+	for _, key := range contractKeys {
+		logger.Info(ctx, "Contract address : %s",
+			bitcoin.NewAddressFromRawAddress(key.Address, appConfig.Net).String())
+	}
+
 	if err := node.Load(ctx); err != nil {
 		logger.Fatal(ctx, "Load Server : %s", err)
 	}
@@ -197,6 +218,30 @@ func main() {
 		wg.Done()
 	}()
 
+	// -------------------------------------------------------------------------
+	// Start Query API
+
+	// Make a channel to listen for errors coming from the listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	queryErrors := make(chan error, 1)
+
+	var queryServer *query.Server
+	if len(cfg.Query.Address) > 0 {
+		queryServer = query.NewServer(ctx, &query.Config{
+			Address: cfg.Query.Address,
+			Key:     cfg.Query.Key,
+		}, appConfig, masterDB, tracer)
+
+		// Start the service listening for requests.
+		wg.Add(1)
+		go func() {
+			logger.Info(ctx, "Query API Running")
+			queryErrors <- queryServer.Run(ctx)
+			logger.Info(ctx, "Query API Finished")
+			wg.Done()
+		}()
+	}
+
 	// -------------------------------------------------------------------------
 	// Shutdown
 
@@ -219,6 +264,8 @@ func main() {
 			logger.Error(ctx, "Could not stop spynode: %s", err)
 		}
 
+		stopQuery(ctx, queryServer)
+
 	case err := <-spynodeErrors:
 		if err != nil {
 			logger.Error(ctx, "Error starting server: %s", err)
@@ -228,6 +275,21 @@ func main() {
 			logger.Error(ctx, "Could not stop server: %s", err)
 		}
 
+		stopQuery(ctx, queryServer)
+
+	case err := <-queryErrors:
+		if err != nil {
+			logger.Error(ctx, "Error starting query API: %s", err)
+		}
+
+		if err := spyNode.Stop(ctx); err != nil {
+			logger.Error(ctx, "Could not stop spynode: %s", err)
+		}
+
+		if err := node.Stop(ctx); err != nil {
+			logger.Error(ctx, "Could not stop server: %s", err)
+		}
+
 	case <-osSignals:
 		logger.Info(ctx, "Shutting down")
 
@@ -238,6 +300,8 @@ func main() {
 		if err := node.Stop(ctx); err != nil {
 			logger.Error(ctx, "Could not stop server: %s", err)
 		}
+
+		stopQuery(ctx, queryServer)
 	}
 
 	// Block until goroutines finish as a result of Stop()
@@ -247,3 +311,14 @@ func main() {
 		logger.Error(ctx, "Save UTXOs : %s", err)
 	}
 }
+
+// stopQuery stops the query API if it was started.
+func stopQuery(ctx context.Context, queryServer *query.Server) {
+	if queryServer == nil {
+		return
+	}
+
+	if err := queryServer.Stop(ctx); err != nil {
+		logger.Error(ctx, "Could not stop query API: %s", err)
+	}
+}
@@ -0,0 +1,298 @@
+package query
+
+import (
+	"context"
+	"sort"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/assets"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// statusTypes are the names of the holding status codes.
+var statusTypes = map[byte]string{
+	holdings.FreezeCode:               "Freeze",
+	holdings.DebitCode:                "PendingSend",
+	holdings.DepositCode:              "PendingReceive",
+	holdings.MultiContractDebitCode:   "MultiContractSend",
+	holdings.MultiContractDepositCode: "MultiContractReceive",
+}
+
+func (server *Server) getContract(ctx context.Context,
+	contractAddress bitcoin.RawAddress) (*ContractResponse, error) {
+
+	c, err := contract.Fetch(ctx, server.masterDB, contractAddress, server.nodeConfig.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch contract")
+	}
+
+	result := &ContractResponse{
+		Address:  bitcoin.NewAddressFromRawAddress(contractAddress, server.nodeConfig.Net).String(),
+		Contract: c,
+	}
+
+	formation, err := contract.FetchContractFormation(ctx, server.masterDB, contractAddress,
+		server.nodeConfig.IsTest)
+	if err != nil && errors.Cause(err) != contract.ErrNotFound {
+		return nil, errors.Wrap(err, "fetch contract formation")
+	}
+	result.Formation = formation
+
+	return result, nil
+}
+
+func (server *Server) getAssets(ctx context.Context,
+	contractAddress bitcoin.RawAddress) ([]*AssetResponse, error) {
+
+	c, err := contract.Fetch(ctx, server.masterDB, contractAddress, server.nodeConfig.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch contract")
+	}
+
+	result := make([]*AssetResponse, 0, len(c.AssetCodes))
+	for _, assetCode := range c.AssetCodes {
+		a, err := server.fetchAsset(ctx, contractAddress, assetCode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "asset %s", assetCode)
+		}
+
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+func (server *Server) getAsset(ctx context.Context, contractAddress bitcoin.RawAddress,
+	assetID string) (*AssetResponse, error) {
+
+	assetCode, err := server.decodeAssetID(ctx, contractAddress, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return server.fetchAsset(ctx, contractAddress, assetCode)
+}
+
+func (server *Server) getHoldings(ctx context.Context, contractAddress bitcoin.RawAddress,
+	assetID string) ([]*HoldingResponse, error) {
+
+	assetCode, err := server.decodeAssetID(ctx, contractAddress, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	hs, err := holdings.FetchAll(ctx, server.masterDB, contractAddress, assetCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch holdings")
+	}
+
+	now := protocol.CurrentTimestamp()
+	result := make([]*HoldingResponse, 0, len(hs))
+	for _, h := range hs {
+		result = append(result, server.holdingResponse(h, now))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+
+	return result, nil
+}
+
+func (server *Server) getHolding(ctx context.Context, contractAddress bitcoin.RawAddress,
+	assetID, address string) (*HoldingResponse, error) {
+
+	assetCode, err := server.decodeAssetID(ctx, contractAddress, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	holderAddress, err := decodeAddress(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "holder address")
+	}
+
+	h, err := holdings.Fetch(ctx, server.masterDB, contractAddress, assetCode, holderAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch holding")
+	}
+
+	return server.holdingResponse(h, protocol.CurrentTimestamp()), nil
+}
+
+func (server *Server) getVotes(ctx context.Context,
+	contractAddress bitcoin.RawAddress) ([]*state.Vote, error) {
+
+	// Check the contract exists so an unknown contract isn't just an empty list.
+	if _, err := contract.Fetch(ctx, server.masterDB, contractAddress,
+		server.nodeConfig.IsTest); err != nil {
+		return nil, errors.Wrap(err, "fetch contract")
+	}
+
+	votes, err := vote.List(ctx, server.masterDB, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list votes")
+	}
+
+	for _, v := range votes {
+		v.BallotList = nil // Ballots are only included when requesting a single vote
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].CreatedAt.Nano() < votes[j].CreatedAt.Nano()
+	})
+
+	return votes, nil
+}
+
+func (server *Server) getVote(ctx context.Context, contractAddress bitcoin.RawAddress,
+	voteTxID string) (*state.Vote, error) {
+
+	txid, err := bitcoin.NewHash32FromStr(voteTxID)
+	if err != nil {
+		return nil, errors.Wrapf(ErrBadRequest, "vote txid %s : %s", voteTxID, err)
+	}
+
+	v, err := vote.Fetch(ctx, server.masterDB, contractAddress, txid)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch vote")
+	}
+
+	// The ballot map can't be encoded to JSON so convert it to the list.
+	v.BallotList = make([]state.Ballot, 0, len(v.Ballots))
+	for _, b := range v.Ballots {
+		v.BallotList = append(v.BallotList, b)
+	}
+
+	sort.Slice(v.BallotList, func(i, j int) bool {
+		return v.BallotList[i].Timestamp.Nano() < v.BallotList[j].Timestamp.Nano()
+	})
+
+	return v, nil
+}
+
+func (server *Server) getTraces(ctx context.Context) ([]*TraceResponse, error) {
+	traces := server.tracer.Traces()
+
+	result := make([]*TraceResponse, 0, len(traces))
+	for _, trace := range traces {
+		result = append(result, traceResponse(trace))
+	}
+
+	return result, nil
+}
+
+// decodeAssetID returns the asset code of an asset ID if the asset belongs to the contract.
+func (server *Server) decodeAssetID(ctx context.Context, contractAddress bitcoin.RawAddress,
+	assetID string) (*bitcoin.Hash20, error) {
+
+	assetType, assetCode, err := protocol.DecodeAssetID(assetID)
+	if err != nil {
+		return nil, errors.Wrapf(ErrBadRequest, "asset id %s : %s", assetID, err)
+	}
+
+	c, err := contract.Fetch(ctx, server.masterDB, contractAddress, server.nodeConfig.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch contract")
+	}
+
+	for _, contractAssetCode := range c.AssetCodes {
+		if !contractAssetCode.Equal(&assetCode) {
+			continue
+		}
+
+		a, err := asset.Fetch(ctx, server.masterDB, contractAddress, &assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch asset")
+		}
+
+		if a.AssetType != assetType {
+			return nil, asset.ErrNotFound
+		}
+
+		return &assetCode, nil
+	}
+
+	return nil, asset.ErrNotFound
+}
+
+func (server *Server) fetchAsset(ctx context.Context, contractAddress bitcoin.RawAddress,
+	assetCode *bitcoin.Hash20) (*AssetResponse, error) {
+
+	a, err := asset.Fetch(ctx, server.masterDB, contractAddress, assetCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch asset")
+	}
+
+	payload, err := assets.Deserialize([]byte(a.AssetType), a.AssetPayload)
+	if err != nil {
+		return nil, errors.Wrap(err, "deserialize payload")
+	}
+
+	return &AssetResponse{
+		AssetID: protocol.AssetID(a.AssetType, *assetCode),
+		Asset:   a,
+		Payload: payload,
+	}, nil
+}
+
+func (server *Server) holdingResponse(h *state.Holding, now protocol.Timestamp) *HoldingResponse {
+	result := &HoldingResponse{
+		Address:          bitcoin.NewAddressFromRawAddress(h.Address, server.nodeConfig.Net).String(),
+		PendingBalance:   h.PendingBalance,
+		FinalizedBalance: h.FinalizedBalance,
+		UnfrozenBalance:  holdings.UnfrozenBalance(h, now),
+		CreatedAt:        h.CreatedAt,
+		UpdatedAt:        h.UpdatedAt,
+	}
+
+	for _, hs := range h.HoldingStatuses {
+		statusType, exists := statusTypes[hs.Code]
+		if !exists {
+			statusType = string([]byte{hs.Code})
+		}
+
+		result.Statuses = append(result.Statuses, &HoldingStatusResponse{
+			Type:           statusType,
+			TxID:           hs.TxId,
+			Amount:         hs.Amount,
+			SettleQuantity: hs.SettleQuantity,
+			Expires:        hs.Expires,
+			Expired:        holdings.StatusExpired(hs, now),
+			Posted:         hs.Posted,
+		})
+	}
+
+	sort.Slice(result.Statuses, func(i, j int) bool {
+		return statusTxID(result.Statuses[i]) < statusTxID(result.Statuses[j])
+	})
+
+	return result
+}
+
+func traceResponse(trace *filters.Trace) *TraceResponse {
+	result := &TraceResponse{
+		Outpoint: trace.Outpoint.String(),
+	}
+
+	for _, child := range trace.Children {
+		result.Children = append(result.Children, traceResponse(child))
+	}
+
+	return result
+}
+
+func statusTxID(hs *HoldingStatusResponse) string {
+	if hs.TxID == nil {
+		return ""
+	}
+	return hs.TxID.String()
+}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/assets"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+// ErrorResponse is returned when a request fails.
+type ErrorResponse struct {
+	Error string `json:"Error"`
+}
+
+// ContractResponse is the state of a contract and the formation it was last given.
+type ContractResponse struct {
+	Address   string                     `json:"Address"`
+	Contract  *state.Contract            `json:"Contract"`
+	Formation *actions.ContractFormation `json:"Formation,omitempty"`
+}
+
+// AssetResponse is the state of an asset and its decoded payload.
+type AssetResponse struct {
+	AssetID string       `json:"AssetID"`
+	Asset   *state.Asset `json:"Asset"`
+	Payload assets.Asset `json:"Payload,omitempty"`
+}
+
+// HoldingResponse is the balance of an address. Frozen and pending amounts are listed in the
+//   statuses.
+type HoldingResponse struct {
+	Address          string                   `json:"Address"`
+	PendingBalance   uint64                   `json:"PendingBalance"`
+	FinalizedBalance uint64                   `json:"FinalizedBalance"`
+	UnfrozenBalance  uint64                   `json:"UnfrozenBalance"`
+	Statuses         []*HoldingStatusResponse `json:"Statuses,omitempty"`
+	CreatedAt        protocol.Timestamp       `json:"CreatedAt"`
+	UpdatedAt        protocol.Timestamp       `json:"UpdatedAt"`
+}
+
+// HoldingStatusResponse is a freeze or pending change of a holding.
+type HoldingStatusResponse struct {
+	Type           string             `json:"Type"`
+	TxID           *bitcoin.Hash32    `json:"TxID,omitempty"`
+	Amount         uint64             `json:"Amount"`
+	SettleQuantity uint64             `json:"SettleQuantity,omitempty"`
+	Expires        protocol.Timestamp `json:"Expires,omitempty"`
+	Expired        bool               `json:"Expired,omitempty"`
+	Posted         bool               `json:"Posted,omitempty"`
+}
+
+// TraceResponse is a transfer trace and the outputs it has followed so far.
+type TraceResponse struct {
+	Outpoint string           `json:"Outpoint"`
+	Children []*TraceResponse `json:"Children,omitempty"`
+}
@@ -0,0 +1,221 @@
+package query
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/vote"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	authorizationPrefix = "Bearer "
+
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	// ErrMissingKey occurs when the query API is configured without a key.
+	ErrMissingKey = errors.New("Query key missing")
+
+	// ErrNotFound occurs when a request is for something that doesn't exist.
+	ErrNotFound = errors.New("Not found")
+
+	// ErrBadRequest occurs when a request can't be parsed.
+	ErrBadRequest = errors.New("Bad request")
+)
+
+// Config is the configuration of the query API.
+type Config struct {
+	Address string // Address to listen on
+	Key     string // Key that must be provided as a bearer token
+}
+
+// Server serves a read-only JSON view of contract state over HTTP. It only reads from storage
+//   and never modifies it, so it can run beside the node.
+type Server struct {
+	ctx        context.Context
+	config     *Config
+	nodeConfig *node.Config
+	masterDB   *db.DB
+	tracer     *filters.Tracer
+	httpServer *http.Server
+}
+
+// NewServer returns a new query server. The context is used for logging.
+func NewServer(ctx context.Context, config *Config, nodeConfig *node.Config, masterDB *db.DB,
+	tracer *filters.Tracer) *Server {
+
+	result := &Server{
+		ctx:        ctx,
+		config:     config,
+		nodeConfig: nodeConfig,
+		masterDB:   masterDB,
+		tracer:     tracer,
+	}
+
+	result.httpServer = &http.Server{
+		Addr:         config.Address,
+		Handler:      result,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	return result
+}
+
+// Run listens for requests until Stop is called.
+func (server *Server) Run(ctx context.Context) error {
+	if len(server.config.Key) == 0 {
+		return ErrMissingKey
+	}
+
+	logger.Info(ctx, "Query API listening on %s", server.config.Address)
+
+	if err := server.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "listen")
+	}
+
+	return nil
+}
+
+// Stop stops listening and waits for active requests to complete.
+func (server *Server) Stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	return server.httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP implements http.Handler.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !server.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := server.ctx
+	response, err := server.route(ctx, strings.Split(strings.Trim(r.URL.Path, "/"), "/"))
+	if err != nil {
+		switch errors.Cause(err) {
+		case ErrNotFound, contract.ErrNotFound, asset.ErrNotFound, holdings.ErrNotFound,
+			vote.ErrNotFound:
+			respondError(w, http.StatusNotFound, errors.Cause(err).Error())
+		case ErrBadRequest:
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			logger.Error(ctx, "Failed to respond to query %s : %s", r.URL.Path, err)
+			respondError(w, http.StatusInternalServerError, "Internal error")
+		}
+		return
+	}
+
+	respond(w, http.StatusOK, response)
+}
+
+// authorized returns true if the request contains the configured key.
+func (server *Server) authorized(r *http.Request) bool {
+	if len(server.config.Key) == 0 {
+		return false
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, authorizationPrefix) {
+		return false
+	}
+
+	key := header[len(authorizationPrefix):]
+	return subtle.ConstantTimeCompare([]byte(key), []byte(server.config.Key)) == 1
+}
+
+// route returns the response for the path.
+//   /contracts/<address>
+//   /contracts/<address>/assets
+//   /contracts/<address>/assets/<asset id>
+//   /contracts/<address>/assets/<asset id>/holdings
+//   /contracts/<address>/assets/<asset id>/holdings/<address>
+//   /contracts/<address>/votes
+//   /contracts/<address>/votes/<vote txid>
+//   /traces
+func (server *Server) route(ctx context.Context, path []string) (interface{}, error) {
+	if len(path) == 1 && path[0] == "traces" {
+		return server.getTraces(ctx)
+	}
+
+	if len(path) < 2 || path[0] != "contracts" {
+		return nil, ErrNotFound
+	}
+
+	contractAddress, err := decodeAddress(path[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "contract address")
+	}
+
+	path = path[2:]
+	if len(path) == 0 {
+		return server.getContract(ctx, contractAddress)
+	}
+
+	switch path[0] {
+	case "assets":
+		switch {
+		case len(path) == 1:
+			return server.getAssets(ctx, contractAddress)
+		case len(path) == 2:
+			return server.getAsset(ctx, contractAddress, path[1])
+		case len(path) == 3 && path[2] == "holdings":
+			return server.getHoldings(ctx, contractAddress, path[1])
+		case len(path) == 4 && path[2] == "holdings":
+			return server.getHolding(ctx, contractAddress, path[1], path[3])
+		}
+
+	case "votes":
+		switch len(path) {
+		case 1:
+			return server.getVotes(ctx, contractAddress)
+		case 2:
+			return server.getVote(ctx, contractAddress, path[1])
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func decodeAddress(s string) (bitcoin.RawAddress, error) {
+	address, err := bitcoin.DecodeAddress(s)
+	if err != nil {
+		return bitcoin.RawAddress{}, errors.Wrapf(ErrBadRequest, "address %s : %s", s, err)
+	}
+
+	return bitcoin.NewRawAddressFromAddress(address), nil
+}
+
+func respond(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	respond(w, status, &ErrorResponse{Error: message})
+}
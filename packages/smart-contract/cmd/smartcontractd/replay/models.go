@@ -0,0 +1,130 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// Recording is a sequence of requests to replay. It contains everything needed to process them
+//   so that replaying it always produces the same responses.
+type Recording struct {
+	Network        string  `json:"network"`     // mainnet or testnet
+	IsTest         bool    `json:"is_test"`     // requests use the test protocol ID
+	FeeAddress     string  `json:"fee_address"` // contract fee address
+	FeeRate        float32 `json:"fee_rate"`    // satoshis per byte of response txs
+	DustFeeRate    float32 `json:"dust_fee_rate"`
+	MinFeeRate     float32 `json:"min_fee_rate"` // requests below this fee rate are rejected
+	RequestTimeout uint64  `json:"request_timeout"`
+
+	// Contracts are the addresses of the contracts that respond to the requests. The contract keys
+	// aren't needed. Responses are signed with test keys derived from the addresses.
+	Contracts []string `json:"contracts"`
+
+	Headers []Header `json:"headers"`
+
+	// Txs are hex txs that are spent by the events, so that the inputs of the requests can be
+	// found.
+	Txs []string `json:"txs"`
+
+	Events []Event `json:"events"`
+}
+
+// Header is a block header on the longest chain.
+type Header struct {
+	Height int    `json:"height"`
+	Header string `json:"header"` // hex
+}
+
+// Event is a request, or a timeout, processed at a specific time. One of Tx or End must be set.
+type Event struct {
+	Time uint64 `json:"time"` // nanoseconds since the Unix epoch
+
+	// Tx is a hex tx that is processed as if it was seen on the network.
+	Tx string `json:"tx,omitempty"`
+
+	// End is the txid of a transfer or vote that is finalized, as when its timeout expires. The
+	// tx must be in an earlier event.
+	End string `json:"end,omitempty"`
+}
+
+// Response is a tx that would have been broadcast.
+type Response struct {
+	Event  int         // index of the event that caused the response
+	Action string      // action code of the response, empty if it doesn't contain one
+	Tx     *wire.MsgTx // signature scripts are removed because they are from test keys
+}
+
+// NodeConfig returns the node configuration of the recording.
+func (r *Recording) NodeConfig() (*node.Config, error) {
+	result := &node.Config{
+		Net:            bitcoin.NetworkFromString(r.Network),
+		FeeRate:        r.FeeRate,
+		DustFeeRate:    r.DustFeeRate,
+		MinFeeRate:     r.MinFeeRate,
+		RequestTimeout: r.RequestTimeout,
+		IsTest:         r.IsTest,
+	}
+
+	if result.Net == bitcoin.InvalidNet {
+		return nil, errors.Errorf("Invalid network : %s", r.Network)
+	}
+
+	if len(r.FeeAddress) > 0 {
+		address, err := bitcoin.DecodeAddress(r.FeeAddress)
+		if err != nil {
+			return nil, errors.Wrap(err, "fee address")
+		}
+		result.FeeAddress = bitcoin.NewRawAddressFromAddress(address)
+	}
+
+	return result, nil
+}
+
+// Timestamp returns the time of the event.
+func (e *Event) Timestamp() protocol.Timestamp {
+	return protocol.NewTimestamp(e.Time)
+}
+
+// TxHex returns the tx as hex, as it is stored in a recording.
+func TxHex(tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func decodeTx(s string) (*wire.MsgTx, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "hex")
+	}
+
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, errors.Wrap(err, "deserialize")
+	}
+
+	return tx, nil
+}
+
+func decodeHeader(s string) (*wire.BlockHeader, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "hex")
+	}
+
+	header := &wire.BlockHeader{}
+	if err := header.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, errors.Wrap(err, "deserialize")
+	}
+
+	return header, nil
+}
@@ -0,0 +1,401 @@
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/scheduler"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/handlers"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/internal/utxos"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+	"github.com/tokenized/spynode/pkg/client"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxResponses limits the responses to one event so that responses that trigger each other
+	//   can't loop forever.
+	maxResponses = 1000
+)
+
+var (
+	// ErrMissingTx occurs when a tx referenced by an event isn't in the recording.
+	ErrMissingTx = errors.New("Tx not in recording")
+
+	// ErrTooManyResponses occurs when an event causes more than maxResponses responses.
+	ErrTooManyResponses = errors.New("Too many responses")
+)
+
+// replayer processes the events of a recording.
+type replayer struct {
+	config   *node.Config
+	masterDB *db.DB
+	handler  protomux.Handler
+	tracer   *filters.Tracer
+	utxos    *utxos.UTXOs
+	txs      *txCache
+	headers  *headers
+	wallet   *contractWallet
+
+	now       protocol.Timestamp
+	event     int
+	pending   []*wire.MsgTx // responses that haven't been processed
+	responses []*Response
+}
+
+// Run processes the events of the recording with the same handlers as the daemon and returns the
+//   response txs that would have been broadcast. masterDB must be scratch storage because the
+//   contract state is written to it.
+//
+// The time of each event is used as the current time. Scheduled jobs, like transfer timeouts and
+//   vote finalization, don't run. They are replayed with End events.
+func Run(ctx context.Context, recording *Recording, masterDB *db.DB) ([]*Response, error) {
+	config, err := recording.NodeConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "config")
+	}
+
+	r := &replayer{
+		config:   config,
+		masterDB: masterDB,
+		tracer:   filters.NewTracer(),
+		txs:      newTxCache(),
+		headers:  newHeaders(),
+	}
+
+	// Requests are processed at the time of the event instead of the system time.
+	config.Clock = func() protocol.Timestamp {
+		return r.now
+	}
+
+	// Responses are signed with test keys so the contract keys aren't needed.
+	r.wallet, err = newContractWallet(recording.Contracts, config.Net)
+	if err != nil {
+		return nil, errors.Wrap(err, "contracts")
+	}
+	config.Signer = &testSigner{}
+
+	for i, h := range recording.Headers {
+		header, err := decodeHeader(h.Header)
+		if err != nil {
+			return nil, errors.Wrapf(err, "header %d", i)
+		}
+		r.headers.add(h.Height, header)
+	}
+
+	for i, s := range recording.Txs {
+		tx, err := decodeTx(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "tx %d", i)
+		}
+		r.txs.SaveTX(ctx, tx)
+	}
+
+	// The package caches would return state from other storage.
+	asset.Reset(ctx)
+	contract.Reset(ctx)
+	holdings.Reset(ctx)
+	vote.Reset(ctx)
+
+	r.utxos, err = utxos.Load(ctx, masterDB)
+	if err != nil {
+		return nil, errors.Wrap(err, "load utxos")
+	}
+
+	// The scheduler is never started so scheduled jobs don't depend on the system time.
+	r.handler, err = handlers.API(ctx, r.wallet, config, masterDB, r.tracer,
+		&scheduler.Scheduler{}, r.headers, r.utxos, &holdings.CacheChannel{})
+	if err != nil {
+		return nil, errors.Wrap(err, "handlers")
+	}
+
+	r.handler.SetResponder(r.respond)
+	r.handler.SetReprocessor(r.reprocess)
+
+	for i, event := range recording.Events {
+		r.event = i
+		r.now = event.Timestamp()
+
+		if err := r.processEvent(ctx, &event); err != nil {
+			return r.responses, errors.Wrapf(err, "event %d", i)
+		}
+	}
+
+	return r.responses, nil
+}
+
+func (r *replayer) processEvent(ctx context.Context, event *Event) error {
+	switch {
+	case len(event.Tx) > 0:
+		tx, err := decodeTx(event.Tx)
+		if err != nil {
+			return errors.Wrap(err, "tx")
+		}
+
+		if err := r.processTx(ctx, tx); err != nil {
+			return err
+		}
+
+	case len(event.End) > 0:
+		txid, err := bitcoin.NewHash32FromStr(event.End)
+		if err != nil {
+			return errors.Wrap(err, "end txid")
+		}
+
+		tx, err := r.txs.GetTX(ctx, txid)
+		if err != nil {
+			return err
+		}
+
+		itx, err := r.promote(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		node.Log(ctx, "Replaying end : %s", itx.Hash.String())
+		r.trigger(ctx, protomux.END, itx)
+
+	default:
+		return errors.New("Event has no tx or end")
+	}
+
+	// Responses are processed as if they were seen on the network, which may create more
+	// responses.
+	count := 0
+	for len(r.pending) > 0 {
+		count++
+		if count > maxResponses {
+			return ErrTooManyResponses
+		}
+
+		tx := r.pending[0]
+		r.pending = r.pending[1:]
+
+		if err := r.processTx(ctx, tx); err != nil {
+			return errors.Wrapf(err, "response %s", tx.TxHash().String())
+		}
+	}
+
+	return nil
+}
+
+// processTx processes a tx the way the daemon does when it is seen on the network.
+func (r *replayer) processTx(ctx context.Context, tx *wire.MsgTx) error {
+	r.txs.SaveTX(ctx, tx)
+
+	itx, err := r.promote(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	ctx = node.ContextWithLogTrace(ctx, itx.Hash.String())
+	node.Log(ctx, "Replaying tx")
+
+	if itx.MsgProto != nil && itx.MsgProto.Code() == actions.CodeContractFormation &&
+		len(itx.Inputs) > 0 {
+		cf := itx.MsgProto.(*actions.ContractFormation)
+		if err := contract.SaveContractFormation(ctx, r.masterDB, itx.Inputs[0].Address, cf,
+			r.config.IsTest); err != nil {
+			return errors.Wrap(err, "save contract formation")
+		}
+	}
+
+	if err := transactions.AddTx(ctx, r.masterDB, itx); err != nil {
+		return errors.Wrap(err, "add tx")
+	}
+
+	if err := listeners.Preprocess(ctx, r.masterDB, r.config, r.headers, itx,
+		r.now); err != nil {
+		return errors.Wrap(err, "preprocess")
+	}
+
+	r.tracer.AddTx(ctx, tx)
+
+	if !itx.IsTokenized() {
+		r.utxos.Add(tx, r.wallet.Addresses())
+		return nil
+	}
+
+	r.trigger(ctx, protomux.SEE, itx)
+	return nil
+}
+
+// trigger calls the handlers. Errors are logged, like in the daemon, because they are the
+//   result of processing the tx and not a problem with the replay.
+func (r *replayer) trigger(ctx context.Context, event string, itx *inspector.Transaction) {
+	if err := r.handler.Trigger(ctx, event, itx); err != nil {
+		switch errors.Cause(err) {
+		case node.ErrNoResponse, node.ErrRejected, node.ErrInsufficientFunds:
+			node.Log(ctx, "Failed to handle tx : %s", err)
+		default:
+			node.LogError(ctx, "Failed to handle tx : %s", err)
+		}
+	}
+}
+
+func (r *replayer) promote(ctx context.Context, tx *wire.MsgTx) (*inspector.Transaction, error) {
+	itx, err := inspector.NewTransactionFromWire(ctx, tx, r.config.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "inspector")
+	}
+
+	if err := itx.Promote(ctx, r.txs); err != nil {
+		return nil, errors.Wrap(err, "promote")
+	}
+
+	return itx, nil
+}
+
+// respond collects a response instead of broadcasting it. The collected response doesn't have
+//   signatures, but the signed tx is processed so later responses spend it.
+func (r *replayer) respond(ctx context.Context, tx *wire.MsgTx) error {
+	node.Log(ctx, "Replay response : %s", tx.TxHash().String())
+
+	unsigned := tx.Copy()
+	StripSignatures(unsigned)
+
+	r.responses = append(r.responses, &Response{
+		Event:  r.event,
+		Action: actionCode(tx, r.config.IsTest),
+		Tx:     unsigned,
+	})
+	r.pending = append(r.pending, tx.Copy())
+	return nil
+}
+
+func (r *replayer) reprocess(ctx context.Context, itx *inspector.Transaction) error {
+	return r.handler.Trigger(ctx, protomux.END, itx)
+}
+
+// actionCode returns the code of the action in the tx, or an empty string if it doesn't contain
+//   one.
+func actionCode(tx *wire.MsgTx, isTest bool) string {
+	for _, output := range tx.TxOut {
+		msg, err := protocol.Deserialize(output.PkScript, isTest)
+		if err == nil {
+			return msg.Code()
+		}
+	}
+	return ""
+}
+
+// ============================================================
+// Txs
+
+// txCache provides the txs spent by the replayed txs.
+type txCache struct {
+	txs  map[bitcoin.Hash32]*wire.MsgTx
+	lock sync.Mutex
+}
+
+func newTxCache() *txCache {
+	return &txCache{
+		txs: make(map[bitcoin.Hash32]*wire.MsgTx),
+	}
+}
+
+func (c *txCache) SaveTX(ctx context.Context, tx *wire.MsgTx) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.txs[*tx.TxHash()] = tx.Copy()
+	return nil
+}
+
+func (c *txCache) GetTX(ctx context.Context, txid *bitcoin.Hash32) (*wire.MsgTx, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	tx, exists := c.txs[*txid]
+	if !exists {
+		return nil, errors.Wrap(ErrMissingTx, txid.String())
+	}
+	return tx, nil
+}
+
+func (c *txCache) GetOutputs(ctx context.Context,
+	outpoints []wire.OutPoint) ([]bitcoin.UTXO, error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	results := make([]bitcoin.UTXO, len(outpoints))
+	for i, outpoint := range outpoints {
+		tx, exists := c.txs[outpoint.Hash]
+		if !exists {
+			return nil, errors.Wrap(ErrMissingTx, outpoint.Hash.String())
+		}
+
+		if int(outpoint.Index) >= len(tx.TxOut) {
+			return nil, errors.Errorf("Invalid output index %d/%d : %s", outpoint.Index,
+				len(tx.TxOut), outpoint.Hash.String())
+		}
+
+		results[i] = bitcoin.UTXO{
+			Hash:          outpoint.Hash,
+			Index:         outpoint.Index,
+			Value:         tx.TxOut[outpoint.Index].Value,
+			LockingScript: tx.TxOut[outpoint.Index].PkScript,
+		}
+	}
+
+	return results, nil
+}
+
+// ============================================================
+// Headers
+
+// headers provides the recorded block headers by height.
+type headers struct {
+	headers map[int]*wire.BlockHeader
+}
+
+func newHeaders() *headers {
+	return &headers{
+		headers: make(map[int]*wire.BlockHeader),
+	}
+}
+
+func (h *headers) add(height int, header *wire.BlockHeader) {
+	h.headers[height] = header
+}
+
+func (h *headers) BlockHash(ctx context.Context, height int) (*bitcoin.Hash32, error) {
+	header, exists := h.headers[height]
+	if !exists {
+		return nil, errors.Errorf("Header not in recording : %d", height)
+	}
+	return header.BlockHash(), nil
+}
+
+func (h *headers) GetHeaders(ctx context.Context, height, count int) (*client.Headers, error) {
+	result := &client.Headers{
+		RequestHeight: int32(height),
+		StartHeight:   uint32(height),
+	}
+
+	for i := 0; i < count; i++ {
+		header, exists := h.headers[height+i]
+		if !exists {
+			return nil, errors.Errorf("Header not in recording : %d", height+i)
+		}
+		result.Headers = append(result.Headers, header)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,236 @@
+package replay
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/permissions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+
+	contractKey := generateKey(t)
+	issuerKey := generateKey(t)
+	userKey := generateKey(t)
+
+	// Only the contract address is recorded. The contract key isn't needed to replay.
+	contractAddress := rawAddress(t, contractKey)
+	contract := bitcoin.NewAddressFromRawAddress(contractAddress, bitcoin.MainNet)
+	issuerFunding := fundingTx(t, rawAddress(t, issuerKey), 100000)
+	userFunding := fundingTx(t, rawAddress(t, userKey), 100000)
+
+	offer := &actions.ContractOffer{
+		ContractName:        "Replay",
+		BodyOfAgreementType: 1,
+		BodyOfAgreement:     []byte("HASHHASHHASHHASHHASHHASHHASHHASH"),
+		Issuer: &actions.EntityField{
+			Type: "I",
+			Administration: []*actions.AdministratorField{
+				&actions.AdministratorField{Type: 1, Name: "John Smith"},
+			},
+		},
+		VotingSystems: []*actions.VotingSystemField{
+			&actions.VotingSystemField{Name: "Relative 50", VoteType: "R",
+				ThresholdPercentage: 50, HolderProposalFee: 50000},
+		},
+	}
+
+	perms := permissions.Permissions{permissions.Permission{VotingSystemsAllowed: []bool{true}}}
+	var err error
+	offer.ContractPermissions, err = perms.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to serialize permissions : %s", err)
+	}
+
+	// An order from an address that isn't the contract operator must be rejected.
+	order := &actions.Order{
+		ComplianceAction:   actions.ComplianceActionFreeze,
+		AssetType:          "SHC",
+		AssetCode:          make([]byte, 20),
+		SignatureAlgorithm: 1,
+		Message:            "Court order",
+	}
+
+	offerTime := uint64(1600000000000000000)
+	recording := &Recording{
+		Network:        "mainnet",
+		IsTest:         true,
+		FeeRate:        1.0,
+		DustFeeRate:    1.0,
+		MinFeeRate:     0.5,
+		RequestTimeout: 1000000000000,
+		Contracts:      []string{contract.String()},
+		Txs:            []string{txHex(t, issuerFunding), txHex(t, userFunding)},
+		Events: []Event{
+			Event{
+				Time: offerTime,
+				Tx:   txHex(t, requestTx(t, issuerFunding, contractAddress, 1000, offer)),
+			},
+			Event{
+				Time: offerTime + 60000000000,
+				Tx:   txHex(t, requestTx(t, userFunding, contractAddress, 2000, order)),
+			},
+		},
+	}
+
+	responses := runReplay(t, ctx, recording)
+
+	if len(responses) != 2 {
+		t.Fatalf("Wrong response count : got %d, want 2", len(responses))
+	}
+
+	// Responses are signed with test keys, so they are returned without signatures.
+	for i, response := range responses {
+		for j, input := range response.Tx.TxIn {
+			if len(input.SignatureScript) != 0 {
+				t.Errorf("Response %d input %d has a signature", i, j)
+			}
+		}
+	}
+
+	if responses[0].Event != 0 || responses[0].Action != actions.CodeContractFormation {
+		t.Fatalf("Wrong first response : event %d, action %s", responses[0].Event,
+			responses[0].Action)
+	}
+
+	formation, ok := responseAction(t, responses[0]).(*actions.ContractFormation)
+	if !ok {
+		t.Fatalf("First response is not a contract formation")
+	}
+
+	// The recorded time is used instead of the system time.
+	if formation.Timestamp != offerTime {
+		t.Errorf("Wrong formation timestamp : got %d, want %d", formation.Timestamp, offerTime)
+	}
+
+	if responses[1].Event != 1 || responses[1].Action != actions.CodeRejection {
+		t.Fatalf("Wrong second response : event %d, action %s", responses[1].Event,
+			responses[1].Action)
+	}
+
+	rejection, ok := responseAction(t, responses[1]).(*actions.Rejection)
+	if !ok {
+		t.Fatalf("Second response is not a rejection")
+	}
+
+	if rejection.RejectionCode != actions.RejectionsNotOperator {
+		t.Errorf("Wrong rejection code : got %d, want %d", rejection.RejectionCode,
+			actions.RejectionsNotOperator)
+	}
+
+	// Replaying again produces the same txs.
+	again := runReplay(t, ctx, recording)
+	if len(again) != len(responses) {
+		t.Fatalf("Wrong response count on second replay : got %d, want %d", len(again),
+			len(responses))
+	}
+
+	for i := range responses {
+		if !again[i].Tx.TxHash().Equal(responses[i].Tx.TxHash()) {
+			t.Errorf("Response %d is different on second replay", i)
+		}
+	}
+}
+
+func runReplay(t *testing.T, ctx context.Context, recording *Recording) []*Response {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	masterDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   dir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create storage : %s", err)
+	}
+	defer masterDB.Close()
+
+	responses, err := Run(ctx, recording, masterDB)
+	if err != nil {
+		t.Fatalf("Failed to replay : %s", err)
+	}
+
+	return responses
+}
+
+func responseAction(t *testing.T, response *Response) actions.Action {
+	for _, output := range response.Tx.TxOut {
+		action, err := protocol.Deserialize(output.PkScript, true)
+		if err == nil {
+			return action
+		}
+	}
+
+	t.Fatalf("Response doesn't contain an action")
+	return nil
+}
+
+// requestTx returns a tx that spends the first output of the funding tx and sends the action to
+// the contract with value to fund the response.
+func requestTx(t *testing.T, funding *wire.MsgTx, contractAddress bitcoin.RawAddress,
+	value uint64, action actions.Action) *wire.MsgTx {
+
+	tx := wire.NewMsgTx(1)
+	tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(funding.TxHash(), 0),
+		make([]byte, 130)))
+
+	script, err := contractAddress.LockingScript()
+	if err != nil {
+		t.Fatalf("Failed to create locking script : %s", err)
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+
+	script, err = protocol.Serialize(action, true)
+	if err != nil {
+		t.Fatalf("Failed to serialize action : %s", err)
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(0, script))
+
+	return tx
+}
+
+func fundingTx(t *testing.T, address bitcoin.RawAddress, value uint64) *wire.MsgTx {
+	script, err := address.LockingScript()
+	if err != nil {
+		t.Fatalf("Failed to create locking script : %s", err)
+	}
+
+	tx := wire.NewMsgTx(1)
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+	return tx
+}
+
+func txHex(t *testing.T, tx *wire.MsgTx) string {
+	s, err := TxHex(tx)
+	if err != nil {
+		t.Fatalf("Failed to serialize tx : %s", err)
+	}
+	return s
+}
+
+func generateKey(t *testing.T) bitcoin.Key {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	return key
+}
+
+func rawAddress(t *testing.T, key bitcoin.Key) bitcoin.RawAddress {
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+	return ra
+}
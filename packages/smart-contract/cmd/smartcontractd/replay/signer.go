@@ -0,0 +1,178 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotSupported occurs when the replay wallet is asked to do something it can't.
+var ErrNotSupported = errors.New("Not supported by replay wallet")
+
+// StripSignatures removes the signature scripts from the inputs of the tx. Responses are compared
+//   without them because they depend on the key that signed them.
+func StripSignatures(tx *wire.MsgTx) {
+	for _, input := range tx.TxIn {
+		input.SignatureScript = nil
+	}
+}
+
+// testKey returns the key that signs for a contract during a replay. It is derived from the
+//   contract address so replaying a recording always produces the same txs.
+func testKey(address bitcoin.RawAddress, net bitcoin.Network) (bitcoin.Key, error) {
+	hash := sha256.Sum256(address.Bytes())
+	return bitcoin.KeyFromNumber(hash[:], net)
+}
+
+// contractWallet holds a test key for each contract of a recording. Unlike wallet.Wallet the keys
+//   are found by the contract address and not by the address of the key.
+type contractWallet struct {
+	keys []*wallet.Key
+}
+
+func newContractWallet(addresses []string, net bitcoin.Network) (*contractWallet, error) {
+	result := &contractWallet{}
+	for i, s := range addresses {
+		address, err := bitcoin.DecodeAddress(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "contract %d", i)
+		}
+
+		ra := bitcoin.NewRawAddressFromAddress(address)
+		key, err := testKey(ra, net)
+		if err != nil {
+			return nil, errors.Wrapf(err, "contract %d key", i)
+		}
+
+		result.keys = append(result.keys, &wallet.Key{
+			Address: ra,
+			Key:     key,
+		})
+	}
+
+	return result, nil
+}
+
+func (w *contractWallet) Get(address bitcoin.RawAddress) (*wallet.Key, error) {
+	for _, key := range w.keys {
+		if key.Address.Equal(address) {
+			return key, nil
+		}
+	}
+	return nil, wallet.ErrKeyNotFound
+}
+
+func (w *contractWallet) List(addresses []bitcoin.RawAddress) ([]*wallet.Key, error) {
+	var result []*wallet.Key
+	for _, address := range addresses {
+		key, err := w.Get(address)
+		if err == wallet.ErrKeyNotFound {
+			continue
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+func (w *contractWallet) ListAll() []*wallet.Key {
+	return w.keys
+}
+
+func (w *contractWallet) Addresses() []bitcoin.RawAddress {
+	result := make([]bitcoin.RawAddress, 0, len(w.keys))
+	for _, key := range w.keys {
+		result = append(result, key.Address)
+	}
+	return result
+}
+
+func (w *contractWallet) Remove(key *wallet.Key) error {
+	return w.RemoveAddress(key.Address)
+}
+
+func (w *contractWallet) RemoveAddress(address bitcoin.RawAddress) error {
+	for i, key := range w.keys {
+		if key.Address.Equal(address) {
+			w.keys = append(w.keys[:i], w.keys[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (w *contractWallet) Serialize(buf *bytes.Buffer) error {
+	return ErrNotSupported
+}
+
+func (w *contractWallet) Deserialize(buf *bytes.Reader) error {
+	return ErrNotSupported
+}
+
+// testSigner signs response txs with the test key of the contract. The inputs locked to the
+//   contract address are temporarily locked to the test key while they are signed, so fees are
+//   calculated the same way as when the contract key signs.
+type testSigner struct{}
+
+func (s *testSigner) Sign(tx *txbuilder.TxBuilder, wk *wallet.Key) error {
+	restore, err := lockToTestKey(tx, wk, -1)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	return tx.Sign([]bitcoin.Key{wk.Key})
+}
+
+func (s *testSigner) SignP2PKHInput(tx *txbuilder.TxBuilder, index int, wk *wallet.Key,
+	hashCache *txbuilder.SigHashCache) error {
+
+	restore, err := lockToTestKey(tx, wk, index)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	return tx.SignP2PKHInput(index, wk.Key, hashCache)
+}
+
+// lockToTestKey replaces the locking scripts of the inputs that are locked to the contract with
+//   the locking script of the test key. If index isn't negative then only that input is replaced.
+//   The returned function restores the original locking scripts.
+func lockToTestKey(tx *txbuilder.TxBuilder, wk *wallet.Key, index int) (func(), error) {
+	contractScript, err := wk.Address.LockingScript()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract locking script")
+	}
+
+	keyAddress, err := wk.Key.RawAddress()
+	if err != nil {
+		return nil, errors.Wrap(err, "test key address")
+	}
+	keyScript, err := keyAddress.LockingScript()
+	if err != nil {
+		return nil, errors.Wrap(err, "test key locking script")
+	}
+
+	var replaced []int
+	for i, input := range tx.Inputs {
+		if index >= 0 && i != index {
+			continue
+		}
+		if bytes.Equal(input.LockingScript, contractScript) {
+			input.LockingScript = keyScript
+			replaced = append(replaced, i)
+		}
+	}
+
+	return func() {
+		for _, i := range replaced {
+			tx.Inputs[i].LockingScript = contractScript
+		}
+	}, nil
+}
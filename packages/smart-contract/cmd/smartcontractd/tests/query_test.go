@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/query"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+const testQueryKey = "test query key"
+
+// TestQuery is the entry point for testing the query API.
+func TestQuery(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Run("auth", queryAuth)
+	t.Run("contract", queryContract)
+	t.Run("holding", queryHolding)
+	t.Run("notFound", queryNotFound)
+}
+
+func queryAuth(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "I", 1, "John Bitcoin", true, true, false, false,
+		false)
+
+	path := "/contracts/" + queryContractAddress()
+
+	if status := queryRequest(t, http.MethodGet, path, "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("\t%s\tMissing key status : got %d, want %d", tests.Failed, status,
+			http.StatusUnauthorized)
+	}
+
+	if status := queryRequest(t, http.MethodGet, path, "wrong key", nil); status != http.StatusUnauthorized {
+		t.Fatalf("\t%s\tWrong key status : got %d, want %d", tests.Failed, status,
+			http.StatusUnauthorized)
+	}
+
+	if status := queryRequest(t, http.MethodPost, path, testQueryKey, nil); status != http.StatusMethodNotAllowed {
+		t.Fatalf("\t%s\tPost status : got %d, want %d", tests.Failed, status,
+			http.StatusMethodNotAllowed)
+	}
+
+	t.Logf("\t%s\tVerified unauthorized requests are rejected", tests.Success)
+}
+
+func queryContract(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "I", 1, "John Bitcoin", true, true, false, false,
+		false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+
+	var contractResponse query.ContractResponse
+	path := "/contracts/" + queryContractAddress()
+	if status := queryRequest(t, http.MethodGet, path, testQueryKey, &contractResponse); status != http.StatusOK {
+		t.Fatalf("\t%s\tContract status : got %d, want %d", tests.Failed, status, http.StatusOK)
+	}
+
+	if contractResponse.Address != queryContractAddress() {
+		t.Fatalf("\t%s\tWrong contract address : got %s, want %s", tests.Failed,
+			contractResponse.Address, queryContractAddress())
+	}
+	if contractResponse.Formation == nil ||
+		contractResponse.Formation.ContractName != "Test Contract" {
+		t.Fatalf("\t%s\tMissing contract formation", tests.Failed)
+	}
+
+	t.Logf("\t%s\tVerified contract", tests.Success)
+
+	// The asset payload is an interface so only decode the asset ID.
+	var assetsResponse []struct {
+		AssetID string
+	}
+	if status := queryRequest(t, http.MethodGet, path+"/assets", testQueryKey, &assetsResponse); status != http.StatusOK {
+		t.Fatalf("\t%s\tAssets status : got %d, want %d", tests.Failed, status, http.StatusOK)
+	}
+
+	assetID := protocol.AssetID(testAssetType, testAssetCodes[0])
+	if len(assetsResponse) != 1 || assetsResponse[0].AssetID != assetID {
+		t.Fatalf("\t%s\tWrong assets : %d assets", tests.Failed, len(assetsResponse))
+	}
+
+	t.Logf("\t%s\tVerified assets", tests.Success)
+}
+
+func queryHolding(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "I", 1, "John Bitcoin", true, true, false, false,
+		false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 300)
+
+	freezeTxId, err := mockUpFreeze(ctx, t, userKey.Address, 200)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to mock up freeze : %v", tests.Failed, err)
+	}
+
+	userAddress := bitcoin.NewAddressFromRawAddress(userKey.Address,
+		test.NodeConfig.Net).String()
+	path := "/contracts/" + queryContractAddress() + "/assets/" +
+		protocol.AssetID(testAssetType, testAssetCodes[0]) + "/holdings"
+
+	var holdingResponse query.HoldingResponse
+	if status := queryRequest(t, http.MethodGet, path+"/"+userAddress, testQueryKey, &holdingResponse); status != http.StatusOK {
+		t.Fatalf("\t%s\tHolding status : got %d, want %d", tests.Failed, status, http.StatusOK)
+	}
+
+	if holdingResponse.FinalizedBalance != 300 {
+		t.Fatalf("\t%s\tWrong finalized balance : got %d, want %d", tests.Failed,
+			holdingResponse.FinalizedBalance, 300)
+	}
+	if holdingResponse.UnfrozenBalance != 100 {
+		t.Fatalf("\t%s\tWrong unfrozen balance : got %d, want %d", tests.Failed,
+			holdingResponse.UnfrozenBalance, 100)
+	}
+	if len(holdingResponse.Statuses) != 1 || holdingResponse.Statuses[0].Type != "Freeze" ||
+		!holdingResponse.Statuses[0].TxID.Equal(freezeTxId) {
+		t.Fatalf("\t%s\tWrong holding statuses : %d statuses", tests.Failed,
+			len(holdingResponse.Statuses))
+	}
+
+	t.Logf("\t%s\tVerified frozen holding", tests.Success)
+
+	var holdingsResponse []*query.HoldingResponse
+	if status := queryRequest(t, http.MethodGet, path, testQueryKey, &holdingsResponse); status != http.StatusOK {
+		t.Fatalf("\t%s\tHoldings status : got %d, want %d", tests.Failed, status, http.StatusOK)
+	}
+
+	found := false
+	for _, h := range holdingsResponse {
+		if h.Address == userAddress {
+			found = true
+			if h.UnfrozenBalance != 100 {
+				t.Fatalf("\t%s\tWrong listed unfrozen balance : got %d, want %d", tests.Failed,
+					h.UnfrozenBalance, 100)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("\t%s\tMissing user holding : %d holdings", tests.Failed, len(holdingsResponse))
+	}
+
+	t.Logf("\t%s\tVerified holdings", tests.Success)
+}
+
+func queryNotFound(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "I", 1, "John Bitcoin", true, true, false, false,
+		false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+
+	path := "/contracts/" + queryContractAddress()
+	var otherAssetCode bitcoin.Hash20
+	otherAssetCode[0] = 1
+
+	paths := map[string]int{
+		"/unknown":       http.StatusNotFound,
+		path + "/other":  http.StatusNotFound,
+		"/contracts/bad": http.StatusBadRequest,
+		path + "/assets/" + protocol.AssetID(testAssetType, otherAssetCode): http.StatusNotFound,
+		path + "/votes/" + testVoteTxId.String():                            http.StatusNotFound,
+	}
+
+	for requestPath, want := range paths {
+		if status := queryRequest(t, http.MethodGet, requestPath, testQueryKey, nil); status != want {
+			t.Fatalf("\t%s\tWrong status for %s : got %d, want %d", tests.Failed, requestPath,
+				status, want)
+		}
+	}
+
+	t.Logf("\t%s\tVerified not found requests", tests.Success)
+}
+
+func queryContractAddress() string {
+	return bitcoin.NewAddressFromRawAddress(test.ContractKey.Address, test.NodeConfig.Net).String()
+}
+
+// queryRequest sends a request to the query API and decodes the response into result when it is
+//   successful. It returns the response status.
+func queryRequest(t testing.TB, method, path, key string, result interface{}) int {
+	server := query.NewServer(test.Context, &query.Config{Key: testQueryKey}, &test.NodeConfig,
+		test.MasterDB, tracer)
+
+	r := httptest.NewRequest(method, path, nil)
+	if len(key) > 0 {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+
+	if w.Code == http.StatusOK && result != nil {
+		if err := json.NewDecoder(w.Body).Decode(result); err != nil {
+			t.Fatalf("\t%s\tFailed to decode response : %v", tests.Failed, err)
+		}
+	}
+
+	return w.Code
+}
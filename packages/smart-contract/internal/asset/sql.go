@@ -0,0 +1,38 @@
+package asset
+
+import (
+	"context"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+)
+
+// saveSQL writes a serialized asset to the assets table. The searchable fields are copied to
+// columns beside the serialized data.
+func saveSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	asset *state.Asset, data []byte) error {
+
+	return dbConn.Exec(ctx, `INSERT INTO assets (contract_hash, asset_code, asset_type, revision,
+			authorized_token_qty, updated_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (contract_hash, asset_code) DO UPDATE SET asset_type = excluded.asset_type,
+			revision = excluded.revision, authorized_token_qty = excluded.authorized_token_qty,
+			updated_at = excluded.updated_at, data = excluded.data`,
+		contractHash.String(), asset.Code.String(), asset.AssetType, int64(asset.Revision),
+		int64(asset.AuthorizedTokenQty), int64(asset.UpdatedAt.Nano()), data)
+}
+
+// fetchSQL returns a serialized asset from the assets table.
+func fetchSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	assetCode *bitcoin.Hash20) ([]byte, error) {
+
+	var data []byte
+	if err := dbConn.QueryRow(ctx, `SELECT data FROM assets
+		WHERE contract_hash = ? AND asset_code = ?`, contractHash.String(),
+		assetCode.String()).Scan(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/smart-contract/internal/platform/db"
@@ -19,6 +20,7 @@ const storageKey = "contracts"
 const storageSubKey = "assets"
 
 var cache map[bitcoin.Hash20]*state.Asset
+var cacheLock sync.Mutex
 
 // Put a single asset in storage
 func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
@@ -33,10 +35,17 @@ func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 	if err != nil {
 		return err
 	}
-	if err := dbConn.Put(ctx, buildStoragePath(contractHash, asset.Code), data); err != nil {
+	if dbConn.IsSQL() {
+		err = saveSQL(ctx, dbConn, contractHash, asset, data)
+	} else {
+		err = dbConn.Put(ctx, buildStoragePath(contractHash, asset.Code), data)
+	}
+	if err != nil {
 		return err
 	}
 
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
 	if cache == nil {
 		cache = make(map[bitcoin.Hash20]*state.Asset)
 	}
@@ -47,20 +56,26 @@ func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 // Fetch a single asset from storage
 func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
 	assetCode *bitcoin.Hash20) (*state.Asset, error) {
+	cacheLock.Lock()
 	if cache != nil {
 		result, exists := cache[*assetCode]
 		if exists {
+			cacheLock.Unlock()
 			return result, nil
 		}
 	}
+	cacheLock.Unlock()
 
 	contractHash, err := contractAddress.Hash()
 	if err != nil {
 		return nil, err
 	}
-	key := buildStoragePath(contractHash, assetCode)
-
-	b, err := dbConn.Fetch(ctx, key)
+	var b []byte
+	if dbConn.IsSQL() {
+		b, err = fetchSQL(ctx, dbConn, contractHash, assetCode)
+	} else {
+		b, err = dbConn.Fetch(ctx, buildStoragePath(contractHash, assetCode))
+	}
 	if err != nil {
 		if err == db.ErrNotFound {
 			return nil, ErrNotFound
@@ -79,6 +94,9 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 }
 
 func Reset(ctx context.Context) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
 	cache = nil
 }
 
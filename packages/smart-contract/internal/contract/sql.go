@@ -0,0 +1,38 @@
+package contract
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+)
+
+// saveSQL writes a contract to the contracts table. The searchable fields are copied to columns
+// beside the JSON.
+func saveSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	contract *state.Contract, data []byte) error {
+
+	return dbConn.Exec(ctx, `INSERT INTO contracts (contract_hash, address, revision,
+			contract_type, moved_to, updated_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (contract_hash) DO UPDATE SET address = excluded.address,
+			revision = excluded.revision, contract_type = excluded.contract_type,
+			moved_to = excluded.moved_to, updated_at = excluded.updated_at, data = excluded.data`,
+		contractHash.String(), hex.EncodeToString(contract.Address.Bytes()),
+		int64(contract.Revision), int64(contract.ContractType),
+		hex.EncodeToString(contract.MovedTo.Bytes()), int64(contract.UpdatedAt.Nano()),
+		string(data))
+}
+
+// fetchSQL returns the JSON of a contract from the contracts table.
+func fetchSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20) ([]byte, error) {
+	var data string
+	if err := dbConn.QueryRow(ctx, `SELECT data FROM contracts WHERE contract_hash = ?`,
+		contractHash.String()).Scan(&data); err != nil {
+		return nil, err
+	}
+
+	return []byte(data), nil
+}
@@ -30,9 +30,12 @@ func Save(ctx context.Context, dbConn *db.DB, contract *state.Contract, isTest b
 		return errors.Wrap(err, "Failed to marshal contract")
 	}
 
-	key := buildStoragePath(contractHash)
-
-	if err := dbConn.Put(ctx, key, b); err != nil {
+	if dbConn.IsSQL() {
+		err = saveSQL(ctx, dbConn, contractHash, contract, b)
+	} else {
+		err = dbConn.Put(ctx, buildStoragePath(contractHash), b)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -60,9 +63,12 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 		}
 	}
 
-	key := buildStoragePath(contractHash)
-
-	b, err := dbConn.Fetch(ctx, key)
+	var b []byte
+	if dbConn.IsSQL() {
+		b, err = fetchSQL(ctx, dbConn, contractHash)
+	} else {
+		b, err = dbConn.Fetch(ctx, buildStoragePath(contractHash))
+	}
 	if err != nil {
 		if err == db.ErrNotFound {
 			return nil, ErrNotFound
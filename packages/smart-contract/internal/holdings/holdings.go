@@ -130,7 +130,7 @@ func UnfrozenBalance(h *state.Holding, now protocol.Timestamp) uint64 {
 		if status.Code != FreezeCode {
 			continue
 		}
-		if statusExpired(status, now) {
+		if StatusExpired(status, now) {
 			continue
 		}
 		if status.Amount > result {
@@ -340,8 +340,8 @@ func RevertStatus(h *state.Holding, txid *bitcoin.Hash32) error {
 	return nil
 }
 
-// statusExpired checks to see if a holding status has expired
-func statusExpired(hs *state.HoldingStatus, now protocol.Timestamp) bool {
+// StatusExpired returns true if a holding status has expired.
+func StatusExpired(hs *state.HoldingStatus, now protocol.Timestamp) bool {
 	if hs.Expires.Nano() == 0 {
 		return false
 	}
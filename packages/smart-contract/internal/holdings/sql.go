@@ -0,0 +1,228 @@
+package holdings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// writeSQL writes a holding and its statuses to the holdings and holding_statuses tables.
+func writeSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	assetCode *bitcoin.Hash20, addressHash *bitcoin.Hash20, h *state.Holding) error {
+
+	return dbConn.Transaction(ctx, func(tx *db.Tx) error {
+		if err := tx.Exec(ctx, `INSERT INTO holdings (contract_hash, asset_code, address_hash,
+				address, pending_balance, finalized_balance, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (contract_hash, asset_code, address_hash) DO UPDATE SET
+				address = excluded.address, pending_balance = excluded.pending_balance,
+				finalized_balance = excluded.finalized_balance, created_at = excluded.created_at,
+				updated_at = excluded.updated_at`,
+			contractHash.String(), assetCode.String(), addressHash.String(),
+			hex.EncodeToString(h.Address.Bytes()), int64(h.PendingBalance),
+			int64(h.FinalizedBalance), int64(h.CreatedAt.Nano()),
+			int64(h.UpdatedAt.Nano())); err != nil {
+			return errors.Wrap(err, "holding")
+		}
+
+		if err := tx.Exec(ctx, `DELETE FROM holding_statuses
+			WHERE contract_hash = ? AND asset_code = ? AND address_hash = ?`,
+			contractHash.String(), assetCode.String(), addressHash.String()); err != nil {
+			return errors.Wrap(err, "delete statuses")
+		}
+
+		for txid, status := range h.HoldingStatuses {
+			if err := tx.Exec(ctx, `INSERT INTO holding_statuses (contract_hash, asset_code,
+					address_hash, txid, code, amount, settle_quantity, expires, posted)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				contractHash.String(), assetCode.String(), addressHash.String(), txid.String(),
+				string([]byte{status.Code}), int64(status.Amount), int64(status.SettleQuantity),
+				int64(status.Expires.Nano()), status.Posted); err != nil {
+				return errors.Wrap(err, "status")
+			}
+		}
+
+		return nil
+	})
+}
+
+// fetchSQL reads a holding and its statuses. ErrNotFound is returned when the holding doesn't
+//   exist.
+func fetchSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	assetCode *bitcoin.Hash20, addressHash *bitcoin.Hash20) (*state.Holding, error) {
+
+	rows, err := dbConn.Query(ctx, `SELECT address_hash, address, pending_balance,
+			finalized_balance, created_at, updated_at
+		FROM holdings
+		WHERE contract_hash = ? AND asset_code = ? AND address_hash = ?`,
+		contractHash.String(), assetCode.String(), addressHash.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query holding")
+	}
+
+	holdings, err := scanHoldings(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan holding")
+	}
+
+	if len(holdings) == 0 {
+		return nil, ErrNotFound
+	}
+
+	rows, err = dbConn.Query(ctx, `SELECT address_hash, txid, code, amount, settle_quantity,
+			expires, posted
+		FROM holding_statuses
+		WHERE contract_hash = ? AND asset_code = ? AND address_hash = ?`,
+		contractHash.String(), assetCode.String(), addressHash.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query statuses")
+	}
+
+	if err := scanStatuses(rows, holdings); err != nil {
+		return nil, errors.Wrap(err, "scan statuses")
+	}
+
+	return holdings[addressHash.String()], nil
+}
+
+// fetchAllSQL reads all holdings of an asset, keyed by address hash.
+func fetchAllSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	assetCode *bitcoin.Hash20) (map[string]*state.Holding, error) {
+
+	// Rows are read completely before the next query because SQLite only uses one connection.
+	rows, err := dbConn.Query(ctx, `SELECT address_hash, address, pending_balance,
+			finalized_balance, created_at, updated_at
+		FROM holdings
+		WHERE contract_hash = ? AND asset_code = ?`,
+		contractHash.String(), assetCode.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query holdings")
+	}
+
+	holdings, err := scanHoldings(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan holdings")
+	}
+
+	rows, err = dbConn.Query(ctx, `SELECT address_hash, txid, code, amount, settle_quantity,
+			expires, posted
+		FROM holding_statuses
+		WHERE contract_hash = ? AND asset_code = ?`,
+		contractHash.String(), assetCode.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query statuses")
+	}
+
+	if err := scanStatuses(rows, holdings); err != nil {
+		return nil, errors.Wrap(err, "scan statuses")
+	}
+
+	return holdings, nil
+}
+
+// listSQL returns the address hashes of the holdings of an asset.
+func listSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	assetCode *bitcoin.Hash20) ([]string, error) {
+
+	rows, err := dbConn.Query(ctx, `SELECT address_hash FROM holdings
+		WHERE contract_hash = ? AND asset_code = ?
+		ORDER BY address_hash`, contractHash.String(), assetCode.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var addressHash string
+		if err := rows.Scan(&addressHash); err != nil {
+			return nil, err
+		}
+		result = append(result, addressHash)
+	}
+
+	return result, rows.Err()
+}
+
+// scanHoldings reads holding rows, keyed by address hash, and closes them.
+func scanHoldings(rows *sql.Rows) (map[string]*state.Holding, error) {
+	defer rows.Close()
+
+	result := make(map[string]*state.Holding)
+	for rows.Next() {
+		var addressHash, address string
+		var pendingBalance, finalizedBalance, createdAt, updatedAt int64
+		if err := rows.Scan(&addressHash, &address, &pendingBalance, &finalizedBalance,
+			&createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		b, err := hex.DecodeString(address)
+		if err != nil {
+			return nil, errors.Wrap(err, "address hex")
+		}
+
+		ra, err := bitcoin.DecodeRawAddress(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "address")
+		}
+
+		result[addressHash] = &state.Holding{
+			Address:          ra,
+			PendingBalance:   uint64(pendingBalance),
+			FinalizedBalance: uint64(finalizedBalance),
+			HoldingStatuses:  make(map[bitcoin.Hash32]*state.HoldingStatus),
+			CreatedAt:        protocol.NewTimestamp(uint64(createdAt)),
+			UpdatedAt:        protocol.NewTimestamp(uint64(updatedAt)),
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// scanStatuses reads holding status rows into the holdings and closes them.
+func scanStatuses(rows *sql.Rows, holdings map[string]*state.Holding) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var addressHash, txid, code string
+		var amount, settleQuantity, expires int64
+		var posted bool
+		if err := rows.Scan(&addressHash, &txid, &code, &amount, &settleQuantity, &expires,
+			&posted); err != nil {
+			return err
+		}
+
+		h, exists := holdings[addressHash]
+		if !exists {
+			continue // holding was added after the holdings were read
+		}
+
+		hash, err := bitcoin.NewHash32FromStr(txid)
+		if err != nil {
+			return errors.Wrap(err, "txid")
+		}
+
+		if len(code) != 1 {
+			return errors.Errorf("Invalid holding status code : %s", code)
+		}
+
+		h.HoldingStatuses[*hash] = &state.HoldingStatus{
+			Code:           code[0],
+			Expires:        protocol.NewTimestamp(uint64(expires)),
+			Amount:         uint64(amount),
+			TxId:           hash,
+			SettleQuantity: uint64(settleQuantity),
+			Posted:         posted,
+		}
+	}
+
+	return rows.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 
 	"github.com/tokenized/pkg/bitcoin"
@@ -126,9 +127,20 @@ func List(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 	}
 
 	// Add storage
-	keys, err := dbConn.List(ctx, path)
-	if err != nil {
-		return nil, errors.Wrap(err, "db list")
+	var keys []string
+	if dbConn.IsSQL() {
+		addressHashes, err := listSQL(ctx, dbConn, contractHash, assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "sql list")
+		}
+		for _, addressHash := range addressHashes {
+			keys = append(keys, path+"/"+addressHash)
+		}
+	} else {
+		keys, err = dbConn.List(ctx, path)
+		if err != nil {
+			return nil, errors.Wrap(err, "db list")
+		}
 	}
 
 	for _, key := range keys {
@@ -159,6 +171,9 @@ func FetchAll(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAdd
 	result := make([]*state.Holding, 0)
 	resultKeys := make(map[string]bool)
 
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
 	if cache == nil {
 		cache = make(map[bitcoin.Hash20]*map[bitcoin.Hash20]*map[bitcoin.Hash20]*cacheUpdate)
 	}
@@ -175,13 +190,39 @@ func FetchAll(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAdd
 		asset = &na
 	}
 
-	for addressHash, h := range *asset {
+	for addressHash, cu := range *asset {
 		key := path + "/" + addressHash.String()
-		result = append(result, h.h)
+
+		// Copy so the object in cache will not be unintentionally modified (by reference)
+		cu.lock.Lock()
+		result = append(result, copyHolding(cu.h))
+		cu.lock.Unlock()
+
 		resultKeys[key] = true
 	}
 
 	// Add storage
+	if dbConn.IsSQL() {
+		stored, err := fetchAllSQL(ctx, dbConn, contractHash, assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to fetch holdings")
+		}
+
+		addressHashes := make([]string, 0, len(stored))
+		for addressHash := range stored {
+			addressHashes = append(addressHashes, addressHash)
+		}
+		sort.Strings(addressHashes) // Same order as bucket storage lists them
+
+		for _, addressHash := range addressHashes {
+			if _, exists := resultKeys[path+"/"+addressHash]; !exists {
+				result = append(result, stored[addressHash])
+			}
+		}
+
+		return result, nil
+	}
+
 	keys, err := dbConn.List(ctx, path)
 	if err != nil {
 		return nil, err
@@ -251,21 +292,33 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 		return copyHolding(cu.h), nil
 	}
 
-	key := buildStoragePath(contractHash, assetCode, addressHash)
+	var readResult *state.Holding
+	if dbConn.IsSQL() {
+		readResult, err = fetchSQL(ctx, dbConn, contractHash, assetCode, addressHash)
+		if err != nil {
+			if err == ErrNotFound {
+				return nil, err
+			}
 
-	b, err := dbConn.Fetch(ctx, key)
-	if err != nil {
-		if err == db.ErrNotFound {
-			return nil, ErrNotFound
+			return nil, errors.Wrap(err, "Failed to fetch holding")
 		}
+	} else {
+		key := buildStoragePath(contractHash, assetCode, addressHash)
 
-		return nil, errors.Wrap(err, "Failed to fetch holding")
-	}
+		b, err := dbConn.Fetch(ctx, key)
+		if err != nil {
+			if err == db.ErrNotFound {
+				return nil, ErrNotFound
+			}
 
-	// Prepare the asset object
-	readResult, err := deserializeHolding(bytes.NewReader(b))
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to deserialize holding")
+			return nil, errors.Wrap(err, "Failed to fetch holding")
+		}
+
+		// Prepare the asset object
+		readResult, err = deserializeHolding(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to deserialize holding")
+		}
 	}
 
 	(*asset)[*addressHash] = &cacheUpdate{h: readResult, modified: false}
@@ -374,6 +427,10 @@ func WriteCacheUpdate(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.
 func write(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
 	assetCode *bitcoin.Hash20, addressHash *bitcoin.Hash20, h *state.Holding) error {
 
+	if dbConn.IsSQL() {
+		return writeSQL(ctx, dbConn, contractHash, assetCode, addressHash, h)
+	}
+
 	data, err := serializeHolding(h)
 	if err != nil {
 		return errors.Wrap(err, "Failed to serialize holding")
@@ -46,7 +46,17 @@ type Config struct {
 		Root   string `default:"./tmp" envconfig:"NODE_STORAGE_ROOT" json:"NODE_STORAGE_ROOT"`
 	}
 	Storage struct {
-		Bucket string `default:"standalone" envconfig:"CONTRACT_STORAGE_BUCKET" json:"CONTRACT_STORAGE_BUCKET"`
-		Root   string `default:"./tmp" envconfig:"CONTRACT_STORAGE_ROOT" json:"CONTRACT_STORAGE_ROOT"`
+		Bucket     string `default:"standalone" envconfig:"CONTRACT_STORAGE_BUCKET" json:"CONTRACT_STORAGE_BUCKET"`
+		Root       string `default:"./tmp" envconfig:"CONTRACT_STORAGE_ROOT" json:"CONTRACT_STORAGE_ROOT"`
+		Driver     string `envconfig:"CONTRACT_STORAGE_DRIVER" json:"CONTRACT_STORAGE_DRIVER"` // sqlite3 or postgres. Bucket storage is used when empty
+		DataSource string `envconfig:"CONTRACT_STORAGE_DATA_SOURCE" json:"CONTRACT_STORAGE_DATA_SOURCE" masked:"true"`
+	}
+	Wallet struct {
+		Passphrase       string `envconfig:"WALLET_PASSPHRASE" json:"WALLET_PASSPHRASE" masked:"true"`
+		AllowUnencrypted bool   `default:"false" envconfig:"WALLET_ALLOW_UNENCRYPTED" json:"WALLET_ALLOW_UNENCRYPTED"`
+	}
+	Query struct {
+		Address string `envconfig:"QUERY_ADDRESS" json:"QUERY_ADDRESS"` // Query API is disabled when empty
+		Key     string `envconfig:"QUERY_KEY" json:"QUERY_KEY" masked:"true"`
 	}
 }
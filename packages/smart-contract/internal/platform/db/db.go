@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"sync"
@@ -29,22 +30,48 @@ var (
 // Each database is too different.
 type DB struct {
 	storage storage.Storage
+	sql     *sql.DB
+	driver  string
 	lock    sync.RWMutex
 }
 
 // StorageConfig is geared towards "bucket" style storage, where you have a
-// specific root (the Bucket).
+// specific root (the Bucket). When Driver is set a SQL database is used
+// instead and the bucket values are ignored.
 type StorageConfig struct {
 	Bucket     string
 	Root       string
 	MaxRetries int
 	RetryDelay int // Milliseconds between retries
+
+	Driver     string // DriverSQLite or DriverPostgres
+	DataSource string // File name for SQLite or connection string for Postgres
 }
 
 // New returns a new DB value for use with document storage based on a
 // registered master session.
 func New(sc *StorageConfig) (*DB, error) {
 
+	// SQL Storage
+	if sc != nil && len(sc.Driver) > 0 {
+		conn, err := openSQL(sc.Driver, sc.DataSource)
+		if err != nil {
+			return nil, err
+		}
+
+		db := &DB{
+			sql:    conn,
+			driver: sc.Driver,
+		}
+
+		if err := db.Migrate(context.Background()); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "migrate")
+		}
+
+		return db, nil
+	}
+
 	// S3 Storage
 	var store storage.Storage
 	if sc != nil {
@@ -71,6 +98,10 @@ func (db *DB) StatusCheck(ctx context.Context) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.sql.PingContext(ctx)
+	}
+
 	if db.storage != nil {
 		// Generate a random key that is almost certain not to exist.
 		uid, _ := uuid.NewRandom()
@@ -90,6 +121,10 @@ func (db *DB) StatusCheck(ctx context.Context) error {
 func (db *DB) Close() {
 	db.lock.Lock()
 	db.storage = nil
+	if db.sql != nil {
+		db.sql.Close()
+		db.sql = nil
+	}
 	db.lock.Unlock()
 }
 
@@ -101,6 +136,10 @@ func (db *DB) Put(ctx context.Context, key string, body []byte) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.putObject(ctx, key, body)
+	}
+
 	if db.storage == nil {
 		return errors.Wrap(ErrInvalidDBProvided, "storage == nil")
 	}
@@ -113,6 +152,10 @@ func (db *DB) Fetch(ctx context.Context, key string) ([]byte, error) {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.fetchObject(ctx, key)
+	}
+
 	if db.storage == nil {
 		return nil, errors.Wrap(ErrInvalidDBProvided, "storage == nil")
 	}
@@ -134,6 +177,10 @@ func (db *DB) Remove(ctx context.Context, key string) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.removeObject(ctx, key)
+	}
+
 	if db.storage == nil {
 		return errors.Wrap(ErrInvalidDBProvided, "storage == nil")
 	}
@@ -146,6 +193,10 @@ func (db *DB) Search(ctx context.Context, keyStart string) ([][]byte, error) {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.searchObjects(ctx, keyStart)
+	}
+
 	if db.storage == nil {
 		return nil, errors.Wrap(ErrInvalidDBProvided, "storage == nil")
 	}
@@ -161,6 +212,10 @@ func (db *DB) List(ctx context.Context, key string) ([]string, error) {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.listObjects(ctx, key)
+	}
+
 	if db.storage == nil {
 		return nil, errors.Wrap(ErrInvalidDBProvided, "storage == nil")
 	}
@@ -168,6 +223,8 @@ func (db *DB) List(ctx context.Context, key string) ([]string, error) {
 	return db.storage.List(ctx, key)
 }
 
+// Clear removes everything under a given path. With a SQL backend, clearing the root path also
+// empties the contract state tables.
 func (db *DB) Clear(ctx context.Context, keyStart string) error {
 	query := map[string]string{
 		"path": keyStart,
@@ -176,5 +233,9 @@ func (db *DB) Clear(ctx context.Context, keyStart string) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
+	if db.sql != nil {
+		return db.clearObjects(ctx, keyStart)
+	}
+
 	return db.storage.Clear(ctx, query)
 }
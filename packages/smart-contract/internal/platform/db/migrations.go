@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// migration is a versioned change to the SQL schema. "{{blob}}" in a statement is replaced with
+// the binary column type of the driver.
+type migration struct {
+	version    int
+	statements []string
+}
+
+// migrations must only be appended to. Once released a migration can't be changed because it will
+// not be run again on databases that already have it.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE objects (
+				path TEXT PRIMARY KEY,
+				data {{blob}} NOT NULL
+			)`,
+			`CREATE TABLE contracts (
+				contract_hash TEXT PRIMARY KEY,
+				address TEXT NOT NULL,
+				revision BIGINT NOT NULL,
+				contract_type BIGINT NOT NULL,
+				moved_to TEXT NOT NULL,
+				updated_at BIGINT NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE TABLE assets (
+				contract_hash TEXT NOT NULL,
+				asset_code TEXT NOT NULL,
+				asset_type TEXT NOT NULL,
+				revision BIGINT NOT NULL,
+				authorized_token_qty BIGINT NOT NULL,
+				updated_at BIGINT NOT NULL,
+				data {{blob}} NOT NULL,
+				PRIMARY KEY (contract_hash, asset_code)
+			)`,
+			`CREATE TABLE holdings (
+				contract_hash TEXT NOT NULL,
+				asset_code TEXT NOT NULL,
+				address_hash TEXT NOT NULL,
+				address TEXT NOT NULL,
+				pending_balance BIGINT NOT NULL,
+				finalized_balance BIGINT NOT NULL,
+				created_at BIGINT NOT NULL,
+				updated_at BIGINT NOT NULL,
+				PRIMARY KEY (contract_hash, asset_code, address_hash)
+			)`,
+			`CREATE TABLE holding_statuses (
+				contract_hash TEXT NOT NULL,
+				asset_code TEXT NOT NULL,
+				address_hash TEXT NOT NULL,
+				txid TEXT NOT NULL,
+				code TEXT NOT NULL,
+				amount BIGINT NOT NULL,
+				settle_quantity BIGINT NOT NULL,
+				expires BIGINT NOT NULL,
+				posted BOOLEAN NOT NULL,
+				PRIMARY KEY (contract_hash, asset_code, address_hash, txid)
+			)`,
+			`CREATE TABLE votes (
+				contract_hash TEXT NOT NULL,
+				vote_txid TEXT NOT NULL,
+				proposal_txid TEXT NOT NULL,
+				asset_code TEXT NOT NULL,
+				result TEXT NOT NULL,
+				expires BIGINT NOT NULL,
+				completed_at BIGINT NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (contract_hash, vote_txid)
+			)`,
+			`CREATE TABLE ballots (
+				contract_hash TEXT NOT NULL,
+				vote_txid TEXT NOT NULL,
+				address_hash TEXT NOT NULL,
+				address TEXT NOT NULL,
+				vote TEXT NOT NULL,
+				quantity BIGINT NOT NULL,
+				cast_at BIGINT NOT NULL,
+				PRIMARY KEY (contract_hash, vote_txid, address_hash)
+			)`,
+			`CREATE TABLE pending_transfers (
+				contract_hash TEXT NOT NULL,
+				transfer_txid TEXT NOT NULL,
+				timeout BIGINT NOT NULL,
+				PRIMARY KEY (contract_hash, transfer_txid)
+			)`,
+			`CREATE TABLE utxos (
+				txid TEXT NOT NULL,
+				output_index BIGINT NOT NULL,
+				list_index BIGINT NOT NULL,
+				value BIGINT NOT NULL,
+				locking_script {{blob}} NOT NULL,
+				spent_by TEXT NOT NULL,
+				PRIMARY KEY (txid, output_index)
+			)`,
+		},
+	},
+}
+
+// stateTables are emptied when the root path is cleared.
+var stateTables = []string{
+	"objects",
+	"contracts",
+	"assets",
+	"holdings",
+	"holding_statuses",
+	"votes",
+	"ballots",
+	"pending_transfers",
+	"utxos",
+}
+
+// Migrate applies the migrations that haven't been applied to the SQL database yet. It is called
+// by New.
+func (db *DB) Migrate(ctx context.Context) error {
+	if err := db.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return errors.Wrap(err, "create schema_migrations")
+	}
+
+	var current sql.NullInt64
+	if err := db.QueryRow(ctx,
+		`SELECT MAX(version) FROM schema_migrations`).Scan(&current); err != nil {
+		return errors.Wrap(err, "schema version")
+	}
+
+	blob := "BLOB"
+	if db.driver == DriverPostgres {
+		blob = "BYTEA"
+	}
+
+	for _, m := range migrations {
+		if current.Valid && int64(m.version) <= current.Int64 {
+			continue
+		}
+
+		if err := db.Transaction(ctx, func(tx *Tx) error {
+			for _, statement := range m.statements {
+				if err := tx.Exec(ctx, strings.Replace(statement, "{{blob}}", blob,
+					-1)); err != nil {
+					return err
+				}
+			}
+
+			return tx.Exec(ctx,
+				`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, m.version,
+				time.Now().UnixNano())
+		}); err != nil {
+			return errors.Wrapf(err, "migration %d", m.version)
+		}
+	}
+
+	return nil
+}
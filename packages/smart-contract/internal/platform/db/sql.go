@@ -0,0 +1,342 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	_ "github.com/lib/pq"           // Postgres driver
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+const (
+	// DriverSQLite stores data in a SQLite database. The data source is the file name.
+	DriverSQLite = "sqlite3"
+
+	// DriverPostgres stores data in a Postgres database. The data source is a connection string.
+	DriverPostgres = "postgres"
+)
+
+var (
+	// ErrUnsupportedDriver occurs when the SQL driver is not one of the supported drivers.
+	ErrUnsupportedDriver = errors.New("Unsupported SQL driver")
+)
+
+// Queryer executes SQL statements. Statements use "?" for parameters, which are converted for
+// the driver. It is implemented by DB, when it is backed by SQL, and by Tx.
+type Queryer interface {
+	Exec(ctx context.Context, query string, args ...interface{}) error
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) *Row
+}
+
+// Tx is a SQL transaction.
+type Tx struct {
+	tx     *sql.Tx
+	driver string
+}
+
+// Row is the result of QueryRow.
+type Row struct {
+	rows *sql.Rows
+	err  error
+}
+
+func openSQL(driver, dataSource string) (*sql.DB, error) {
+	switch driver {
+	case DriverSQLite, DriverPostgres:
+	default:
+		return nil, errors.Wrap(ErrUnsupportedDriver, driver)
+	}
+
+	conn, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, errors.Wrap(err, "open")
+	}
+
+	if driver == DriverSQLite {
+		// SQLite only allows one writer at a time, so share a single connection rather than
+		// returning "database is locked" errors.
+		conn.SetMaxOpenConns(1)
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "ping")
+	}
+
+	return conn, nil
+}
+
+// IsSQL returns true when the DB is backed by a SQL database rather than bucket storage.
+func (db *DB) IsSQL() bool {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.sql != nil
+}
+
+// Driver returns the SQL driver name, or an empty string for bucket storage.
+func (db *DB) Driver() string {
+	return db.driver
+}
+
+// Exec executes a statement that doesn't return rows.
+func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.sql == nil {
+		return errors.Wrap(ErrInvalidDBProvided, "sql == nil")
+	}
+
+	_, err := db.sql.ExecContext(ctx, rebind(db.driver, query), args...)
+	return err
+}
+
+// Query executes a statement that returns rows. The rows must be closed.
+func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.sql == nil {
+		return nil, errors.Wrap(ErrInvalidDBProvided, "sql == nil")
+	}
+
+	return db.sql.QueryContext(ctx, rebind(db.driver, query), args...)
+}
+
+// QueryRow executes a statement that returns at most one row.
+func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	rows, err := db.Query(ctx, query, args...)
+	return &Row{rows: rows, err: err}
+}
+
+// Transaction calls fn within a SQL transaction. The transaction is committed when fn returns nil
+// and rolled back otherwise.
+func (db *DB) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.sql == nil {
+		return errors.Wrap(ErrInvalidDBProvided, "sql == nil")
+	}
+
+	sqlTx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin")
+	}
+
+	if err := fn(&Tx{tx: sqlTx, driver: db.driver}); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return errors.Wrap(err, "commit")
+	}
+
+	return nil
+}
+
+// Exec executes a statement that doesn't return rows.
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := tx.tx.ExecContext(ctx, rebind(tx.driver, query), args...)
+	return err
+}
+
+// Query executes a statement that returns rows. The rows must be closed.
+func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.tx.QueryContext(ctx, rebind(tx.driver, query), args...)
+}
+
+// QueryRow executes a statement that returns at most one row.
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) *Row {
+	rows, err := tx.Query(ctx, query, args...)
+	return &Row{rows: rows, err: err}
+}
+
+// Scan copies the columns of the row into dest. ErrNotFound is returned when there is no row.
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	return r.rows.Close()
+}
+
+// rebind converts "?" parameters to the "$1" style used by Postgres. A "?" within a quoted string
+// or identifier is not a parameter and is left as it is.
+func rebind(driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	var quote rune // The quote character of the quoted section being copied, if any.
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString("$")
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// -------------------------------------------------------------------------
+// Objects
+//
+// Data that doesn't have its own table is kept in the objects table by key, with the same
+// semantics as bucket storage.
+
+func (db *DB) putObject(ctx context.Context, key string, body []byte) error {
+	_, err := db.sql.ExecContext(ctx, rebind(db.driver, `INSERT INTO objects (path, data)
+		VALUES (?, ?)
+		ON CONFLICT (path) DO UPDATE SET data = excluded.data`), key, body)
+	return err
+}
+
+func (db *DB) fetchObject(ctx context.Context, key string) ([]byte, error) {
+	rows, err := db.sql.QueryContext(ctx, rebind(db.driver,
+		`SELECT data FROM objects WHERE path = ?`), key)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if err := (&Row{rows: rows}).Scan(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// removeObject removes the object and anything under it. It is not an error if nothing exists.
+func (db *DB) removeObject(ctx context.Context, key string) error {
+	_, err := db.sql.ExecContext(ctx, rebind(db.driver,
+		`DELETE FROM objects WHERE path = ? OR path LIKE ? ESCAPE '\'`), key,
+		likePrefix(key)+"%")
+	return err
+}
+
+// searchObjects returns the data of the objects directly under the path.
+func (db *DB) searchObjects(ctx context.Context, path string) ([][]byte, error) {
+	prefix := likePrefix(path)
+	rows, err := db.sql.QueryContext(ctx, rebind(db.driver, `SELECT data FROM objects
+		WHERE path LIKE ? ESCAPE '\' AND path NOT LIKE ? ESCAPE '\'
+		ORDER BY path`), prefix+"%", prefix+"%/%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+
+	return result, rows.Err()
+}
+
+// listObjects returns the keys directly under the path. Like directories in bucket storage, a key
+// that only has objects under it is included.
+func (db *DB) listObjects(ctx context.Context, path string) ([]string, error) {
+	rows, err := db.sql.QueryContext(ctx, rebind(db.driver,
+		`SELECT path FROM objects WHERE path LIKE ? ESCAPE '\'`), likePrefix(path)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keyStart := path
+	if len(keyStart) > 0 {
+		keyStart += "/"
+	}
+
+	var result []string
+	found := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(key, keyStart)
+		if i := strings.Index(name, "/"); i != -1 {
+			name = name[:i]
+		}
+
+		if found[name] {
+			continue
+		}
+		found[name] = true
+		result = append(result, keyStart+name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// clearObjects removes the objects under the path. Clearing the root path removes everything,
+// including the contract state tables.
+func (db *DB) clearObjects(ctx context.Context, path string) error {
+	if len(path) > 0 {
+		_, err := db.sql.ExecContext(ctx, rebind(db.driver,
+			`DELETE FROM objects WHERE path LIKE ? ESCAPE '\'`), likePrefix(path)+"%")
+		return err
+	}
+
+	for _, table := range stateTables {
+		if _, err := db.sql.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return errors.Wrap(err, table)
+		}
+	}
+
+	return nil
+}
+
+// likePrefix returns the LIKE pattern prefix matching keys under the path.
+func likePrefix(path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(path) + "/"
+}
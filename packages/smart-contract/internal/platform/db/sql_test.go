@@ -0,0 +1,162 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newSQLiteDB returns a DB in a temporary SQLite file and a function that removes it.
+func newSQLiteDB(t *testing.T) (*DB, func()) {
+	dir, err := ioutil.TempDir("", "db")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+
+	dbConn, err := New(&StorageConfig{
+		Driver:     DriverSQLite,
+		DataSource: filepath.Join(dir, "test.db"),
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+
+	return dbConn, func() {
+		dbConn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSQLObjects(t *testing.T) {
+	ctx := context.Background()
+	dbConn, cleanup := newSQLiteDB(t)
+	defer cleanup()
+
+	objects := map[string][]byte{
+		"chainstate":            []byte("state"),
+		"txs/a":                 []byte("a"),
+		"txs/b":                 []byte("b"),
+		"txs/sub/c":             []byte("c"),
+		"txs_other":             []byte("other"),
+		"contracts/x/agreement": []byte("agreement"),
+	}
+
+	for key, value := range objects {
+		if err := dbConn.Put(ctx, key, value); err != nil {
+			t.Fatalf("Failed to put %s : %s", key, err)
+		}
+	}
+
+	// Overwrite
+	if err := dbConn.Put(ctx, "txs/a", []byte("a2")); err != nil {
+		t.Fatalf("Failed to overwrite : %s", err)
+	}
+
+	b, err := dbConn.Fetch(ctx, "txs/a")
+	if err != nil {
+		t.Fatalf("Failed to fetch : %s", err)
+	}
+	if !bytes.Equal(b, []byte("a2")) {
+		t.Errorf("Wrong value : got %s, want %s", b, "a2")
+	}
+
+	if _, err := dbConn.Fetch(ctx, "txs/missing"); err != ErrNotFound {
+		t.Errorf("Wrong error for missing key : got %v, want %v", err, ErrNotFound)
+	}
+
+	keys, err := dbConn.List(ctx, "txs")
+	if err != nil {
+		t.Fatalf("Failed to list : %s", err)
+	}
+	wantKeys := []string{"txs/a", "txs/b", "txs/sub"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("Wrong keys : got %v, want %v", keys, wantKeys)
+	}
+
+	values, err := dbConn.Search(ctx, "txs")
+	if err != nil {
+		t.Fatalf("Failed to search : %s", err)
+	}
+	wantValues := [][]byte{[]byte("a2"), []byte("b")}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("Wrong values : got %q, want %q", values, wantValues)
+	}
+
+	if err := dbConn.Remove(ctx, "txs/b"); err != nil {
+		t.Fatalf("Failed to remove : %s", err)
+	}
+	if err := dbConn.Remove(ctx, "txs/b"); err != nil {
+		t.Errorf("Failed to remove missing key : %s", err)
+	}
+	if _, err := dbConn.Fetch(ctx, "txs/b"); err != ErrNotFound {
+		t.Errorf("Removed key still exists : %v", err)
+	}
+
+	if err := dbConn.Clear(ctx, "txs"); err != nil {
+		t.Fatalf("Failed to clear : %s", err)
+	}
+	keys, err = dbConn.List(ctx, "txs")
+	if err != nil {
+		t.Fatalf("Failed to list : %s", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Keys remain after clear : %v", keys)
+	}
+	if _, err := dbConn.Fetch(ctx, "txs_other"); err != nil {
+		t.Errorf("Clear removed a key outside the path : %s", err)
+	}
+
+	if err := dbConn.Clear(ctx, ""); err != nil {
+		t.Fatalf("Failed to clear all : %s", err)
+	}
+	if _, err := dbConn.Fetch(ctx, "chainstate"); err != ErrNotFound {
+		t.Errorf("Key remains after clearing everything : %v", err)
+	}
+}
+
+func TestSQLMigrate(t *testing.T) {
+	ctx := context.Background()
+	dbConn, cleanup := newSQLiteDB(t)
+	defer cleanup()
+
+	// Migrations were applied by New, so applying them again must not fail.
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate again : %s", err)
+	}
+
+	var version int
+	if err := dbConn.QueryRow(ctx,
+		`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		t.Fatalf("Failed to read version : %s", err)
+	}
+
+	if version != migrations[len(migrations)-1].version {
+		t.Errorf("Wrong version : got %d, want %d", version,
+			migrations[len(migrations)-1].version)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := `SELECT a FROM b WHERE c = ? AND d = ?`
+
+	if got := rebind(DriverSQLite, query); got != query {
+		t.Errorf("SQLite query changed : %s", got)
+	}
+
+	want := `SELECT a FROM b WHERE c = $1 AND d = $2`
+	if got := rebind(DriverPostgres, query); got != want {
+		t.Errorf("Wrong Postgres query : got %s, want %s", got, want)
+	}
+
+	// Question marks in quoted strings and identifiers are not parameters.
+	query = `SELECT "a?" FROM b WHERE c = '?' AND d = ? AND e LIKE 'it''s ?' ESCAPE '\' AND f = ?`
+	want = `SELECT "a?" FROM b WHERE c = '?' AND d = $1 AND e LIKE 'it''s ?' ESCAPE '\' AND f = $2`
+	if got := rebind(DriverPostgres, query); got != want {
+		t.Errorf("Wrong quoted Postgres query : got %s, want %s", got, want)
+	}
+}
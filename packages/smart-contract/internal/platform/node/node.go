@@ -56,6 +56,14 @@ type Config struct {
 	RequestTimeout    uint64 // Nanoseconds until a request to another contract times out and the original request is rejected.
 	PreprocessThreads int
 	IsTest            bool
+
+	// Clock returns the time used to process each request. The system clock is used when it is
+	// nil. It is set to replay requests at the time they were originally processed.
+	Clock func() protocol.Timestamp
+
+	// Signer signs response txs. The contract keys are used when it is nil. It is set to replay
+	// requests without the contract keys.
+	Signer Signer
 }
 
 // New creates an App value that handle a set of routes for the application.
@@ -95,7 +103,7 @@ func (a *App) Handle(verb, event string, handler Handler, mw ...Middleware) {
 
 			// Set the context with the required values to process the event.
 			v := Values{
-				Now: protocol.CurrentTimestamp(),
+				Now: a.now(),
 			}
 			ctx = context.WithValue(ctx, KeyValues, &v)
 
@@ -116,3 +124,11 @@ func (a *App) Handle(verb, event string, handler Handler, mw ...Middleware) {
 	// Add this handler for the specified verb and event.
 	a.ProtoMux.Handle(verb, event, h)
 }
+
+// now returns the current time from the configured clock.
+func (a *App) now() protocol.Timestamp {
+	if a.config.Clock != nil {
+		return a.config.Clock()
+	}
+	return protocol.CurrentTimestamp()
+}
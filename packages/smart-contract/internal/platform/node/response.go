@@ -157,7 +157,7 @@ func RespondRejectText(ctx context.Context, w *ResponseWriter, itx *inspector.Tr
 	rejectTx.AddOutput(payload, 0, false, false)
 
 	// Sign the tx
-	err = rejectTx.Sign([]bitcoin.Key{wk.Key})
+	err = Sign(w.Config, rejectTx, wk)
 	if err != nil {
 		Error(ctx, w, err)
 		return ErrNoResponse
@@ -224,7 +224,7 @@ func RespondSuccess(ctx context.Context, w *ResponseWriter, itx *inspector.Trans
 	respondTx.AddOutput(payload, 0, false, false)
 
 	// Sign the tx
-	err = respondTx.Sign([]bitcoin.Key{wk.Key})
+	err = Sign(w.Config, respondTx, wk)
 	if err != nil {
 		if errors.Cause(err) == txbuilder.ErrInsufficientValue {
 			LogWarn(ctx, "Sending reject. Failed to sign tx : %s\n%s", err,
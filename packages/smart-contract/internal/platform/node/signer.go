@@ -0,0 +1,37 @@
+package node
+
+import (
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+)
+
+// Signer signs the contract inputs of response txs.
+type Signer interface {
+	// Sign updates the fee and signs all inputs of the tx.
+	Sign(tx *txbuilder.TxBuilder, wk *wallet.Key) error
+
+	// SignP2PKHInput signs one input of the tx. It returns txbuilder.ErrWrongPrivateKey when the
+	//   input isn't locked to the contract.
+	SignP2PKHInput(tx *txbuilder.TxBuilder, index int, wk *wallet.Key,
+		hashCache *txbuilder.SigHashCache) error
+}
+
+// Sign signs the tx with the configured signer, or with the contract key when there isn't one.
+func Sign(config *Config, tx *txbuilder.TxBuilder, wk *wallet.Key) error {
+	if config.Signer != nil {
+		return config.Signer.Sign(tx, wk)
+	}
+	return tx.Sign([]bitcoin.Key{wk.Key})
+}
+
+// SignP2PKHInput signs one input of the tx with the configured signer, or with the contract key
+//   when there isn't one.
+func SignP2PKHInput(config *Config, tx *txbuilder.TxBuilder, index int, wk *wallet.Key,
+	hashCache *txbuilder.SigHashCache) error {
+
+	if config.Signer != nil {
+		return config.Signer.SignP2PKHInput(tx, index, wk, hashCache)
+	}
+	return tx.SignP2PKHInput(index, wk.Key, hashCache)
+}
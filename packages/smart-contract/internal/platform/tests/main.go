@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"fmt"
+	"os"
 	"runtime/debug"
 	"testing"
 
@@ -87,10 +88,7 @@ func New(logFileName string) *Test {
 	// Database
 
 	path := "./tmp"
-	masterDB, err := db.New(&db.StorageConfig{
-		Bucket: "standalone",
-		Root:   path,
-	})
+	masterDB, err := db.New(storageConfig(path))
 	if err != nil {
 		fmt.Printf("main : Failed to create DB : %v\n", err)
 		return nil
@@ -211,10 +209,7 @@ func NewContext() context.Context {
 
 func NewMasterDB(t *testing.T) *db.DB {
 	path := "./tmp"
-	masterDB, err := db.New(&db.StorageConfig{
-		Bucket: "standalone",
-		Root:   path,
-	})
+	masterDB, err := db.New(storageConfig(path))
 	if err != nil {
 		t.Fatalf("Failed to create DB : %v\n", err)
 	}
@@ -222,6 +217,17 @@ func NewMasterDB(t *testing.T) *db.DB {
 	return masterDB
 }
 
+// storageConfig returns the storage configuration for tests. The filesystem is used unless
+// TEST_STORAGE_DRIVER and TEST_STORAGE_DATA_SOURCE specify a SQL database.
+func storageConfig(path string) *db.StorageConfig {
+	return &db.StorageConfig{
+		Bucket:     "standalone",
+		Root:       path,
+		Driver:     os.Getenv("TEST_STORAGE_DRIVER"),
+		DataSource: os.Getenv("TEST_STORAGE_DATA_SOURCE"),
+	}
+}
+
 // GenerateKey generates a new wallet key.
 func GenerateKey(net bitcoin.Network) (*wallet.Key, error) {
 	key, err := bitcoin.GenerateKey(net)
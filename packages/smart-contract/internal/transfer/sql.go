@@ -0,0 +1,84 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+// saveSQL writes a pending transfer to the pending_transfers table.
+func saveSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	t *state.PendingTransfer) error {
+
+	return dbConn.Exec(ctx, `INSERT INTO pending_transfers (contract_hash, transfer_txid, timeout)
+		VALUES (?, ?, ?)
+		ON CONFLICT (contract_hash, transfer_txid) DO UPDATE SET timeout = excluded.timeout`,
+		contractHash.String(), t.TransferTxId.String(), int64(t.Timeout.Nano()))
+}
+
+// fetchSQL reads a pending transfer. ErrNotFound is returned when it doesn't exist.
+func fetchSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	transferTxId *bitcoin.Hash32) (*state.PendingTransfer, error) {
+
+	var timeout int64
+	if err := dbConn.QueryRow(ctx, `SELECT timeout FROM pending_transfers
+		WHERE contract_hash = ? AND transfer_txid = ?`, contractHash.String(),
+		transferTxId.String()).Scan(&timeout); err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	txid := *transferTxId
+	return &state.PendingTransfer{
+		TransferTxId: &txid,
+		Timeout:      protocol.NewTimestamp(uint64(timeout)),
+	}, nil
+}
+
+// removeSQL deletes a pending transfer. It is not an error if it doesn't exist.
+func removeSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	transferTxId *bitcoin.Hash32) error {
+
+	return dbConn.Exec(ctx, `DELETE FROM pending_transfers
+		WHERE contract_hash = ? AND transfer_txid = ?`, contractHash.String(),
+		transferTxId.String())
+}
+
+// listSQL reads all pending transfers of a contract.
+func listSQL(ctx context.Context, dbConn *db.DB,
+	contractHash *bitcoin.Hash20) ([]*state.PendingTransfer, error) {
+
+	rows, err := dbConn.Query(ctx, `SELECT transfer_txid, timeout FROM pending_transfers
+		WHERE contract_hash = ?
+		ORDER BY transfer_txid`, contractHash.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*state.PendingTransfer, 0)
+	for rows.Next() {
+		var transferTxId string
+		var timeout int64
+		if err := rows.Scan(&transferTxId, &timeout); err != nil {
+			return nil, err
+		}
+
+		txid, err := bitcoin.NewHash32FromStr(transferTxId)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &state.PendingTransfer{
+			TransferTxId: txid,
+			Timeout:      protocol.NewTimestamp(uint64(timeout)),
+		})
+	}
+
+	return result, rows.Err()
+}
@@ -25,6 +25,10 @@ func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 	if err != nil {
 		return err
 	}
+	if dbConn.IsSQL() {
+		return saveSQL(ctx, dbConn, contractHash, t)
+	}
+
 	key := buildStoragePath(contractHash, t.TransferTxId)
 
 	// Save the contract
@@ -44,6 +48,10 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 	if err != nil {
 		return nil, err
 	}
+	if dbConn.IsSQL() {
+		return fetchSQL(ctx, dbConn, contractHash, transferTxId)
+	}
+
 	key := buildStoragePath(contractHash, transferTxId)
 
 	data, err := dbConn.Fetch(ctx, key)
@@ -71,6 +79,10 @@ func Remove(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddre
 	if err != nil {
 		return err
 	}
+	if dbConn.IsSQL() {
+		return removeSQL(ctx, dbConn, contractHash, transferTxId)
+	}
+
 	err = dbConn.Remove(ctx, buildStoragePath(contractHash, transferTxId))
 	if err != nil {
 		if err == db.ErrNotFound {
@@ -89,6 +101,10 @@ func List(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 		return nil, err
 	}
 
+	if dbConn.IsSQL() {
+		return listSQL(ctx, dbConn, contractHash)
+	}
+
 	// TODO: This should probably use dbConn.List for greater efficiency
 	data, err := dbConn.Search(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
 		storageSubKey))
@@ -0,0 +1,77 @@
+package utxos
+
+import (
+	"context"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+
+	"github.com/pkg/errors"
+)
+
+// saveSQL replaces the contents of the utxos table with the list.
+func (us *UTXOs) saveSQL(ctx context.Context, masterDb *db.DB) error {
+	return masterDb.Transaction(ctx, func(tx *db.Tx) error {
+		if err := tx.Exec(ctx, `DELETE FROM utxos`); err != nil {
+			return errors.Wrap(err, "delete")
+		}
+
+		for i, utxo := range us.list {
+			spentBy := zeroTxId.String() // Unspent
+			if utxo.SpentBy != nil {
+				spentBy = utxo.SpentBy.String()
+			}
+
+			if err := tx.Exec(ctx, `INSERT INTO utxos (txid, output_index, list_index, value,
+					locking_script, spent_by)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				utxo.OutPoint.Hash.String(), int64(utxo.OutPoint.Index), int64(i),
+				int64(utxo.Output.Value), utxo.Output.PkScript, spentBy); err != nil {
+				return errors.Wrap(err, "insert")
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadSQL reads the list from the utxos table.
+func loadSQL(ctx context.Context, masterDb *db.DB) (*UTXOs, error) {
+	rows, err := masterDb.Query(ctx, `SELECT txid, output_index, value, locking_script, spent_by
+		FROM utxos
+		ORDER BY list_index`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := UTXOs{}
+	for rows.Next() {
+		var txid, spentBy string
+		var index, value int64
+		var lockingScript []byte
+		if err := rows.Scan(&txid, &index, &value, &lockingScript, &spentBy); err != nil {
+			return nil, err
+		}
+
+		hash, err := bitcoin.NewHash32FromStr(txid)
+		if err != nil {
+			return nil, errors.Wrap(err, "txid")
+		}
+
+		utxo := UTXO{}
+		utxo.OutPoint.Hash = *hash
+		utxo.OutPoint.Index = uint32(index)
+		utxo.Output.Value = uint64(value)
+		utxo.Output.PkScript = lockingScript
+
+		utxo.SpentBy, err = bitcoin.NewHash32FromStr(spentBy)
+		if err != nil {
+			return nil, errors.Wrap(err, "spent by")
+		}
+
+		result.list = append(result.list, &utxo)
+	}
+
+	return &result, rows.Err()
+}
@@ -14,6 +14,10 @@ const (
 )
 
 func (us *UTXOs) Save(ctx context.Context, masterDb *db.DB) error {
+	if masterDb.IsSQL() {
+		return us.saveSQL(ctx, masterDb)
+	}
+
 	var buf bytes.Buffer
 
 	count := uint32(len(us.list))
@@ -31,6 +35,10 @@ func (us *UTXOs) Save(ctx context.Context, masterDb *db.DB) error {
 }
 
 func Load(ctx context.Context, masterDb *db.DB) (*UTXOs, error) {
+	if masterDb.IsSQL() {
+		return loadSQL(ctx, masterDb)
+	}
+
 	result := UTXOs{}
 	data, err := masterDb.Fetch(ctx, storageKey)
 	if err != nil {
@@ -0,0 +1,200 @@
+package vote
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// saveSQL writes a vote to the votes table and its ballots to the ballots table. The JSON in the
+// votes table doesn't contain the ballots.
+func saveSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	v *state.Vote) error {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "json marshal vote")
+	}
+
+	proposalTxId := ""
+	if v.ProposalTxId != nil {
+		proposalTxId = v.ProposalTxId.String()
+	}
+	assetCode := ""
+	if v.AssetCode != nil {
+		assetCode = v.AssetCode.String()
+	}
+
+	return dbConn.Transaction(ctx, func(tx *db.Tx) error {
+		if err := tx.Exec(ctx, `INSERT INTO votes (contract_hash, vote_txid, proposal_txid,
+				asset_code, result, expires, completed_at, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (contract_hash, vote_txid) DO UPDATE SET
+				proposal_txid = excluded.proposal_txid, asset_code = excluded.asset_code,
+				result = excluded.result, expires = excluded.expires,
+				completed_at = excluded.completed_at, data = excluded.data`,
+			contractHash.String(), v.VoteTxId.String(), proposalTxId, assetCode, v.Result,
+			int64(v.Expires.Nano()), int64(v.CompletedAt.Nano()), string(data)); err != nil {
+			return errors.Wrap(err, "vote")
+		}
+
+		if err := tx.Exec(ctx, `DELETE FROM ballots WHERE contract_hash = ? AND vote_txid = ?`,
+			contractHash.String(), v.VoteTxId.String()); err != nil {
+			return errors.Wrap(err, "delete ballots")
+		}
+
+		for addressHash, b := range v.Ballots {
+			if err := tx.Exec(ctx, `INSERT INTO ballots (contract_hash, vote_txid, address_hash,
+					address, vote, quantity, cast_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				contractHash.String(), v.VoteTxId.String(), addressHash.String(),
+				hex.EncodeToString(b.Address.Bytes()), b.Vote, int64(b.Quantity),
+				int64(b.Timestamp.Nano())); err != nil {
+				return errors.Wrap(err, "ballot")
+			}
+		}
+
+		return nil
+	})
+}
+
+// fetchSQL reads a vote and its ballots. ErrNotFound is returned when the vote doesn't exist.
+func fetchSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20,
+	voteTxId *bitcoin.Hash32) (*state.Vote, error) {
+
+	var data string
+	if err := dbConn.QueryRow(ctx, `SELECT data FROM votes
+		WHERE contract_hash = ? AND vote_txid = ?`, contractHash.String(),
+		voteTxId.String()).Scan(&data); err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	result := &state.Vote{}
+	if err := json.Unmarshal([]byte(data), result); err != nil {
+		return nil, err
+	}
+
+	rows, err := dbConn.Query(ctx, `SELECT vote_txid, address, vote, quantity, cast_at
+		FROM ballots
+		WHERE contract_hash = ? AND vote_txid = ?
+		ORDER BY address_hash`, contractHash.String(), voteTxId.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query ballots")
+	}
+
+	ballots, err := scanBallots(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan ballots")
+	}
+
+	result.Ballots = make(map[bitcoin.Hash20]state.Ballot)
+	for _, b := range ballots[voteTxId.String()] {
+		hash, err := b.Address.Hash()
+		if err != nil {
+			return nil, errors.Wrap(err, "address hash")
+		}
+		result.Ballots[*hash] = b
+	}
+
+	return result, nil
+}
+
+// listSQL reads all votes of a contract. Like votes listed from bucket storage, the ballots are
+//   in BallotList and Ballots is not set.
+func listSQL(ctx context.Context, dbConn *db.DB, contractHash *bitcoin.Hash20) ([]*state.Vote,
+	error) {
+
+	// Rows are read completely before the next query because SQLite only uses one connection.
+	rows, err := dbConn.Query(ctx, `SELECT data FROM votes WHERE contract_hash = ?
+		ORDER BY vote_txid`, contractHash.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query votes")
+	}
+
+	result := make([]*state.Vote, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		vote := &state.Vote{}
+		if err := json.Unmarshal([]byte(data), vote); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		result = append(result, vote)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = dbConn.Query(ctx, `SELECT vote_txid, address, vote, quantity, cast_at
+		FROM ballots
+		WHERE contract_hash = ?
+		ORDER BY vote_txid, address_hash`, contractHash.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "query ballots")
+	}
+
+	ballots, err := scanBallots(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan ballots")
+	}
+
+	for _, vote := range result {
+		if vote.VoteTxId != nil {
+			vote.BallotList = ballots[vote.VoteTxId.String()]
+		}
+	}
+
+	return result, nil
+}
+
+// scanBallots reads ballot rows, grouped by vote txid, and closes them.
+func scanBallots(rows *sql.Rows) (map[string][]state.Ballot, error) {
+	defer rows.Close()
+
+	result := make(map[string][]state.Ballot)
+	for rows.Next() {
+		var voteTxId, address, vote string
+		var quantity, castAt int64
+		if err := rows.Scan(&voteTxId, &address, &vote, &quantity, &castAt); err != nil {
+			return nil, err
+		}
+
+		b, err := hex.DecodeString(address)
+		if err != nil {
+			return nil, errors.Wrap(err, "address hex")
+		}
+
+		ra, err := bitcoin.DecodeRawAddress(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "address")
+		}
+
+		result[voteTxId] = append(result[voteTxId], state.Ballot{
+			Address:   ra,
+			Vote:      vote,
+			Quantity:  uint64(quantity),
+			Timestamp: protocol.NewTimestamp(uint64(castAt)),
+		})
+	}
+
+	return result, rows.Err()
+}
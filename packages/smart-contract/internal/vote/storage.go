@@ -40,6 +40,11 @@ func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 	if err != nil {
 		return errors.Wrap(err, "contract address hash")
 	}
+	if dbConn.IsSQL() {
+		v.BallotList = nil // Ballots are saved in their own table
+		return saveSQL(ctx, dbConn, contractHash, v)
+	}
+
 	key := buildStoragePath(contractHash, v.VoteTxId)
 
 	// Update ballot list
@@ -65,7 +70,12 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 	cacheLock.Lock()
 	for _, cv := range cache {
 		if cv.VoteTxId.Equal(voteTxId) {
+			// Copy ballots so the map in cache will not be modified (by reference)
 			result := cv
+			result.Ballots = make(map[bitcoin.Hash20]state.Ballot, len(cv.Ballots))
+			for hash, b := range cv.Ballots {
+				result.Ballots[hash] = b
+			}
 			cacheLock.Unlock()
 			return &result, nil
 		}
@@ -76,6 +86,10 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 	if err != nil {
 		return nil, err
 	}
+	if dbConn.IsSQL() {
+		return fetchSQL(ctx, dbConn, contractHash, voteTxId)
+	}
+
 	key := buildStoragePath(contractHash, voteTxId)
 
 	data, err := dbConn.Fetch(ctx, key)
@@ -114,6 +128,10 @@ func List(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress
 		return nil, err
 	}
 
+	if dbConn.IsSQL() {
+		return listSQL(ctx, dbConn, contractHash)
+	}
+
 	// TODO: This should probably use dbConn.List for greater efficiency
 	data, err := dbConn.Search(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
 		storageSubKey))
@@ -0,0 +1,146 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// scrypt parameters recommended for interactive logins in 2017. Keys are only derived when the
+	// keystore is loaded or saved so this is not a bottleneck.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// Limit the memory and time used to decrypt.
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+
+	saltSize = 32
+	keySize  = 32 // AES-256
+)
+
+var (
+	// ErrDecrypt occurs when encrypted data can't be decrypted, usually because the passphrase is
+	// wrong.
+	ErrDecrypt = errors.New("Decryption failed")
+
+	// ErrMissingPassphrase occurs when a passphrase encrypter is created without a passphrase.
+	ErrMissingPassphrase = errors.New("Missing passphrase")
+)
+
+// Encrypter encrypts the keystore at rest. PassphraseEncrypter derives a key from a passphrase.
+//   An external key management service can be used by implementing Encrypter with calls to its
+//   encrypt and decrypt operations, so the key never leaves the service.
+type Encrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// PassphraseEncrypter encrypts with AES-256-GCM using a key derived from a passphrase with scrypt.
+//   A new random salt is used for every encryption and stored with the ciphertext along with the
+//   scrypt parameters.
+type PassphraseEncrypter struct {
+	passphrase []byte
+}
+
+// NewPassphraseEncrypter returns an Encrypter using the passphrase.
+func NewPassphraseEncrypter(passphrase string) (*PassphraseEncrypter, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrMissingPassphrase
+	}
+
+	return &PassphraseEncrypter{passphrase: []byte(passphrase)}, nil
+}
+
+// Encrypt implements Encrypter.
+func (pe *PassphraseEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "salt")
+	}
+
+	aead, err := pe.aead(salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "nonce")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(salt)
+	for _, param := range []uint32{scryptN, scryptR, scryptP} {
+		if err := binary.Write(&buf, binary.LittleEndian, param); err != nil {
+			return nil, err
+		}
+	}
+	buf.Write(nonce)
+
+	// The header is authenticated so the parameters can't be changed.
+	header := buf.Bytes()
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// Decrypt implements Encrypter.
+func (pe *PassphraseEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	r := bytes.NewReader(ciphertext)
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(r, salt); err != nil {
+		return nil, errors.Wrap(err, "salt")
+	}
+
+	var params [3]uint32
+	for i := range params {
+		if err := binary.Read(r, binary.LittleEndian, &params[i]); err != nil {
+			return nil, errors.Wrap(err, "scrypt parameters")
+		}
+	}
+
+	if params[0] > maxScryptN || params[1] > maxScryptR || params[2] > maxScryptP {
+		return nil, errors.New("scrypt parameters too large")
+	}
+
+	aead, err := pe.aead(salt, int(params[0]), int(params[1]), int(params[2]))
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, errors.Wrap(err, "nonce")
+	}
+
+	headerSize := len(ciphertext) - r.Len()
+	plaintext, err := aead.Open(nil, nonce, ciphertext[headerSize:], ciphertext[:headerSize])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+func (pe *PassphraseEncrypter) aead(salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(pe.passphrase, salt, n, r, p, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -3,6 +3,7 @@ package wallet
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
 
 	"github.com/tokenized/pkg/bitcoin"
 
@@ -11,8 +12,18 @@ import (
 
 var (
 	ErrKeyNotFound = errors.New("Key not found")
+
+	// ErrEncrypted occurs when encrypted keys are read without an encrypter.
+	ErrEncrypted = errors.New("Keystore is encrypted")
+
+	// ErrNotEncrypted occurs when unencrypted keys are read and they aren't allowed.
+	ErrNotEncrypted = errors.New("Keystore is not encrypted")
 )
 
+// encryptedHeader starts serialized encrypted keys. Unencrypted keys start with a key count, which
+//   would never be this large.
+var encryptedHeader = []byte{'E', 'N', 'C', 'K', 0} // "ENCK" and version 0
+
 type KeyStore struct {
 	Keys map[bitcoin.Hash20]*Key
 }
@@ -115,3 +126,50 @@ func (k *KeyStore) Deserialize(buf *bytes.Reader) error {
 
 	return nil
 }
+
+// SerializeEncrypted writes the keys encrypted by enc.
+func (k *KeyStore) SerializeEncrypted(buf *bytes.Buffer, enc Encrypter) error {
+	var plaintext bytes.Buffer
+	if err := k.Serialize(&plaintext); err != nil {
+		return err
+	}
+
+	ciphertext, err := enc.Encrypt(plaintext.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "encrypt")
+	}
+
+	if _, err := buf.Write(encryptedHeader); err != nil {
+		return err
+	}
+	_, err = buf.Write(ciphertext)
+	return err
+}
+
+// DeserializeEncrypted reads keys written by SerializeEncrypted.
+func (k *KeyStore) DeserializeEncrypted(buf *bytes.Reader, enc Encrypter) error {
+	header := make([]byte, len(encryptedHeader))
+	if _, err := io.ReadFull(buf, header); err != nil {
+		return errors.Wrap(err, "header")
+	}
+	if !bytes.Equal(header, encryptedHeader) {
+		return ErrNotEncrypted
+	}
+
+	ciphertext := make([]byte, buf.Len())
+	if _, err := io.ReadFull(buf, ciphertext); err != nil {
+		return err
+	}
+
+	plaintext, err := enc.Decrypt(ciphertext)
+	if err != nil {
+		return errors.Wrap(err, "decrypt")
+	}
+
+	return k.Deserialize(bytes.NewReader(plaintext))
+}
+
+// IsEncrypted returns true if the serialized keys were written by SerializeEncrypted.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedHeader)
+}
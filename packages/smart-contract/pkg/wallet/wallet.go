@@ -28,6 +28,9 @@ type WalletInterface interface {
 type Wallet struct {
 	lock     sync.RWMutex
 	KeyStore *KeyStore
+
+	encrypter        Encrypter
+	allowUnencrypted bool
 }
 
 func New() *Wallet {
@@ -36,21 +39,41 @@ func New() *Wallet {
 	}
 }
 
-func (w Wallet) Add(key *Key) error {
+// NewEncrypted returns a wallet that encrypts the keys when it is serialized. Unencrypted keys
+//   are only deserialized when allowUnencrypted is true, so that existing keys can be loaded and
+//   then saved encrypted.
+func NewEncrypted(enc Encrypter, allowUnencrypted bool) *Wallet {
+	return &Wallet{
+		KeyStore:         NewKeyStore(),
+		encrypter:        enc,
+		allowUnencrypted: allowUnencrypted,
+	}
+}
+
+// SetEncrypter replaces the encrypter. This is used to rotate the encryption key. The keys are
+//   re-encrypted with the new encrypter the next time the wallet is serialized.
+func (w *Wallet) SetEncrypter(enc Encrypter) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.encrypter = enc
+}
+
+func (w *Wallet) Add(key *Key) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
 	return w.KeyStore.Add(key)
 }
 
-func (w Wallet) Remove(key *Key) error {
+func (w *Wallet) Remove(key *Key) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
 	return w.KeyStore.Remove(key)
 }
 
-func (w Wallet) RemoveAddress(ra bitcoin.RawAddress) error {
+func (w *Wallet) RemoveAddress(ra bitcoin.RawAddress) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
@@ -58,7 +81,7 @@ func (w Wallet) RemoveAddress(ra bitcoin.RawAddress) error {
 }
 
 // Register a private key with the wallet
-func (w Wallet) Register(wif string, net bitcoin.Network) error {
+func (w *Wallet) Register(wif string, net bitcoin.Network) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
@@ -78,14 +101,14 @@ func (w Wallet) Register(wif string, net bitcoin.Network) error {
 	return nil
 }
 
-func (w Wallet) List(addrs []bitcoin.RawAddress) ([]*Key, error) {
+func (w *Wallet) List(addrs []bitcoin.RawAddress) ([]*Key, error) {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 
 	var rks []*Key
 
 	for _, addr := range addrs {
-		rk, err := w.Get(addr)
+		rk, err := w.KeyStore.Get(addr)
 		if err != nil {
 			if err == ErrKeyNotFound {
 				continue
@@ -99,29 +122,51 @@ func (w Wallet) List(addrs []bitcoin.RawAddress) ([]*Key, error) {
 	return rks, nil
 }
 
-func (w Wallet) ListAll() []*Key {
+func (w *Wallet) ListAll() []*Key {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 	return w.KeyStore.GetAll()
 }
 
-func (w Wallet) Get(address bitcoin.RawAddress) (*Key, error) {
+func (w *Wallet) Get(address bitcoin.RawAddress) (*Key, error) {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 
 	return w.KeyStore.Get(address)
 }
 
-func (w Wallet) Serialize(buf *bytes.Buffer) error {
+// Serialize writes the keys, encrypted if the wallet has an encrypter.
+func (w *Wallet) Serialize(buf *bytes.Buffer) error {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 
+	if w.encrypter != nil {
+		return w.KeyStore.SerializeEncrypted(buf, w.encrypter)
+	}
+
 	return w.KeyStore.Serialize(buf)
 }
 
-func (w Wallet) Deserialize(buf *bytes.Reader) error {
+// Deserialize reads encrypted or unencrypted keys. ErrEncrypted is returned for encrypted keys
+//   when the wallet doesn't have an encrypter. ErrNotEncrypted is returned for unencrypted keys
+//   when the wallet has an encrypter and unencrypted keys aren't allowed.
+func (w *Wallet) Deserialize(buf *bytes.Reader) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
+	pos := buf.Size() - int64(buf.Len())
+	header := make([]byte, len(encryptedHeader))
+	n, _ := buf.ReadAt(header, pos)
+	if IsEncrypted(header[:n]) {
+		if w.encrypter == nil {
+			return ErrEncrypted
+		}
+		return w.KeyStore.DeserializeEncrypted(buf, w.encrypter)
+	}
+
+	if w.encrypter != nil && !w.allowUnencrypted {
+		return ErrNotEncrypted
+	}
+
 	return w.KeyStore.Deserialize(buf)
 }
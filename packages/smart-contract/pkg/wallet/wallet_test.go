@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+
+	"github.com/pkg/errors"
+)
+
+func TestEncryptedWallet(t *testing.T) {
+	w := newTestWallet(t, newTestEncrypter(t, "passphrase"), 2)
+
+	var buf bytes.Buffer
+	if err := w.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize : %s", err)
+	}
+
+	if !IsEncrypted(buf.Bytes()) {
+		t.Fatalf("Serialized wallet is not encrypted")
+	}
+
+	for _, key := range w.ListAll() {
+		if bytes.Contains(buf.Bytes(), key.Key.Number()) {
+			t.Fatalf("Serialized wallet contains a private key")
+		}
+	}
+
+	read := NewEncrypted(newTestEncrypter(t, "passphrase"), false)
+	if err := read.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Failed to deserialize : %s", err)
+	}
+
+	checkKeys(t, read, w)
+
+	wrong := NewEncrypted(newTestEncrypter(t, "wrong"), false)
+	err := wrong.Deserialize(bytes.NewReader(buf.Bytes()))
+	if errors.Cause(err) != ErrDecrypt {
+		t.Errorf("Wrong error for wrong passphrase : got %v, want %v", err, ErrDecrypt)
+	}
+
+	plain := New()
+	if err := plain.Deserialize(bytes.NewReader(buf.Bytes())); err != ErrEncrypted {
+		t.Errorf("Wrong error without encrypter : got %v, want %v", err, ErrEncrypted)
+	}
+}
+
+func TestUnencryptedWallet(t *testing.T) {
+	w := newTestWallet(t, nil, 2)
+
+	var buf bytes.Buffer
+	if err := w.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize : %s", err)
+	}
+
+	if IsEncrypted(buf.Bytes()) {
+		t.Fatalf("Serialized wallet is encrypted")
+	}
+
+	refused := NewEncrypted(newTestEncrypter(t, "passphrase"), false)
+	if err := refused.Deserialize(bytes.NewReader(buf.Bytes())); err != ErrNotEncrypted {
+		t.Errorf("Wrong error for unencrypted keys : got %v, want %v", err, ErrNotEncrypted)
+	}
+
+	allowed := NewEncrypted(newTestEncrypter(t, "passphrase"), true)
+	if err := allowed.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Failed to deserialize allowed unencrypted keys : %s", err)
+	}
+
+	checkKeys(t, allowed, w)
+}
+
+func TestRotateEncrypter(t *testing.T) {
+	w := newTestWallet(t, newTestEncrypter(t, "old"), 3)
+
+	var oldBuf bytes.Buffer
+	if err := w.Serialize(&oldBuf); err != nil {
+		t.Fatalf("Failed to serialize : %s", err)
+	}
+
+	w.SetEncrypter(newTestEncrypter(t, "new"))
+
+	var newBuf bytes.Buffer
+	if err := w.Serialize(&newBuf); err != nil {
+		t.Fatalf("Failed to serialize rotated : %s", err)
+	}
+
+	old := NewEncrypted(newTestEncrypter(t, "old"), false)
+	err := old.Deserialize(bytes.NewReader(newBuf.Bytes()))
+	if errors.Cause(err) != ErrDecrypt {
+		t.Errorf("Wrong error for old passphrase : got %v, want %v", err, ErrDecrypt)
+	}
+
+	read := NewEncrypted(newTestEncrypter(t, "new"), false)
+	if err := read.Deserialize(bytes.NewReader(newBuf.Bytes())); err != nil {
+		t.Fatalf("Failed to deserialize rotated : %s", err)
+	}
+
+	checkKeys(t, read, w)
+}
+
+func TestPassphraseEncrypter(t *testing.T) {
+	if _, err := NewPassphraseEncrypter(""); err != ErrMissingPassphrase {
+		t.Errorf("Wrong error for empty passphrase : got %v, want %v", err, ErrMissingPassphrase)
+	}
+
+	enc := newTestEncrypter(t, "passphrase")
+	plaintext := []byte("secret data")
+
+	first, err := enc.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Failed to encrypt : %s", err)
+	}
+
+	second, err := enc.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Failed to encrypt : %s", err)
+	}
+
+	if bytes.Equal(first, second) {
+		t.Errorf("Encryption is not randomized")
+	}
+
+	decrypted, err := enc.Decrypt(first)
+	if err != nil {
+		t.Fatalf("Failed to decrypt : %s", err)
+	}
+
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Wrong plaintext : got %s, want %s", decrypted, plaintext)
+	}
+
+	// The header is authenticated so changing the salt must fail.
+	first[0] ^= 0xff
+	if _, err := enc.Decrypt(first); err != ErrDecrypt {
+		t.Errorf("Wrong error for modified data : got %v, want %v", err, ErrDecrypt)
+	}
+
+	if _, err := enc.Decrypt(first[:10]); err == nil {
+		t.Errorf("Decrypted truncated data")
+	}
+}
+
+func newTestEncrypter(t *testing.T, passphrase string) Encrypter {
+	enc, err := NewPassphraseEncrypter(passphrase)
+	if err != nil {
+		t.Fatalf("Failed to create encrypter : %s", err)
+	}
+	return enc
+}
+
+func newTestWallet(t *testing.T, enc Encrypter, count int) *Wallet {
+	w := New()
+	if enc != nil {
+		w = NewEncrypted(enc, false)
+	}
+
+	for i := 0; i < count; i++ {
+		key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+		if err != nil {
+			t.Fatalf("Failed to generate key : %s", err)
+		}
+
+		if err := w.Register(key.String(), bitcoin.MainNet); err != nil {
+			t.Fatalf("Failed to register key : %s", err)
+		}
+	}
+
+	return w
+}
+
+// checkKeys verifies that the wallets contain the same keys.
+func checkKeys(t *testing.T, got, want *Wallet) {
+	if len(got.ListAll()) != len(want.ListAll()) {
+		t.Fatalf("Wrong key count : got %d, want %d", len(got.ListAll()), len(want.ListAll()))
+	}
+
+	for _, wantKey := range want.ListAll() {
+		gotKey, err := got.Get(wantKey.Address)
+		if err != nil {
+			t.Fatalf("Failed to get key : %s", err)
+		}
+
+		if !bytes.Equal(gotKey.Key.Number(), wantKey.Key.Number()) {
+			t.Errorf("Wrong key for %x", wantKey.Address.Bytes())
+		}
+	}
+}
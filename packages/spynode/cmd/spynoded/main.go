@@ -51,6 +51,8 @@ func main() {
 			MaxRetries     int    `default:"25" envconfig:"MAX_RETRIES"`
 			RetryDelay     int    `default:"2000" envconfig:"RETRY_DELAY"`
 			RequestMempool bool   `default:"true" envconfig:"REQUEST_MEMPOOL" json:"REQUEST_MEMPOOL"`
+			MemPoolMaxSize uint64 `default:"1000000000" envconfig:"MEMPOOL_MAX_SIZE" json:"MEMPOOL_MAX_SIZE"`
+			MemPoolMaxAge  int    `default:"336" envconfig:"MEMPOOL_MAX_AGE" json:"MEMPOOL_MAX_AGE"`
 		}
 		NodeStorage struct {
 			Region    string `default:"ap-southeast-2" envconfig:"NODE_STORAGE_REGION"`
@@ -100,6 +102,8 @@ func main() {
 		logger.Error(ctx, "Failed to create node config : %s\n", err)
 		return
 	}
+	nodeConfig.MemPoolMaxSize = cfg.Node.MemPoolMaxSize
+	nodeConfig.MemPoolMaxAge = cfg.Node.MemPoolMaxAge
 
 	// -------------------------------------------------------------------------
 	// RPC
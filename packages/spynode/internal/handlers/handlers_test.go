@@ -79,7 +79,7 @@ func TestHandlers(test *testing.T) {
 	txTracker := state.NewTxTracker()
 
 	// Create mempool
-	memPool := state.NewMemPool()
+	memPool := state.NewMemPool(0)
 
 	// Setup handlers
 	testHandler := &TestHandler{test: test, state: st, blocks: blockRepo, txs: txRepo, height: 0,
@@ -22,11 +22,19 @@ type Config struct {
 	// Retry attempts when main connection fails.
 	MaxRetries int
 	RetryDelay int
+
+	// Mempool limits. Txs older than the max age are evicted, then txs with the lowest fee rates
+	// when the mempool uses more than the max size. Zero is no limit.
+	MemPoolMaxSize uint64 // Approximate bytes of memory used by mempool txs
+	MemPoolMaxAge  int    // Number of hours
 }
 
 const (
 	DefaultMaxRetries = 25
 	DefaultRetryDelay = 5000
+
+	DefaultMemPoolMaxSize = 1000000000 // 1 GB
+	DefaultMemPoolMaxAge  = 336        // 2 weeks
 )
 
 // NewConfig returns a new Config populated from environment variables.
@@ -44,6 +52,8 @@ func NewConfig(net bitcoin.Network, isTest bool, host, useragent, starthash stri
 		MaxRetries:     maxRetries,
 		RetryDelay:     retryDelay,
 		RequestMempool: requestMempool,
+		MemPoolMaxSize: DefaultMemPoolMaxSize,
+		MemPoolMaxAge:  DefaultMemPoolMaxAge,
 	}
 
 	hash, err := bitcoin.NewHash32FromStr(starthash)
@@ -59,10 +69,12 @@ func NewConfig(net bitcoin.Network, isTest bool, host, useragent, starthash stri
 // This is important so we don't log sensitive config values.
 func (c Config) String() string {
 	pairs := map[string]string{
-		"NodeAddress": c.NodeAddress,
-		"UserAgent":   c.UserAgent,
-		"StartHash":   c.StartHash.String(),
-		"SafeTxDelay": fmt.Sprintf("%d ms", c.SafeTxDelay),
+		"NodeAddress":    c.NodeAddress,
+		"UserAgent":      c.UserAgent,
+		"StartHash":      c.StartHash.String(),
+		"SafeTxDelay":    fmt.Sprintf("%d ms", c.SafeTxDelay),
+		"MemPoolMaxSize": fmt.Sprintf("%d bytes", c.MemPoolMaxSize),
+		"MemPoolMaxAge":  fmt.Sprintf("%d hours", c.MemPoolMaxAge),
 	}
 
 	parts := []string{}
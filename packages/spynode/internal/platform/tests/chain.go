@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrTxNotFound     = errors.New("Tx not found")
+	ErrOutputNotFound = errors.New("Output not found")
+)
+
+// Chain is a scripted block chain for peers to serve. Blocks are mined on the tip of the active
+//   chain and a reorg replaces the blocks above a height with new ones. Blocks that are reorged
+//   out are kept so they can still be requested.
+// Headers are not given valid proof of work since spynode doesn't check it.
+// A Chain also implements the tx and output fetchers of spynode using the txs of all of its
+//   blocks.
+type Chain struct {
+	blocks map[bitcoin.Hash32]*wire.MsgBlock
+	active []bitcoin.Hash32 // Hashes of the active chain by height
+	txs    map[bitcoin.Hash32]*wire.MsgTx
+	nonce  uint32 // Makes each coinbase, and so each block, unique
+	lock   sync.Mutex
+}
+
+// NewChain returns a new Chain built on the genesis header.
+func NewChain(genesis *wire.BlockHeader) *Chain {
+	block := wire.NewMsgBlock(genesis)
+	hash := *genesis.BlockHash()
+
+	return &Chain{
+		blocks: map[bitcoin.Hash32]*wire.MsgBlock{hash: block},
+		active: []bitcoin.Hash32{hash},
+		txs:    make(map[bitcoin.Hash32]*wire.MsgTx),
+	}
+}
+
+// Height returns the height of the tip of the active chain.
+func (c *Chain) Height() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.active) - 1
+}
+
+// Hash returns the hash of the active block at the height, or nil if there isn't one.
+func (c *Chain) Hash(height int) *bitcoin.Hash32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if height < 0 || height >= len(c.active) {
+		return nil
+	}
+	hash := c.active[height]
+	return &hash
+}
+
+// Block returns the block with the hash, including blocks that have been reorged out, or nil if
+//   there isn't one.
+func (c *Chain) Block(hash bitcoin.Hash32) *wire.MsgBlock {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.blocks[hash]
+}
+
+// Mine adds a block containing the txs, after a coinbase tx, to the tip of the active chain.
+func (c *Chain) Mine(txs ...*wire.MsgTx) *wire.MsgBlock {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.mine(txs)
+}
+
+// MineEmpty adds count blocks containing only a coinbase tx to the tip of the active chain.
+func (c *Chain) MineEmpty(count int) []*wire.MsgBlock {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := make([]*wire.MsgBlock, 0, count)
+	for i := 0; i < count; i++ {
+		result = append(result, c.mine(nil))
+	}
+	return result
+}
+
+// Reorg removes the blocks above the height from the active chain and mines count new empty
+//   blocks in their place. The new blocks are returned.
+// To orphan the current tip the new branch must be longer, so count is normally at least one
+//   more than the number of blocks removed.
+func (c *Chain) Reorg(height, count int) ([]*wire.MsgBlock, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if height < 0 || height >= len(c.active) {
+		return nil, errors.New("Reorg height out of range")
+	}
+
+	c.active = c.active[:height+1]
+
+	result := make([]*wire.MsgBlock, 0, count)
+	for i := 0; i < count; i++ {
+		result = append(result, c.mine(nil))
+	}
+	return result, nil
+}
+
+// Headers returns the headers of the active chain following the first locator hash that is in
+//   the active chain, or following the genesis header if none are. No more than max headers are
+//   returned, and none after the stop hash.
+// This is how a node responds to a getheaders message.
+func (c *Chain) Headers(locator []*bitcoin.Hash32, stop bitcoin.Hash32,
+	max int) []*wire.BlockHeader {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	start := 1
+	for _, hash := range locator {
+		if height, exists := c.activeHeight(*hash); exists {
+			start = height + 1
+			break
+		}
+	}
+
+	var result []*wire.BlockHeader
+	for height := start; height < len(c.active) && len(result) < max; height++ {
+		header := c.blocks[c.active[height]].Header
+		result = append(result, &header)
+		if c.active[height].Equal(&stop) {
+			break
+		}
+	}
+
+	return result
+}
+
+// HeadersAfterFork returns the headers of the active chain after the point where the branch
+//   ending with the hash joins it. These are the headers to announce to a peer whose best known
+//   block is the hash.
+func (c *Chain) HeadersAfterFork(hash bitcoin.Hash32) []*wire.BlockHeader {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	start := 1
+	for {
+		if height, exists := c.activeHeight(hash); exists {
+			start = height + 1
+			break
+		}
+
+		block, exists := c.blocks[hash]
+		if !exists {
+			break
+		}
+		hash = block.Header.PrevBlock
+	}
+
+	var result []*wire.BlockHeader
+	for height := start; height < len(c.active); height++ {
+		header := c.blocks[c.active[height]].Header
+		result = append(result, &header)
+	}
+
+	return result
+}
+
+// GetTx returns a tx from any block. It implements the spynode TxFetcher interface.
+func (c *Chain) GetTx(ctx context.Context, txid bitcoin.Hash32) (*wire.MsgTx, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	tx, exists := c.txs[txid]
+	if !exists {
+		return nil, ErrTxNotFound
+	}
+	return tx, nil
+}
+
+// GetOutputs returns outputs of txs from any block. It implements the spynode OutputFetcher
+//   interface.
+func (c *Chain) GetOutputs(ctx context.Context,
+	outpoints []wire.OutPoint) ([]bitcoin.UTXO, error) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := make([]bitcoin.UTXO, 0, len(outpoints))
+	for _, outpoint := range outpoints {
+		tx, exists := c.txs[outpoint.Hash]
+		if !exists || int(outpoint.Index) >= len(tx.TxOut) {
+			return nil, errors.Wrap(ErrOutputNotFound, outpoint.String())
+		}
+
+		output := tx.TxOut[outpoint.Index]
+		result = append(result, bitcoin.UTXO{
+			Hash:          outpoint.Hash,
+			Index:         outpoint.Index,
+			Value:         output.Value,
+			LockingScript: output.PkScript,
+		})
+	}
+
+	return result, nil
+}
+
+func (c *Chain) mine(txs []*wire.MsgTx) *wire.MsgBlock {
+	height := len(c.active)
+	previous := c.blocks[c.active[height-1]]
+	c.nonce++
+
+	// Coinbase script pushes the height and a nonce.
+	script := make([]byte, 10)
+	script[0] = 4 // push 4 bytes
+	binary.LittleEndian.PutUint32(script[1:5], uint32(height))
+	script[5] = 4 // push 4 bytes
+	binary.LittleEndian.PutUint32(script[6:10], c.nonce)
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&bitcoin.Hash32{}, wire.MaxPrevOutIndex),
+		script))
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, nil))
+
+	header := wire.BlockHeader{
+		Version:   1,
+		PrevBlock: *previous.Header.BlockHash(),
+		Timestamp: previous.Header.Timestamp.Add(10 * time.Minute),
+		Bits:      previous.Header.Bits,
+		Nonce:     c.nonce,
+	}
+	block := wire.NewMsgBlock(&header)
+	block.AddTransaction(coinbase)
+	for _, tx := range txs {
+		block.AddTransaction(tx)
+	}
+
+	merkleRoot, _ := block.CalculateMerkleHash()
+	block.Header.MerkleRoot = *merkleRoot
+
+	hash := *block.Header.BlockHash()
+	c.blocks[hash] = block
+	c.active = append(c.active, hash)
+	for _, tx := range block.Transactions {
+		c.txs[*tx.TxHash()] = tx
+	}
+
+	return block
+}
+
+func (c *Chain) activeHeight(hash bitcoin.Hash32) (int, bool) {
+	for height, activeHash := range c.active {
+		if activeHash.Equal(&hash) {
+			return height, true
+		}
+	}
+	return 0, false
+}
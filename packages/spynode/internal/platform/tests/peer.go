@@ -0,0 +1,449 @@
+package tests
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPingInterval is how often a peer pings connected nodes. spynode only checks its state
+	//   between messages, so regular pings keep it progressing when nothing else is sent.
+	DefaultPingInterval = 100 * time.Millisecond
+
+	peerUserAgent = "/spynode-test-peer/"
+)
+
+// Peer is an in-process bitcoin node that spynode can connect to, either as its trusted node or
+//   as an untrusted peer. It serves the blocks of a Chain, and keeps its own mempool of txs, so
+//   peers sharing a chain can announce different txs, for example to inject a double spend.
+// A peer only announces blocks and txs when told to, so tests can control the order of events.
+type Peer struct {
+	net          bitcoin.Network
+	chain        *Chain
+	PingInterval time.Duration
+
+	listener    net.Listener
+	connections []*peerConnection
+	memPool     map[bitcoin.Hash32]*wire.MsgTx
+	received    []*wire.MsgTx // Txs sent to the peer by connected nodes
+	wait        sync.WaitGroup
+	lock        sync.Mutex
+}
+
+// peerConnection is a connection from a node to a peer.
+type peerConnection struct {
+	peer        *Peer
+	conn        net.Conn
+	ready       bool           // Handshake is complete
+	sendHeaders bool           // Announce blocks with headers instead of inventories
+	lastHeader  bitcoin.Hash32 // Hash of the last header sent
+	done        chan struct{}
+	lock        sync.Mutex
+	sendLock    sync.Mutex
+}
+
+// NewPeer returns a new Peer serving the chain.
+func NewPeer(net bitcoin.Network, chain *Chain) *Peer {
+	return &Peer{
+		net:          net,
+		chain:        chain,
+		PingInterval: DefaultPingInterval,
+		memPool:      make(map[bitcoin.Hash32]*wire.MsgTx),
+	}
+}
+
+// Start starts listening for connections on a local port.
+func (p *Peer) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+
+	p.lock.Lock()
+	p.listener = listener
+	p.lock.Unlock()
+
+	p.wait.Add(1)
+	go func() {
+		defer p.wait.Done()
+		p.accept(listener)
+	}()
+
+	return nil
+}
+
+// Address returns the address to connect to the peer. Start must be called first.
+func (p *Peer) Address() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.listener.Addr().String()
+}
+
+// Stop closes the listener and all connections.
+func (p *Peer) Stop() error {
+	p.lock.Lock()
+	var err error
+	if p.listener != nil {
+		err = p.listener.Close()
+	}
+	connections := p.connections
+	p.connections = nil
+	p.lock.Unlock()
+
+	for _, connection := range connections {
+		connection.close()
+	}
+
+	p.wait.Wait()
+	return err
+}
+
+// ConnectionCount returns the number of connections that have completed the handshake.
+func (p *Peer) ConnectionCount() int {
+	return len(p.readyConnections())
+}
+
+// SendHeadersCount returns the number of connections that have asked for new blocks to be
+//   announced with headers. spynode ignores block inventories so a node hasn't finished
+//   connecting until it has asked for headers.
+func (p *Peer) SendHeadersCount() int {
+	result := 0
+	for _, connection := range p.readyConnections() {
+		connection.lock.Lock()
+		if connection.sendHeaders {
+			result++
+		}
+		connection.lock.Unlock()
+	}
+	return result
+}
+
+// AnnounceBlocks announces the blocks of the active chain that haven't been sent to each
+//   connection yet. After a reorg this is the blocks after the fork point.
+func (p *Peer) AnnounceBlocks() error {
+	for _, connection := range p.readyConnections() {
+		if err := connection.announceBlocks(); err != nil {
+			return errors.Wrap(err, "announce")
+		}
+	}
+
+	return nil
+}
+
+// AnnounceTx adds a tx to the mempool of the peer and announces it to connected nodes.
+func (p *Peer) AnnounceTx(tx *wire.MsgTx) error {
+	p.lock.Lock()
+	p.memPool[*tx.TxHash()] = tx
+	p.lock.Unlock()
+
+	inv := wire.NewMsgInv()
+	if err := inv.AddInvVect(wire.NewInvVect(wire.InvTypeTx, tx.TxHash())); err != nil {
+		return errors.Wrap(err, "add inventory")
+	}
+
+	for _, connection := range p.readyConnections() {
+		if err := connection.send(inv); err != nil {
+			return errors.Wrap(err, "send inventory")
+		}
+	}
+
+	return nil
+}
+
+// ReceivedTxs returns the txs sent to the peer by connected nodes.
+func (p *Peer) ReceivedTxs() []*wire.MsgTx {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	result := make([]*wire.MsgTx, len(p.received))
+	copy(result, p.received)
+	return result
+}
+
+func (p *Peer) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return // Listener closed
+		}
+
+		connection := &peerConnection{
+			peer: p,
+			conn: conn,
+			done: make(chan struct{}),
+		}
+
+		p.lock.Lock()
+		p.connections = append(p.connections, connection)
+		p.lock.Unlock()
+
+		p.wait.Add(2)
+		go func() {
+			defer p.wait.Done()
+			connection.run()
+		}()
+		go func() {
+			defer p.wait.Done()
+			connection.ping()
+		}()
+	}
+}
+
+func (p *Peer) readyConnections() []*peerConnection {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var result []*peerConnection
+	for _, connection := range p.connections {
+		if connection.isReady() {
+			result = append(result, connection)
+		}
+	}
+	return result
+}
+
+func (p *Peer) findTx(txid bitcoin.Hash32) *wire.MsgTx {
+	p.lock.Lock()
+	tx, exists := p.memPool[txid]
+	p.lock.Unlock()
+	if exists {
+		return tx
+	}
+
+	tx, err := p.chain.GetTx(context.Background(), txid)
+	if err != nil {
+		return nil
+	}
+	return tx
+}
+
+// run reads and responds to messages until the connection is closed.
+func (c *peerConnection) run() {
+	defer c.close()
+
+	for {
+		_, msg, _, err := wire.ReadMessageN(c.conn, wire.ProtocolVersion,
+			wire.BitcoinNet(c.peer.net))
+		if err != nil {
+			if wireError, ok := errors.Cause(err).(*wire.MessageError); ok &&
+				wireError.Type == wire.MessageErrorUnknownCommand {
+				continue
+			}
+			return
+		}
+
+		if err := c.handle(msg); err != nil {
+			return
+		}
+	}
+}
+
+// ping sends pings until the connection is closed.
+func (c *peerConnection) ping() {
+	ticker := time.NewTicker(c.peer.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if !c.isReady() {
+				continue
+			}
+			if err := c.send(wire.NewMsgPing(rand.Uint64())); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *peerConnection) handle(m wire.Message) error {
+	switch msg := m.(type) {
+	case *wire.MsgVersion:
+		local := wire.NewNetAddressIPPort(net.IPv4(127, 0, 0, 1), 8333, wire.SFNodeNetwork)
+		version := wire.NewMsgVersion(local, &msg.AddrMe, rand.Uint64(),
+			int32(c.peer.chain.Height()))
+		version.UserAgent = peerUserAgent
+		version.Services = wire.SFNodeNetwork
+		if err := c.send(version); err != nil {
+			return err
+		}
+		return c.send(wire.NewMsgVerAck())
+
+	case *wire.MsgVerAck:
+		c.lock.Lock()
+		c.ready = true
+		c.lock.Unlock()
+
+	case *wire.MsgPing:
+		return c.send(wire.NewMsgPong(msg.Nonce))
+
+	case *wire.MsgSendHeaders:
+		c.lock.Lock()
+		c.sendHeaders = true
+		c.lock.Unlock()
+
+	case *wire.MsgGetAddr:
+		return c.send(wire.NewMsgAddr())
+
+	case *wire.MsgGetHeaders:
+		headers := c.peer.chain.Headers(msg.BlockLocatorHashes, msg.HashStop,
+			wire.MaxBlockHeadersPerMsg)
+		return c.sendHeaderList(headers)
+
+	case *wire.MsgGetData:
+		return c.handleGetData(msg)
+
+	case *wire.MsgMemPool:
+		c.peer.lock.Lock()
+		inv := wire.NewMsgInv()
+		for txid := range c.peer.memPool {
+			hash := txid
+			inv.AddInvVect(wire.NewInvVect(wire.InvTypeTx, &hash))
+		}
+		c.peer.lock.Unlock()
+
+		if len(inv.InvList) == 0 {
+			return nil
+		}
+		return c.send(inv)
+
+	case *wire.MsgTx:
+		c.peer.lock.Lock()
+		c.peer.memPool[*msg.TxHash()] = msg
+		c.peer.received = append(c.peer.received, msg)
+		c.peer.lock.Unlock()
+	}
+
+	return nil
+}
+
+func (c *peerConnection) handleGetData(msg *wire.MsgGetData) error {
+	notFound := wire.NewMsgNotFound()
+	for _, item := range msg.InvList {
+		switch item.Type {
+		case wire.InvTypeBlock:
+			block := c.peer.chain.Block(item.Hash)
+			if block == nil {
+				notFound.AddInvVect(item)
+				continue
+			}
+			if err := c.send(block); err != nil {
+				return err
+			}
+
+		case wire.InvTypeTx:
+			tx := c.peer.findTx(item.Hash)
+			if tx == nil {
+				notFound.AddInvVect(item)
+				continue
+			}
+			if err := c.send(tx); err != nil {
+				return err
+			}
+
+		default:
+			notFound.AddInvVect(item)
+		}
+	}
+
+	if len(notFound.InvList) == 0 {
+		return nil
+	}
+	return c.send(notFound)
+}
+
+func (c *peerConnection) announceBlocks() error {
+	c.lock.Lock()
+	lastHeader := c.lastHeader
+	sendHeaders := c.sendHeaders
+	c.lock.Unlock()
+
+	headers := c.peer.chain.HeadersAfterFork(lastHeader)
+	if len(headers) == 0 {
+		return nil
+	}
+
+	if sendHeaders {
+		return c.sendHeaderList(headers)
+	}
+
+	// Without sendheaders only the new tip is announced and the node requests the headers.
+	inv := wire.NewMsgInv()
+	if err := inv.AddInvVect(wire.NewInvVect(wire.InvTypeBlock,
+		headers[len(headers)-1].BlockHash())); err != nil {
+		return err
+	}
+	return c.send(inv)
+}
+
+// sendHeaderList sends headers in as many messages as needed and remembers the last one sent.
+func (c *peerConnection) sendHeaderList(headers []*wire.BlockHeader) error {
+	msg := wire.NewMsgHeaders()
+	for _, header := range headers {
+		if len(msg.Headers) == wire.MaxBlockHeadersPerMsg {
+			if err := c.send(msg); err != nil {
+				return err
+			}
+			msg = wire.NewMsgHeaders()
+		}
+		if err := msg.AddBlockHeader(header); err != nil {
+			return err
+		}
+	}
+
+	if err := c.send(msg); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	if len(headers) > 0 {
+		c.lastHeader = *headers[len(headers)-1].BlockHash()
+	} else if tip := c.peer.chain.Hash(c.peer.chain.Height()); tip != nil {
+		c.lastHeader = *tip
+	}
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *peerConnection) send(msg wire.Message) error {
+	c.sendLock.Lock()
+	defer c.sendLock.Unlock()
+
+	if _, err := wire.WriteMessageN(c.conn, msg, wire.ProtocolVersion,
+		wire.BitcoinNet(c.peer.net)); err != nil {
+		return errors.Wrap(err, "write message")
+	}
+	return nil
+}
+
+func (c *peerConnection) isReady() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.ready
+}
+
+func (c *peerConnection) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	select {
+	case <-c.done:
+		return // Already closed
+	default:
+	}
+
+	close(c.done)
+	c.conn.Close()
+}
@@ -2,6 +2,7 @@ package spynode
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"net"
 	"sync"
@@ -42,23 +43,24 @@ type TxFetcher interface {
 
 // Node is the main object for spynode.
 type Node struct {
-	config          config.Config                      // Configuration
-	state           *state.State                       // Non-persistent data
-	store           storage.Storage                    // Persistent data
-	peers           *internalStorage.PeerRepository    // Peer data
-	blocks          *internalStorage.BlockRepository   // Block data
-	blockRefeeder   handlers.BlockRefeeder             // Reprocess older blocks
-	txs             *internalStorage.TxRepository      // Tx data
-	reorgs          *internalStorage.ReorgRepository   // Reorg data
-	txTracker       *state.TxTracker                   // Tracks tx requests to ensure all txs are received
-	memPool         *state.MemPool                     // Tracks which txs have been received and checked
-	messageHandlers map[string]handlers.MessageHandler // Handlers for messages from trusted node
-	connection      net.Conn                           // Connection to trusted node
-	outgoing        MessageChannel                     // Channel for messages to send to trusted node
-	handlers        []client.Handler                   // Receive data and notifications about transactions
-	untrustedNodes  []*UntrustedNode                   // Randomized peer connections to monitor for double spends
-	addresses       map[string]time.Time               // Recently used peer addresses
-	unconfTxChannel handlers.TxChannel                 // Channel for directly handled txs so they don't lock the calling thread
+	config          config.Config                           // Configuration
+	state           *state.State                            // Non-persistent data
+	store           storage.Storage                         // Persistent data
+	peers           *internalStorage.PeerRepository         // Peer data
+	blocks          *internalStorage.BlockRepository        // Block data
+	blockRefeeder   handlers.BlockRefeeder                  // Reprocess older blocks
+	txs             *internalStorage.TxRepository           // Tx data
+	reorgs          *internalStorage.ReorgRepository        // Reorg data
+	subscriptions   *internalStorage.SubscriptionRepository // Subscribed txs and outputs
+	txTracker       *state.TxTracker                        // Tracks tx requests to ensure all txs are received
+	memPool         *state.MemPool                          // Tracks which txs have been received and checked
+	messageHandlers map[string]handlers.MessageHandler      // Handlers for messages from trusted node
+	connection      net.Conn                                // Connection to trusted node
+	outgoing        MessageChannel                          // Channel for messages to send to trusted node
+	handlers        []client.Handler                        // Receive data and notifications about transactions
+	untrustedNodes  []*UntrustedNode                        // Randomized peer connections to monitor for double spends
+	addresses       map[string]time.Time                    // Recently used peer addresses
+	unconfTxChannel handlers.TxChannel                      // Channel for directly handled txs so they don't lock the calling thread
 	broadcastLock   sync.Mutex
 	broadcastTxs    []TxCount // Txs to transmit to nodes upon connection
 	needsRestart    bool
@@ -74,8 +76,7 @@ type Node struct {
 	txFetcher     TxFetcher
 	outputFetcher OutputFetcher
 
-	pushDataHashes []bitcoin.Hash20
-	pushDataLock   sync.Mutex
+	pushDatas *internalStorage.PushDataRepository // Subscribed push data hashes of each client
 
 	sendContracts bool
 	sendHeaders   bool
@@ -100,8 +101,10 @@ func NewNode(config config.Config, store storage.Storage, txFetcher TxFetcher,
 		blocks:         internalStorage.NewBlockRepository(config, store),
 		txs:            internalStorage.NewTxRepository(store),
 		reorgs:         internalStorage.NewReorgRepository(store),
+		subscriptions:  internalStorage.NewSubscriptionRepository(store),
+		pushDatas:      internalStorage.NewPushDataRepository(store),
 		txTracker:      state.NewTxTracker(),
-		memPool:        state.NewMemPool(),
+		memPool:        state.NewMemPool(config.MemPoolMaxSize),
 		handlers:       make([]client.Handler, 0),
 		untrustedNodes: make([]*UntrustedNode, 0),
 		addresses:      make(map[string]time.Time),
@@ -162,60 +165,77 @@ func (node *Node) SendTx(ctx context.Context, tx *wire.MsgTx) error {
 	return nil
 }
 
+// SendTxAndMarkOutputs sends a tx to the network and subscribes to the outputs specified by
+//   indexes, so that txs spending them are seen.
 func (node *Node) SendTxAndMarkOutputs(ctx context.Context, tx *wire.MsgTx,
 	indexes []uint32) error {
-	return errors.New("Not implemented")
-}
-
-func (node *Node) SubscribePushDatas(ctx context.Context, pushDatas [][]byte) error {
-	node.pushDataLock.Lock()
-	defer node.pushDataLock.Unlock()
 
-	for _, pd := range pushDatas {
-		var hash bitcoin.Hash20
-		if len(pd) == 20 {
-			copy(hash[:], pd)
-		} else {
-			copy(hash[:], bitcoin.Hash160(pd))
+	if len(indexes) > 0 {
+		txid := *tx.TxHash()
+		outpoints := make([]*wire.OutPoint, 0, len(indexes))
+		for _, index := range indexes {
+			if int(index) >= len(tx.TxOut) {
+				return fmt.Errorf("Output index out of range : %d/%d", index, len(tx.TxOut))
+			}
+			outpoints = append(outpoints, wire.NewOutPoint(&txid, index))
 		}
 
-		node.pushDataHashes = append(node.pushDataHashes, hash)
+		// Subscribe before sending so a spend can't be missed.
+		if err := node.subscriptions.AddOutputs(ctx, outpoints); err != nil {
+			return errors.Wrap(err, "subscribe outputs")
+		}
 	}
 
-	return nil
+	return node.SendTx(ctx, tx)
+}
+
+func (node *Node) SubscribePushDatas(ctx context.Context, pushDatas [][]byte) error {
+	return node.SubscribeClientPushDatas(ctx, "", pushDatas)
 }
 
 func (node *Node) UnsubscribePushDatas(ctx context.Context, pushDatas [][]byte) error {
-	node.pushDataLock.Lock()
-	defer node.pushDataLock.Unlock()
-
-	for _, pd := range pushDatas {
-		hash := pushDataToHash(pd)
-		for i, epd := range node.pushDataHashes {
-			if hash.Equal(&epd) {
-				node.pushDataHashes = append(node.pushDataHashes[:i], node.pushDataHashes[i+1:]...)
-				break
-			}
-		}
-	}
+	return node.UnsubscribeClientPushDatas(ctx, "", pushDatas)
+}
 
-	return nil
+// SubscribeClientPushDatas subscribes a client to txs containing the push datas. Each client has
+//   its own set of push datas, so a server can serve many remote clients with different filters.
+//   The embedded client uses an empty client id.
+func (node *Node) SubscribeClientPushDatas(ctx context.Context, clientID string,
+	pushDatas [][]byte) error {
+	return node.pushDatas.Add(ctx, clientID, pushDatasToHashes(pushDatas))
+}
+
+func (node *Node) UnsubscribeClientPushDatas(ctx context.Context, clientID string,
+	pushDatas [][]byte) error {
+	return node.pushDatas.Remove(ctx, clientID, pushDatasToHashes(pushDatas))
 }
 
-func (node *Node) SubscribeTx(context.Context, bitcoin.Hash32, []uint32) error {
-	return errors.New("Not implemented")
+// UnsubscribeClient removes all of a client's push data subscriptions.
+func (node *Node) UnsubscribeClient(ctx context.Context, clientID string) error {
+	return node.pushDatas.RemoveClient(ctx, clientID)
 }
 
-func (node *Node) UnsubscribeTx(context.Context, bitcoin.Hash32, []uint32) error {
-	return errors.New("Not implemented")
+// SubscribeTx subscribes to the tx with the specified hash and any txs spending the outputs
+//   specified by indexes.
+func (node *Node) SubscribeTx(ctx context.Context, txid bitcoin.Hash32, indexes []uint32) error {
+	logger.Info(ctx, "Subscribing to tx %s outputs %v", txid, indexes)
+	return node.subscriptions.AddTx(ctx, txid, indexes)
 }
 
-func (node *Node) SubscribeOutputs(context.Context, []*wire.OutPoint) error {
-	return errors.New("Not implemented")
+func (node *Node) UnsubscribeTx(ctx context.Context, txid bitcoin.Hash32, indexes []uint32) error {
+	logger.Info(ctx, "Unsubscribing from tx %s outputs %v", txid, indexes)
+	return node.subscriptions.RemoveTx(ctx, txid, indexes)
 }
 
-func (node *Node) UnsubscribeOutputs(context.Context, []*wire.OutPoint) error {
-	return errors.New("Not implemented")
+// SubscribeOutputs subscribes to any txs spending the outputs.
+func (node *Node) SubscribeOutputs(ctx context.Context, outpoints []*wire.OutPoint) error {
+	logger.Info(ctx, "Subscribing to %d outputs", len(outpoints))
+	return node.subscriptions.AddOutputs(ctx, outpoints)
+}
+
+func (node *Node) UnsubscribeOutputs(ctx context.Context, outpoints []*wire.OutPoint) error {
+	logger.Info(ctx, "Unsubscribing from %d outputs", len(outpoints))
+	return node.subscriptions.RemoveOutputs(ctx, outpoints)
 }
 
 func (node *Node) SubscribeContracts(ctx context.Context) error {
@@ -297,6 +317,18 @@ func (node *Node) load(ctx context.Context) error {
 		return err
 	}
 
+	if err := node.subscriptions.Load(ctx); err != nil {
+		return err
+	}
+
+	if err := node.pushDatas.Load(ctx); err != nil {
+		return err
+	}
+
+	if err := internalStorage.LoadMemPool(ctx, node.store, node.memPool); err != nil {
+		return err
+	}
+
 	node.messageHandlers = handlers.NewTrustedMessageHandlers(ctx, node.config, node.state,
 		node.peers, node.blocks, &node.blockRefeeder, node.txs, node.reorgs, node.txTracker,
 		node.memPool, &node.unconfTxChannel, node.handlers)
@@ -384,6 +416,13 @@ func (node *Node) Run(ctx context.Context) error {
 			logger.Verbose(ctx, "Check tx delays finished")
 		}()
 
+		go func() {
+			atomic.AddUint32(&node.processingCount, 1)                // increment
+			defer atomic.AddUint32(&node.processingCount, ^uint32(0)) // decrement
+			node.monitorMemPool(ctx)
+			logger.Verbose(ctx, "Monitor mempool finished")
+		}()
+
 		if node.config.UntrustedCount == 0 {
 			logger.Verbose(ctx, "Monitor untrusted not started")
 		} else {
@@ -461,6 +500,9 @@ func (node *Node) Run(ctx context.Context) error {
 		node.blocks.Save(ctx)
 		node.txs.Save(ctx)
 		node.peers.Save(ctx)
+		if err := internalStorage.SaveMemPool(ctx, node.store, node.memPool); err != nil {
+			logger.Error(ctx, "SpyNodeFailed to save mempool : %s", err)
+		}
 
 		node.lock.Lock()
 		if !node.needsRestart || node.hardStop {
@@ -954,6 +996,44 @@ func (node *Node) monitorRequestTimeouts(ctx context.Context) {
 	}
 }
 
+// monitorMemPool evicts txs from the mempool that have passed the max age, saves a snapshot of the
+//   mempool so its conflicts are kept across restarts, and logs its stats.
+//
+// This is a blocking function that will run forever, so it should be run
+// in a goroutine.
+func (node *Node) monitorMemPool(ctx context.Context) {
+	for !node.isStopping() {
+		node.sleepUntilStop(600) // Only check every minute
+		if node.isStopping() {
+			break // Saved after stopping
+		}
+
+		if node.config.MemPoolMaxAge > 0 {
+			cutoffTime := time.Now().Add(time.Hour * -time.Duration(node.config.MemPoolMaxAge))
+			if expired := node.memPool.Expire(ctx, cutoffTime); expired > 0 {
+				logger.Verbose(ctx, "Expired %d mempool txs", expired)
+			}
+		}
+
+		stats := node.memPool.Stats()
+		logger.InfoWithFields(ctx, []logger.Field{
+			logger.Int("tx_count", stats.Count),
+			logger.Uint64("size", stats.Size),
+			logger.Int("conflict_count", stats.Conflicts),
+			logger.Uint64("evicted_count", stats.Evicted),
+		}, "Mempool stats")
+
+		if err := internalStorage.SaveMemPool(ctx, node.store, node.memPool); err != nil {
+			logger.Error(ctx, "SpyNodeFailed to save mempool : %s", err)
+		}
+	}
+}
+
+// MemPoolStats returns the size of the mempool and the number of conflicting txs in it.
+func (node *Node) MemPoolStats() state.MemPoolStats {
+	return node.memPool.Stats()
+}
+
 // checkTxDelays monitors txs for when they have passed the safe tx delay without seeing a
 //   conflicting tx.
 //
@@ -1369,6 +1449,47 @@ func (node *Node) BlockHash(ctx context.Context, height int) (*bitcoin.Hash32, e
 	return node.blocks.Hash(ctx, height)
 }
 
+// GetHeader returns the header with the block hash if it is in the most POW chain.
+func (node *Node) GetHeader(ctx context.Context, blockHash bitcoin.Hash32) (*client.Header,
+	error) {
+
+	height, exists := node.blocks.Height(&blockHash)
+	if !exists {
+		return nil, errors.Wrap(client.ErrNotFound, blockHash.String())
+	}
+
+	header, err := node.blocks.Header(ctx, height)
+	if err != nil {
+		return nil, errors.Wrap(err, "header")
+	}
+
+	return &client.Header{
+		Height: uint32(height),
+		Header: *header,
+	}, nil
+}
+
+// GetMerkleProof returns the merkle proof of a confirmed tx. Merkle proofs are only calculated
+//   for relevant txs, so other txs are not found.
+func (node *Node) GetMerkleProof(ctx context.Context,
+	txid bitcoin.Hash32) (*client.MerkleProof, error) {
+
+	txState, err := internalStorage.FetchTxState(ctx, node.store, txid)
+	if err != nil {
+		if errors.Cause(err) == storage.ErrNotFound {
+			return nil, errors.Wrap(client.ErrNotFound, txid.String())
+		}
+		return nil, errors.Wrap(err, "fetch tx state")
+	}
+
+	merkleProof := txState.State.MerkleProof
+	if merkleProof == nil || !node.blocks.Contains(merkleProof.BlockHeader.BlockHash()) {
+		return nil, errors.Wrap(client.ErrNotConfirmed, txid.String())
+	}
+
+	return merkleProof, nil
+}
+
 func (node *Node) Time(ctx context.Context, height int) (uint32, error) {
 	return node.blocks.Time(ctx, height)
 }
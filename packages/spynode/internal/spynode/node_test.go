@@ -0,0 +1,442 @@
+package spynode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/spynode/internal/platform/config"
+	"github.com/tokenized/spynode/internal/platform/tests"
+	internalStorage "github.com/tokenized/spynode/internal/storage"
+	"github.com/tokenized/spynode/pkg/client"
+
+	"github.com/pkg/errors"
+)
+
+const testTimeout = 10 * time.Second
+
+func TestNodeSync(test *testing.T) {
+	ctx := testContext()
+	chain := newTestChain(ctx, test)
+	peer := startTestPeer(test, chain)
+	defer peer.Stop()
+
+	// Block 1 pays a subscribed address.
+	blocks := chain.MineEmpty(1)
+	tx := spendTx(blocks[0].Transactions[0], 0, p2pkhScript(1))
+	block := chain.Mine(tx)
+	chain.MineEmpty(8)
+
+	node, handler := newTestNode(ctx, test, chain, peer, 0)
+	if err := node.SubscribePushDatas(ctx, [][]byte{p2pkhScript(1)[3:23]}); err != nil {
+		test.Fatalf("Failed to subscribe : %s", err)
+	}
+	runTestNode(ctx, test, node)
+	defer node.Stop(ctx)
+
+	waitForSync(ctx, test, node, handler, chain, peer)
+
+	for height := 0; height <= chain.Height(); height++ {
+		hash, err := node.Hash(ctx, height)
+		if err != nil {
+			test.Fatalf("Failed to get hash %d : %s", height, err)
+		}
+		if !hash.Equal(chain.Hash(height)) {
+			test.Errorf("Wrong hash at height %d : got %s, want %s", height, hash,
+				chain.Hash(height))
+		}
+	}
+
+	confirmed := handler.tx(*tx.TxHash())
+	if confirmed == nil {
+		test.Fatalf("Relevant tx not received")
+	}
+	if confirmed.State.MerkleProof == nil {
+		test.Fatalf("Relevant tx missing merkle proof")
+	}
+	proofHash := confirmed.State.MerkleProof.BlockHeader.BlockHash()
+	if !proofHash.Equal(block.Header.BlockHash()) {
+		test.Errorf("Wrong merkle proof block : got %s, want %s", proofHash,
+			block.Header.BlockHash())
+	}
+
+	merkleProof, err := node.GetMerkleProof(ctx, *tx.TxHash())
+	if err != nil {
+		test.Fatalf("Failed to get merkle proof : %s", err)
+	}
+	if err := merkleProof.IsValid(*tx.TxHash()); err != nil {
+		test.Errorf("Invalid merkle proof : %s", err)
+	}
+
+	unknown := spendTx(tx, 0, p2pkhScript(2))
+	if _, err := node.GetMerkleProof(ctx, *unknown.TxHash()); errors.Cause(err) != client.ErrNotFound {
+		test.Errorf("Wrong unknown tx merkle proof error : got %v, want %s", err,
+			client.ErrNotFound)
+	}
+
+	header, err := node.GetHeader(ctx, *block.Header.BlockHash())
+	if err != nil {
+		test.Fatalf("Failed to get header : %s", err)
+	}
+	if header.Height != 2 {
+		test.Errorf("Wrong header height : got %d, want %d", header.Height, 2)
+	}
+	if !header.Header.BlockHash().Equal(block.Header.BlockHash()) {
+		test.Errorf("Wrong header : got %s, want %s", header.Header.BlockHash(),
+			block.Header.BlockHash())
+	}
+
+	// New blocks are announced with headers.
+	chain.MineEmpty(2)
+	if err := peer.AnnounceBlocks(); err != nil {
+		test.Fatalf("Failed to announce blocks : %s", err)
+	}
+
+	waitFor(test, "new blocks", func() bool {
+		return node.LastHeight(ctx) == chain.Height()
+	})
+}
+
+func TestNodeRelevance(test *testing.T) {
+	ctx := testContext()
+	chain := newTestChain(ctx, test)
+	peer := startTestPeer(test, chain)
+	defer peer.Stop()
+
+	// Block 3 pays both a subscribed and an unsubscribed address.
+	blocks := chain.MineEmpty(4)
+	relevant := spendTx(blocks[0].Transactions[0], 0, p2pkhScript(1))
+	irrelevant := spendTx(blocks[1].Transactions[0], 0, p2pkhScript(2))
+	chain.Mine(irrelevant, relevant)
+	chain.MineEmpty(8)
+
+	node, handler := newTestNode(ctx, test, chain, peer, 0)
+	if err := node.SubscribePushDatas(ctx, [][]byte{p2pkhScript(1)[3:23]}); err != nil {
+		test.Fatalf("Failed to subscribe : %s", err)
+	}
+	runTestNode(ctx, test, node)
+	defer node.Stop(ctx)
+
+	waitForSync(ctx, test, node, handler, chain, peer)
+
+	if handler.tx(*relevant.TxHash()) == nil {
+		test.Errorf("Relevant block tx not received")
+	}
+	if handler.tx(*irrelevant.TxHash()) != nil {
+		test.Errorf("Irrelevant block tx received")
+	}
+
+	// Mempool txs are filtered the same way. The irrelevant tx is announced first, so it has been
+	//   processed by the time the relevant tx is received.
+	relevantMemPool := spendTx(blocks[2].Transactions[0], 0, p2pkhScript(1))
+	irrelevantMemPool := spendTx(blocks[3].Transactions[0], 0, p2pkhScript(3))
+	if err := peer.AnnounceTx(irrelevantMemPool); err != nil {
+		test.Fatalf("Failed to announce tx : %s", err)
+	}
+	if err := peer.AnnounceTx(relevantMemPool); err != nil {
+		test.Fatalf("Failed to announce tx : %s", err)
+	}
+
+	waitFor(test, "relevant tx", func() bool {
+		return handler.tx(*relevantMemPool.TxHash()) != nil
+	})
+	if handler.tx(*irrelevantMemPool.TxHash()) != nil {
+		test.Errorf("Irrelevant mempool tx received")
+	}
+}
+
+func TestNodeReorg(test *testing.T) {
+	ctx := testContext()
+	chain := newTestChain(ctx, test)
+	peer := startTestPeer(test, chain)
+	defer peer.Stop()
+
+	chain.MineEmpty(10)
+
+	node, handler := newTestNode(ctx, test, chain, peer, 0)
+	runTestNode(ctx, test, node)
+	defer node.Stop(ctx)
+
+	waitForSync(ctx, test, node, handler, chain, peer)
+
+	// Replace the top 3 blocks with 4 new ones.
+	reorgHeight := 7
+	removed := make([]bitcoin.Hash32, 0, 3)
+	for height := reorgHeight + 1; height <= chain.Height(); height++ {
+		removed = append(removed, *chain.Hash(height))
+	}
+	if _, err := chain.Reorg(reorgHeight, 4); err != nil {
+		test.Fatalf("Failed to reorg : %s", err)
+	}
+	if err := peer.AnnounceBlocks(); err != nil {
+		test.Fatalf("Failed to announce blocks : %s", err)
+	}
+
+	waitFor(test, "reorg", func() bool {
+		hash, err := node.Hash(ctx, chain.Height())
+		return err == nil && hash.Equal(chain.Hash(chain.Height()))
+	})
+
+	for _, hash := range removed {
+		if _, err := node.GetHeader(ctx, hash); errors.Cause(err) != client.ErrNotFound {
+			test.Errorf("Wrong orphaned header error : got %v, want %s", err, client.ErrNotFound)
+		}
+	}
+
+	for height := reorgHeight + 1; height <= chain.Height(); height++ {
+		hash, err := node.Hash(ctx, height)
+		if err != nil {
+			test.Fatalf("Failed to get hash %d : %s", height, err)
+		}
+		if !hash.Equal(chain.Hash(height)) {
+			test.Errorf("Wrong hash at height %d : got %s, want %s", height, hash,
+				chain.Hash(height))
+		}
+	}
+
+	// The reorg is archived once the node is back in sync.
+	var reorgs []*internalStorage.Reorg
+	waitFor(test, "reorg archived", func() bool {
+		active, err := node.reorgs.GetActive(ctx)
+		if err != nil || active != nil {
+			return false
+		}
+		reorgs, err = node.reorgs.List(ctx)
+		return err == nil && len(reorgs) > 0
+	})
+
+	if len(reorgs) != 1 {
+		test.Fatalf("Wrong reorg count : got %d, want 1", len(reorgs))
+	}
+	reorg := reorgs[0]
+	if reorg.BlockHeight != reorgHeight {
+		test.Errorf("Wrong reorg height : got %d, want %d", reorg.BlockHeight, reorgHeight)
+	}
+	if len(reorg.Blocks) != len(removed) {
+		test.Fatalf("Wrong reorg block count : got %d, want %d", len(reorg.Blocks), len(removed))
+	}
+
+	// Reorg blocks are listed from the top down.
+	for i, reorgBlock := range reorg.Blocks {
+		want := removed[len(removed)-1-i]
+		if !reorgBlock.Header.BlockHash().Equal(&want) {
+			test.Errorf("Wrong reorg block %d : got %s, want %s", i,
+				reorgBlock.Header.BlockHash(), want)
+		}
+	}
+}
+
+func TestNodeDoubleSpend(test *testing.T) {
+	ctx := testContext()
+	chain := newTestChain(ctx, test)
+	trusted := startTestPeer(test, chain)
+	defer trusted.Stop()
+	untrusted := startTestPeer(test, chain)
+	defer untrusted.Stop()
+
+	blocks := chain.MineEmpty(10)
+	coinbase := blocks[0].Transactions[0]
+
+	node, handler := newTestNode(ctx, test, chain, trusted, 1)
+	if _, err := node.peers.Add(ctx, untrusted.Address()); err != nil {
+		test.Fatalf("Failed to add peer : %s", err)
+	}
+	node.peers.UpdateScore(ctx, untrusted.Address(), 5)
+	if err := node.peers.Save(ctx); err != nil {
+		test.Fatalf("Failed to save peers : %s", err)
+	}
+	if err := node.SubscribePushDatas(ctx, [][]byte{p2pkhScript(1)[3:23]}); err != nil {
+		test.Fatalf("Failed to subscribe : %s", err)
+	}
+	runTestNode(ctx, test, node)
+	defer node.Stop(ctx)
+
+	waitForSync(ctx, test, node, handler, chain, trusted)
+	waitFor(test, "untrusted connection", func() bool {
+		return node.OutgoingCount() == 1
+	})
+
+	tx := spendTx(coinbase, 0, p2pkhScript(1))
+	txid := *tx.TxHash()
+	if err := trusted.AnnounceTx(tx); err != nil {
+		test.Fatalf("Failed to announce tx : %s", err)
+	}
+
+	waitFor(test, "tx", func() bool {
+		return handler.tx(txid) != nil
+	})
+
+	// An untrusted peer relays a tx spending the same output.
+	doubleSpend := spendTx(coinbase, 0, p2pkhScript(2))
+	if err := untrusted.AnnounceTx(doubleSpend); err != nil {
+		test.Fatalf("Failed to announce double spend : %s", err)
+	}
+
+	waitFor(test, "unsafe update", func() bool {
+		update := handler.update(txid)
+		return update != nil && update.State.UnSafe
+	})
+}
+
+func testContext() context.Context {
+	logConfig := logger.NewConfig(true, false, "")
+	logConfig.EnableSubSystem(SubSystem)
+	return logger.ContextWithLogConfig(context.Background(), logConfig)
+}
+
+// newTestChain returns a chain built on the genesis block spynode uses for main net.
+func newTestChain(ctx context.Context, test *testing.T) *tests.Chain {
+	blocks := internalStorage.NewBlockRepository(config.Config{Net: bitcoin.MainNet},
+		storage.NewMockStorage())
+	if err := blocks.Load(ctx); err != nil {
+		test.Fatalf("Failed to load blocks : %s", err)
+	}
+
+	genesis, err := blocks.Header(ctx, 0)
+	if err != nil {
+		test.Fatalf("Failed to get genesis header : %s", err)
+	}
+
+	return tests.NewChain(genesis)
+}
+
+func startTestPeer(test *testing.T, chain *tests.Chain) *tests.Peer {
+	peer := tests.NewPeer(bitcoin.MainNet, chain)
+	if err := peer.Start(); err != nil {
+		test.Fatalf("Failed to start peer : %s", err)
+	}
+	return peer
+}
+
+// newTestNode returns a node connecting to the peer and starting at block 1 of the chain.
+func newTestNode(ctx context.Context, test *testing.T, chain *tests.Chain, peer *tests.Peer,
+	untrustedCount int) (*Node, *testHandler) {
+
+	cfg, err := config.NewConfig(bitcoin.MainNet, true, peer.Address(), "/spynode-test/",
+		chain.Hash(1).String(), untrustedCount, 100, untrustedCount, 0, 100, false)
+	if err != nil {
+		test.Fatalf("Failed to create config : %s", err)
+	}
+
+	node := NewNode(cfg, newTestStorage(test), chain, chain)
+	handler := newTestHandler()
+	node.RegisterHandler(handler)
+	return node, handler
+}
+
+// newTestStorage returns empty filesystem storage for the test. Mock storage can't be used
+//   because the node accesses storage from many goroutines.
+func newTestStorage(test *testing.T) storage.Storage {
+	path := filepath.Join("./tmp", test.Name())
+	if err := os.RemoveAll(path); err != nil {
+		test.Fatalf("Failed to remove storage : %s", err)
+	}
+	test.Cleanup(func() { os.RemoveAll(path) })
+
+	return storage.NewFilesystemStorage(storage.NewConfig("standalone", path))
+}
+
+func runTestNode(ctx context.Context, test *testing.T, node *Node) {
+	go func() {
+		if err := node.Run(ctx); err != nil {
+			test.Errorf("Node failed : %s", err)
+		}
+	}()
+}
+
+// waitFor waits for the condition to be true, failing the test if it takes too long.
+func waitFor(test *testing.T, name string, condition func() bool) {
+	timeout := time.Now().Add(testTimeout)
+	for !condition() {
+		if time.Now().After(timeout) {
+			test.Fatalf("Timed out waiting for %s", name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// waitForSync waits for the node to sync the chain from the peer and be ready for new blocks.
+func waitForSync(ctx context.Context, test *testing.T, node *Node, handler *testHandler,
+	chain *tests.Chain, peer *tests.Peer) {
+
+	waitFor(test, "sync", func() bool {
+		return handler.isInSync() && node.LastHeight(ctx) == chain.Height() &&
+			peer.SendHeadersCount() == 1
+	})
+}
+
+// spendTx returns a tx spending an output of the previous tx to the locking script.
+func spendTx(previous *wire.MsgTx, index uint32, lockingScript []byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(previous.TxHash(), index), nil))
+	tx.AddTxOut(wire.NewTxOut(previous.TxOut[index].Value-1000, lockingScript))
+	return tx
+}
+
+// testHandler records the data sent to spynode clients.
+type testHandler struct {
+	txs     map[bitcoin.Hash32]*client.Tx
+	updates map[bitcoin.Hash32]*client.TxUpdate
+	inSync  bool
+	lock    sync.Mutex
+}
+
+func newTestHandler() *testHandler {
+	return &testHandler{
+		txs:     make(map[bitcoin.Hash32]*client.Tx),
+		updates: make(map[bitcoin.Hash32]*client.TxUpdate),
+	}
+}
+
+func (h *testHandler) HandleTx(ctx context.Context, tx *client.Tx) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.txs[*tx.Tx.TxHash()] = tx
+}
+
+func (h *testHandler) HandleTxUpdate(ctx context.Context, update *client.TxUpdate) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.updates[update.TxID] = update
+}
+
+func (h *testHandler) HandleHeaders(ctx context.Context, headers *client.Headers) {}
+
+func (h *testHandler) HandleInSync(ctx context.Context) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.inSync = true
+}
+
+func (h *testHandler) HandleMessage(ctx context.Context, payload client.MessagePayload) {}
+
+func (h *testHandler) tx(txid bitcoin.Hash32) *client.Tx {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.txs[txid]
+}
+
+func (h *testHandler) update(txid bitcoin.Hash32) *client.TxUpdate {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.updates[txid]
+}
+
+func (h *testHandler) isInSync() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.inSync
+}
@@ -128,6 +128,11 @@ func (node *Node) processUnconfirmedTx(ctx context.Context, tx handlers.TxData)
 		logger.Info(ctx, "Updating tx state : %s", hash)
 	}
 
+	// The spent outputs of relevant txs are known, so their fees can rank them for eviction.
+	if fee, ok := txFee(txState); ok {
+		node.memPool.SetFee(*hash, fee)
+	}
+
 	txState.State.Safe = tx.Safe || newlySafe
 	if txState.State.MerkleProof == nil {
 		txState.State.UnconfirmedDepth = 1
@@ -153,6 +158,29 @@ func (node *Node) processUnconfirmedTx(ctx context.Context, tx handlers.TxData)
 	return nil
 }
 
+// txFee returns the fee paid by the tx, or false if the spent outputs are not all known.
+func txFee(tx *client.Tx) (uint64, bool) {
+	if len(tx.Outputs) != len(tx.Tx.TxIn) {
+		return 0, false
+	}
+
+	var inputValue, outputValue uint64
+	for _, output := range tx.Outputs {
+		if output == nil {
+			return 0, false
+		}
+		inputValue += output.Value
+	}
+	for _, output := range tx.Tx.TxOut {
+		outputValue += output.Value
+	}
+
+	if outputValue > inputValue {
+		return 0, false
+	}
+	return inputValue - outputValue, true
+}
+
 // fetchSpentOutputs fetches the outputs spent by this tx.
 func fetchSpentOutputs(ctx context.Context, store storage.Storage, outputFetcher OutputFetcher,
 	tx *client.Tx) error {
@@ -235,53 +263,52 @@ func (node *Node) IsRelevant(ctx context.Context, tx *wire.MsgTx) bool {
 		return true
 	}
 
-	node.pushDataLock.Lock()
-	defer node.pushDataLock.Unlock()
+	if node.subscriptions.IsRelevant(tx) {
+		logger.Info(ctx, "Subscribed tx or output found : %s", tx.TxHash())
+		return true
+	}
+
+	return node.pushDatas.ContainsAny(txPushDataHashes(tx))
+}
+
+// RelevantClients returns the ids of the clients with push data subscriptions matching the tx.
+func (node *Node) RelevantClients(ctx context.Context, tx *wire.MsgTx) []string {
+	return node.pushDatas.Clients(txPushDataHashes(tx))
+}
+
+// txPushDataHashes returns the hashes of the push datas in the tx's output and input scripts.
+func txPushDataHashes(tx *wire.MsgTx) []bitcoin.Hash20 {
+	var result []bitcoin.Hash20
 
 	// Check the hashes from each output against this client.
 	for _, output := range tx.TxOut {
-		r := bytes.NewReader(output.PkScript)
-		for {
-			_, pushdata, err := bitcoin.ParsePushDataScript(r)
-			if err != nil {
-				if err == bitcoin.ErrNotPushOp { // ignore non push op codes
-					continue
-				}
-				break
-			}
-
-			hash := pushDataToHash(pushdata)
-			for _, pd := range node.pushDataHashes {
-				if pd.Equal(&hash) {
-					return true
-				}
-			}
-		}
+		result = appendScriptPushDataHashes(result, output.PkScript)
 	}
 
 	// Note: To support P2PK addresses we would need to track UTXOs since the signature scripts
 	// would only be the signature.
 	for _, input := range tx.TxIn {
-		r := bytes.NewReader(input.SignatureScript)
-		for {
-			_, pushdata, err := bitcoin.ParsePushDataScript(r)
-			if err != nil {
-				if err == bitcoin.ErrNotPushOp { // ignore non push op codes
-					continue
-				}
-				break
-			}
+		result = appendScriptPushDataHashes(result, input.SignatureScript)
+	}
+
+	return result
+}
 
-			hash := pushDataToHash(pushdata)
-			for _, pd := range node.pushDataHashes {
-				if pd.Equal(&hash) {
-					return true
-				}
+func appendScriptPushDataHashes(hashes []bitcoin.Hash20, script []byte) []bitcoin.Hash20 {
+	r := bytes.NewReader(script)
+	for {
+		_, pushdata, err := bitcoin.ParsePushDataScript(r)
+		if err != nil {
+			if err == bitcoin.ErrNotPushOp { // ignore non push op codes
+				continue
 			}
+			break
 		}
+
+		hashes = append(hashes, pushDataToHash(pushdata))
 	}
 
-	return false
+	return hashes
 }
 
 func pushDataToHash(b []byte) bitcoin.Hash20 {
@@ -295,6 +322,15 @@ func pushDataToHash(b []byte) bitcoin.Hash20 {
 	return hash
 }
 
+func pushDatasToHashes(pushDatas [][]byte) []bitcoin.Hash20 {
+	result := make([]bitcoin.Hash20, 0, len(pushDatas))
+	for _, pd := range pushDatas {
+		result = append(result, pushDataToHash(pd))
+	}
+
+	return result
+}
+
 // checkContracts returns true if the tx contains a Tokenized "contract wide" op return.
 // This includes contract formations and asset creations so can be used to index contract and asset
 // information.
@@ -0,0 +1,155 @@
+package spynode
+
+import (
+	"context"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/spynode/internal/platform/config"
+)
+
+// p2pkhScript returns a P2PKH locking script for a hash derived from i.
+func p2pkhScript(i int) []byte {
+	var hash [20]byte
+	binary.LittleEndian.PutUint64(hash[:], uint64(i))
+
+	script := []byte{0x76, 0xa9, 0x14}
+	script = append(script, hash[:]...)
+	return append(script, 0x88, 0xac)
+}
+
+func TestIsRelevantPushDatas(t *testing.T) {
+	ctx := context.Background()
+	node := NewNode(config.Config{}, storage.NewMockStorage(), nil, nil)
+
+	tx := wire.NewMsgTx(1)
+	tx.AddTxOut(wire.NewTxOut(1000, p2pkhScript(1)))
+
+	if node.IsRelevant(ctx, tx) {
+		t.Errorf("Tx should not be relevant before subscribing")
+	}
+
+	if err := node.SubscribePushDatas(ctx, [][]byte{p2pkhScript(1)[3:23]}); err != nil {
+		t.Fatalf("Failed to subscribe : %v", err)
+	}
+	if !node.IsRelevant(ctx, tx) {
+		t.Errorf("Tx should be relevant after subscribing")
+	}
+
+	if err := node.UnsubscribePushDatas(ctx, [][]byte{p2pkhScript(1)[3:23]}); err != nil {
+		t.Fatalf("Failed to unsubscribe : %v", err)
+	}
+	if node.IsRelevant(ctx, tx) {
+		t.Errorf("Tx should not be relevant after unsubscribing")
+	}
+}
+
+func TestRelevantClients(t *testing.T) {
+	ctx := context.Background()
+	node := NewNode(config.Config{}, storage.NewMockStorage(), nil, nil)
+
+	tx1 := wire.NewMsgTx(1)
+	tx1.AddTxOut(wire.NewTxOut(1000, p2pkhScript(1)))
+	tx2 := wire.NewMsgTx(1)
+	tx2.AddTxOut(wire.NewTxOut(1000, p2pkhScript(2)))
+
+	if err := node.SubscribeClientPushDatas(ctx, "client 1",
+		[][]byte{p2pkhScript(1)[3:23], p2pkhScript(2)[3:23]}); err != nil {
+		t.Fatalf("Failed to subscribe : %v", err)
+	}
+	if err := node.SubscribeClientPushDatas(ctx, "client 2",
+		[][]byte{p2pkhScript(2)[3:23]}); err != nil {
+		t.Fatalf("Failed to subscribe : %v", err)
+	}
+
+	if clients := node.RelevantClients(ctx, tx1); !reflect.DeepEqual(clients, []string{"client 1"}) {
+		t.Errorf("Wrong relevant clients for tx 1 : %v", clients)
+	}
+	if clients := node.RelevantClients(ctx, tx2); !reflect.DeepEqual(clients,
+		[]string{"client 1", "client 2"}) {
+		t.Errorf("Wrong relevant clients for tx 2 : %v", clients)
+	}
+
+	// Unsubscribing one client leaves the other's filter in place.
+	if err := node.UnsubscribeClientPushDatas(ctx, "client 1",
+		[][]byte{p2pkhScript(2)[3:23]}); err != nil {
+		t.Fatalf("Failed to unsubscribe : %v", err)
+	}
+	if clients := node.RelevantClients(ctx, tx2); !reflect.DeepEqual(clients, []string{"client 2"}) {
+		t.Errorf("Wrong relevant clients after unsubscribe : %v", clients)
+	}
+	if !node.IsRelevant(ctx, tx1) {
+		t.Errorf("Tx 1 should still be relevant to client 1")
+	}
+
+	if err := node.UnsubscribeClient(ctx, "client 1"); err != nil {
+		t.Fatalf("Failed to unsubscribe client : %v", err)
+	}
+	if clients := node.RelevantClients(ctx, tx1); len(clients) != 0 {
+		t.Errorf("Wrong relevant clients after removing client : %v", clients)
+	}
+	if node.IsRelevant(ctx, tx1) {
+		t.Errorf("Tx 1 should not be relevant after removing client 1")
+	}
+	if !node.IsRelevant(ctx, tx2) {
+		t.Errorf("Tx 2 should still be relevant to client 2")
+	}
+}
+
+// BenchmarkIsRelevant measures filtering a block of txs against many subscribed addresses, which
+//   is the main cost of block processing.
+func BenchmarkIsRelevant(b *testing.B) {
+	const (
+		subscriptionCount = 50000
+		blockTxCount      = 1000
+	)
+
+	ctx := context.Background()
+	node := NewNode(config.Config{}, storage.NewMockStorage(), nil, nil)
+
+	pushDatas := make([][]byte, 0, subscriptionCount)
+	for i := 0; i < subscriptionCount; i++ {
+		pushDatas = append(pushDatas, p2pkhScript(i)[3:23])
+	}
+	if err := node.SubscribePushDatas(ctx, pushDatas); err != nil {
+		b.Fatalf("Failed to subscribe : %v", err)
+	}
+
+	// A signature and public key push.
+	unlockingScript := append([]byte{72}, make([]byte, 72)...)
+	unlockingScript = append(unlockingScript, 33)
+	unlockingScript = append(unlockingScript, make([]byte, 33)...)
+
+	// Every tenth tx pays a subscribed address.
+	txs := make([]*wire.MsgTx, 0, blockTxCount)
+	for i := 0; i < blockTxCount; i++ {
+		tx := wire.NewMsgTx(1)
+		var hash bitcoin.Hash32
+		binary.LittleEndian.PutUint64(hash[:], uint64(i))
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&hash, 0), unlockingScript))
+		tx.AddTxOut(wire.NewTxOut(1000, p2pkhScript(subscriptionCount+i)))
+		if i%10 == 0 {
+			tx.AddTxOut(wire.NewTxOut(1000, p2pkhScript(i)))
+		} else {
+			tx.AddTxOut(wire.NewTxOut(1000, p2pkhScript(2*subscriptionCount+i)))
+		}
+		txs = append(txs, tx)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		relevant := 0
+		for _, tx := range txs {
+			if node.IsRelevant(ctx, tx) {
+				relevant++
+			}
+		}
+		if relevant != blockTxCount/10 {
+			b.Fatalf("Wrong relevant count : got %d, want %d", relevant, blockTxCount/10)
+		}
+	}
+}
@@ -2,28 +2,62 @@ package state
 
 import (
 	"context"
+	"encoding/binary"
+	"io"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	memPoolVersion = uint8(0)
+
+	// Approximate memory used by a tx in the mempool and by each of its inputs, including the
+	//   entries in the lookup maps.
+	memPoolTxMemory    = 128
+	memPoolInputMemory = 112
+
+	// When the mempool is over its maximum size, txs are evicted until it is below this percent
+	//   of the maximum so that every new tx doesn't cause another eviction.
+	memPoolEvictPercent = 90
 )
 
 // MemPool is used for managing announced transactions that haven't confirmed yet.
-// The mempool is non-persistent and is mainly used to prevent duplicate tx requests.
+// The mempool is mainly used to prevent duplicate tx requests and to find conflicting txs (double
+//   spends). It can be serialized so that the conflicts are still found after a restart.
+// When a maximum size is set the txs with the lowest fee rates are evicted when the mempool grows
+//   past it. Fees are only known for txs that have had them set, so other txs are evicted first.
 type MemPool struct {
 	txs      map[bitcoin.Hash32]*memPoolTx       // Lookup of block height by hash.
 	inputs   map[bitcoin.Hash32][]bitcoin.Hash32 // Lookup by hash of outpoint. Used to find conflicting inputs.
 	requests map[bitcoin.Hash32]time.Time        // Transactions that have been requested
+	size     uint64                              // Approximate memory used by txs
+	maxSize  uint64                              // Zero for no limit
+	evicted  uint64                              // Count of txs evicted
 	mutex    sync.Mutex
 }
 
-// NewMemPool returns a new MemPool.
-func NewMemPool() *MemPool {
+// MemPoolStats is a summary of the mempool for monitoring.
+type MemPoolStats struct {
+	Count     int    // Number of txs
+	Size      uint64 // Approximate memory used by txs
+	Conflicts int    // Number of txs that conflict with at least one other tx
+	Evicted   uint64 // Number of txs evicted because the mempool was full
+}
+
+// NewMemPool returns a new MemPool. maxSize is the approximate memory in bytes that the txs can
+//   use before some are evicted. Zero means no limit.
+func NewMemPool(maxSize uint64) *MemPool {
 	result := MemPool{
 		txs:      make(map[bitcoin.Hash32]*memPoolTx),
 		inputs:   make(map[bitcoin.Hash32][]bitcoin.Hash32),
 		requests: make(map[bitcoin.Hash32]time.Time),
+		maxSize:  maxSize,
 	}
 	return &result
 }
@@ -97,6 +131,19 @@ func (memPool *MemPool) AddTransaction(ctx context.Context, tx *wire.MsgTx,
 
 	// Add outpoints to mempool tx
 	memTx.populateMemPoolTx(tx)
+	conflicts = memPool.addInputs(*hash, memTx)
+
+	if memPool.maxSize > 0 && memPool.size > memPool.maxSize {
+		memPool.evict(memPool.maxSize * memPoolEvictPercent / 100)
+	}
+
+	return conflicts, trusted, true
+}
+
+// addInputs adds the inputs of a populated tx to the conflict lookup and returns the txs that
+//   already spend any of them.
+func (memPool *MemPool) addInputs(hash bitcoin.Hash32, memTx *memPoolTx) []bitcoin.Hash32 {
+	conflicts := []bitcoin.Hash32{}
 
 	// Add inputs while checking for conflicts
 	for _, outpoint := range memTx.outPoints {
@@ -106,20 +153,106 @@ func (memPool *MemPool) AddTransaction(ctx context.Context, tx *wire.MsgTx,
 			// Append conflicting
 			// It is possible tx conflict on more than one input and we don't want duplicates in
 			//   the conflicts list.
-			appendIfNotContained(conflicts, list)
-			list = append(list, *hash)
+			conflicts = appendIfNotContained(conflicts, list)
+			memPool.inputs[*outpointHash] = append(list, hash)
 		} else {
 			// Create new list with only this tx hash
-			list := []bitcoin.Hash32{*hash}
+			list := []bitcoin.Hash32{hash}
 			memPool.inputs[*outpointHash] = list
 		}
 	}
 
-	return conflicts, trusted, true
+	memPool.size += memTx.memory()
+	return conflicts
+}
+
+// SetFee sets the fee paid by a tx in the mempool so it can be ranked by fee rate for eviction.
+func (memPool *MemPool) SetFee(txid bitcoin.Hash32, fee uint64) {
+	memPool.mutex.Lock()
+	defer memPool.mutex.Unlock()
+
+	if memTx, exists := memPool.txs[txid]; exists {
+		memTx.fee = fee
+	}
+}
+
+// Expire removes txs and requests that were first seen before the specified time.
+// Returns the number of txs removed.
+func (memPool *MemPool) Expire(ctx context.Context, beforeTime time.Time) int {
+	memPool.mutex.Lock()
+	defer memPool.mutex.Unlock()
+
+	result := 0
+	for hash, memTx := range memPool.txs {
+		if memTx.time.Before(beforeTime) && memPool.removeTransaction(hash) {
+			result++
+		}
+	}
+
+	for hash, requestTime := range memPool.requests {
+		if requestTime.Before(beforeTime) {
+			delete(memPool.requests, hash)
+		}
+	}
+
+	return result
+}
+
+// evict removes the txs with the lowest fee rates, oldest first when the fee rates are the same,
+//   until the mempool is no larger than the target size.
+func (memPool *MemPool) evict(targetSize uint64) {
+	hashes := make([]bitcoin.Hash32, 0, len(memPool.txs))
+	for hash, memTx := range memPool.txs {
+		if len(memTx.outPoints) > 0 {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return memPool.txs[hashes[i]].evictBefore(memPool.txs[hashes[j]])
+	})
+
+	for _, hash := range hashes {
+		if memPool.size <= targetSize {
+			break
+		}
+
+		memPool.removeTransaction(hash)
+		memPool.evicted++
+	}
+}
+
+// Stats returns a summary of the mempool.
+func (memPool *MemPool) Stats() MemPoolStats {
+	memPool.mutex.Lock()
+	defer memPool.mutex.Unlock()
+
+	result := MemPoolStats{
+		Size:    memPool.size,
+		Evicted: memPool.evicted,
+	}
+
+	for _, memTx := range memPool.txs {
+		if len(memTx.outPoints) > 0 {
+			result.Count++
+		}
+	}
+
+	conflicted := make(map[bitcoin.Hash32]bool)
+	for _, list := range memPool.inputs {
+		if len(list) > 1 {
+			for _, hash := range list {
+				conflicted[hash] = true
+			}
+		}
+	}
+	result.Conflicts = len(conflicted)
+
+	return result
 }
 
 // Appends the items in add to list if they are not already in list
-func appendIfNotContained(list []bitcoin.Hash32, add []bitcoin.Hash32) {
+func appendIfNotContained(list []bitcoin.Hash32, add []bitcoin.Hash32) []bitcoin.Hash32 {
 	for _, addHash := range add {
 		found := false
 		for _, hash := range list {
@@ -133,6 +266,8 @@ func appendIfNotContained(list []bitcoin.Hash32, add []bitcoin.Hash32) {
 			list = append(list, addHash)
 		}
 	}
+
+	return list
 }
 
 // Removes a tx hash from the mempool
@@ -160,20 +295,26 @@ func (memPool *MemPool) removeTransaction(hash bitcoin.Hash32) bool {
 			outpointHash := outpoint.OutpointHash()
 			otherHashes, exists := memPool.inputs[*outpointHash]
 			if exists { // It should always exist
-				if len(otherHashes) > 1 {
-					// Remove this outpoint hash from the list
-					for i, otherHash := range otherHashes {
-						if otherHash.Equal(outpointHash) {
-							otherHashes = append(otherHashes[:i], otherHashes[i+1:]...)
-							break
-						}
+				// Remove this tx hash from the list
+				for i, otherHash := range otherHashes {
+					if otherHash.Equal(&hash) {
+						otherHashes = append(otherHashes[:i], otherHashes[i+1:]...)
+						break
 					}
-				} else {
+				}
+
+				if len(otherHashes) == 0 {
 					delete(memPool.inputs, *outpointHash)
+				} else {
+					memPool.inputs[*outpointHash] = otherHashes
 				}
 			}
 		}
 
+		if hadOutpoints {
+			memPool.size -= tx.memory()
+		}
+
 		// Remove tx
 		delete(memPool.txs, hash)
 		return hadOutpoints
@@ -218,6 +359,8 @@ func (memPool *MemPool) Conflicting(tx *wire.MsgTx) []bitcoin.Hash32 {
 	// Check for conflicting inputs
 	for _, input := range tx.TxIn {
 		if list, exists := memPool.inputs[*input.PreviousOutPoint.OutpointHash()]; exists {
+			// Copy the list because removing the txs modifies it.
+			list = append([]bitcoin.Hash32{}, list...)
 			for _, hash := range list {
 				result = append(result, hash)
 				memPool.removeTransaction(hash)
@@ -227,10 +370,95 @@ func (memPool *MemPool) Conflicting(tx *wire.MsgTx) []bitcoin.Hash32 {
 	return result
 }
 
+// Serialize writes the txs in the mempool, and so the conflicts between them, to a writer.
+//   Pending requests are not included.
+func (memPool *MemPool) Serialize(w io.Writer) error {
+	memPool.mutex.Lock()
+	defer memPool.mutex.Unlock()
+
+	if err := binary.Write(w, binary.LittleEndian, memPoolVersion); err != nil {
+		return errors.Wrap(err, "version")
+	}
+
+	// Write in the order the txs were seen so the conflict lists are rebuilt in the same order.
+	hashes := make([]bitcoin.Hash32, 0, len(memPool.txs))
+	for hash, memTx := range memPool.txs {
+		if len(memTx.outPoints) > 0 {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return memPool.txs[hashes[i]].time.Before(memPool.txs[hashes[j]].time)
+	})
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(hashes))); err != nil {
+		return errors.Wrap(err, "tx count")
+	}
+
+	for _, hash := range hashes {
+		if err := hash.Serialize(w); err != nil {
+			return errors.Wrap(err, "hash")
+		}
+		if err := memPool.txs[hash].serialize(w); err != nil {
+			return errors.Wrapf(err, "tx %s", hash)
+		}
+	}
+
+	return nil
+}
+
+// Deserialize replaces the txs in the mempool with those read from a reader. The mempool is then
+//   evicted down to its maximum size if it is over.
+func (memPool *MemPool) Deserialize(r io.Reader) error {
+	memPool.mutex.Lock()
+	defer memPool.mutex.Unlock()
+
+	var version uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return errors.Wrap(err, "version")
+	}
+	if version != memPoolVersion {
+		return errors.New("Unknown mempool version")
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return errors.Wrap(err, "tx count")
+	}
+
+	memPool.txs = make(map[bitcoin.Hash32]*memPoolTx)
+	memPool.inputs = make(map[bitcoin.Hash32][]bitcoin.Hash32)
+	memPool.requests = make(map[bitcoin.Hash32]time.Time)
+	memPool.size = 0
+
+	for i := uint32(0); i < count; i++ {
+		var hash bitcoin.Hash32
+		if err := hash.Deserialize(r); err != nil {
+			return errors.Wrapf(err, "tx %d hash", i)
+		}
+
+		memTx := &memPoolTx{}
+		if err := memTx.deserialize(r); err != nil {
+			return errors.Wrapf(err, "tx %d", i)
+		}
+
+		memPool.txs[hash] = memTx
+		memPool.addInputs(hash, memTx)
+	}
+
+	if memPool.maxSize > 0 && memPool.size > memPool.maxSize {
+		memPool.evict(memPool.maxSize * memPoolEvictPercent / 100)
+	}
+
+	return nil
+}
+
 type memPoolTx struct {
 	time      time.Time
 	outPoints []wire.OutPoint
 	trusted   bool
+	size      uint32 // Size of the tx in bytes
+	fee       uint64 // Zero when not known
 }
 
 func newMemPoolTx(t time.Time, trusted bool) *memPoolTx {
@@ -250,4 +478,91 @@ func (tx *memPoolTx) populateMemPoolTx(txMsg *wire.MsgTx) {
 	for _, input := range txMsg.TxIn {
 		tx.outPoints = append(tx.outPoints, input.PreviousOutPoint)
 	}
+	tx.size = uint32(txMsg.SerializeSize())
+}
+
+// memory returns the approximate memory used by the tx in the mempool.
+func (tx *memPoolTx) memory() uint64 {
+	return memPoolTxMemory + uint64(len(tx.outPoints))*memPoolInputMemory
+}
+
+// evictBefore returns true if the tx should be evicted before the other tx because it has a
+//   lower fee rate, or the same fee rate and was seen earlier.
+func (tx *memPoolTx) evictBefore(other *memPoolTx) bool {
+	// Compare fee / size without division.
+	rate := tx.fee * uint64(other.size)
+	otherRate := other.fee * uint64(tx.size)
+	if rate != otherRate {
+		return rate < otherRate
+	}
+
+	return tx.time.Before(other.time)
+}
+
+func (tx memPoolTx) serialize(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, tx.time.UnixNano()); err != nil {
+		return errors.Wrap(err, "time")
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, tx.trusted); err != nil {
+		return errors.Wrap(err, "trusted")
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, tx.size); err != nil {
+		return errors.Wrap(err, "size")
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, tx.fee); err != nil {
+		return errors.Wrap(err, "fee")
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(tx.outPoints))); err != nil {
+		return errors.Wrap(err, "outpoint count")
+	}
+	for _, outpoint := range tx.outPoints {
+		if err := outpoint.Hash.Serialize(w); err != nil {
+			return errors.Wrap(err, "outpoint hash")
+		}
+		if err := binary.Write(w, binary.LittleEndian, outpoint.Index); err != nil {
+			return errors.Wrap(err, "outpoint index")
+		}
+	}
+
+	return nil
+}
+
+func (tx *memPoolTx) deserialize(r io.Reader) error {
+	var nanoseconds int64
+	if err := binary.Read(r, binary.LittleEndian, &nanoseconds); err != nil {
+		return errors.Wrap(err, "time")
+	}
+	tx.time = time.Unix(0, nanoseconds)
+
+	if err := binary.Read(r, binary.LittleEndian, &tx.trusted); err != nil {
+		return errors.Wrap(err, "trusted")
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &tx.size); err != nil {
+		return errors.Wrap(err, "size")
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &tx.fee); err != nil {
+		return errors.Wrap(err, "fee")
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return errors.Wrap(err, "outpoint count")
+	}
+	tx.outPoints = make([]wire.OutPoint, count)
+	for i := range tx.outPoints {
+		if err := tx.outPoints[i].Hash.Deserialize(r); err != nil {
+			return errors.Wrapf(err, "outpoint %d hash", i)
+		}
+		if err := binary.Read(r, binary.LittleEndian, &tx.outPoints[i].Index); err != nil {
+			return errors.Wrapf(err, "outpoint %d index", i)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,135 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+)
+
+func TestMemPoolConflicts(test *testing.T) {
+	ctx := context.Background()
+	memPool := NewMemPool(0)
+
+	var spentHash bitcoin.Hash32
+	spentHash[0] = 1
+	spent := wire.NewOutPoint(&spentHash, 0)
+
+	txA := testSpendTx(spent, 1000)
+	txB := testSpendTx(spent, 900)
+	txC := testSpendTx(spent, 800)
+
+	if conflicts, _, added := memPool.AddTransaction(ctx, txA, true); !added ||
+		len(conflicts) != 0 {
+		test.Fatalf("Wrong first tx result : added %t, %d conflicts", added, len(conflicts))
+	}
+
+	conflicts, _, _ := memPool.AddTransaction(ctx, txB, false)
+	testCheckHashes(test, "second tx conflicts", conflicts, txA.TxHash())
+
+	conflicts, _, _ = memPool.AddTransaction(ctx, txC, false)
+	testCheckHashes(test, "third tx conflicts", conflicts, txA.TxHash(), txB.TxHash())
+
+	stats := memPool.Stats()
+	if stats.Count != 3 || stats.Conflicts != 3 {
+		test.Errorf("Wrong stats : %d txs, %d conflicts", stats.Count, stats.Conflicts)
+	}
+
+	// A removed tx is no longer a conflict.
+	if !memPool.RemoveTransaction(*txA.TxHash()) {
+		test.Fatalf("Failed to remove tx")
+	}
+
+	conflicts, _, _ = memPool.AddTransaction(ctx, testSpendTx(spent, 700), false)
+	testCheckHashes(test, "conflicts after remove", conflicts, txB.TxHash(), txC.TxHash())
+
+	// Confirming a tx removes the txs that conflict with it.
+	conflicting := memPool.Conflicting(testSpendTx(spent, 600))
+	if len(conflicting) != 3 {
+		test.Errorf("Wrong conflicting count : got %d, want %d", len(conflicting), 3)
+	}
+
+	stats = memPool.Stats()
+	if stats.Count != 0 || stats.Conflicts != 0 || stats.Size != 0 {
+		test.Errorf("Wrong stats after confirm : %d txs, %d conflicts, %d bytes", stats.Count,
+			stats.Conflicts, stats.Size)
+	}
+}
+
+func TestMemPoolEvict(test *testing.T) {
+	ctx := context.Background()
+
+	// Room for 4 txs with one input each.
+	memPool := NewMemPool(4 * (memPoolTxMemory + memPoolInputMemory))
+
+	var txs []*wire.MsgTx
+	for i := 0; i < 5; i++ {
+		var spentHash bitcoin.Hash32
+		spentHash[0] = byte(i)
+		tx := testSpendTx(wire.NewOutPoint(&spentHash, 0), 1000)
+		txs = append(txs, tx)
+
+		memPool.AddTransaction(ctx, tx, true)
+		if i == 3 {
+			memPool.SetFee(*txs[0].TxHash(), 1000)
+			memPool.SetFee(*txs[2].TxHash(), 500)
+		}
+		time.Sleep(time.Millisecond) // Make sure each tx is seen at a different time
+	}
+
+	// The oldest txs without known fees are evicted first.
+	for i, want := range []bool{true, false, true, false, true} {
+		if exists := memPool.TransactionExists(txs[i].TxHash()); exists != want {
+			test.Errorf("Wrong tx %d exists : got %t, want %t", i, exists, want)
+		}
+	}
+
+	if stats := memPool.Stats(); stats.Evicted != 2 {
+		test.Errorf("Wrong evicted count : got %d, want %d", stats.Evicted, 2)
+	}
+}
+
+func TestMemPoolExpire(test *testing.T) {
+	ctx := context.Background()
+	memPool := NewMemPool(0)
+
+	var spentHash bitcoin.Hash32
+	tx := testSpendTx(wire.NewOutPoint(&spentHash, 0), 1000)
+	memPool.AddTransaction(ctx, tx, true)
+
+	if expired := memPool.Expire(ctx, time.Now().Add(-time.Hour)); expired != 0 {
+		test.Errorf("Wrong expired count before max age : got %d, want %d", expired, 0)
+	}
+
+	if expired := memPool.Expire(ctx, time.Now().Add(time.Second)); expired != 1 {
+		test.Errorf("Wrong expired count after max age : got %d, want %d", expired, 1)
+	}
+
+	if memPool.TransactionExists(tx.TxHash()) {
+		test.Errorf("Expired tx still exists")
+	}
+}
+
+func testSpendTx(outpoint *wire.OutPoint, value uint64) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(outpoint, nil))
+	tx.AddTxOut(wire.NewTxOut(value, []byte{0x51}))
+	return tx
+}
+
+func testCheckHashes(test *testing.T, name string, got []bitcoin.Hash32,
+	want ...*bitcoin.Hash32) {
+
+	if len(got) != len(want) {
+		test.Errorf("Wrong %s count : got %d, want %d", name, len(got), len(want))
+		return
+	}
+
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			test.Errorf("Wrong %s %d : got %s, want %s", name, i, got[i], want[i])
+		}
+	}
+}
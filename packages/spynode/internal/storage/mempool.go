@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/spynode/internal/state"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	memPoolPath = "spynode/mempool"
+)
+
+// SaveMemPool saves a snapshot of the mempool to storage.
+func SaveMemPool(ctx context.Context, store storage.Storage, memPool *state.MemPool) error {
+	var buf bytes.Buffer
+	if err := memPool.Serialize(&buf); err != nil {
+		return errors.Wrap(err, "serialize")
+	}
+
+	if err := store.Write(ctx, memPoolPath, buf.Bytes(), nil); err != nil {
+		return errors.Wrap(err, "write")
+	}
+
+	return nil
+}
+
+// LoadMemPool loads the last snapshot of the mempool from storage.
+func LoadMemPool(ctx context.Context, store storage.Storage, memPool *state.MemPool) error {
+	b, err := store.Read(ctx, memPoolPath)
+	if err == storage.ErrNotFound {
+		logger.Verbose(ctx, "No mempool to load")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "read")
+	}
+
+	if err := memPool.Deserialize(bytes.NewReader(b)); err != nil {
+		return errors.Wrap(err, "deserialize")
+	}
+
+	stats := memPool.Stats()
+	logger.Verbose(ctx, "Loaded %d mempool txs with %d conflicting", stats.Count,
+		stats.Conflicts)
+	return nil
+}
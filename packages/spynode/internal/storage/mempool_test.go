@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/spynode/internal/state"
+)
+
+func TestMemPool(test *testing.T) {
+	ctx := context.Background()
+	storageConfig := storage.NewConfig("standalone", "./tmp/test")
+	store := storage.NewFilesystemStorage(storageConfig)
+
+	// Remove any previous data
+	store.Remove(ctx, memPoolPath)
+
+	spendTx := func(outpoint *wire.OutPoint, value uint64) *wire.MsgTx {
+		tx := wire.NewMsgTx(1)
+		tx.AddTxIn(wire.NewTxIn(outpoint, nil))
+		tx.AddTxOut(wire.NewTxOut(value, []byte{0x51}))
+		return tx
+	}
+
+	var spentHash bitcoin.Hash32
+	spentHash[0] = 1
+	spent := wire.NewOutPoint(&spentHash, 0)
+
+	memPool := state.NewMemPool(0)
+
+	// Loading without a snapshot leaves the mempool empty.
+	if err := LoadMemPool(ctx, store, memPool); err != nil {
+		test.Fatalf("Failed to load empty : %v", err)
+	}
+
+	txA := spendTx(spent, 1000)
+	txB := spendTx(spent, 900)
+	memPool.AddTransaction(ctx, txA, true)
+	time.Sleep(time.Millisecond) // Saved in the order seen
+	memPool.AddTransaction(ctx, txB, false)
+
+	if err := SaveMemPool(ctx, store, memPool); err != nil {
+		test.Fatalf("Failed to save : %v", err)
+	}
+
+	// Reload to check the txs and their conflicts were saved.
+	memPool = state.NewMemPool(0)
+	if err := LoadMemPool(ctx, store, memPool); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+
+	stats := memPool.Stats()
+	if stats.Count != 2 || stats.Conflicts != 2 {
+		test.Errorf("Wrong loaded stats : %d txs, %d conflicts", stats.Count, stats.Conflicts)
+	}
+
+	if !memPool.IsTrusted(ctx, *txA.TxHash()) {
+		test.Errorf("Trusted tx should still be trusted")
+	}
+	if memPool.IsTrusted(ctx, *txB.TxHash()) {
+		test.Errorf("Untrusted tx should not be trusted")
+	}
+
+	conflicts, _, added := memPool.AddTransaction(ctx, spendTx(spent, 800), false)
+	if !added {
+		test.Fatalf("Failed to add new tx")
+	}
+	if len(conflicts) != 2 {
+		test.Fatalf("Wrong conflict count : got %d, want %d", len(conflicts), 2)
+	}
+	if !conflicts[0].Equal(txA.TxHash()) || !conflicts[1].Equal(txB.TxHash()) {
+		test.Errorf("Wrong conflicts : got %s, want %s, %s", conflicts, txA.TxHash(),
+			txB.TxHash())
+	}
+
+	if _, _, added := memPool.AddTransaction(ctx, txA, true); added {
+		test.Errorf("Loaded tx should already be in the mempool")
+	}
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/pkg/storage"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	pushDatasPath    = "spynode/pushdatas"
+	pushDatasVersion = uint8(0)
+)
+
+// PushDataRepository is an index of the push data hashes that clients have subscribed to. Each
+//   client has its own set of hashes, identified by a client id. The embedded client uses an
+//   empty client id.
+// Each hash is saved to storage under its own key with the ids of the clients subscribed to it,
+//   so subscriptions are kept across restarts and a change only writes the hashes it touches.
+type PushDataRepository struct {
+	store storage.Storage
+
+	// hashes maps each subscribed hash to the ids of the clients subscribed to it.
+	hashes map[bitcoin.Hash20]map[string]bool
+
+	// clients maps each client id to the hashes it is subscribed to.
+	clients map[string]map[bitcoin.Hash20]bool
+
+	lock sync.RWMutex
+}
+
+// NewPushDataRepository returns a new PushDataRepository.
+func NewPushDataRepository(store storage.Storage) *PushDataRepository {
+	return &PushDataRepository{
+		store:   store,
+		hashes:  make(map[bitcoin.Hash20]map[string]bool),
+		clients: make(map[string]map[bitcoin.Hash20]bool),
+	}
+}
+
+// Load loads the subscriptions from storage.
+func (repo *PushDataRepository) Load(ctx context.Context) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.hashes = make(map[bitcoin.Hash20]map[string]bool)
+	repo.clients = make(map[string]map[bitcoin.Hash20]bool)
+
+	keys, err := repo.store.List(ctx, pushDatasPath)
+	if err != nil {
+		return errors.Wrap(err, "list")
+	}
+
+	for _, key := range keys {
+		hash, err := bitcoin.NewHash20FromStr(strings.TrimPrefix(key, pushDatasPath+"/"))
+		if err != nil {
+			return errors.Wrapf(err, "parse key %s", key)
+		}
+
+		data, err := repo.store.Read(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "read %s", key)
+		}
+
+		clientIDs, err := deserializeClientIDs(data)
+		if err != nil {
+			return errors.Wrapf(err, "client ids %s", key)
+		}
+
+		for _, clientID := range clientIDs {
+			repo.add(clientID, *hash)
+		}
+	}
+
+	logger.Verbose(ctx, "Loaded %d push data subscriptions for %d clients", len(repo.hashes),
+		len(repo.clients))
+	return nil
+}
+
+// Add subscribes a client to the hashes.
+func (repo *PushDataRepository) Add(ctx context.Context, clientID string,
+	hashes []bitcoin.Hash20) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	for _, hash := range hashes {
+		if repo.clients[clientID][hash] {
+			continue
+		}
+
+		repo.add(clientID, hash)
+		if err := repo.save(ctx, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove unsubscribes a client from the hashes.
+func (repo *PushDataRepository) Remove(ctx context.Context, clientID string,
+	hashes []bitcoin.Hash20) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	return repo.removeHashes(ctx, clientID, hashes)
+}
+
+// RemoveClient unsubscribes a client from all of its hashes.
+func (repo *PushDataRepository) RemoveClient(ctx context.Context, clientID string) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	hashes := make([]bitcoin.Hash20, 0, len(repo.clients[clientID]))
+	for hash := range repo.clients[clientID] {
+		hashes = append(hashes, hash)
+	}
+
+	return repo.removeHashes(ctx, clientID, hashes)
+}
+
+// Count returns the number of distinct hashes subscribed to by any client.
+func (repo *PushDataRepository) Count() int {
+	repo.lock.RLock()
+	defer repo.lock.RUnlock()
+
+	return len(repo.hashes)
+}
+
+// ContainsAny returns true if any client is subscribed to any of the hashes.
+func (repo *PushDataRepository) ContainsAny(hashes []bitcoin.Hash20) bool {
+	repo.lock.RLock()
+	defer repo.lock.RUnlock()
+
+	for _, hash := range hashes {
+		if _, exists := repo.hashes[hash]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Clients returns the ids of the clients subscribed to any of the hashes, sorted.
+func (repo *PushDataRepository) Clients(hashes []bitcoin.Hash20) []string {
+	repo.lock.RLock()
+	defer repo.lock.RUnlock()
+
+	var result []string
+	found := make(map[string]bool)
+	for _, hash := range hashes {
+		for clientID := range repo.hashes[hash] {
+			if !found[clientID] {
+				found[clientID] = true
+				result = append(result, clientID)
+			}
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Clear removes all subscriptions.
+func (repo *PushDataRepository) Clear(ctx context.Context) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.hashes = make(map[bitcoin.Hash20]map[string]bool)
+	repo.clients = make(map[string]map[bitcoin.Hash20]bool)
+	return repo.store.Clear(ctx, map[string]string{"path": pushDatasPath})
+}
+
+func (repo *PushDataRepository) add(clientID string, hash bitcoin.Hash20) {
+	client, exists := repo.clients[clientID]
+	if !exists {
+		client = make(map[bitcoin.Hash20]bool)
+		repo.clients[clientID] = client
+	}
+	client[hash] = true
+
+	clientIDs, exists := repo.hashes[hash]
+	if !exists {
+		clientIDs = make(map[string]bool)
+		repo.hashes[hash] = clientIDs
+	}
+	clientIDs[clientID] = true
+}
+
+func (repo *PushDataRepository) removeHashes(ctx context.Context, clientID string,
+	hashes []bitcoin.Hash20) error {
+
+	for _, hash := range hashes {
+		client, exists := repo.clients[clientID]
+		if !exists || !client[hash] {
+			continue
+		}
+
+		delete(client, hash)
+		if len(client) == 0 {
+			delete(repo.clients, clientID)
+		}
+
+		clientIDs := repo.hashes[hash]
+		delete(clientIDs, clientID)
+		if len(clientIDs) == 0 {
+			delete(repo.hashes, hash)
+		}
+
+		if err := repo.save(ctx, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save writes the ids of the clients subscribed to the hash, or removes the hash when no
+//   client is subscribed to it.
+func (repo *PushDataRepository) save(ctx context.Context, hash bitcoin.Hash20) error {
+	clientIDs, exists := repo.hashes[hash]
+	if !exists {
+		err := repo.store.Remove(ctx, repo.buildPath(hash))
+		if err != nil && err != storage.ErrNotFound {
+			return errors.Wrapf(err, "remove %s", hash)
+		}
+		return nil
+	}
+
+	data, err := serializeClientIDs(clientIDs)
+	if err != nil {
+		return errors.Wrapf(err, "serialize %s", hash)
+	}
+
+	if err := repo.store.Write(ctx, repo.buildPath(hash), data, nil); err != nil {
+		return errors.Wrapf(err, "write %s", hash)
+	}
+
+	return nil
+}
+
+func (repo *PushDataRepository) buildPath(hash bitcoin.Hash20) string {
+	return pushDatasPath + "/" + hash.String()
+}
+
+func serializeClientIDs(clientIDs map[string]bool) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	if err := binary.Write(&buffer, binary.LittleEndian, pushDatasVersion); err != nil {
+		return nil, errors.Wrap(err, "version")
+	}
+
+	if err := binary.Write(&buffer, binary.LittleEndian, uint32(len(clientIDs))); err != nil {
+		return nil, errors.Wrap(err, "client count")
+	}
+
+	for clientID := range clientIDs {
+		if err := binary.Write(&buffer, binary.LittleEndian, uint32(len(clientID))); err != nil {
+			return nil, errors.Wrap(err, "client id size")
+		}
+		if _, err := buffer.Write([]byte(clientID)); err != nil {
+			return nil, errors.Wrap(err, "client id")
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// deserializeClientIDs reads the ids saved by serializeClientIDs. A hash saved without client
+//   ids is subscribed by the embedded client.
+func deserializeClientIDs(data []byte) ([]string, error) {
+	if len(data) == 0 {
+		return []string{""}, nil
+	}
+
+	reader := bytes.NewReader(data)
+
+	var version uint8
+	if err := binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return nil, errors.Wrap(err, "version")
+	}
+	if version != pushDatasVersion {
+		return nil, errors.New("Unknown push data subscriptions version")
+	}
+
+	var count uint32
+	if err := binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return nil, errors.Wrap(err, "client count")
+	}
+
+	result := make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var size uint32
+		if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+			return nil, errors.Wrapf(err, "client %d id size", i)
+		}
+		id := make([]byte, size)
+		if _, err := io.ReadFull(reader, id); err != nil {
+			return nil, errors.Wrapf(err, "client %d id", i)
+		}
+		result = append(result, string(id))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/storage"
+)
+
+func TestPushDatas(test *testing.T) {
+	ctx := context.Background()
+	storageConfig := storage.NewConfig("standalone", "./tmp/test")
+	store := storage.NewFilesystemStorage(storageConfig)
+	repo := NewPushDataRepository(store)
+
+	// Remove any previous data
+	repo.Clear(ctx)
+
+	hashes := make([]bitcoin.Hash20, 4)
+	for i := range hashes {
+		hashes[i][0] = byte(i + 1)
+	}
+
+	if err := repo.Add(ctx, "", hashes[:2]); err != nil {
+		test.Fatalf("Failed to add : %v", err)
+	}
+	if err := repo.Add(ctx, "client 1", hashes[1:3]); err != nil {
+		test.Fatalf("Failed to add : %v", err)
+	}
+
+	// Each hash is saved under its own key.
+	if keys, err := store.List(ctx, pushDatasPath); err != nil || len(keys) != 3 {
+		test.Errorf("Wrong keys : %v %v", keys, err)
+	}
+
+	// Reload to check the subscriptions were saved.
+	repo = NewPushDataRepository(store)
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+
+	if repo.Count() != 3 {
+		test.Errorf("Wrong hash count : got %d, want %d", repo.Count(), 3)
+	}
+	if repo.ContainsAny(hashes[3:]) {
+		test.Errorf("Unsubscribed hash should not be contained")
+	}
+	if !repo.ContainsAny(hashes[2:]) {
+		test.Errorf("Subscribed hash should be contained")
+	}
+
+	tests := []struct {
+		hashes  []bitcoin.Hash20
+		clients []string
+	}{
+		{hashes: hashes[:1], clients: []string{""}},
+		{hashes: hashes[1:2], clients: []string{"", "client 1"}},
+		{hashes: hashes[2:], clients: []string{"client 1"}},
+		{hashes: hashes[3:], clients: nil},
+	}
+	for i, tt := range tests {
+		if clients := repo.Clients(tt.hashes); !reflect.DeepEqual(clients, tt.clients) {
+			test.Errorf("Test %d wrong clients : got %v, want %v", i, clients, tt.clients)
+		}
+	}
+
+	// A hash stays subscribed until no client is subscribed to it.
+	if err := repo.Remove(ctx, "", hashes[:2]); err != nil {
+		test.Fatalf("Failed to remove : %v", err)
+	}
+	if repo.ContainsAny(hashes[:1]) {
+		test.Errorf("Unsubscribed hash should not be contained")
+	}
+	if !repo.ContainsAny(hashes[1:2]) {
+		test.Errorf("Hash subscribed by other client should be contained")
+	}
+	if clients := repo.Clients(hashes[1:2]); !reflect.DeepEqual(clients, []string{"client 1"}) {
+		test.Errorf("Wrong clients after remove : got %v", clients)
+	}
+
+	repo = NewPushDataRepository(store)
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+	if clients := repo.Clients(hashes); !reflect.DeepEqual(clients, []string{"client 1"}) {
+		test.Errorf("Wrong clients after reload : got %v", clients)
+	}
+
+	if err := repo.RemoveClient(ctx, "client 1"); err != nil {
+		test.Fatalf("Failed to remove client : %v", err)
+	}
+	if keys, err := store.List(ctx, pushDatasPath); err != nil || len(keys) != 0 {
+		test.Errorf("Wrong keys after removing client : %v %v", keys, err)
+	}
+
+	repo = NewPushDataRepository(store)
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+	if repo.Count() != 0 {
+		test.Errorf("Wrong hash count : got %d, want %d", repo.Count(), 0)
+	}
+}
+
+func TestPushDatasWithoutClients(test *testing.T) {
+	ctx := context.Background()
+	storageConfig := storage.NewConfig("standalone", "./tmp/test")
+	store := storage.NewFilesystemStorage(storageConfig)
+	repo := NewPushDataRepository(store)
+
+	// Remove any previous data
+	repo.Clear(ctx)
+
+	// Hashes saved without client ids belong to the embedded client.
+	var hash bitcoin.Hash20
+	hash[0] = 1
+	if err := store.Write(ctx, repo.buildPath(hash), nil, nil); err != nil {
+		test.Fatalf("Failed to write : %v", err)
+	}
+
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+	if clients := repo.Clients([]bitcoin.Hash20{hash}); !reflect.DeepEqual(clients, []string{""}) {
+		test.Errorf("Wrong clients : got %v", clients)
+	}
+}
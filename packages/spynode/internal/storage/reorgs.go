@@ -109,12 +109,13 @@ func (repo *ReorgRepository) List(ctx context.Context) ([]*Reorg, error) {
 		return nil, err
 	}
 
-	result := make([]*Reorg, len(data))
-	for i, b := range data {
-		buf := bytes.NewBuffer(b)
-		if err := result[i].Read(buf); err != nil {
+	result := make([]*Reorg, 0, len(data))
+	for _, b := range data {
+		reorg := &Reorg{}
+		if err := reorg.Read(bytes.NewBuffer(b)); err != nil {
 			return nil, err
 		}
+		result = append(result, reorg)
 	}
 
 	return result, nil
@@ -51,3 +51,46 @@ func TestReorgs(test *testing.T) {
 		test.Errorf("Active reorg doesn't match")
 	}
 }
+
+func TestReorgsList(test *testing.T) {
+	ctx := context.Background()
+	repo := NewReorgRepository(storage.NewMockStorage())
+
+	var reorgs []Reorg
+	for i := 0; i < 2; i++ {
+		reorg := Reorg{}
+		header := wire.BlockHeader{Version: 1, Nonce: uint32(i)}
+		reorg.Blocks = append(reorg.Blocks, ReorgBlock{Header: header})
+		reorgs = append(reorgs, reorg)
+
+		if err := repo.Save(ctx, &reorg); err != nil {
+			test.Fatalf("Failed to save reorg : %v", err)
+		}
+		if err := repo.ClearActive(ctx); err != nil {
+			test.Fatalf("Failed to clear active reorg : %v", err)
+		}
+	}
+
+	list, err := repo.List(ctx)
+	if err != nil {
+		test.Fatalf("Failed to list reorgs : %v", err)
+	}
+	if len(list) != len(reorgs) {
+		test.Fatalf("Wrong reorg count : got %d, want %d", len(list), len(reorgs))
+	}
+
+	for _, reorg := range reorgs {
+		hash := reorg.Id()
+		found := false
+		for _, listed := range list {
+			listedHash := listed.Id()
+			if bytes.Equal(hash[:], listedHash[:]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			test.Errorf("Reorg %x not listed", hash)
+		}
+	}
+}
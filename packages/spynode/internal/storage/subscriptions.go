@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	subscriptionsPath     = "spynode/subscriptions"
+	subscribedTxsPath     = subscriptionsPath + "/txs"
+	subscribedOutputsPath = subscriptionsPath + "/outputs"
+)
+
+// SubscriptionRepository holds the txs and outputs that clients have subscribed to. Txs with a
+//   subscribed hash, or spending a subscribed output, are relevant.
+// Each tx and output is saved to storage under its own key, so subscriptions are kept across
+//   restarts and a change only writes the txs and outputs it adds or removes.
+type SubscriptionRepository struct {
+	store   storage.Storage
+	txs     map[bitcoin.Hash32]bool
+	outputs map[wire.OutPoint]bool
+	lock    sync.Mutex
+}
+
+// NewSubscriptionRepository returns a new SubscriptionRepository.
+func NewSubscriptionRepository(store storage.Storage) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		store:   store,
+		txs:     make(map[bitcoin.Hash32]bool),
+		outputs: make(map[wire.OutPoint]bool),
+	}
+}
+
+// Load loads the subscriptions from storage.
+func (repo *SubscriptionRepository) Load(ctx context.Context) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.txs = make(map[bitcoin.Hash32]bool)
+	repo.outputs = make(map[wire.OutPoint]bool)
+
+	keys, err := repo.store.List(ctx, subscribedTxsPath)
+	if err != nil {
+		return errors.Wrap(err, "list txs")
+	}
+	for _, key := range keys {
+		txid, err := bitcoin.NewHash32FromStr(strings.TrimPrefix(key, subscribedTxsPath+"/"))
+		if err != nil {
+			return errors.Wrapf(err, "parse key %s", key)
+		}
+		repo.txs[*txid] = true
+	}
+
+	keys, err = repo.store.List(ctx, subscribedOutputsPath)
+	if err != nil {
+		return errors.Wrap(err, "list outputs")
+	}
+	for _, key := range keys {
+		outpoint, err := parseOutPointKey(strings.TrimPrefix(key, subscribedOutputsPath+"/"))
+		if err != nil {
+			return errors.Wrapf(err, "parse key %s", key)
+		}
+		repo.outputs[*outpoint] = true
+	}
+
+	logger.Verbose(ctx, "Loaded %d tx and %d output subscriptions", len(repo.txs),
+		len(repo.outputs))
+	return nil
+}
+
+// AddTx subscribes to a tx and the outputs of it specified by indexes.
+func (repo *SubscriptionRepository) AddTx(ctx context.Context, txid bitcoin.Hash32,
+	indexes []uint32) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	if !repo.txs[txid] {
+		if err := repo.store.Write(ctx, subscribedTxsPath+"/"+txid.String(), nil, nil); err != nil {
+			return errors.Wrapf(err, "write tx %s", txid)
+		}
+		repo.txs[txid] = true
+	}
+
+	for _, index := range indexes {
+		if err := repo.addOutput(ctx, wire.OutPoint{Hash: txid, Index: index}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveTx unsubscribes from a tx and the outputs of it specified by indexes.
+func (repo *SubscriptionRepository) RemoveTx(ctx context.Context, txid bitcoin.Hash32,
+	indexes []uint32) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	if repo.txs[txid] {
+		err := repo.store.Remove(ctx, subscribedTxsPath+"/"+txid.String())
+		if err != nil && err != storage.ErrNotFound {
+			return errors.Wrapf(err, "remove tx %s", txid)
+		}
+		delete(repo.txs, txid)
+	}
+
+	for _, index := range indexes {
+		if err := repo.removeOutput(ctx, wire.OutPoint{Hash: txid, Index: index}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddOutputs subscribes to the outputs.
+func (repo *SubscriptionRepository) AddOutputs(ctx context.Context,
+	outpoints []*wire.OutPoint) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	for _, outpoint := range outpoints {
+		if err := repo.addOutput(ctx, *outpoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveOutputs unsubscribes from the outputs.
+func (repo *SubscriptionRepository) RemoveOutputs(ctx context.Context,
+	outpoints []*wire.OutPoint) error {
+
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	for _, outpoint := range outpoints {
+		if err := repo.removeOutput(ctx, *outpoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsRelevant returns true if the tx is subscribed to or spends a subscribed output.
+func (repo *SubscriptionRepository) IsRelevant(tx *wire.MsgTx) bool {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	if len(repo.txs) == 0 && len(repo.outputs) == 0 {
+		return false
+	}
+
+	if repo.txs[*tx.TxHash()] {
+		return true
+	}
+
+	for _, input := range tx.TxIn {
+		if repo.outputs[input.PreviousOutPoint] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Clear removes all subscriptions.
+func (repo *SubscriptionRepository) Clear(ctx context.Context) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	repo.txs = make(map[bitcoin.Hash32]bool)
+	repo.outputs = make(map[wire.OutPoint]bool)
+	return repo.store.Remove(ctx, subscriptionsPath)
+}
+
+func (repo *SubscriptionRepository) addOutput(ctx context.Context, outpoint wire.OutPoint) error {
+	if repo.outputs[outpoint] {
+		return nil
+	}
+
+	if err := repo.store.Write(ctx, outPointPath(outpoint), nil, nil); err != nil {
+		return errors.Wrapf(err, "write output %s", outpoint)
+	}
+	repo.outputs[outpoint] = true
+	return nil
+}
+
+func (repo *SubscriptionRepository) removeOutput(ctx context.Context, outpoint wire.OutPoint) error {
+	if !repo.outputs[outpoint] {
+		return nil
+	}
+
+	err := repo.store.Remove(ctx, outPointPath(outpoint))
+	if err != nil && err != storage.ErrNotFound {
+		return errors.Wrapf(err, "remove output %s", outpoint)
+	}
+	delete(repo.outputs, outpoint)
+	return nil
+}
+
+// outPointPath returns the key of an output subscription, <txid>:<index>.
+func outPointPath(outpoint wire.OutPoint) string {
+	return subscribedOutputsPath + "/" + outpoint.String()
+}
+
+func parseOutPointKey(key string) (*wire.OutPoint, error) {
+	i := strings.LastIndex(key, ":")
+	if i == -1 {
+		return nil, errors.New("missing index")
+	}
+
+	hash, err := bitcoin.NewHash32FromStr(key[:i])
+	if err != nil {
+		return nil, errors.Wrap(err, "hash")
+	}
+
+	index, err := strconv.ParseUint(key[i+1:], 10, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "index")
+	}
+
+	return wire.NewOutPoint(hash, uint32(index)), nil
+}
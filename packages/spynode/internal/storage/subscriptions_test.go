@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/storage"
+	"github.com/tokenized/pkg/wire"
+)
+
+func TestSubscriptions(test *testing.T) {
+	ctx := context.Background()
+	storageConfig := storage.NewConfig("standalone", "./tmp/test")
+	store := storage.NewFilesystemStorage(storageConfig)
+	repo := NewSubscriptionRepository(store)
+
+	// Remove any previous data
+	repo.Clear(ctx)
+
+	subscribedTx := wire.NewMsgTx(1)
+	subscribedTx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	subscribedTx.AddTxOut(wire.NewTxOut(2000, []byte{0x51}))
+	txid := *subscribedTx.TxHash()
+
+	spendTx := func(outpoint *wire.OutPoint) *wire.MsgTx {
+		tx := wire.NewMsgTx(1)
+		tx.AddTxIn(wire.NewTxIn(outpoint, nil))
+		tx.AddTxOut(wire.NewTxOut(900, []byte{0x51}))
+		return tx
+	}
+
+	var otherHash bitcoin.Hash32
+	otherHash[0] = 1
+	otherOutpoint := wire.NewOutPoint(&otherHash, 3)
+
+	if repo.IsRelevant(subscribedTx) {
+		test.Errorf("Tx should not be relevant before subscribing")
+	}
+
+	if err := repo.AddTx(ctx, txid, []uint32{1}); err != nil {
+		test.Fatalf("Failed to add tx : %v", err)
+	}
+	if err := repo.AddOutputs(ctx, []*wire.OutPoint{otherOutpoint}); err != nil {
+		test.Fatalf("Failed to add outputs : %v", err)
+	}
+
+	// Each tx and output is saved under its own key.
+	if keys, err := store.List(ctx, subscribedTxsPath); err != nil || len(keys) != 1 {
+		test.Errorf("Wrong tx keys : %v %v", keys, err)
+	}
+	if keys, err := store.List(ctx, subscribedOutputsPath); err != nil || len(keys) != 2 {
+		test.Errorf("Wrong output keys : %v %v", keys, err)
+	}
+
+	// Reload to check the subscriptions were saved.
+	repo = NewSubscriptionRepository(store)
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+
+	if !repo.IsRelevant(subscribedTx) {
+		test.Errorf("Subscribed tx should be relevant")
+	}
+	if repo.IsRelevant(spendTx(wire.NewOutPoint(&txid, 0))) {
+		test.Errorf("Tx spending unsubscribed output should not be relevant")
+	}
+	if !repo.IsRelevant(spendTx(wire.NewOutPoint(&txid, 1))) {
+		test.Errorf("Tx spending subscribed tx output should be relevant")
+	}
+	if !repo.IsRelevant(spendTx(otherOutpoint)) {
+		test.Errorf("Tx spending subscribed output should be relevant")
+	}
+
+	if err := repo.RemoveTx(ctx, txid, []uint32{1}); err != nil {
+		test.Fatalf("Failed to remove tx : %v", err)
+	}
+	if err := repo.RemoveOutputs(ctx, []*wire.OutPoint{otherOutpoint}); err != nil {
+		test.Fatalf("Failed to remove outputs : %v", err)
+	}
+
+	repo = NewSubscriptionRepository(store)
+	if err := repo.Load(ctx); err != nil {
+		test.Fatalf("Failed to load : %v", err)
+	}
+
+	if repo.IsRelevant(subscribedTx) {
+		test.Errorf("Unsubscribed tx should not be relevant")
+	}
+	if repo.IsRelevant(spendTx(wire.NewOutPoint(&txid, 1))) {
+		test.Errorf("Tx spending unsubscribed tx output should not be relevant")
+	}
+	if repo.IsRelevant(spendTx(otherOutpoint)) {
+		test.Errorf("Tx spending unsubscribed output should not be relevant")
+	}
+}
@@ -19,6 +19,8 @@ var (
 	ErrBadSignature       = errors.New("Bad Signature")
 	ErrTimeout            = errors.New("Timeout")
 	ErrReject             = errors.New("Reject")
+	ErrNotFound           = errors.New("Not Found")     // Requested item is not known
+	ErrNotConfirmed       = errors.New("Not Confirmed") // Tx is not in the most POW chain
 )
 
 // Handler provides an interface for handling data from the spynode client.
@@ -73,6 +75,12 @@ type Client interface {
 	GetHeaders(context.Context, int, int) (*Headers, error)
 	BlockHash(context.Context, int) (*bitcoin.Hash32, error)
 
+	// Returns the header with the block hash if it is in the most POW chain.
+	GetHeader(context.Context, bitcoin.Hash32) (*Header, error)
+
+	// Returns the merkle proof of a confirmed tx. Only txs relevant to the client have proofs.
+	GetMerkleProof(context.Context, bitcoin.Hash32) (*MerkleProof, error)
+
 	// Notify the service to activate the notification message feed.
 	Ready(context.Context, uint64) error
 
@@ -11,6 +11,18 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// Flags and node types of the TSC binary merkle proof format.
+	tscFlagTx            = uint8(0x01) // Full tx instead of txid
+	tscFlagTargetHeader  = uint8(0x02) // Block header target instead of block hash
+	tscFlagTargetMask    = uint8(0x06)
+	tscFlagProofTypeTree = uint8(0x08)
+	tscFlagComposite     = uint8(0x10)
+
+	tscNodeTypeHash      = uint8(0)
+	tscNodeTypeDuplicate = uint8(1)
+)
+
 // Deserialize reads the message from a reader.
 func (m *Message) Deserialize(r io.Reader) error {
 	t, err := wire.ReadVarInt(r, wire.ProtocolVersion)
@@ -105,6 +117,10 @@ func PayloadForType(t uint64) MessagePayload {
 		return &SendTx{}
 	case MessageTypeGetTx:
 		return &GetTx{}
+	case MessageTypeGetMerkleProof:
+		return &GetMerkleProof{}
+	case MessageTypeGetHeader:
+		return &GetHeader{}
 
 	case MessageTypeAcceptRegister:
 		return &AcceptRegister{}
@@ -120,6 +136,10 @@ func PayloadForType(t uint64) MessagePayload {
 		return &ChainTip{}
 	case MessageTypeHeaders:
 		return &Headers{}
+	case MessageTypeTxMerkleProof:
+		return &TxMerkleProof{}
+	case MessageTypeHeader:
+		return &Header{}
 
 	case MessageTypeAccept:
 		return &Accept{}
@@ -705,6 +725,52 @@ func (m GetTx) Type() uint64 {
 	return MessageTypeGetTx
 }
 
+// Deserialize reads the message from a reader.
+func (m *GetMerkleProof) Deserialize(r io.Reader) error {
+	if err := m.TxID.Deserialize(r); err != nil {
+		return errors.Wrap(err, "tx")
+	}
+
+	return nil
+}
+
+// Serialize writes the message to a writer.
+func (m GetMerkleProof) Serialize(w io.Writer) error {
+	if err := m.TxID.Serialize(w); err != nil {
+		return errors.Wrap(err, "tx")
+	}
+
+	return nil
+}
+
+// Type returns they type of the message.
+func (m GetMerkleProof) Type() uint64 {
+	return MessageTypeGetMerkleProof
+}
+
+// Deserialize reads the message from a reader.
+func (m *GetHeader) Deserialize(r io.Reader) error {
+	if err := m.BlockHash.Deserialize(r); err != nil {
+		return errors.Wrap(err, "block hash")
+	}
+
+	return nil
+}
+
+// Serialize writes the message to a writer.
+func (m GetHeader) Serialize(w io.Writer) error {
+	if err := m.BlockHash.Serialize(w); err != nil {
+		return errors.Wrap(err, "block hash")
+	}
+
+	return nil
+}
+
+// Type returns they type of the message.
+func (m GetHeader) Type() uint64 {
+	return MessageTypeGetHeader
+}
+
 // Server to Client Messages -----------------------------------------------------------------------
 
 // Deserialize reads the message from a reader.
@@ -976,6 +1042,166 @@ func (m Headers) Type() uint64 {
 	return MessageTypeHeaders
 }
 
+// Deserialize reads the message from a reader in the TSC binary merkle proof format. Only proofs
+// with a txid or full tx, a block header target, and hash or duplicate nodes are supported.
+func (m *TxMerkleProof) Deserialize(r io.Reader) error {
+	var flags uint8
+	if err := binary.Read(r, Endian, &flags); err != nil {
+		return errors.Wrap(err, "flags")
+	}
+
+	if flags&tscFlagTargetMask != tscFlagTargetHeader {
+		return errors.Wrapf(ErrInvalid, "unsupported target type : %02x", flags)
+	}
+	if flags&(tscFlagProofTypeTree|tscFlagComposite) != 0 {
+		return errors.Wrapf(ErrInvalid, "unsupported proof type : %02x", flags)
+	}
+
+	index, err := wire.ReadVarInt(r, wire.ProtocolVersion)
+	if err != nil {
+		return errors.Wrap(err, "index")
+	}
+
+	if flags&tscFlagTx != 0 {
+		tx := &wire.MsgTx{}
+		if _, err := wire.ReadVarInt(r, wire.ProtocolVersion); err != nil {
+			return errors.Wrap(err, "tx size")
+		}
+		if err := tx.Deserialize(r); err != nil {
+			return errors.Wrap(err, "tx")
+		}
+		m.TxID = *tx.TxHash()
+	} else if err := m.TxID.Deserialize(r); err != nil {
+		return errors.Wrap(err, "txid")
+	}
+
+	m.MerkleProof = MerkleProof{Index: index}
+	if err := m.MerkleProof.BlockHeader.Deserialize(r); err != nil {
+		return errors.Wrap(err, "block header")
+	}
+
+	count, err := wire.ReadVarInt(r, wire.ProtocolVersion)
+	if err != nil {
+		return errors.Wrap(err, "node count")
+	}
+
+	m.MerkleProof.Path = make([]bitcoin.Hash32, 0, count)
+	m.MerkleProof.DuplicatedIndexes = []uint64{}
+	for layer := uint64(1); layer <= count; layer++ {
+		var nodeType uint8
+		if err := binary.Read(r, Endian, &nodeType); err != nil {
+			return errors.Wrapf(err, "node %d type", layer)
+		}
+
+		switch nodeType {
+		case tscNodeTypeHash:
+			var hash bitcoin.Hash32
+			if err := hash.Deserialize(r); err != nil {
+				return errors.Wrapf(err, "node %d", layer)
+			}
+			m.MerkleProof.Path = append(m.MerkleProof.Path, hash)
+
+		case tscNodeTypeDuplicate:
+			m.MerkleProof.DuplicatedIndexes = append(m.MerkleProof.DuplicatedIndexes, layer)
+
+		default:
+			return errors.Wrapf(ErrInvalid, "unsupported node %d type : %d", layer, nodeType)
+		}
+	}
+
+	return nil
+}
+
+// Serialize writes the message to a writer in the TSC binary merkle proof format.
+func (m TxMerkleProof) Serialize(w io.Writer) error {
+	if err := binary.Write(w, Endian, tscFlagTargetHeader); err != nil {
+		return errors.Wrap(err, "flags")
+	}
+
+	if err := wire.WriteVarInt(w, wire.ProtocolVersion, m.MerkleProof.Index); err != nil {
+		return errors.Wrap(err, "index")
+	}
+
+	if err := m.TxID.Serialize(w); err != nil {
+		return errors.Wrap(err, "txid")
+	}
+
+	if err := m.MerkleProof.BlockHeader.Serialize(w); err != nil {
+		return errors.Wrap(err, "block header")
+	}
+
+	path := m.MerkleProof.Path
+	duplicatedIndexes := m.MerkleProof.DuplicatedIndexes
+	count := uint64(len(path) + len(duplicatedIndexes))
+	if err := wire.WriteVarInt(w, wire.ProtocolVersion, count); err != nil {
+		return errors.Wrap(err, "node count")
+	}
+
+	// Duplicated indexes are the layers, starting at 1, where the node is a duplicate of the hash
+	// calculated so far.
+	for layer := uint64(1); layer <= count; layer++ {
+		if len(duplicatedIndexes) > 0 && duplicatedIndexes[0] == layer {
+			if err := binary.Write(w, Endian, tscNodeTypeDuplicate); err != nil {
+				return errors.Wrapf(err, "node %d type", layer)
+			}
+			duplicatedIndexes = duplicatedIndexes[1:]
+			continue
+		}
+
+		if len(path) == 0 {
+			return errors.Wrap(ErrInvalid, "duplicate index out of range")
+		}
+
+		if err := binary.Write(w, Endian, tscNodeTypeHash); err != nil {
+			return errors.Wrapf(err, "node %d type", layer)
+		}
+		if err := path[0].Serialize(w); err != nil {
+			return errors.Wrapf(err, "node %d", layer)
+		}
+		path = path[1:]
+	}
+
+	return nil
+}
+
+// Type returns they type of the message.
+func (m TxMerkleProof) Type() uint64 {
+	return MessageTypeTxMerkleProof
+}
+
+// Deserialize reads the message from a reader.
+func (m *Header) Deserialize(r io.Reader) error {
+	height, err := wire.ReadVarInt(r, wire.ProtocolVersion)
+	if err != nil {
+		return errors.Wrap(err, "height")
+	}
+	m.Height = uint32(height)
+
+	if err := m.Header.Deserialize(r); err != nil {
+		return errors.Wrap(err, "header")
+	}
+
+	return nil
+}
+
+// Serialize writes the message to a writer.
+func (m Header) Serialize(w io.Writer) error {
+	if err := wire.WriteVarInt(w, wire.ProtocolVersion, uint64(m.Height)); err != nil {
+		return errors.Wrap(err, "height")
+	}
+
+	if err := m.Header.Serialize(w); err != nil {
+		return errors.Wrap(err, "header")
+	}
+
+	return nil
+}
+
+// Type returns they type of the message.
+func (m Header) Type() uint64 {
+	return MessageTypeHeader
+}
+
 // Deserialize reads the message from a reader.
 func (m *InSync) Deserialize(r io.Reader) error {
 	return nil
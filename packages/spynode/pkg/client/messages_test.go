@@ -9,6 +9,8 @@ import (
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
 )
 
 func TestSerializeMessages(t *testing.T) {
@@ -174,6 +176,20 @@ func TestSerializeMessages(t *testing.T) {
 				TxID: hash,
 			},
 		},
+		{
+			name: "GetMerkleProof",
+			t:    MessageTypeGetMerkleProof,
+			m: &GetMerkleProof{
+				TxID: hash,
+			},
+		},
+		{
+			name: "GetHeader",
+			t:    MessageTypeGetHeader,
+			m: &GetHeader{
+				BlockHash: hash,
+			},
+		},
 		{
 			name: "AcceptRegister",
 			t:    MessageTypeAcceptRegister,
@@ -245,6 +261,31 @@ func TestSerializeMessages(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "TxMerkleProof",
+			t:    MessageTypeTxMerkleProof,
+			m: &TxMerkleProof{
+				TxID: hash,
+				MerkleProof: MerkleProof{
+					Index: 4,
+					Path:  []bitcoin.Hash32{hash, hash},
+					BlockHeader: wire.BlockHeader{
+						Timestamp: tm,
+					},
+					DuplicatedIndexes: []uint64{1},
+				},
+			},
+		},
+		{
+			name: "Header",
+			t:    MessageTypeHeader,
+			m: &Header{
+				Height: 1000,
+				Header: wire.BlockHeader{
+					Timestamp: tm,
+				},
+			},
+		},
 		{
 			name: "InSync",
 			t:    MessageTypeInSync,
@@ -330,3 +371,78 @@ func TestSerializeMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestTxMerkleProofTSC(t *testing.T) {
+	var txids [3]bitcoin.Hash32
+	for i := range txids {
+		rand.Read(txids[i][:])
+	}
+
+	// The last tx in a tree with an odd number of txs is paired with itself.
+	left := merkleParent(txids[0], txids[1])
+	right := merkleParent(txids[2], txids[2])
+
+	m := &TxMerkleProof{
+		TxID: txids[2],
+		MerkleProof: MerkleProof{
+			Index: 2,
+			Path:  []bitcoin.Hash32{left},
+			BlockHeader: wire.BlockHeader{
+				MerkleRoot: merkleParent(left, right),
+				Timestamp:  time.Unix(time.Now().Unix(), 0),
+			},
+			DuplicatedIndexes: []uint64{1},
+		},
+	}
+
+	if err := m.MerkleProof.IsValid(m.TxID); err != nil {
+		t.Fatalf("Invalid merkle proof : %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize : %s", err)
+	}
+
+	var header bytes.Buffer
+	if err := m.MerkleProof.BlockHeader.Serialize(&header); err != nil {
+		t.Fatalf("Failed to serialize header : %s", err)
+	}
+
+	// flags, index, txid, header, node count, duplicate node, hash node
+	var want []byte
+	want = append(want, 0x02, 0x02)
+	want = append(want, txids[2][:]...)
+	want = append(want, header.Bytes()...)
+	want = append(want, 0x02, 0x01, 0x00)
+	want = append(want, left[:]...)
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("Wrong TSC encoding : \n  got  %x\n  want %x", buf.Bytes(), want)
+	}
+
+	read := &TxMerkleProof{}
+	if err := read.Deserialize(bytes.NewReader(want)); err != nil {
+		t.Fatalf("Failed to deserialize : %s", err)
+	}
+
+	if !reflect.DeepEqual(m, read) {
+		t.Fatalf("Deserialize not equal : \n  got  %+v\n  want %+v", read, m)
+	}
+
+	if err := read.MerkleProof.IsValid(read.TxID); err != nil {
+		t.Fatalf("Invalid deserialized merkle proof : %s", err)
+	}
+
+	// Only block header targets are supported.
+	want[0] = 0x00
+	if err := read.Deserialize(bytes.NewReader(want)); errors.Cause(err) != ErrInvalid {
+		t.Fatalf("Wrong error for block hash target : got %v, want %s", err, ErrInvalid)
+	}
+}
+
+// merkleParent returns the hash of the merkle tree node above the left and right nodes.
+func merkleParent(left, right bitcoin.Hash32) bitcoin.Hash32 {
+	hash, _ := bitcoin.NewHash32(bitcoin.DoubleSha256(append(left.Bytes(), right.Bytes()...)))
+	return *hash
+}
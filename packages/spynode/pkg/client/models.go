@@ -60,6 +60,12 @@ const (
 	// MessageTypeGetTx requests a transaction.
 	MessageTypeGetTx = 44
 
+	// MessageTypeGetMerkleProof requests the merkle proof of a confirmed transaction.
+	MessageTypeGetMerkleProof = 45
+
+	// MessageTypeGetHeader requests a header by block hash.
+	MessageTypeGetHeader = 46
+
 	// MessageTypeAcceptRegister is the type of an accept register message.
 	MessageTypeAcceptRegister = 101
 
@@ -81,6 +87,12 @@ const (
 	// MessageTypeHeaders is headers.
 	MessageTypeHeaders = 123
 
+	// MessageTypeTxMerkleProof is the merkle proof of a transaction.
+	MessageTypeTxMerkleProof = 124
+
+	// MessageTypeHeader is a header in the most POW chain.
+	MessageTypeHeader = 125
+
 	// MessageTypeAccept is an accept of the previous request.
 	MessageTypeAccept = 200
 
@@ -96,6 +108,13 @@ const (
 
 	// ConnectionTypeControl is a control only connection type that does not receive data messages.
 	ConnectionTypeControl = uint8(2)
+
+	// RejectCodeNotFound is the reject code when the requested item is not known.
+	RejectCodeNotFound = uint32(1)
+
+	// RejectCodeNotConfirmed is the reject code when a merkle proof is requested for a
+	// transaction that is not in the most POW chain.
+	RejectCodeNotConfirmed = uint32(2)
 )
 
 type Message struct {
@@ -203,6 +222,17 @@ type GetTx struct {
 	TxID bitcoin.Hash32
 }
 
+// GetMerkleProof requests the merkle proof of a confirmed tx by its hash.
+type GetMerkleProof struct {
+	TxID bitcoin.Hash32
+}
+
+// GetHeader requests the header with a block hash. It is used to verify that a block is in the
+// most POW chain.
+type GetHeader struct {
+	BlockHash bitcoin.Hash32
+}
+
 // Server to Client Messages -----------------------------------------------------------------------
 
 type AcceptRegister struct {
@@ -240,6 +270,19 @@ type Headers struct {
 	Headers       []*wire.BlockHeader
 }
 
+// TxMerkleProof is the merkle proof of a tx. It is serialized in the TSC (Technical Standards
+// Committee) binary merkle proof format with the block header as the target.
+type TxMerkleProof struct {
+	TxID        bitcoin.Hash32
+	MerkleProof MerkleProof
+}
+
+// Header is a block header in the most POW chain.
+type Header struct {
+	Height uint32
+	Header wire.BlockHeader
+}
+
 // InSync is a notification that the messages are "up to date" with the network.
 type InSync struct{}
 
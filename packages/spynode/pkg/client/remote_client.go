@@ -51,6 +51,9 @@ type RemoteClient struct {
 	headerRequests []*headerRequest
 	requestLock    sync.Mutex
 
+	getMerkleProofRequests []*getMerkleProofRequest
+	getHeaderRequests      []*getHeaderRequest
+
 	accepted, ready bool
 	lock            sync.Mutex
 	wait            sync.WaitGroup
@@ -79,6 +82,18 @@ type headerRequest struct {
 	lock     sync.Mutex
 }
 
+type getMerkleProofRequest struct {
+	txid     bitcoin.Hash32
+	response *Message
+	lock     sync.Mutex
+}
+
+type getHeaderRequest struct {
+	blockHash bitcoin.Hash32
+	response  *Message
+	lock      sync.Mutex
+}
+
 // NewRemoteClient creates a remote client.
 // Note: If the connection type is not "full" then it will auto-connect when a function is called to
 // communicate with the spynode service. Make sure `Close` is called before application end so that
@@ -466,6 +481,129 @@ func (c *RemoteClient) BlockHash(ctx context.Context, height int) (*bitcoin.Hash
 	return headers.Headers[0].BlockHash(), nil
 }
 
+// GetMerkleProof requests the merkle proof of a confirmed tx. It is synchronous meaning it will
+// wait for a response before returning.
+func (c *RemoteClient) GetMerkleProof(ctx context.Context,
+	txid bitcoin.Hash32) (*MerkleProof, error) {
+	start := time.Now()
+	defer metrics.Elapsed(ctx, start, "SpyNodeClient.GetMerkleProof")
+
+	// Register with listener for response merkle proof
+	request := &getMerkleProofRequest{
+		txid: txid,
+	}
+
+	c.requestLock.Lock()
+	c.getMerkleProofRequests = append(c.getMerkleProofRequests, request)
+	c.requestLock.Unlock()
+
+	logger.Info(ctx, "Sending get merkle proof message : %s", txid)
+	m := &GetMerkleProof{TxID: txid}
+	if err := c.sendMessage(ctx, m); err != nil {
+		return nil, err
+	}
+
+	// Wait for response
+	timeout := start.Add(time.Duration(c.config.RequestTimeout) * time.Millisecond)
+	for time.Now().Before(timeout) {
+		request.lock.Lock()
+		if request.response != nil {
+			request.lock.Unlock()
+			// Remove
+			c.requestLock.Lock()
+			for i, r := range c.getMerkleProofRequests {
+				if r == request {
+					c.getMerkleProofRequests = append(c.getMerkleProofRequests[:i],
+						c.getMerkleProofRequests[i+1:]...)
+					break
+				}
+			}
+			c.requestLock.Unlock()
+
+			switch msg := request.response.Payload.(type) {
+			case *Reject:
+				return nil, rejectError(msg)
+			case *TxMerkleProof:
+				return &msg.MerkleProof, nil
+			default:
+				return nil, fmt.Errorf("Unknown response : %d", request.response.Payload.Type())
+			}
+		}
+		request.lock.Unlock()
+
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	return nil, ErrTimeout
+}
+
+// GetHeader requests the header with the block hash. It returns ErrNotFound if the block is not in
+// the most POW chain. It is synchronous meaning it will wait for a response before returning.
+func (c *RemoteClient) GetHeader(ctx context.Context, blockHash bitcoin.Hash32) (*Header, error) {
+	start := time.Now()
+	defer metrics.Elapsed(ctx, start, "SpyNodeClient.GetHeader")
+
+	// Register with listener for response header
+	request := &getHeaderRequest{
+		blockHash: blockHash,
+	}
+
+	c.requestLock.Lock()
+	c.getHeaderRequests = append(c.getHeaderRequests, request)
+	c.requestLock.Unlock()
+
+	logger.Info(ctx, "Sending get header message : %s", blockHash)
+	m := &GetHeader{BlockHash: blockHash}
+	if err := c.sendMessage(ctx, m); err != nil {
+		return nil, err
+	}
+
+	// Wait for response
+	timeout := start.Add(time.Duration(c.config.RequestTimeout) * time.Millisecond)
+	for time.Now().Before(timeout) {
+		request.lock.Lock()
+		if request.response != nil {
+			request.lock.Unlock()
+			// Remove
+			c.requestLock.Lock()
+			for i, r := range c.getHeaderRequests {
+				if r == request {
+					c.getHeaderRequests = append(c.getHeaderRequests[:i],
+						c.getHeaderRequests[i+1:]...)
+					break
+				}
+			}
+			c.requestLock.Unlock()
+
+			switch msg := request.response.Payload.(type) {
+			case *Reject:
+				return nil, rejectError(msg)
+			case *Header:
+				return msg, nil
+			default:
+				return nil, fmt.Errorf("Unknown response : %d", request.response.Payload.Type())
+			}
+		}
+		request.lock.Unlock()
+
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	return nil, ErrTimeout
+}
+
+// rejectError returns the error for a reject of a request.
+func rejectError(msg *Reject) error {
+	switch msg.Code {
+	case RejectCodeNotFound:
+		return errors.Wrap(ErrNotFound, msg.Message)
+	case RejectCodeNotConfirmed:
+		return errors.Wrap(ErrNotConfirmed, msg.Message)
+	default:
+		return errors.Wrap(ErrReject, msg.Message)
+	}
+}
+
 // sendMessage wraps and sends a message to the server.
 func (c *RemoteClient) sendMessage(ctx context.Context, payload MessagePayload) error {
 	if c.config.ConnectionType != ConnectionTypeFull {
@@ -870,6 +1008,58 @@ func (c *RemoteClient) listen(ctx context.Context) error {
 				c.addHandlerMessage(ctx, m.Payload)
 			}
 
+		case *TxMerkleProof:
+			logger.InfoWithFields(ctx, []logger.Field{
+				logger.Stringer("txid", msg.TxID),
+			}, "Received merkle proof")
+
+			found := false
+			c.requestLock.Lock()
+			for _, request := range c.getMerkleProofRequests {
+				request.lock.Lock()
+				if request.txid.Equal(&msg.TxID) {
+					request.response = m
+					request.lock.Unlock()
+					found = true
+					break
+				}
+				request.lock.Unlock()
+			}
+			c.requestLock.Unlock()
+
+			if !found {
+				logger.WarnWithFields(ctx, []logger.Field{
+					logger.Stringer("txid", msg.TxID),
+				}, "No matching request found for merkle proof")
+			}
+
+		case *Header:
+			blockHash := *msg.Header.BlockHash()
+			logger.InfoWithFields(ctx, []logger.Field{
+				logger.Stringer("block_hash", blockHash),
+				logger.Uint32("height", msg.Height),
+			}, "Received header")
+
+			found := false
+			c.requestLock.Lock()
+			for _, request := range c.getHeaderRequests {
+				request.lock.Lock()
+				if request.blockHash.Equal(&blockHash) {
+					request.response = m
+					request.lock.Unlock()
+					found = true
+					break
+				}
+				request.lock.Unlock()
+			}
+			c.requestLock.Unlock()
+
+			if !found {
+				logger.WarnWithFields(ctx, []logger.Field{
+					logger.Stringer("block_hash", blockHash),
+				}, "No matching request found for header")
+			}
+
 		case *InSync:
 			logger.Info(ctx, "Received in sync")
 
@@ -996,6 +1186,56 @@ func (c *RemoteClient) listen(ctx context.Context) error {
 						}, "No matching request found for get tx reject")
 					}
 				}
+
+				if msg.MessageType == MessageTypeGetMerkleProof {
+					logger.WarnWithFields(ctx, []logger.Field{
+						logger.Stringer("txid", msg.Hash),
+					}, "Received reject for get merkle proof : %s", msg.Message)
+
+					c.requestLock.Lock()
+					for _, request := range c.getMerkleProofRequests {
+						request.lock.Lock()
+						if request.txid.Equal(msg.Hash) {
+							request.response = m
+							request.lock.Unlock()
+							found = true
+							break
+						}
+						request.lock.Unlock()
+					}
+					c.requestLock.Unlock()
+
+					if !found {
+						logger.WarnWithFields(ctx, []logger.Field{
+							logger.Stringer("txid", msg.Hash),
+						}, "No matching request found for get merkle proof reject")
+					}
+				}
+
+				if msg.MessageType == MessageTypeGetHeader {
+					logger.WarnWithFields(ctx, []logger.Field{
+						logger.Stringer("block_hash", msg.Hash),
+					}, "Received reject for get header : %s", msg.Message)
+
+					c.requestLock.Lock()
+					for _, request := range c.getHeaderRequests {
+						request.lock.Lock()
+						if request.blockHash.Equal(msg.Hash) {
+							request.response = m
+							request.lock.Unlock()
+							found = true
+							break
+						}
+						request.lock.Unlock()
+					}
+					c.requestLock.Unlock()
+
+					if !found {
+						logger.WarnWithFields(ctx, []logger.Field{
+							logger.Stringer("block_hash", msg.Hash),
+						}, "No matching request found for get header reject")
+					}
+				}
 			}
 
 		case *Ping:
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -17,8 +18,8 @@ import (
 	"github.com/libsv/payd/config/databases"
 	phttp "github.com/libsv/payd/data/http"
 	"github.com/libsv/payd/data/mapi"
+	"github.com/libsv/payd/data/passphrase"
 	"github.com/libsv/payd/data/paymail"
-	paydSQL "github.com/libsv/payd/data/sqlite"
 	"github.com/libsv/payd/service"
 	"github.com/libsv/payd/service/ppctl"
 	thttp "github.com/libsv/payd/transports/http"
@@ -54,7 +55,9 @@ func main() {
 		WithHeadersv().
 		WithPaymail().
 		WithWallet().
-		WithMapi()
+		WithMapi().
+		WithKeys().
+		WithWebhooks()
 	// validate the config, fail if it fails.
 	if err := cfg.Validate(); err != nil {
 		log.Fatal(err)
@@ -82,32 +85,47 @@ func main() {
 	e.HTTPErrorHandler = paydMiddleware.ErrorHandler
 
 	// setup stores
-	sqlLiteStore := paydSQL.NewSQLiteStore(db)
-	mapiCli, err := minercraft.NewClient(nil, nil, []*minercraft.Miner{
-		{
-			Name:  cfg.Mapi.MinerName,
-			Token: cfg.Mapi.Token,
-			URL:   cfg.Mapi.URL,
-		},
-	})
+	miners := make([]*minercraft.Miner, 0, len(cfg.Mapi.Miners))
+	for _, m := range cfg.Mapi.Miners {
+		miners = append(miners, &minercraft.Miner{
+			Name:    m.Name,
+			MinerID: m.MinerID,
+			Token:   m.Token,
+			URL:     m.URL,
+		})
+	}
+	mapiCli, err := minercraft.NewClient(nil, nil, miners)
 	if err != nil {
 		log.Fatalf("error occurred: %s", err)
 	}
 	mapiStore := mapi.NewMapi(cfg.Mapi, cfg.Server, mapiCli)
 	// setup services
-	paymentSender := ppctl.NewPaymentMapiSender(mapiStore)
+	webhookSvc := service.NewWebhooks(db, db)
+	paymentSender := ppctl.NewPaymentMapiSender(mapiStore, db)
+	pCli, err := gopaymail.NewClient(nil, nil, nil)
+	if err != nil {
+		log.Fatalf("unable to create paymail client %s: ", err)
+	}
+	paymailStore := paymail.NewPaymail(cfg.Paymail, pCli)
 	var paymentOutputter gopayd.PaymentRequestOutputer
+	var sendSvc gopayd.SendService
 	if cfg.Paymail.UsePaymail {
-		pCli, err := gopaymail.NewClient(nil, nil, nil)
-		if err != nil {
-			log.Fatalf("unable to create paymail client %s: ", err)
-		}
-		paymailStore := paymail.NewPaymail(cfg.Paymail, pCli)
-		paymentOutputter = ppctl.NewPaymailOutputs(cfg.Paymail, paymailStore, sqlLiteStore)
+		paymentOutputter = ppctl.NewPaymailOutputs(cfg.Paymail, paymailStore, db)
 	} else {
-		pkSvc := service.NewPrivateKeys(sqlLiteStore, cfg.Deployment.MainNet)
+		pkSvc := service.NewPrivateKeys(db, passphrase.NewEncrypter(cfg.Keys.Passphrase), cfg.Deployment.MainNet)
+		// encrypt any plain text keys and create a key if there isn't one.
+		if err := pkSvc.Setup(context.Background(), cfg.Keys.Name); err != nil {
+			log.Fatalf("failed to setup private keys: %s", err)
+		}
 
-		paymentOutputter = ppctl.NewMapiOutputs(cfg.Server, pkSvc, sqlLiteStore, sqlLiteStore)
+		paymentOutputter = ppctl.NewMapiOutputs(cfg.Server, pkSvc, db, db)
+		// outputs are only spendable when we hold the keys.
+		envCreator, err := spv.NewEnvelopeCreator(db, db)
+		if err != nil {
+			log.Fatalf("failed to create spv envelope creator: %s", err)
+		}
+		sendSvc = service.NewSend(cfg.Wallet, pkSvc, db, db, db, db, mapiStore, paymentSender,
+			paymailStore, phttp.NewPaymentHost(&http.Client{Timeout: 30 * time.Second}), envCreator, db.Transacter, webhookSvc)
 	}
 
 	spvv, err := spv.NewPaymentVerifier(phttp.NewHeadersv(&http.Client{Timeout: time.Duration(cfg.Headersv.Timeout) * time.Second}, cfg.Headersv.Address))
@@ -115,19 +133,32 @@ func main() {
 		log.Fatalf("failed to create spv cient %w", err)
 	}
 	thttp.NewPaymentRequestHandler(
-		ppctl.NewPaymentRequest(cfg.Wallet, cfg.Server, paymentOutputter, sqlLiteStore, mapiStore)).
+		ppctl.NewPaymentRequest(cfg.Wallet, cfg.Server, paymentOutputter, db, mapiStore)).
 		RegisterRoutes(g)
 	thttp.NewPaymentHandler(
-		ppctl.NewPayment(cfg.Wallet, sqlLiteStore, sqlLiteStore, sqlLiteStore, paymentSender, &paydSQL.Transacter{}, spvv)).
+		ppctl.NewPayment(cfg.Wallet, db, db, db, paymentSender, db.Transacter, spvv, webhookSvc)).
 		RegisterRoutes(g)
-	thttp.NewInvoice(service.NewInvoice(cfg.Server, sqlLiteStore)).
+	thttp.NewInvoice(service.NewInvoice(cfg.Server, cfg.Wallet, db, webhookSvc, db.Transacter)).
 		RegisterRoutes(g)
-	thttp.NewBalance(service.NewBalance(sqlLiteStore)).
+	thttp.NewBalance(service.NewBalance(db)).
 		RegisterRoutes(g)
-	thttp.NewProofs(service.NewProofsService(sqlLiteStore)).
+	thttp.NewProofs(service.NewProofsService(db, db, cfg.Mapi, webhookSvc, db.Transacter)).
 		RegisterRoutes(g)
 	thttp.NewTxStatusHandler(ppctl.NewTxStatusService(mapiStore)).
 		RegisterRoutes(g)
+	thttp.NewWebhooks(webhookSvc).
+		RegisterRoutes(g)
+	if sendSvc != nil {
+		thttp.NewSend(sendSvc).
+			RegisterRoutes(g)
+	}
+
+	// expire unpaid invoices in the background.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.NewInvoiceExpiry(db, cfg.Wallet.ExpiryInterval).Run(ctx)
+	// send webhook events from the outbox in the background.
+	go service.NewWebhookDelivery(db, db, phttp.NewWebhook(&http.Client{Timeout: cfg.Webhooks.Timeout}), cfg.Webhooks).Run(ctx)
 
 	if cfg.Deployment.IsDev() {
 		printDev(e)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/labstack/gommon/log"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/config/databases"
+	"github.com/libsv/payd/data/passphrase"
+	"github.com/libsv/payd/service"
+)
+
+const appname = "payd"
+
+const usage = `Manage the extended private keys payd derives outputs from.
+
+Keys are encrypted with KEYS_PASSPHRASE, the database and network are
+read from the same environment variables as the server.
+
+Usage:
+  keys list             list stored keys, the active key is marked with *
+  keys rotate <name>    generate a new key and make it the active key
+  keys import <name>    read an xprv from stdin and make it the active key
+
+Previous keys are kept when rotating so outputs derived from them can
+still be spent.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg := config.NewViperConfig(appname).
+		WithDb().
+		WithDeployment(appname).
+		WithLog().
+		WithKeys()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	config.SetupLog(cfg.Logging)
+	db, err := databases.NewDbSetup().SetupDb(cfg.Db)
+	if err != nil {
+		log.Fatalf("failed to setup database: %s", err)
+	}
+	// nolint:errcheck // dont care about error.
+	defer db.Close()
+
+	svc := service.NewPrivateKeys(db, passphrase.NewEncrypter(cfg.Keys.Passphrase), cfg.Deployment.MainNet)
+	ctx := context.Background()
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "list":
+		err = list(ctx, svc)
+	case "rotate":
+		if len(args) != 1 {
+			log.Fatal("a key name is required")
+		}
+		err = svc.Rotate(ctx, args[0])
+	case "import":
+		if len(args) != 1 {
+			log.Fatal("a key name is required")
+		}
+		var xprv string
+		if xprv, err = readKey(); err == nil {
+			err = svc.Import(ctx, args[0], xprv)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func list(ctx context.Context, svc gopayd.PrivateKeyService) error {
+	keys, err := svc.PrivateKeys(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tCREATED\tROTATED")
+	for _, k := range keys {
+		active := ""
+		if k.Active {
+			active = "*"
+		}
+		rotated := ""
+		if k.RotatedAt.Valid {
+			rotated = k.RotatedAt.Time.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", active, k.Name, k.CreatedAt.Format(time.RFC3339), rotated)
+	}
+	return w.Flush()
+}
+
+// readKey reads the key from stdin so it isn't stored in shell history.
+func readKey() (string, error) {
+	fmt.Fprint(os.Stderr, "xprv: ")
+	s, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && s == "" {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"time"
@@ -10,33 +11,45 @@ import (
 
 // Environment variable constants.
 const (
-	EnvServerPort      = "server.port"
-	EnvServerHost      = "server.host"
-	EnvEnvironment     = "env.environment"
-	EnvMainNet         = "env.mainnet"
-	EnvRegion          = "env.region"
-	EnvVersion         = "env.version"
-	EnvCommit          = "env.commit"
-	EnvBuildDate       = "env.builddate"
-	EnvLogLevel        = "log.level"
-	EnvDb              = "db.type"
-	EnvDbSchema        = "db.schema.path"
-	EnvDbDsn           = "db.dsn"
-	EnvDbMigrate       = "db.migrate"
-	EnvHeadersvAddress = "headersv.address"
-	EnvHeadersvTimeout = "headersv.timeout"
-	EnvPaymailEnabled  = "paymail.enabled"
-	EnvPaymailIsBeta   = "paymail.isbeta"
-	EnvPaymailAddress  = "paymail.address"
-	EnvNetwork         = "wallet.network"
-	EnvAvatarURL       = "wallet.avatarurl"
-	EnvMerchantName    = "wallet.merchantname"
-	EnvMerchantEmail   = "wallet.merchantemail"
-	EnvMerchantAddress = "wallet.merchantaddress"
-	EnvPaymentExpiry   = "wallet.paymentexpiry"
-	EnvMAPIMinerName   = "mapi.minername"
-	EnvMAPIURL         = "mapi.minerurl"
-	EnvMAPIToken       = "mapi.token"
+	EnvServerPort          = "server.port"
+	EnvServerHost          = "server.host"
+	EnvEnvironment         = "env.environment"
+	EnvMainNet             = "env.mainnet"
+	EnvRegion              = "env.region"
+	EnvVersion             = "env.version"
+	EnvCommit              = "env.commit"
+	EnvBuildDate           = "env.builddate"
+	EnvLogLevel            = "log.level"
+	EnvDb                  = "db.type"
+	EnvDbSchema            = "db.schema.path"
+	EnvDbDsn               = "db.dsn"
+	EnvDbMigrate           = "db.migrate"
+	EnvHeadersvAddress     = "headersv.address"
+	EnvHeadersvTimeout     = "headersv.timeout"
+	EnvPaymailEnabled      = "paymail.enabled"
+	EnvPaymailIsBeta       = "paymail.isbeta"
+	EnvPaymailAddress      = "paymail.address"
+	EnvNetwork             = "wallet.network"
+	EnvAvatarURL           = "wallet.avatarurl"
+	EnvMerchantName        = "wallet.merchantname"
+	EnvMerchantEmail       = "wallet.merchantemail"
+	EnvMerchantAddress     = "wallet.merchantaddress"
+	EnvPaymentExpiry       = "wallet.paymentexpiry"
+	EnvExpiryInterval      = "wallet.expiryinterval"
+	EnvDustLimit           = "wallet.dustlimit"
+	EnvMAPIMinerName       = "mapi.minername"
+	EnvMAPIURL             = "mapi.minerurl"
+	EnvMAPIToken           = "mapi.token"
+	EnvMAPIMinerID         = "mapi.minerid"
+	EnvMAPIMiners          = "mapi.miners"
+	EnvMAPIPolicy          = "mapi.policy"
+	EnvMAPITimeout         = "mapi.timeout"
+	EnvWebhooksInterval    = "webhooks.interval"
+	EnvWebhooksMaxAttempts = "webhooks.maxattempts"
+	EnvWebhooksBackoff     = "webhooks.backoff"
+	EnvWebhooksTimeout     = "webhooks.timeout"
+	EnvKeysName            = "keys.name"
+	EnvKeysPassphrase      = "keys.passphrase"
 
 	LogDebug = "debug"
 	LogInfo  = "info"
@@ -44,7 +57,10 @@ const (
 	LogWarn  = "warn"
 )
 
-var reDbType = regexp.MustCompile(`sqlite|mysql|postgres`)
+var (
+	reDbType          = regexp.MustCompile(`sqlite|mysql|postgres|badger`)
+	reBroadcastPolicy = regexp.MustCompile(`^(first|all)$`)
+)
 
 // DbType is used to restrict the dbs we can support.
 type DbType string
@@ -54,6 +70,7 @@ const (
 	DBSqlite   DbType = "sqlite"
 	DBMySql    DbType = "mysql"
 	DBPostgres DbType = "postgres"
+	DBBadger   DbType = "badger"
 )
 
 // Config returns strongly typed config values.
@@ -66,6 +83,8 @@ type Config struct {
 	Paymail    *Paymail
 	Wallet     *Wallet
 	Mapi       *MApi
+	Keys       *Keys
+	Webhooks   *Webhooks
 }
 
 // Validate will ensure the config matches certain parameters.
@@ -74,6 +93,47 @@ func (c *Config) Validate() error {
 	if c.Db != nil {
 		vl = vl.Validate("db.type", validator.MatchString(string(c.Db.Type), reDbType))
 	}
+	// keys aren't used when outputs are created by a paymail provider.
+	if c.Keys != nil && (c.Paymail == nil || !c.Paymail.UsePaymail) {
+		vl = vl.Validate("keys.passphrase", validator.Length(c.Keys.Passphrase, 12, 1024))
+	}
+	if c.Mapi != nil {
+		vl = vl.Validate("mapi.policy", validator.MatchString(string(c.Mapi.Policy), reBroadcastPolicy)).
+			Validate("mapi.timeout", func() error {
+				if c.Mapi.Timeout <= 0 {
+					return errors.New("timeout must be greater than 0")
+				}
+				return nil
+			}).
+			Validate("mapi.miners", func() error {
+				if c.Mapi.minersErr != nil {
+					return c.Mapi.minersErr
+				}
+				if len(c.Mapi.Miners) == 0 {
+					return errors.New("at least one miner is required")
+				}
+				names := map[string]struct{}{}
+				for _, m := range c.Mapi.Miners {
+					if m.Name == "" || m.URL == "" {
+						return errors.New("miners require a name and url")
+					}
+					if _, ok := names[m.Name]; ok {
+						return fmt.Errorf("miner name %s is duplicated", m.Name)
+					}
+					names[m.Name] = struct{}{}
+				}
+				return nil
+			})
+	}
+	if c.Webhooks != nil {
+		vl = vl.Validate("webhooks.maxattempts", validator.MinInt(c.Webhooks.MaxAttempts, 1)).
+			Validate("webhooks.timeout", func() error {
+				if c.Webhooks.Timeout <= 0 {
+					return errors.New("timeout must be greater than 0")
+				}
+				return nil
+			})
+	}
 	return vl.Err()
 }
 
@@ -139,13 +199,75 @@ type Wallet struct {
 	MerchantEmail      string
 	Address            string
 	PaymentExpiryHours int
+	// ExpiryInterval is how often invoices are checked for expiry.
+	ExpiryInterval time.Duration
+	// DustLimit is the smallest change output created when sending, anything less is
+	// added to the fee.
+	DustLimit uint64
 }
 
+// BroadcastPolicy sets how transactions are broadcast when several miners are configured.
+type BroadcastPolicy string
+
+// Supported broadcast policies.
+const (
+	// BroadcastFirst submits to each miner in turn until one accepts the transaction.
+	BroadcastFirst BroadcastPolicy = "first"
+	// BroadcastAll submits to every miner, at least one of them must accept the transaction.
+	BroadcastAll BroadcastPolicy = "all"
+)
+
 // MApi contains MAPI connection settings.
 type MApi struct {
-	MinerName string
-	URL       string
-	Token     string
+	// Miners are used in the order they are configured, if one rejects
+	// a request or times out the next is tried.
+	Miners []*Miner
+	Policy BroadcastPolicy
+	// Timeout is the time allowed for each request to a miner.
+	Timeout time.Duration
+	// minersErr is set if the miners couldn't be read, it is returned when validating.
+	minersErr error
+}
+
+// MinerIDs returns the minerIds configured for the miners.
+func (m *MApi) MinerIDs() []string {
+	var ids []string
+	for _, miner := range m.Miners {
+		if miner.MinerID != "" {
+			ids = append(ids, miner.MinerID)
+		}
+	}
+	return ids
+}
+
+// Miner contains the connection settings for a single mAPI endpoint.
+type Miner struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	// MinerID is the public key the miner signs responses and callbacks with,
+	// if set anything not signed with it is rejected.
+	MinerID string `json:"minerId"`
+}
+
+// Keys contains settings for storing private keys.
+type Keys struct {
+	// Name of the key created on startup if there isn't an active key.
+	Name string
+	// Passphrase is used to encrypt private keys at rest.
+	Passphrase string
+}
+
+// Webhooks contains settings for delivering webhook events.
+type Webhooks struct {
+	// Interval is how often the outbox is checked for events to send.
+	Interval time.Duration
+	// MaxAttempts is the number of times an event is sent before giving up.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it doubles after each attempt.
+	Backoff time.Duration
+	// Timeout is the time allowed for a webhook to respond.
+	Timeout time.Duration
 }
 
 // ConfigurationLoader will load configuration items
@@ -157,4 +279,6 @@ type ConfigurationLoader interface {
 	WithLog() *Config
 	WithPaymail() *Config
 	WithWallet() *Config
+	WithKeys() *Config
+	WithWebhooks() *Config
 }
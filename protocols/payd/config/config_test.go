@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,6 +35,13 @@ func Test_ConfigValidate(t *testing.T) {
 				},
 			},
 			err: nil,
+		}, "valid db config (badger) should return no errors": {
+			cfg: &Config{
+				Db: &Db{
+					Type: "badger",
+				},
+			},
+			err: nil,
 		}, "invalid db config should return no errors": {
 			cfg: &Config{
 				Db: &Db{
@@ -41,6 +49,89 @@ func Test_ConfigValidate(t *testing.T) {
 				},
 			},
 			err: errors.New("[db.type: value mydb failed to meet requirements]"),
+		}, "keys config with passphrase should return no errors": {
+			cfg: &Config{
+				Keys: &Keys{
+					Passphrase: "a long passphrase",
+				},
+			},
+			err: nil,
+		}, "keys config with short passphrase should error": {
+			cfg: &Config{
+				Keys: &Keys{
+					Passphrase: "short",
+				},
+			},
+			err: errors.New("[keys.passphrase: value must be between 12 and 1024 characters]"),
+		}, "mapi config with miners should return no errors": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Timeout: time.Second,
+					Policy:  BroadcastAll,
+					Miners:  []*Miner{{Name: "one", URL: "http://one"}, {Name: "two", URL: "http://two"}},
+				},
+			},
+			err: nil,
+		}, "mapi config without miners should error": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Timeout: time.Second,
+					Policy:  BroadcastFirst,
+				},
+			},
+			err: errors.New("[mapi.miners: at least one miner is required]"),
+		}, "mapi config with invalid miners json should error": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Timeout:   time.Second,
+					Policy:    BroadcastFirst,
+					minersErr: errors.New("MAPI_MINERS is not a valid json array of miners"),
+				},
+			},
+			err: errors.New("[mapi.miners: MAPI_MINERS is not a valid json array of miners]"),
+		}, "mapi config with duplicate miners should error": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Timeout: time.Second,
+					Policy:  BroadcastFirst,
+					Miners:  []*Miner{{Name: "one", URL: "http://one"}, {Name: "one", URL: "http://two"}},
+				},
+			},
+			err: errors.New("[mapi.miners: miner name one is duplicated]"),
+		}, "mapi config without timeout should error": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Policy: BroadcastFirst,
+					Miners: []*Miner{{Name: "one", URL: "http://one"}},
+				},
+			},
+			err: errors.New("[mapi.timeout: timeout must be greater than 0]"),
+		}, "mapi config with unknown policy should error": {
+			cfg: &Config{
+				Mapi: &MApi{
+					Timeout: time.Second,
+					Policy:  "some",
+					Miners:  []*Miner{{Name: "one", URL: "http://one"}},
+				},
+			},
+			err: errors.New("[mapi.policy: value some failed to meet requirements]"),
+		}, "webhooks config should return no errors": {
+			cfg: &Config{
+				Webhooks: &Webhooks{
+					Interval:    10 * time.Second,
+					MaxAttempts: 10,
+					Backoff:     30 * time.Second,
+					Timeout:     10 * time.Second,
+				},
+			},
+			err: nil,
+		}, "webhooks config without attempts or timeout should error": {
+			cfg: &Config{
+				Webhooks: &Webhooks{
+					Interval: 10 * time.Second,
+				},
+			},
+			err: errors.New("[webhooks.maxattempts: value 0 is smaller than minimum 1], [webhooks.timeout: timeout must be greater than 0]"),
 		},
 	}
 	for name, test := range tests {
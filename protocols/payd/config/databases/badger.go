@@ -0,0 +1,33 @@
+package databases
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+
+	"github.com/libsv/payd/config"
+	paydBadger "github.com/libsv/payd/data/badger"
+)
+
+// setupBadgerDB opens an embedded badger database in the directory set
+// as the dsn, badger has no schema so only records written by earlier
+// versions are migrated.
+func setupBadgerDB(c *config.Db) (*Db, error) {
+	db, err := badger.Open(badger.DefaultOptions(c.Dsn).WithLoggingLevel(badger.WARNING))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup database")
+	}
+	store := paydBadger.NewBadgerStore(db)
+	if c.MigrateDb {
+		if err := store.Migrate(context.Background()); err != nil {
+			_ = db.Close()
+			return nil, errors.Wrap(err, "failed to migrate database")
+		}
+	}
+	return &Db{
+		Store:      store,
+		Transacter: &paydBadger.Transacter{},
+		closer:     db,
+	}, nil
+}
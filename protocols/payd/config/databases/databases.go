@@ -2,26 +2,40 @@ package databases
 
 import (
 	"fmt"
+	"io"
 
-	"github.com/jmoiron/sqlx"
-
+	gopayd "github.com/libsv/payd"
 	"github.com/libsv/payd/config"
 )
 
-type dbSetupFunc func(c *config.Db) (*sqlx.DB, error)
+// Db is a data store along with the Transacter used to run
+// its methods in a single database transaction.
+type Db struct {
+	gopayd.Store
+	Transacter gopayd.Transacter
+	closer     io.Closer
+}
+
+// Close will close the underlying database.
+func (d *Db) Close() error {
+	return d.closer.Close()
+}
+
+type dbSetupFunc func(c *config.Db) (*Db, error)
 type dbSetups map[config.DbType]dbSetupFunc
 
 // NewDbSetup will load the db setup functions into a lookup map
 // ready for being called in main.go.
 func NewDbSetup() dbSetups {
-	s := make(map[config.DbType]dbSetupFunc, 3)
+	s := make(map[config.DbType]dbSetupFunc, 4)
 	s[config.DBSqlite] = setupSqliteDB
 	//s[config.DBMySql] = setupMySqlDB
-	//s[config.DBPostgres] = setupPostgresDB
+	s[config.DBPostgres] = setupPostgresDB
+	s[config.DBBadger] = setupBadgerDB
 	return s
 }
 
-func (d dbSetups) SetupDb(cfg *config.Db) (*sqlx.DB, error) {
+func (d dbSetups) SetupDb(cfg *config.Db) (*Db, error) {
 	fn, ok := d[cfg.Type]
 	if !ok {
 		return nil, fmt.Errorf("db type %s not supported", cfg.Type)
@@ -0,0 +1,60 @@
+package databases
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+
+	// this is needed for laoding file based migrations.
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+
+	// used to import the postgres drivers.
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/libsv/payd/config"
+	paydPostgres "github.com/libsv/payd/data/postgres"
+)
+
+// setupPostgresDB connects to a postgres database shared by payd replicas,
+// migrations hold a lock so replicas starting together run them once.
+func setupPostgresDB(c *config.Db) (*Db, error) {
+	db, err := sqlx.Open("postgres", c.Dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup database")
+	}
+	if !c.MigrateDb {
+		log.Println("migrate database set to false, skipping migration")
+		return newPostgresDb(db), nil
+	}
+	log.Println("migrating database")
+	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	if err != nil {
+		log.Fatalf("creating postgres db driver failed %s", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", c.SchemaPath), "postgres",
+		driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			log.Fatal(err)
+		}
+	}
+	log.Println("migrating database completed")
+	return newPostgresDb(db), nil
+}
+
+func newPostgresDb(db *sqlx.DB) *Db {
+	return &Db{
+		Store:      paydPostgres.NewPostgresStore(db),
+		Transacter: &paydPostgres.Transacter{},
+		closer:     db,
+	}
+}
@@ -16,16 +16,17 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/libsv/payd/config"
+	paydSQL "github.com/libsv/payd/data/sqlite"
 )
 
-func setupSqliteDB(c *config.Db) (*sqlx.DB, error) {
+func setupSqliteDB(c *config.Db) (*Db, error) {
 	db, err := sqlx.Open("sqlite3", c.Dsn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to setup database")
 	}
 	if !c.MigrateDb {
 		log.Println("migrate database set to false, skipping migration")
-		return db, nil
+		return newSqliteDb(db), nil
 	}
 	log.Println("migrating database")
 	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
@@ -46,5 +47,13 @@ func setupSqliteDB(c *config.Db) (*sqlx.DB, error) {
 
 	}
 	log.Println("migrating database completed")
-	return db, nil
+	return newSqliteDb(db), nil
+}
+
+func newSqliteDb(db *sqlx.DB) *Db {
+	return &Db{
+		Store:      paydSQL.NewSQLiteStore(db),
+		Transacter: &paydSQL.Transacter{},
+		closer:     db,
+	}
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -56,8 +58,14 @@ func (c *Config) WithLog() *Config {
 // WithDb sets up and returns database configuration.
 func (c *Config) WithDb() *Config {
 	viper.SetDefault(EnvDb, "sqlite")
-	viper.SetDefault(EnvDbDsn, "file:data/wallet.db?_foreign_keys=true&pooled=true")
-	viper.SetDefault(EnvDbSchema, "data/sqlite/migrations")
+	// badger is stored in a directory rather than a file.
+	if DbType(viper.GetString(EnvDb)) == DBBadger {
+		viper.SetDefault(EnvDbDsn, "data/badger")
+	} else {
+		viper.SetDefault(EnvDbDsn, "file:data/wallet.db?_foreign_keys=true&pooled=true")
+	}
+	// each sql database has its own migrations.
+	viper.SetDefault(EnvDbSchema, fmt.Sprintf("data/%s/migrations", viper.GetString(EnvDb)))
 	viper.SetDefault(EnvDbMigrate, true)
 	c.Db = &Db{
 		Type:       DbType(viper.GetString(EnvDb)),
@@ -100,6 +108,9 @@ func (c *Config) WithWallet() *Config {
 	viper.SetDefault(EnvMerchantAddress, "1 the street, town, T1 1TT")
 	viper.SetDefault(EnvMerchantEmail, "test@ppctl.nchain.com")
 	viper.SetDefault(EnvPaymentExpiry, 24)
+	viper.SetDefault(EnvExpiryInterval, time.Minute)
+	// the network dust limit, bt.DustLimit in go-bt.
+	viper.SetDefault(EnvDustLimit, 136)
 	c.Wallet = &Wallet{
 		Network:            viper.GetString(EnvNetwork),
 		MerchantAvatarURL:  viper.GetString(EnvAvatarURL),
@@ -107,19 +118,65 @@ func (c *Config) WithWallet() *Config {
 		MerchantEmail:      viper.GetString(EnvMerchantEmail),
 		Address:            viper.GetString(EnvMerchantAddress),
 		PaymentExpiryHours: viper.GetInt(EnvPaymentExpiry),
+		ExpiryInterval:     viper.GetDuration(EnvExpiryInterval),
+		DustLimit:          viper.GetUint64(EnvDustLimit),
 	}
 	return c
 }
 
-// WithMapi will setup Mapi settings.
+// WithMapi will setup Mapi settings. Several miners can be set as a json array in
+// MAPI_MINERS, otherwise a single miner is read from the MAPI_MINERNAME, MAPI_MINERURL,
+// MAPI_TOKEN and MAPI_MINERID variables.
 func (c *Config) WithMapi() *Config {
 	viper.SetDefault(EnvMAPIMinerName, "local-mapi")
 	viper.SetDefault(EnvMAPIURL, "http://mapi:9014")
 	viper.SetDefault(EnvMAPIToken, "")
+	viper.SetDefault(EnvMAPIMinerID, "")
+	viper.SetDefault(EnvMAPIMiners, "")
+	viper.SetDefault(EnvMAPIPolicy, string(BroadcastFirst))
+	viper.SetDefault(EnvMAPITimeout, 30*time.Second)
 	c.Mapi = &MApi{
-		MinerName: viper.GetString(EnvMAPIMinerName),
-		URL:       viper.GetString(EnvMAPIURL),
-		Token:     viper.GetString(EnvMAPIToken),
+		Policy:  BroadcastPolicy(viper.GetString(EnvMAPIPolicy)),
+		Timeout: viper.GetDuration(EnvMAPITimeout),
+	}
+	if miners := viper.GetString(EnvMAPIMiners); miners != "" {
+		// an invalid list is reported when validating.
+		if err := json.Unmarshal([]byte(miners), &c.Mapi.Miners); err != nil {
+			c.Mapi.Miners = nil
+			c.Mapi.minersErr = fmt.Errorf("MAPI_MINERS is not a valid json array of miners: %w", err)
+		}
+		return c
+	}
+	c.Mapi.Miners = []*Miner{{
+		Name:    viper.GetString(EnvMAPIMinerName),
+		URL:     viper.GetString(EnvMAPIURL),
+		Token:   viper.GetString(EnvMAPIToken),
+		MinerID: viper.GetString(EnvMAPIMinerID),
+	}}
+	return c
+}
+
+// WithKeys will setup private key settings, there is no default passphrase.
+func (c *Config) WithKeys() *Config {
+	viper.SetDefault(EnvKeysName, "keyname")
+	c.Keys = &Keys{
+		Name:       viper.GetString(EnvKeysName),
+		Passphrase: viper.GetString(EnvKeysPassphrase),
+	}
+	return c
+}
+
+// WithWebhooks will setup webhook delivery settings.
+func (c *Config) WithWebhooks() *Config {
+	viper.SetDefault(EnvWebhooksInterval, 10*time.Second)
+	viper.SetDefault(EnvWebhooksMaxAttempts, 10)
+	viper.SetDefault(EnvWebhooksBackoff, 30*time.Second)
+	viper.SetDefault(EnvWebhooksTimeout, 10*time.Second)
+	c.Webhooks = &Webhooks{
+		Interval:    viper.GetDuration(EnvWebhooksInterval),
+		MaxAttempts: viper.GetInt(EnvWebhooksMaxAttempts),
+		Backoff:     viper.GetDuration(EnvWebhooksBackoff),
+		Timeout:     viper.GetDuration(EnvWebhooksTimeout),
 	}
 	return c
 }
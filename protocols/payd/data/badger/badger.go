@@ -0,0 +1,210 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+)
+
+// Keys are made up of a table prefix followed by the values identifying the
+// record, separated by a null byte as derivation paths and scripts can
+// contain any of the printable separators.
+const (
+	tblInvoices     = "invoices"
+	tblPayments     = "payments"
+	tblTransactions = "transactions"
+	tblTxos         = "txos"
+	tblPartialTxos  = "partialtxos"
+	tblDerivations  = "derivations"
+	tblKeys         = "keys"
+	tblProofs       = "proofs"
+	tblCallbacks    = "callbacktokens"
+	tblWebhooks     = "webhooks"
+	tblEvents       = "webhookevents"
+	tblEventsDue    = "webhookeventsdue"
+	tblMigrations   = "migrations"
+
+	keySep = "\x00"
+)
+
+type badgerStore struct {
+	db *badger.DB
+}
+
+// NewBadgerStore will setup and return a badger data store.
+//
+// Badger has no secondary indexes, the txos are indexed by derivation path
+// when written so payment requests don't need to scan every txo.
+func NewBadgerStore(db *badger.DB) *badgerStore {
+	return &badgerStore{db: db}
+}
+
+func (s *badgerStore) newTx(ctx context.Context) *badger.Txn {
+	ctxx := TxFromContext(ctx)
+	if ctxx != nil {
+		if ctxx.Txn == nil {
+			ctxx.Txn = s.db.NewTransaction(true)
+		}
+		return ctxx.Txn
+	}
+	return s.db.NewTransaction(true)
+}
+
+// view will run fn in the context based transaction if there is one so
+// reads see its pending writes, otherwise a read only transaction is used.
+func (s *badgerStore) view(ctx context.Context, fn func(txn *badger.Txn) error) error {
+	ctxx := TxFromContext(ctx)
+	if ctxx != nil && ctxx.Txn != nil {
+		return fn(ctxx.Txn)
+	}
+	return s.db.View(fn)
+}
+
+// commit a transaction, if there is a context based tx
+// this will not commit - we wait on the context to close it.
+func commit(ctx context.Context, txn *badger.Txn) error {
+	ctxx := TxFromContext(ctx)
+	if ctxx != nil {
+		if ctxx.Txn != nil {
+			return nil
+		}
+	}
+	return txn.Commit()
+}
+
+// rollback a transaction, if there is a context based tx
+// this will not rollback - we wait on the context to close it.
+func rollback(ctx context.Context, txn *badger.Txn) {
+	ctxx := TxFromContext(ctx)
+	if ctxx != nil {
+		if ctxx.Txn != nil {
+			return
+		}
+	}
+	txn.Discard()
+}
+
+// key joins the table and values into a record key.
+func key(tbl string, vals ...string) []byte {
+	return []byte(strings.Join(append([]string{tbl}, vals...), keySep))
+}
+
+// prefix returns the key prefix shared by all records starting with the values.
+func prefix(tbl string, vals ...string) []byte {
+	return append(key(tbl, vals...), keySep...)
+}
+
+// get will read the record at k into v, badger.ErrKeyNotFound is returned
+// if there isn't one.
+func get(txn *badger.Txn, k []byte, v interface{}) error {
+	item, err := txn.Get(k)
+	if err != nil {
+		return err
+	}
+	return item.Value(func(val []byte) error {
+		return errors.Wrapf(json.Unmarshal(val, v), "failed to decode %s", k)
+	})
+}
+
+// set will store v at k.
+func set(txn *badger.Txn, k []byte, v interface{}) error {
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s", k)
+	}
+	return txn.Set(k, bb)
+}
+
+// exists will return true if there is a record at k.
+func exists(txn *badger.Txn, k []byte) (bool, error) {
+	if _, err := txn.Get(k); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// each will call fn with the key and value of every record starting with p.
+// The value is only valid until fn returns.
+func each(txn *badger.Txn, p []byte, fn func(k, val []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = p
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Seek(p); it.ValidForPrefix(p); it.Next() {
+		item := it.Item()
+		if err := item.Value(func(val []byte) error {
+			return fn(item.KeyCopy(nil), val)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPrefix will return true if any record starts with p.
+func hasPrefix(txn *badger.Txn, p []byte) bool {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = p
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	it.Seek(p)
+	return it.ValidForPrefix(p)
+}
+
+type execKey int
+
+// nolint:gochecknoglobals // this variable is fine as it's used for context & is private
+var exec execKey
+
+// Tx wraps the transaction used in context.
+type Tx struct {
+	*badger.Txn
+}
+
+// WithTxContext will add a new empty transaction to the provided context.
+func WithTxContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exec, &Tx{})
+}
+
+// TxFromContext will return a context based transaction if found.
+func TxFromContext(ctx context.Context) *Tx {
+	if tx, ok := ctx.Value(exec).(*Tx); ok {
+		return tx
+	}
+	return nil
+}
+
+// Transacter is used to implement the DBTransacter interface for
+// managing db transactions in other layers.
+type Transacter struct {
+}
+
+// WithTx will add a TX to the provided context.
+func (t *Transacter) WithTx(ctx context.Context) context.Context {
+	return WithTxContext(ctx)
+}
+
+// Commit will commit a distributed transaction.
+func (t *Transacter) Commit(ctx context.Context) error {
+	tx := TxFromContext(ctx)
+	if tx.Txn != nil {
+		return tx.Txn.Commit()
+	}
+	return nil
+}
+
+// Rollback will revert the tx and rollback any changes.
+func (t *Transacter) Rollback(ctx context.Context) error {
+	tx := TxFromContext(ctx)
+	if tx.Txn != nil {
+		tx.Txn.Discard()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package badger_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/lathos"
+
+	gopayd "github.com/libsv/payd"
+	paydBadger "github.com/libsv/payd/data/badger"
+	"github.com/libsv/payd/data/storetest"
+)
+
+func TestBadgerStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (gopayd.Store, gopayd.Transacter) {
+		return setupStore(t), &paydBadger.Transacter{}
+	})
+}
+
+func TestBadgerStore_Migrate(t *testing.T) {
+	ctx := context.Background()
+	s := setupStore(t)
+	// broadcast before callback tokens were introduced.
+	_, err := s.StoreUtxos(ctx, gopayd.CreateTransaction{TxID: "abc", TxHex: "00"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Migrate(ctx))
+	token, err := s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: "abc"})
+	if assert.NoError(t, err) {
+		assert.True(t, token.Legacy())
+	}
+
+	// the migration only runs once.
+	_, err = s.StoreUtxos(ctx, gopayd.CreateTransaction{TxID: "def", TxHex: "00"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Migrate(ctx))
+	_, err = s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: "def"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+}
+
+func setupStore(t *testing.T) paydStore {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	return paydBadger.NewBadgerStore(db)
+}
+
+// paydStore is the badger store along with its migrations.
+type paydStore interface {
+	gopayd.Store
+	Migrate(ctx context.Context) error
+}
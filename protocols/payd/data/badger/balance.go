@@ -0,0 +1,31 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// Balance will return the current account balance.
+func (s *badgerStore) Balance(ctx context.Context) (*gopayd.Balance, error) {
+	var resp gopayd.Balance
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return each(txn, prefix(tblTxos), func(k, val []byte) error {
+			var txo gopayd.Txo
+			if err := json.Unmarshal(val, &txo); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			if !txo.SpentAt.Valid {
+				resp.Satoshis += txo.Satoshis
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to get balance")
+	}
+	return &resp, nil
+}
@@ -0,0 +1,88 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// CallbackToken will return the callback token stored for a transaction.
+func (s *badgerStore) CallbackToken(ctx context.Context, args gopayd.CallbackTokenArgs) (*gopayd.CallbackToken, error) {
+	var resp gopayd.CallbackToken
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return get(txn, key(tblCallbacks, args.TxID), &resp)
+	}); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, lathos.NewErrNotFound("N0006", fmt.Sprintf("callback token for txid %s not found", args.TxID))
+		}
+		return nil, errors.Wrapf(err, "failed to get callback token for txid %s", args.TxID)
+	}
+	return &resp, nil
+}
+
+// Migrate will upgrade records written by earlier versions of payd, it is recorded
+// once it has run so it is safe to call on every start.
+//
+// Transactions broadcast before callback tokens were introduced are given a token
+// with no hash so their callbacks are still accepted.
+func (s *badgerStore) Migrate(ctx context.Context) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblMigrations, tblCallbacks)
+	done, err := exists(txn, k)
+	if err != nil {
+		return errors.Wrap(err, "failed to read migrations")
+	}
+	if done {
+		return nil
+	}
+	now := time.Now().UTC()
+	var txIDs []string
+	if err := each(txn, prefix(tblTransactions), func(k, val []byte) error {
+		var tx gopayd.Transaction
+		if err := json.Unmarshal(val, &tx); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		txIDs = append(txIDs, tx.TxID)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to read transactions")
+	}
+	for _, txID := range txIDs {
+		ok, err := exists(txn, key(tblCallbacks, txID))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read callback token for txid %s", txID)
+		}
+		if ok {
+			continue
+		}
+		if err := set(txn, key(tblCallbacks, txID), gopayd.CallbackToken{TxID: txID, CreatedAt: now}); err != nil {
+			return errors.Wrapf(err, "failed to insert callback token for txid %s", txID)
+		}
+	}
+	if err := set(txn, k, now); err != nil {
+		return errors.Wrap(err, "failed to record migration")
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit migration")
+}
+
+// CallbackTokenCreate will store a callback token, replacing any existing token for the transaction.
+func (s *badgerStore) CallbackTokenCreate(ctx context.Context, req gopayd.CallbackTokenCreate) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	if err := set(txn, key(tblCallbacks, req.TxID), gopayd.CallbackToken{
+		TxID:      req.TxID,
+		TokenHash: req.TokenHash,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to insert callback token for txid %s", req.TxID)
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit create callback token tx")
+}
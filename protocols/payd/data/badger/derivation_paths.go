@@ -0,0 +1,31 @@
+package badger
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// DerivationPathExists will return true / false if the supplied derivation path exists or not.
+func (s *badgerStore) DerivationPathExists(ctx context.Context, args gopayd.DerivationExistsArgs) (bool, error) {
+	var exists bool
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		exists = hasPrefix(txn, prefix(tblDerivations, args.KeyName, args.Path))
+		return nil
+	}); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return exists, nil
+}
+
+// txDerivationCreate will index the derivation path used by the txo stored at ref,
+// paymail txos aren't derived from our keys so are skipped.
+func txDerivationCreate(txn *badger.Txn, keyName, path string, ref []byte) error {
+	if path == "" {
+		return nil
+	}
+	return txn.Set(key(tblDerivations, keyName, path, string(ref)), nil)
+}
@@ -0,0 +1,217 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// Invoice will return an invoice that matches the provided args.
+func (s *badgerStore) Invoice(ctx context.Context, args gopayd.InvoiceArgs) (*gopayd.Invoice, error) {
+	var resp *gopayd.Invoice
+	if err := s.view(ctx, func(txn *badger.Txn) (err error) {
+		resp, err = txInvoice(txn, args.PaymentID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Invoices will return all currently stored invoices.
+func (s *badgerStore) Invoices(ctx context.Context) ([]gopayd.Invoice, error) {
+	var resp []gopayd.Invoice
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return each(txn, prefix(tblInvoices), func(k, val []byte) error {
+			var inv gopayd.Invoice
+			if err := json.Unmarshal(val, &inv); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			resp = append(resp, inv)
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to get invoices")
+	}
+	return resp, nil
+}
+
+// Create will persist a new Invoice in the data store.
+func (s *badgerStore) Create(ctx context.Context, req gopayd.InvoiceCreate) (*gopayd.Invoice, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblInvoices, req.PaymentID)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create new invoice with paymentID %s", req.PaymentID)
+	}
+	if ok {
+		return nil, lathos.NewErrDuplicate("D0001", fmt.Sprintf("invoice with paymentID %s already exists", req.PaymentID))
+	}
+	resp := gopayd.Invoice{
+		PaymentID: req.PaymentID,
+		Satoshis:  req.Satoshis,
+		State:     gopayd.InvoiceStatePending,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := set(txn, k, resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert invoice for paymentID %s", req.PaymentID)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when creating invoice with paymentID %s", req.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Update will update the state of an invoice and return the result.
+func (s *badgerStore) Update(ctx context.Context, args gopayd.InvoiceUpdateArgs, req gopayd.InvoiceUpdate) (*gopayd.Invoice, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	resp, err := txInvoice(txn, args.PaymentID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to update invoice with paymentID %s", args.PaymentID)
+	}
+	resp.State = req.State
+	resp.PaymentReceivedAt = req.PaymentReceivedAt
+	resp.RefundTo = req.RefundTo
+	resp.RefundedAt = req.RefundedAt
+	if err := set(txn, key(tblInvoices, args.PaymentID), resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to update invoice for paymentID %s", args.PaymentID)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when updating invoice with paymentID %s", args.PaymentID)
+	}
+	return resp, nil
+}
+
+// Delete will remove an invoice and release the txos reserved for it.
+func (s *badgerStore) Delete(ctx context.Context, args gopayd.InvoiceArgs) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	if _, err := txInvoice(txn, args.PaymentID); err != nil {
+		return errors.WithMessagef(err, "failed to find invoice with paymentID %s to delete", args.PaymentID)
+	}
+	// release any outputs still reserved for the invoice.
+	if err := txPartialTxosDelete(txn, args.PaymentID); err != nil {
+		return errors.Wrapf(err, "failed to delete reserved txos for paymentID %s", args.PaymentID)
+	}
+	if err := txn.Delete(key(tblInvoices, args.PaymentID)); err != nil {
+		return errors.Wrapf(err, "failed to delete invoice for paymentID %s", args.PaymentID)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return errors.Wrapf(err, "failed to commit transaction when deleting invoice with paymentID %s", args.PaymentID)
+	}
+	return nil
+}
+
+// PaymentCreate will store a payment and add its satoshis to the invoice, returning the updated invoice.
+func (s *badgerStore) PaymentCreate(ctx context.Context, req gopayd.PaymentCreate) (*gopayd.Invoice, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	inv, err := txInvoice(txn, req.PaymentID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to create payment for paymentID %s", req.PaymentID)
+	}
+	k := key(tblPayments, req.PaymentID, req.TxID)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create payment for paymentID %s", req.PaymentID)
+	}
+	if ok {
+		return nil, lathos.NewErrDuplicate("D0002", fmt.Sprintf("payment with txid %s already exists", req.TxID))
+	}
+	if err := set(txn, k, gopayd.Payment{
+		TxID:      req.TxID,
+		PaymentID: req.PaymentID,
+		Satoshis:  req.Satoshis,
+		RefundTo:  req.RefundTo,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert payment for paymentID %s", req.PaymentID)
+	}
+	inv.SatoshisPaid += req.Satoshis
+	if err := set(txn, key(tblInvoices, req.PaymentID), inv); err != nil {
+		return nil, errors.Wrapf(err, "failed to add payment to invoice for paymentID %s", req.PaymentID)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when creating payment for paymentID %s", req.PaymentID)
+	}
+	return inv, nil
+}
+
+// Payments will return all payments received for an invoice, oldest first.
+func (s *badgerStore) Payments(ctx context.Context, args gopayd.InvoiceArgs) ([]gopayd.Payment, error) {
+	var resp []gopayd.Payment
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return each(txn, prefix(tblPayments, args.PaymentID), func(k, val []byte) error {
+			var p gopayd.Payment
+			if err := json.Unmarshal(val, &p); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			resp = append(resp, p)
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get payments for paymentID %s", args.PaymentID)
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].CreatedAt.Before(resp[j].CreatedAt)
+	})
+	return resp, nil
+}
+
+// InvoicesExpire will expire all payable invoices past their expiry date and release the
+// txos reserved for them so their derivation paths can be reused.
+func (s *badgerStore) InvoicesExpire(ctx context.Context, args gopayd.InvoicesExpireArgs) (int64, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	var expired []gopayd.Invoice
+	if err := each(txn, prefix(tblInvoices), func(k, val []byte) error {
+		var inv gopayd.Invoice
+		if err := json.Unmarshal(val, &inv); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		if inv.Payable() && inv.Expired(args.Now) {
+			expired = append(expired, inv)
+		}
+		return nil
+	}); err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	for _, inv := range expired {
+		inv.State = gopayd.InvoiceStateExpired
+		if err := set(txn, key(tblInvoices, inv.PaymentID), inv); err != nil {
+			return 0, errors.Wrapf(err, "failed to expire invoice with paymentID %s", inv.PaymentID)
+		}
+		if err := txPartialTxosDelete(txn, inv.PaymentID); err != nil {
+			return 0, errors.Wrapf(err, "failed to delete reserved txos for expired invoice with paymentID %s", inv.PaymentID)
+		}
+	}
+	if err := commit(ctx, txn); err != nil {
+		return 0, errors.Wrap(err, "failed to commit transaction when expiring invoices")
+	}
+	return int64(len(expired)), nil
+}
+
+// txInvoice reads an invoice in txn, a not found error is returned if it doesn't exist.
+func txInvoice(txn *badger.Txn, paymentID string) (*gopayd.Invoice, error) {
+	var resp gopayd.Invoice
+	if err := get(txn, key(tblInvoices, paymentID), &resp); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, lathos.NewErrNotFound("N0001", fmt.Sprintf("invoice with paymentID %s not found", paymentID))
+		}
+		return nil, errors.Wrapf(err, "failed to get invoice with paymentID %s", paymentID)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,151 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// PrivateKey will return a key by name from the datastore.
+// If not found an error will be returned.
+func (s *badgerStore) PrivateKey(ctx context.Context, args gopayd.KeyArgs) (*gopayd.PrivateKey, error) {
+	var resp *gopayd.PrivateKey
+	if err := s.view(ctx, func(txn *badger.Txn) (err error) {
+		resp, err = txPrivateKey(txn, args.Name)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PrivateKeyActive will return the key new outputs are derived from.
+// If there isn't an active key a not found error is returned.
+func (s *badgerStore) PrivateKeyActive(ctx context.Context) (*gopayd.PrivateKey, error) {
+	keys, err := s.PrivateKeys(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get active key from datastore")
+	}
+	for _, k := range keys {
+		if k.Active {
+			return &k, nil
+		}
+	}
+	return nil, lathos.NewErrNotFound("N0005", "no active key found")
+}
+
+// PrivateKeys will return all keys, oldest first.
+func (s *badgerStore) PrivateKeys(ctx context.Context) ([]gopayd.PrivateKey, error) {
+	var resp []gopayd.PrivateKey
+	if err := s.view(ctx, func(txn *badger.Txn) (err error) {
+		resp, err = txPrivateKeys(txn)
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to get keys from datastore")
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].CreatedAt.Before(resp[j].CreatedAt)
+	})
+	return resp, nil
+}
+
+// PrivateKeyCreate will create and return a new key in the database.
+// If the new key is active, the current active key is rotated in the same transaction.
+func (s *badgerStore) PrivateKeyCreate(ctx context.Context, req gopayd.PrivateKey) (*gopayd.PrivateKey, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblKeys, req.Name)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to add key named '%s'", req.Name)
+	}
+	if ok {
+		return nil, lathos.NewErrDuplicate("D0005", fmt.Sprintf("key named %s already exists", req.Name))
+	}
+	now := time.Now().UTC()
+	if req.Active {
+		keys, err := txPrivateKeys(txn)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to rotate active key")
+		}
+		for _, pk := range keys {
+			if !pk.Active {
+				continue
+			}
+			pk.Active = false
+			pk.RotatedAt.SetValid(now)
+			if err := set(txn, key(tblKeys, pk.Name), pk); err != nil {
+				return nil, errors.Wrap(err, "failed to rotate active key")
+			}
+		}
+	}
+	resp := gopayd.PrivateKey{
+		Name:       req.Name,
+		Xprv:       req.Xprv,
+		Encryption: req.Encryption,
+		Active:     req.Active,
+		CreatedAt:  now,
+	}
+	if err := set(txn, k, resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to add key named '%s'", req.Name)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrap(err, "failed to commit create key tx")
+	}
+	return &resp, nil
+}
+
+// PrivateKeyUpdate will update the xprv and encryption of a key.
+func (s *badgerStore) PrivateKeyUpdate(ctx context.Context, args gopayd.KeyArgs, req gopayd.PrivateKeyUpdate) (*gopayd.PrivateKey, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	resp, err := txPrivateKey(txn, args.Name)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to update key named '%s'", args.Name)
+	}
+	resp.Xprv = req.Xprv
+	resp.Encryption = req.Encryption
+	if err := set(txn, key(tblKeys, args.Name), resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to update key named '%s'", args.Name)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrap(err, "failed to commit update key tx")
+	}
+	return resp, nil
+}
+
+// txPrivateKey reads a key in txn, a not found error is returned if it doesn't exist.
+func txPrivateKey(txn *badger.Txn, name string) (*gopayd.PrivateKey, error) {
+	var resp gopayd.PrivateKey
+	if err := get(txn, key(tblKeys, name), &resp); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, lathos.NewErrNotFound("N0004", fmt.Sprintf("key named %s not found", name))
+		}
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", name)
+	}
+	return &resp, nil
+}
+
+// txPrivateKeys reads all keys in txn.
+func txPrivateKeys(txn *badger.Txn) ([]gopayd.PrivateKey, error) {
+	var resp []gopayd.PrivateKey
+	if err := each(txn, prefix(tblKeys), func(k, val []byte) error {
+		var pk gopayd.PrivateKey
+		if err := json.Unmarshal(val, &pk); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		resp = append(resp, pk)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
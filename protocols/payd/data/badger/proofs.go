@@ -0,0 +1,69 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/libsv/go-bc"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// proof is a merkle proof received from a miner for a transaction in a block.
+type proof struct {
+	BlockHash string
+	Data      *bc.MerkleProof
+	CreatedAt time.Time
+}
+
+// ProofCreate will insert a proof to the database.
+func (s *badgerStore) ProofCreate(ctx context.Context, req gopayd.ProofWrapper) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblProofs, req.CallbackTxID, req.BlockHash)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return errors.Wrapf(err, "failed to insert proof for txid %s and blockhash '%s'", req.CallbackTxID, req.BlockHash)
+	}
+	if ok {
+		return lathos.NewErrDuplicate("D0006",
+			fmt.Sprintf("proof for txid %s and blockhash '%s' already exists", req.CallbackTxID, req.BlockHash))
+	}
+	if err := set(txn, k, proof{
+		BlockHash: req.BlockHash,
+		Data:      req.CallbackPayload,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to insert proof for txid %s and blockhash '%s'", req.CallbackTxID, req.BlockHash)
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit create proof tx")
+}
+
+// MerkleProof will return the latest merkle proof for a transaction, nil is
+// returned if a proof hasn't been received yet.
+func (s *badgerStore) MerkleProof(ctx context.Context, txID string) (*bc.MerkleProof, error) {
+	var latest *proof
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return each(txn, prefix(tblProofs, txID), func(k, val []byte) error {
+			var p proof
+			if err := json.Unmarshal(val, &p); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			if latest == nil || p.CreatedAt.After(latest.CreatedAt) {
+				latest = &p
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get proof for txid %s", txID)
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return latest.Data, nil
+}
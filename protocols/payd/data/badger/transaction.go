@@ -0,0 +1,92 @@
+package badger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// StoreUtxos will store a transaction along with the txos we hold keys or paymail references for.
+func (s *badgerStore) StoreUtxos(ctx context.Context, req gopayd.CreateTransaction) (*gopayd.Transaction, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblTransactions, req.TxID)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to insert new transaction")
+	}
+	if ok {
+		return nil, lathos.NewErrDuplicate("D0003", fmt.Sprintf("transaction %s already exists", req.TxID))
+	}
+	now := time.Now().UTC()
+	resp := gopayd.Transaction{
+		PaymentID: null.NewString(req.PaymentID, req.PaymentID != ""),
+		TxID:      req.TxID,
+		TxHex:     req.TxHex,
+		CreatedAt: now,
+	}
+	if err := set(txn, k, resp); err != nil {
+		return nil, errors.Wrap(err, "failed to insert new transaction")
+	}
+	// transactions sent from the wallet may not have change.
+	for _, o := range req.Outputs {
+		ok, err := exists(txn, key(tblTxos, o.Outpoint))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to insert transaction outputs")
+		}
+		if ok {
+			return nil, lathos.NewErrDuplicate("D0004", fmt.Sprintf("txo %s already exists", o.Outpoint))
+		}
+		txo := gopayd.Txo{
+			Outpoint:       o.Outpoint,
+			TxID:           o.TxID,
+			Vout:           o.Vout,
+			KeyName:        o.KeyName,
+			DerivationPath: o.DerivationPath,
+			LockingScript:  o.LockingScript,
+			Satoshis:       o.Satoshis,
+			CreatedAt:      now,
+			ModifiedAt:     now,
+		}
+		if err := set(txn, key(tblTxos, o.Outpoint), txo); err != nil {
+			return nil, errors.Wrap(err, "failed to insert transaction outputs")
+		}
+		if err := txDerivationCreate(txn, o.KeyName.String, o.DerivationPath.String, key(tblTxos, o.Outpoint)); err != nil {
+			return nil, errors.Wrap(err, "failed to index transaction output derivation paths")
+		}
+		resp.Outputs = append(resp.Outputs, txo)
+	}
+	sort.SliceStable(resp.Outputs, func(i, j int) bool {
+		return resp.Outputs[i].Vout < resp.Outputs[j].Vout
+	})
+	return &resp, errors.Wrapf(commit(ctx, txn),
+		"failed to commit transaction when adding tx and outputs for paymentID %s", req.PaymentID)
+}
+
+// Tx will return a stored transaction, nil is returned if it isn't found.
+// This is used to build spv envelopes for transactions we send.
+func (s *badgerStore) Tx(ctx context.Context, txID string) (*bt.Tx, error) {
+	var stored gopayd.Transaction
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return get(txn, key(tblTransactions, txID), &stored)
+	}); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get transaction %s", txID)
+	}
+	tx, err := bt.NewTxFromString(stored.TxHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse transaction %s", txID)
+	}
+	return tx, nil
+}
@@ -0,0 +1,221 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/errcodes"
+)
+
+// partialTxo is a txo reserved for an invoice that hasn't been paid.
+type partialTxo struct {
+	PaymentID string
+	gopayd.UnspentTxo
+}
+
+// TxoCreate will store a txo created during payment requests.
+func (s *badgerStore) TxoCreate(ctx context.Context, req gopayd.TxoCreate) error {
+	return s.TxosCreate(ctx, []*gopayd.TxoCreate{
+		&req,
+	})
+}
+
+// TxosCreate will store txos created during payment requests.
+func (s *badgerStore) TxosCreate(ctx context.Context, req []*gopayd.TxoCreate) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	now := time.Now().UTC()
+	for _, r := range req {
+		k := key(tblPartialTxos, r.PaymentID, r.LockingScript)
+		if err := set(txn, k, partialTxo{
+			PaymentID: r.PaymentID,
+			UnspentTxo: gopayd.UnspentTxo{
+				KeyName:        r.KeyName,
+				DerivationPath: r.DerivationPath,
+				LockingScript:  r.LockingScript,
+				Satoshis:       r.Satoshis,
+				CreatedAt:      now,
+				ModifiedAt:     now,
+			},
+		}); err != nil {
+			return errors.Wrap(err, "failed to insert script keys.")
+		}
+		if err := txDerivationCreate(txn, r.KeyName, r.DerivationPath, k); err != nil {
+			return errors.Wrap(err, "failed to index derivation path.")
+		}
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit transaction when creating txos.")
+}
+
+// PartialTxo will return a txo that has been stored but not yet assigned to a transaction.
+func (s *badgerStore) PartialTxo(ctx context.Context, args gopayd.UnspentTxoArgs) (*gopayd.UnspentTxo, error) {
+	var txo partialTxo
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return get(txn, key(tblPartialTxos, args.PaymentID, args.LockingScript), &txo)
+	}); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, errs.NewErrNotFound("N104",
+				fmt.Sprintf("unable to find txo with script '%s' and paymentID '%s'",
+					args.LockingScript, args.PaymentID))
+		}
+		return nil, errors.Wrap(err, "failed to read partialTxo")
+	}
+	return &txo.UnspentTxo, nil
+}
+
+// SpendableTxos will return the unspent txos we hold keys for, largest first.
+func (s *badgerStore) SpendableTxos(ctx context.Context) ([]gopayd.Txo, error) {
+	var txos []gopayd.Txo
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		keys := map[string]struct{}{}
+		if err := each(txn, prefix(tblKeys), func(k, val []byte) error {
+			var pk gopayd.PrivateKey
+			if err := json.Unmarshal(val, &pk); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			keys[pk.Name] = struct{}{}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return each(txn, prefix(tblTxos), func(k, val []byte) error {
+			var txo gopayd.Txo
+			if err := json.Unmarshal(val, &txo); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			if txo.SpentAt.Valid || !txo.DerivationPath.Valid {
+				return nil
+			}
+			if _, ok := keys[txo.KeyName.String]; ok {
+				txos = append(txos, txo)
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to read spendable txos")
+	}
+	sort.SliceStable(txos, func(i, j int) bool {
+		return txos[i].Satoshis > txos[j].Satoshis
+	})
+	return txos, nil
+}
+
+// TxosSpend will mark the txos as spent by req.SpendingTxID. If a txo has already
+// been spent a duplicate error is returned and the transaction is rolled back.
+//
+// Badger transactions are optimistic, if another transaction spends the same
+// txo first the commit fails with badger.ErrConflict.
+func (s *badgerStore) TxosSpend(ctx context.Context, args []gopayd.SpendTxoArgs, req gopayd.SpendTxo) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	now := time.Now().UTC()
+	spentAt := now
+	if req.SpentAt != nil {
+		spentAt = *req.SpentAt
+	}
+	for _, a := range args {
+		k := key(tblTxos, a.Outpoint)
+		var txo gopayd.Txo
+		if err := get(txn, k, &txo); err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return errs.NewErrDuplicate(errcodes.ErrTxoSpent, fmt.Sprintf("txo %s has already been spent", a.Outpoint))
+			}
+			return errors.Wrapf(err, "failed to spend txo %s", a.Outpoint)
+		}
+		if txo.SpentAt.Valid {
+			return errs.NewErrDuplicate(errcodes.ErrTxoSpent, fmt.Sprintf("txo %s has already been spent", a.Outpoint))
+		}
+		txo.SpentAt.SetValid(spentAt)
+		txo.SpendingTxID.SetValid(req.SpendingTxID)
+		txo.ModifiedAt = now
+		if err := set(txn, k, txo); err != nil {
+			return errors.Wrapf(err, "failed to spend txo %s", a.Outpoint)
+		}
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit transaction when spending txos")
+}
+
+// TxosRelease will mark the txos spent by args.SpendingTxID as unspent and remove
+// the spending transaction along with its outputs and callback token.
+func (s *badgerStore) TxosRelease(ctx context.Context, args gopayd.ReleaseTxosArgs) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	var txos []gopayd.Txo
+	if err := each(txn, prefix(tblTxos), func(k, val []byte) error {
+		var txo gopayd.Txo
+		if err := json.Unmarshal(val, &txo); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		if txo.TxID == args.SpendingTxID || txo.SpendingTxID.String == args.SpendingTxID {
+			txos = append(txos, txo)
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to read txos spent by %s", args.SpendingTxID)
+	}
+	now := time.Now().UTC()
+	for _, txo := range txos {
+		k := key(tblTxos, txo.Outpoint)
+		if txo.TxID != args.SpendingTxID {
+			txo.SpentAt, txo.SpendingTxID, txo.ModifiedAt = null.Time{}, null.String{}, now
+			if err := set(txn, k, txo); err != nil {
+				return errors.Wrapf(err, "failed to release txo %s", txo.Outpoint)
+			}
+			continue
+		}
+		if err := txn.Delete(k); err != nil {
+			return errors.Wrapf(err, "failed to delete %s", k)
+		}
+		if txo.DerivationPath.String == "" {
+			continue
+		}
+		if err := txn.Delete(key(tblDerivations, txo.KeyName.String, txo.DerivationPath.String, string(k))); err != nil {
+			return errors.Wrapf(err, "failed to delete derivation path for %s", k)
+		}
+	}
+	if err := txn.Delete(key(tblCallbacks, args.SpendingTxID)); err != nil {
+		return errors.Wrapf(err, "failed to delete callback token for %s", args.SpendingTxID)
+	}
+	if err := txn.Delete(key(tblTransactions, args.SpendingTxID)); err != nil {
+		return errors.Wrapf(err, "failed to delete transaction %s", args.SpendingTxID)
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit transaction when releasing txos")
+}
+
+// txPartialTxosDelete will delete the txos reserved for an invoice and
+// release their derivation paths.
+func txPartialTxosDelete(txn *badger.Txn, paymentID string) error {
+	var txos []partialTxo
+	if err := each(txn, prefix(tblPartialTxos, paymentID), func(k, val []byte) error {
+		var txo partialTxo
+		if err := json.Unmarshal(val, &txo); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		txos = append(txos, txo)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, txo := range txos {
+		k := key(tblPartialTxos, paymentID, txo.LockingScript)
+		if err := txn.Delete(k); err != nil {
+			return errors.Wrapf(err, "failed to delete %s", k)
+		}
+		if txo.DerivationPath == "" {
+			continue
+		}
+		if err := txn.Delete(key(tblDerivations, txo.KeyName, txo.DerivationPath, string(k))); err != nil {
+			return errors.Wrapf(err, "failed to delete derivation path for %s", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// Webhook will return the webhook matching args.
+func (s *badgerStore) Webhook(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+	var resp *gopayd.Webhook
+	if err := s.view(ctx, func(txn *badger.Txn) (err error) {
+		resp, err = txWebhook(txn, args.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Webhooks will return all webhooks, oldest first.
+func (s *badgerStore) Webhooks(ctx context.Context) ([]gopayd.Webhook, error) {
+	var resp []gopayd.Webhook
+	if err := s.view(ctx, func(txn *badger.Txn) error {
+		return each(txn, prefix(tblWebhooks), func(k, val []byte) error {
+			var wh gopayd.Webhook
+			if err := json.Unmarshal(val, &wh); err != nil {
+				return errors.Wrapf(err, "failed to decode %s", k)
+			}
+			resp = append(resp, wh)
+			return nil
+		})
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to get webhooks")
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].CreatedAt.Before(resp[j].CreatedAt)
+	})
+	return resp, nil
+}
+
+// WebhookCreate will store a new webhook and return it.
+func (s *badgerStore) WebhookCreate(ctx context.Context, req gopayd.WebhookCreate) (*gopayd.Webhook, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	k := key(tblWebhooks, req.ID)
+	ok, err := exists(txn, k)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create webhook with ID %s", req.ID)
+	}
+	if ok {
+		return nil, lathos.NewErrDuplicate("D0007", fmt.Sprintf("webhook with ID %s already exists", req.ID))
+	}
+	resp := gopayd.Webhook{
+		ID:        req.ID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := set(txn, k, resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert webhook with ID %s", req.ID)
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrap(err, "failed to commit create webhook tx")
+	}
+	return &resp, nil
+}
+
+// WebhookDelete will remove a webhook and all of its outbox events.
+//
+// Events aren't indexed by webhook so every event is read, webhooks
+// are rarely deleted so this is preferred to another index.
+func (s *badgerStore) WebhookDelete(ctx context.Context, args gopayd.WebhookArgs) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	if _, err := txWebhook(txn, args.ID); err != nil {
+		return errors.WithMessagef(err, "failed to find webhook with ID %s to delete", args.ID)
+	}
+	var ids []string
+	if err := each(txn, prefix(tblEvents), func(k, val []byte) error {
+		var e gopayd.WebhookEvent
+		if err := json.Unmarshal(val, &e); err != nil {
+			return errors.Wrapf(err, "failed to decode %s", k)
+		}
+		if e.WebhookID == args.ID {
+			ids = append(ids, e.ID)
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "failed to read events for webhook with ID %s", args.ID)
+	}
+	for _, id := range ids {
+		if err := txn.Delete(key(tblEvents, id)); err != nil {
+			return errors.Wrapf(err, "failed to delete webhook event %s", id)
+		}
+		if err := txn.Delete(key(tblEventsDue, id)); err != nil {
+			return errors.Wrapf(err, "failed to delete webhook event %s", id)
+		}
+	}
+	if err := txn.Delete(key(tblWebhooks, args.ID)); err != nil {
+		return errors.Wrapf(err, "failed to delete webhook with ID %s", args.ID)
+	}
+	return errors.Wrapf(commit(ctx, txn), "failed to commit transaction when deleting webhook with ID %s", args.ID)
+}
+
+// WebhookEventsCreate will add the events to the outbox.
+func (s *badgerStore) WebhookEventsCreate(ctx context.Context, req []gopayd.WebhookEventCreate) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	now := time.Now().UTC()
+	for _, e := range req {
+		if err := set(txn, key(tblEvents, e.ID), gopayd.WebhookEvent{
+			ID:            e.ID,
+			WebhookID:     e.WebhookID,
+			Event:         e.Event,
+			Payload:       e.Payload,
+			NextAttemptAt: e.NextAttemptAt,
+			CreatedAt:     now,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to insert webhook event %s", e.ID)
+		}
+		if err := txn.Set(key(tblEventsDue, e.ID), nil); err != nil {
+			return errors.Wrapf(err, "failed to index webhook event %s", e.ID)
+		}
+	}
+	return errors.Wrap(commit(ctx, txn), "failed to commit create webhook events tx")
+}
+
+// WebhookEventsDue will lease and return the events that are due to be sent, oldest first.
+// The commit fails with a conflict if another deliverer leased any of them first.
+func (s *badgerStore) WebhookEventsDue(ctx context.Context, args gopayd.WebhookEventsDueArgs) ([]gopayd.WebhookEvent, error) {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	var resp []gopayd.WebhookEvent
+	p := prefix(tblEventsDue)
+	if err := each(txn, p, func(k, _ []byte) error {
+		var e gopayd.WebhookEvent
+		if err := get(txn, key(tblEvents, string(k[len(p):])), &e); err != nil {
+			return errors.Wrapf(err, "failed to get webhook event for %s", k)
+		}
+		if !e.NextAttemptAt.After(args.Now) {
+			resp = append(resp, e)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to get due webhook events")
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].CreatedAt.Before(resp[j].CreatedAt)
+	})
+	if args.Limit > 0 && len(resp) > args.Limit {
+		resp = resp[:args.Limit]
+	}
+	for i := range resp {
+		resp[i].NextAttemptAt = args.LeaseUntil
+		if err := set(txn, key(tblEvents, resp[i].ID), resp[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed to lease webhook event %s", resp[i].ID)
+		}
+	}
+	if err := commit(ctx, txn); err != nil {
+		return nil, errors.Wrap(err, "failed to commit transaction when leasing webhook events")
+	}
+	return resp, nil
+}
+
+// WebhookEventUpdate will record an attempt to send an event, it is removed
+// from the due index once it is delivered or has failed.
+func (s *badgerStore) WebhookEventUpdate(ctx context.Context, args gopayd.WebhookEventArgs, req gopayd.WebhookEventUpdate) error {
+	txn := s.newTx(ctx)
+	defer rollback(ctx, txn)
+	var e gopayd.WebhookEvent
+	if err := get(txn, key(tblEvents, args.ID), &e); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return lathos.NewErrNotFound("N0008", fmt.Sprintf("webhook event %s not found", args.ID))
+		}
+		return errors.Wrapf(err, "failed to get webhook event %s", args.ID)
+	}
+	e.Attempts = req.Attempts
+	e.NextAttemptAt = req.NextAttemptAt
+	e.DeliveredAt = req.DeliveredAt
+	e.FailedAt = req.FailedAt
+	e.LastError = req.LastError
+	if err := set(txn, key(tblEvents, args.ID), e); err != nil {
+		return errors.Wrapf(err, "failed to update webhook event %s", args.ID)
+	}
+	if e.DeliveredAt.Valid || e.FailedAt.Valid {
+		if err := txn.Delete(key(tblEventsDue, args.ID)); err != nil {
+			return errors.Wrapf(err, "failed to remove webhook event %s from due index", args.ID)
+		}
+	}
+	return errors.Wrapf(commit(ctx, txn), "failed to commit transaction when updating webhook event %s", args.ID)
+}
+
+// txWebhook reads a webhook in txn, a not found error is returned if it doesn't exist.
+func txWebhook(txn *badger.Txn, id string) (*gopayd.Webhook, error) {
+	var resp gopayd.Webhook
+	if err := get(txn, key(tblWebhooks, id), &resp); err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, lathos.NewErrNotFound("N0007", fmt.Sprintf("webhook with ID %s not found", id))
+		}
+		return nil, errors.Wrapf(err, "failed to get webhook with ID %s", id)
+	}
+	return &resp, nil
+}
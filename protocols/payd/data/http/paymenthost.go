@@ -0,0 +1,83 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+type paymentHost struct {
+	client HTTPClient
+}
+
+// NewPaymentHost returns a data store used to read PaymentRequests from
+// and send Payments to BIP-270 payment hosts.
+func NewPaymentHost(client HTTPClient) *paymentHost {
+	return &paymentHost{
+		client: client,
+	}
+}
+
+// PaymentRequest will get the PaymentRequest found at args.URL.
+func (p *paymentHost) PaymentRequest(ctx context.Context, args gopayd.PaymentHostArgs) (*gopayd.PaymentRequest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, args.URL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating payment request for %s", args.URL)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var pr *gopayd.PaymentRequest
+	if err := p.do(req, &pr, http.StatusOK); err != nil {
+		return nil, errors.WithMessage(err, "payment request")
+	}
+	return pr, nil
+}
+
+// PaymentSend will send the payment to args.URL, the PaymentACK is returned
+// when the payment has been accepted or rejected by the host.
+func (p *paymentHost) PaymentSend(ctx context.Context, args gopayd.PaymentHostArgs, req gopayd.CreatePayment) (*gopayd.PaymentACK, error) {
+	bb, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode payment")
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, args.URL, bytes.NewReader(bb))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating payment for %s", args.URL)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+
+	var ack *gopayd.PaymentACK
+	// payd returns rejected payments with a 422, the ack explains why.
+	if err := p.do(r, &ack, http.StatusOK, http.StatusCreated, http.StatusUnprocessableEntity); err != nil {
+		return nil, errors.WithMessage(err, "payment")
+	}
+	return ack, nil
+}
+
+func (p *paymentHost) do(req *http.Request, out interface{}, statuses ...int) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	for _, s := range statuses {
+		if resp.StatusCode == s {
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse error message body")
+	}
+	return fmt.Errorf("unexpected status code %d\nresponse body:\n%s", resp.StatusCode, body)
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// Headers sent with webhook events.
+const (
+	HeaderWebhookEvent     = "X-Payd-Event"
+	HeaderWebhookDelivery  = "X-Payd-Delivery"
+	HeaderWebhookSignature = "X-Payd-Signature"
+)
+
+type webhook struct {
+	client HTTPClient
+}
+
+// NewWebhook returns a data store used to send events to webhooks.
+func NewWebhook(client HTTPClient) *webhook {
+	return &webhook{
+		client: client,
+	}
+}
+
+// WebhookSend will post the payload to args.URL, any 2xx status is treated as success.
+// The signature is sent as sha256=<hex hmac> so the algorithm can be changed later.
+func (w *webhook) WebhookSend(ctx context.Context, args gopayd.WebhookSendArgs, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, args.URL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "error creating webhook request for %s", args.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, string(args.Event))
+	req.Header.Set(HeaderWebhookDelivery, args.EventID)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+args.Signature)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send webhook event to %s", args.URL)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	// the body is stored as the last error, only the start is kept.
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse error message body")
+	}
+	return fmt.Errorf("unexpected status code %d\nresponse body:\n%s", resp.StatusCode, body)
+}
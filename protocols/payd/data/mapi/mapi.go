@@ -2,8 +2,10 @@ package mapi
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/labstack/gommon/log"
 	"github.com/libsv/go-bt/v2"
 	"github.com/pkg/errors"
 	"github.com/tonicpow/go-minercraft"
@@ -24,37 +26,77 @@ func NewMapi(cfg *config.MApi, svrCfg *config.Server, client *minercraft.Client)
 	return &minercraftMapi{client: client, cfg: cfg, svrCfg: svrCfg, fq: bt.NewFeeQuote()}
 }
 
-// Broadcast will submit a transaction to mapi for inclusion in a block.
+// Send will submit a transaction to mapi for inclusion in a block.
 // Any errors will be returned, no error denotes success.
+//
+// With the first policy miners are tried in order until one accepts the transaction,
+// with the all policy the transaction is submitted to every miner. Either way an error
+// is only returned if no miner accepts it, miners that reject it are logged.
 func (m *minercraftMapi) Send(ctx context.Context, args gopayd.SendTransactionArgs, req gopayd.CreatePayment) error {
-	resp, err := m.client.SubmitTransaction(ctx,
-		m.client.MinerByName(m.cfg.MinerName),
-		&minercraft.Transaction{
-			RawTx:              req.Transaction,
-			CallBackURL:        "http://" + m.svrCfg.Hostname + "/api/v1/proofs/" + args.TxID,
-			CallBackToken:      "",
-			MerkleFormat:       "TSC",
-			CallBackEncryption: "",
-			MerkleProof:        true,
-			DsCheck:            true,
-		})
-	if err != nil {
-		return errors.Wrap(err, "failed to submit transaction to minerpool")
+	tx := &minercraft.Transaction{
+		RawTx:              req.Transaction,
+		CallBackURL:        "http://" + m.svrCfg.Hostname + "/api/v1/proofs/" + args.TxID,
+		CallBackToken:      args.CallbackToken,
+		MerkleFormat:       "TSC",
+		CallBackEncryption: "",
+		MerkleProof:        true,
+		DsCheck:            true,
+	}
+	var accepted bool
+	var errs []string
+	for _, miner := range m.cfg.Miners {
+		if err := m.submit(ctx, miner, tx); err != nil {
+			log.Warnf("miner %s failed to accept transaction %s: %s", miner.Name, args.TxID, err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		if m.cfg.Policy == config.BroadcastFirst {
+			return nil
+		}
+		accepted = true
 	}
-	if resp.Results.ReturnResult == minercraft.QueryTransactionSuccess {
+	if len(errs) == 0 {
 		return nil
 	}
-	return errors.Errorf("failed to submit transaction %s", resp.Results.ResultDescription)
+	if accepted {
+		log.Warnf("transaction %s was rejected by %d of %d miners", args.TxID, len(errs), len(m.cfg.Miners))
+		return nil
+	}
+	return errors.Errorf("failed to submit transaction %s: %s", args.TxID, strings.Join(errs, ", "))
 }
 
-// Status will return the current network status of a transaction.
-func (m *minercraftMapi) Status(ctx context.Context, args gopayd.TxStatusArgs) (*gopayd.TxStatus, error) {
-	resp, err := m.client.QueryTransaction(ctx, m.client.MinerByName(m.cfg.MinerName), args.TxID)
+// submit will send the transaction to a single miner, the request times out after the configured timeout.
+func (m *minercraftMapi) submit(ctx context.Context, miner *config.Miner, tx *minercraft.Transaction) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	resp, err := m.client.SubmitTransaction(ctx, m.client.MinerByName(miner.Name), tx)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to query Tx from mAPI")
+		return errors.Wrapf(err, "failed to submit transaction to miner %s", miner.Name)
 	}
-	if !resp.Validated {
-		return nil, errors.Wrap(err, "invalid message payload received from mAPI")
+	if miner.MinerID != "" && (!resp.Validated || resp.PublicKey == nil || !strings.EqualFold(*resp.PublicKey, miner.MinerID)) {
+		return errors.Errorf("response from miner %s is not signed by its minerId", miner.Name)
+	}
+	if resp.Results.ReturnResult != minercraft.QueryTransactionSuccess {
+		return errors.Errorf("miner %s rejected transaction: %s", miner.Name, resp.Results.ResultDescription)
+	}
+	return nil
+}
+
+// Status will return the current network status of a transaction, each miner is
+// queried in turn until one responds.
+func (m *minercraftMapi) Status(ctx context.Context, args gopayd.TxStatusArgs) (*gopayd.TxStatus, error) {
+	var resp *minercraft.QueryTransactionResponse
+	if err := m.each(ctx, func(ctx context.Context, miner *minercraft.Miner) (err error) {
+		resp, err = m.client.QueryTransaction(ctx, miner, args.TxID)
+		if err != nil {
+			return errors.Wrap(err, "failed to query Tx from mAPI")
+		}
+		if !resp.Validated {
+			return errors.New("invalid message payload received from mAPI")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 	errNum := 0
 	if resp.Query.ReturnResult == minercraft.QueryTransactionFailure {
@@ -80,9 +122,12 @@ func (m *minercraftMapi) Fees(ctx context.Context) (*bt.FeeQuote, error) {
 	if !m.fq.Expired() {
 		return m.fq, nil
 	}
-	fq, err := m.client.FeeQuote(ctx, m.client.MinerByName(m.cfg.MinerName))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read fees for ")
+	var fq *minercraft.FeeQuoteResponse
+	if err := m.each(ctx, func(ctx context.Context, miner *minercraft.Miner) (err error) {
+		fq, err = m.client.FeeQuote(ctx, miner)
+		return errors.Wrapf(err, "failed to read fees for %s", miner.Name)
+	}); err != nil {
+		return nil, err
 	}
 	if !fq.Validated {
 		return m.fq, nil
@@ -118,3 +163,23 @@ func (m *minercraftMapi) Fees(ctx context.Context) (*bt.FeeQuote, error) {
 	m.fq.UpdateExpiry(exp.UTC())
 	return m.fq, nil
 }
+
+// each will call fn with each miner in turn until it succeeds, the last error
+// is returned if every miner fails.
+func (m *minercraftMapi) each(ctx context.Context, fn func(ctx context.Context, miner *minercraft.Miner) error) error {
+	err := errors.New("no miners configured")
+	for _, miner := range m.cfg.Miners {
+		if err = m.call(ctx, m.client.MinerByName(miner.Name), fn); err == nil {
+			return nil
+		}
+		log.Warnf("miner %s failed to respond: %s", miner.Name, err)
+	}
+	return err
+}
+
+// call runs fn with the configured timeout.
+func (m *minercraftMapi) call(ctx context.Context, miner *minercraft.Miner, fn func(ctx context.Context, miner *minercraft.Miner) error) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	return fn(ctx, miner)
+}
@@ -0,0 +1,121 @@
+package mapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonicpow/go-minercraft"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+)
+
+// miner returns a mAPI server responding to submissions with result, if delay
+// is set the response is delayed. The number of submissions is counted in calls.
+func miner(t *testing.T, result string, delay time.Duration, calls *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var tx minercraft.Transaction
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&tx))
+		assert.Equal(t, "token", tx.CallBackToken)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		payload := fmt.Sprintf(`{"txid":"abc123","returnResult":%q,"resultDescription":"%s result"}`, result, result)
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"payload":  payload,
+			"encoding": "UTF-8",
+			"mimetype": "application/json",
+		}))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Mapi_Send(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		policy  config.BroadcastPolicy
+		results []string
+		delays  []time.Duration
+		calls   []int32
+		err     error
+	}{
+		"first policy should stop at the first success": {
+			policy:  config.BroadcastFirst,
+			results: []string{"success", "success"},
+			calls:   []int32{1, 0},
+		}, "first policy should fail over when a miner rejects": {
+			policy:  config.BroadcastFirst,
+			results: []string{"failure", "success"},
+			calls:   []int32{1, 1},
+		}, "first policy should fail over when a miner times out": {
+			policy:  config.BroadcastFirst,
+			results: []string{"success", "success"},
+			delays:  []time.Duration{time.Second, 0},
+			calls:   []int32{1, 1},
+		}, "first policy should error when all miners reject": {
+			policy:  config.BroadcastFirst,
+			results: []string{"failure", "failure"},
+			calls:   []int32{1, 1},
+			err:     errors.New("failed to submit transaction abc123: miner m0 rejected transaction: failure result, miner m1 rejected transaction: failure result"),
+		}, "all policy should submit to every miner": {
+			policy:  config.BroadcastAll,
+			results: []string{"success", "success"},
+			calls:   []int32{1, 1},
+		}, "all policy should succeed when any miner accepts": {
+			policy:  config.BroadcastAll,
+			results: []string{"failure", "success", "failure"},
+			calls:   []int32{1, 1, 1},
+		}, "all policy should error when every miner rejects": {
+			policy:  config.BroadcastAll,
+			results: []string{"failure", "failure"},
+			calls:   []int32{1, 1},
+			err:     errors.New("failed to submit transaction abc123: miner m0 rejected transaction: failure result, miner m1 rejected transaction: failure result"),
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &config.MApi{Policy: test.policy, Timeout: 100 * time.Millisecond}
+			var miners []*minercraft.Miner
+			calls := make([]int32, len(test.results))
+			for i, result := range test.results {
+				var delay time.Duration
+				if test.delays != nil {
+					delay = test.delays[i]
+				}
+				srv := miner(t, result, delay, &calls[i])
+				minerName := fmt.Sprintf("m%d", i)
+				cfg.Miners = append(cfg.Miners, &config.Miner{Name: minerName, URL: srv.URL})
+				miners = append(miners, &minercraft.Miner{Name: minerName, URL: srv.URL})
+			}
+			opts := minercraft.DefaultClientOptions()
+			opts.RequestRetryCount = 0
+			cli, err := minercraft.NewClient(opts, nil, miners)
+			assert.NoError(t, err)
+			err = NewMapi(cfg, &config.Server{Hostname: "payd"}, cli).Send(context.Background(),
+				gopayd.SendTransactionArgs{TxID: "abc123", CallbackToken: "token"},
+				gopayd.CreatePayment{Transaction: "0100"})
+			if test.err != nil {
+				assert.EqualError(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			for i := range calls {
+				assert.Equal(t, test.calls[i], atomic.LoadInt32(&calls[i]), "calls to miner %d", i)
+			}
+		})
+	}
+}
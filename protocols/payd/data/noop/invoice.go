@@ -4,7 +4,6 @@ import (
 	"context"
 
 	gopayd "github.com/libsv/payd"
-	"gopkg.in/guregu/null.v3"
 )
 
 // invoice is a no-op invoice that returns some stubbed data.
@@ -21,6 +20,7 @@ func (i *invoice) Invoice(ctx context.Context, args gopayd.InvoiceArgs) (*gopayd
 	return &gopayd.Invoice{
 		PaymentID: args.PaymentID,
 		Satoshis:  10000,
+		State:     gopayd.InvoiceStatePending,
 	}, nil
 }
 
@@ -29,6 +29,8 @@ func (i *invoice) Create(ctx context.Context, req gopayd.InvoiceCreate) (*gopayd
 	return &gopayd.Invoice{
 		PaymentID: req.PaymentID,
 		Satoshis:  req.Satoshis,
+		State:     gopayd.InvoiceStatePending,
+		ExpiresAt: req.ExpiresAt,
 	}, nil
 }
 
@@ -37,6 +39,9 @@ func (i *invoice) Update(ctx context.Context, args gopayd.InvoiceUpdateArgs, req
 	return &gopayd.Invoice{
 		PaymentID:         args.PaymentID,
 		Satoshis:          10000,
-		PaymentReceivedAt: null.TimeFrom(req.PaymentReceivedAt),
+		State:             req.State,
+		PaymentReceivedAt: req.PaymentReceivedAt,
+		RefundTo:          req.RefundTo,
+		RefundedAt:        req.RefundedAt,
 	}, nil
 }
@@ -0,0 +1,120 @@
+package passphrase
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	name = "passphrase"
+
+	// iterations is the number of pbkdf2 rounds used to derive a key from the passphrase.
+	iterations = 210000
+	saltLen    = 16
+	keyLen     = 32
+	// headerLen is the length of the iterations and salt prepended to the ciphertext.
+	headerLen = 4 + saltLen
+	// maxCachedKeys limits the derived keys cached, each stored key has its own salt.
+	maxCachedKeys = 32
+)
+
+type encrypter struct {
+	passphrase []byte
+	iterations uint32
+
+	mu sync.Mutex
+	// keys caches the key derived for each salt, deriving is deliberately slow,
+	// it is cleared once it holds maxCachedKeys.
+	keys map[string][]byte
+}
+
+// NewEncrypter will setup and return a KeyEncrypter that encrypts with AES-256-GCM using a
+// key derived from the passphrase. Each ciphertext has its own random salt.
+func NewEncrypter(passphrase string) *encrypter {
+	return &encrypter{
+		passphrase: []byte(passphrase),
+		iterations: iterations,
+		keys:       map[string][]byte{},
+	}
+}
+
+// Name identifies the encrypter.
+func (e *encrypter) Name() string {
+	return name
+}
+
+// Encrypt will encrypt the plaintext, the result contains the
+// iterations and salt used to derive the key followed by the nonce and sealed data.
+func (e *encrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	header := make([]byte, headerLen)
+	binary.BigEndian.PutUint32(header, e.iterations)
+	if _, err := rand.Read(header[4:]); err != nil {
+		return nil, errors.Wrap(err, "failed to generate salt")
+	}
+	aead, err := e.aead(header)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// Decrypt will decrypt ciphertext returned from Encrypt, an error is returned
+// if the passphrase is incorrect or the ciphertext has been modified.
+func (e *encrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < headerLen {
+		return nil, errors.New("ciphertext too short")
+	}
+	header := ciphertext[:headerLen]
+	aead, err := e.aead(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < headerLen+aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce := ciphertext[headerLen : headerLen+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[headerLen+aead.NonceSize():], header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt, the passphrase may be incorrect")
+	}
+	return plaintext, nil
+}
+
+func (e *encrypter) aead(header []byte) (cipher.AEAD, error) {
+	iter := binary.BigEndian.Uint32(header)
+	if iter == 0 {
+		return nil, errors.New("invalid key derivation iterations")
+	}
+	e.mu.Lock()
+	key, ok := e.keys[string(header)]
+	if !ok {
+		key = pbkdf2.Key(e.passphrase, header[4:], int(iter), keyLen, sha256.New)
+		if len(e.keys) >= maxCachedKeys {
+			e.keys = map[string][]byte{}
+		}
+		e.keys[string(header)] = key
+	}
+	e.mu.Unlock()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gcm cipher")
+	}
+	return aead, nil
+}
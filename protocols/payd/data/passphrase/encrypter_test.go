@@ -0,0 +1,98 @@
+package passphrase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEncrypter_EncryptDecrypt(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	plaintext := []byte("xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi")
+	tests := map[string]struct {
+		decrypter  *encrypter
+		modify     func(b []byte) []byte
+		err        bool
+		iterations uint32
+	}{
+		"same passphrase should decrypt": {
+			decrypter: newTestEncrypter("correct horse"),
+		}, "different passphrase should error": {
+			decrypter: newTestEncrypter("battery staple"),
+			err:       true,
+		}, "modified ciphertext should error": {
+			decrypter: newTestEncrypter("correct horse"),
+			modify: func(b []byte) []byte {
+				b[len(b)-1] ^= 0xff
+				return b
+			},
+			err: true,
+		}, "modified salt should error": {
+			decrypter: newTestEncrypter("correct horse"),
+			modify: func(b []byte) []byte {
+				b[4] ^= 0xff
+				return b
+			},
+			err: true,
+		}, "truncated ciphertext should error": {
+			decrypter: newTestEncrypter("correct horse"),
+			modify: func(b []byte) []byte {
+				return b[:headerLen]
+			},
+			err: true,
+		}, "ciphertext encrypted with other iterations should decrypt": {
+			decrypter:  newTestEncrypter("correct horse"),
+			iterations: 5,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.NewRelaxed(t)
+			enc := newTestEncrypter("correct horse")
+			if test.iterations > 0 {
+				enc.iterations = test.iterations
+			}
+			ciphertext, err := enc.Encrypt(ctx, plaintext)
+			is.NoErr(err)
+			if test.modify != nil {
+				ciphertext = test.modify(ciphertext)
+			}
+			decrypted, err := test.decrypter.Decrypt(ctx, ciphertext)
+			is.Equal(err != nil, test.err)
+			if !test.err {
+				is.Equal(decrypted, plaintext)
+			}
+		})
+	}
+}
+
+func TestEncrypter_EncryptUsesNewSalt(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	enc := newTestEncrypter("correct horse")
+	a, err := enc.Encrypt(ctx, []byte("key"))
+	is.NoErr(err)
+	b, err := enc.Encrypt(ctx, []byte("key"))
+	is.NoErr(err)
+	is.True(string(a[:headerLen]) != string(b[:headerLen]))
+}
+
+func TestEncrypter_KeyCacheIsLimited(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	enc := newTestEncrypter("correct horse")
+	for i := 0; i <= maxCachedKeys; i++ {
+		_, err := enc.Encrypt(ctx, []byte("key"))
+		is.NoErr(err)
+	}
+	is.True(len(enc.keys) <= maxCachedKeys)
+}
+
+// newTestEncrypter returns an encrypter with few iterations to keep tests fast.
+func newTestEncrypter(passphrase string) *encrypter {
+	e := NewEncrypter(passphrase)
+	e.iterations = 2
+	return e
+}
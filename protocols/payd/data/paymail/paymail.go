@@ -24,6 +24,7 @@ type powmailClient interface {
 	GetCapabilities(target string, port int) (response *gopaymail.Capabilities, err error)
 	GetP2PPaymentDestination(p2pURL, alias, domain string, paymentRequest *gopaymail.PaymentRequest) (response *gopaymail.PaymentDestination, err error)
 	VerifyPubKey(verifyURL, alias, domain, pubKey string) (response *gopaymail.Verification, err error)
+	SendP2PTransaction(p2pURL, alias, domain string, transaction *gopaymail.P2PTransaction) (response *gopaymail.P2PTransactionResponse, err error)
 }
 
 type paymail struct {
@@ -75,6 +76,16 @@ func (p *paymail) Capability(ctx context.Context, args gopayd.P2PCapabilityArgs)
 // OutputsCreate will create outputs for the provided payment information. Args are used to gather capability information
 // a lathos.NotFound error may be returned if the paymail or brfc doesn't exist.
 func (p *paymail) OutputsCreate(ctx context.Context, args gopayd.P2POutputCreateArgs, req gopayd.P2PPayment) ([]*gopayd.Output, error) {
+	d, err := p.P2PDestinationCreate(ctx, args, req)
+	if err != nil {
+		return nil, err
+	}
+	return d.Outputs, nil
+}
+
+// P2PDestinationCreate will request outputs and a reference for the provided payment information, the reference
+// should be sent back with the transaction. A lathos.NotFound error may be returned if the paymail or brfc doesn't exist.
+func (p *paymail) P2PDestinationCreate(ctx context.Context, args gopayd.P2POutputCreateArgs, req gopayd.P2PPayment) (*gopayd.P2PDestination, error) {
 	url, err := p.Capability(ctx, gopayd.P2PCapabilityArgs{
 		Domain: args.Domain,
 		BrfcID: gopaymail.BRFCP2PPaymentDestination,
@@ -89,5 +100,33 @@ func (p *paymail) OutputsCreate(ctx context.Context, args gopayd.P2POutputCreate
 		}
 		return nil, errors.Wrapf(err, "failed to generate paymail outputs for alias %s", args.Alias)
 	}
-	return models.OutputsToPayd(resp.Outputs), nil
+	return &gopayd.P2PDestination{
+		Outputs:   models.OutputsToPayd(resp.Outputs),
+		Reference: resp.Reference,
+	}, nil
+}
+
+// P2PTransactionSend will send a transaction to the paymail receiver, args.PaymentID should be
+// the reference returned from P2PDestinationCreate.
+func (p *paymail) P2PTransactionSend(ctx context.Context, args gopayd.P2PTransactionArgs, req gopayd.P2PTransaction) error {
+	url, err := p.Capability(ctx, gopayd.P2PCapabilityArgs{
+		Domain: args.Domain,
+		BrfcID: gopaymail.BRFCP2PTransactions,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get BRFCP2P Transactions for domain %s", args.Domain)
+	}
+	if _, err := p.cli.SendP2PTransaction(url, args.Alias, args.Domain, &gopaymail.P2PTransaction{
+		Hex: req.TxHex,
+		MetaData: &gopaymail.P2PMetaData{
+			Note:      req.Metadata.Note,
+			PubKey:    req.Metadata.PubKey,
+			Sender:    req.Metadata.Sender,
+			Signature: req.Metadata.Signature,
+		},
+		Reference: args.PaymentID,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to send transaction to alias %s", args.Alias)
+	}
+	return nil
 }
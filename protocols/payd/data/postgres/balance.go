@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlBalance = `
+	SELECT COALESCE(SUM(satoshis), 0) as satoshis
+	FROM txos
+	WHERE spentat IS NULL AND outpoint IS NOT NULL
+	`
+)
+
+// Balance will return the current account balance.
+func (s *postgresStore) Balance(ctx context.Context) (*gopayd.Balance, error) {
+	var resp gopayd.Balance
+	if err := s.db.GetContext(ctx, &resp, sqlBalance); err != nil {
+		return nil, errors.Wrap(err, "failed to get balance")
+	}
+	return &resp, nil
+}
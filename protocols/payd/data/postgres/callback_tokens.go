@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlCallbackTokenByTxID = `
+	SELECT txid, tokenhash, createdat
+	FROM callback_tokens
+	WHERE txid = $1
+	`
+
+	sqlCallbackTokenUpsert = `
+	INSERT INTO callback_tokens(txid, tokenhash)
+	VALUES(:txid, :tokenhash)
+	ON CONFLICT(txid) DO UPDATE SET tokenhash = excluded.tokenhash, createdat = CURRENT_TIMESTAMP
+	`
+)
+
+// CallbackToken will return the callback token stored for a transaction.
+func (s *postgresStore) CallbackToken(ctx context.Context, args gopayd.CallbackTokenArgs) (*gopayd.CallbackToken, error) {
+	var resp gopayd.CallbackToken
+	if err := s.db.GetContext(ctx, &resp, sqlCallbackTokenByTxID, args.TxID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0006", fmt.Sprintf("callback token for txid %s not found", args.TxID))
+		}
+		return nil, errors.Wrapf(err, "failed to get callback token for txid %s", args.TxID)
+	}
+	return &resp, nil
+}
+
+// CallbackTokenCreate will store a callback token, replacing any existing token for the transaction.
+func (s *postgresStore) CallbackTokenCreate(ctx context.Context, req gopayd.CallbackTokenCreate) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin tx when creating callback token")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlCallbackTokenUpsert, req); err != nil {
+		return errors.Wrapf(err, "failed to insert callback token for txid %s", req.TxID)
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit create callback token tx")
+}
@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlCreateInvoice = `
+	INSERT INTO invoices(paymentid, satoshis, expiresat)
+	VALUES(:paymentid, :satoshis, :expiresat)
+	`
+
+	sqlInvoiceByPayID = `
+	SELECT paymentid, satoshis, satoshispaid, state, expiresat, paymentreceivedat, refundto, refundedat
+	FROM invoices
+	WHERE paymentid = $1
+	`
+
+	sqlInvoices = `
+	SELECT paymentid, satoshis, satoshispaid, state, expiresat, paymentreceivedat, refundto, refundedat
+	FROM invoices
+	`
+
+	sqlInvoiceUpdate = `
+	UPDATE invoices
+	SET state = :state, paymentreceivedat = :paymentreceivedat, refundto = :refundto, refundedat = :refundedat
+	WHERE paymentid = :paymentid
+	`
+
+	sqlInvoiceDelete = `
+	DELETE FROM invoices
+	WHERE paymentid = :paymentid
+	`
+
+	sqlInvoicePaid = `
+	UPDATE invoices
+	SET satoshispaid = satoshispaid + :satoshis
+	WHERE paymentid = :paymentid
+	`
+
+	sqlInvoicesExpire = `
+	UPDATE invoices
+	SET state = :expired
+	WHERE state IN (:pending, :partiallypaid) AND expiresat <= :now
+	`
+
+	sqlPaymentCreate = `
+	INSERT INTO payments(txid, paymentid, satoshis, refundto)
+	VALUES(:txid, :paymentid, :satoshis, :refundto)
+	`
+
+	sqlPaymentsByPayID = `
+	SELECT txid, paymentid, satoshis, refundto, createdat
+	FROM payments
+	WHERE paymentid = $1
+	ORDER BY createdat
+	`
+)
+
+// Invoice will return an invoice that matches the provided args.
+func (s *postgresStore) Invoice(ctx context.Context, args gopayd.InvoiceArgs) (*gopayd.Invoice, error) {
+	var resp gopayd.Invoice
+	if err := s.db.GetContext(ctx, &resp, sqlInvoiceByPayID, args.PaymentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0001", fmt.Sprintf("invoice with paymentID %s not found", args.PaymentID))
+		}
+		return nil, errors.Wrapf(err, "failed to get invoice with paymentID %s", args.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Invoices will return all currently stored invoices.
+func (s *postgresStore) Invoices(ctx context.Context) ([]gopayd.Invoice, error) {
+	var resp []gopayd.Invoice
+	if err := s.db.SelectContext(ctx, &resp, sqlInvoices); err != nil {
+		return nil, errors.Wrapf(err, "failed to get invoices")
+	}
+	return resp, nil
+}
+
+// Create will persist a new Invoice in the data store.
+func (s *postgresStore) Create(ctx context.Context, req gopayd.InvoiceCreate) (*gopayd.Invoice, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create new invoice with paymentID %s", req.PaymentID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlCreateInvoice, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert invoice for paymentID %s", req.PaymentID)
+	}
+	var resp gopayd.Invoice
+	if err := tx.Get(&resp, sqlInvoiceByPayID, req.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get new invoice with paymentID %s after creation", req.PaymentID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when creating invoice with paymentID %s", req.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Update will update the state of an invoice and return the result.
+func (s *postgresStore) Update(ctx context.Context, args gopayd.InvoiceUpdateArgs, req gopayd.InvoiceUpdate) (*gopayd.Invoice, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update invoice with paymentID %s", args.PaymentID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlInvoiceUpdate, map[string]interface{}{
+		"state":             req.State,
+		"paymentreceivedat": req.PaymentReceivedAt,
+		"refundto":          req.RefundTo,
+		"refundedat":        req.RefundedAt,
+		"paymentid":         args.PaymentID,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to update invoice for paymentID %s", args.PaymentID)
+	}
+	var resp gopayd.Invoice
+	if err := tx.Get(&resp, sqlInvoiceByPayID, args.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get invoice with paymentID %s after update", args.PaymentID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when updating invoice with paymentID %s", args.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Delete will remove an invoice and release the txos reserved for it.
+func (s *postgresStore) Delete(ctx context.Context, args gopayd.InvoiceArgs) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete invoice with paymentID %s", args.PaymentID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if _, err := s.Invoice(ctx, args); err != nil {
+		return errors.WithMessagef(err, "failed to find invoice with paymentID %s to delete", args.PaymentID)
+	}
+	params := map[string]interface{}{
+		"paymentid": args.PaymentID,
+	}
+	// release any outputs still reserved for the invoice.
+	if _, err := tx.NamedExec(sqlPartialTxosDelete, params); err != nil {
+		return errors.Wrapf(err, "failed to delete reserved txos for paymentID %s", args.PaymentID)
+	}
+	if err := handleNamedExec(tx, sqlInvoiceDelete, params); err != nil {
+		return errors.Wrapf(err, "failed to delete invoice for paymentID %s", args.PaymentID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return errors.Wrapf(err, "failed to commit transaction when deleting invoice with paymentID %s", args.PaymentID)
+	}
+	return nil
+}
+
+// PaymentCreate will store a payment and add its satoshis to the invoice, returning the updated invoice.
+func (s *postgresStore) PaymentCreate(ctx context.Context, req gopayd.PaymentCreate) (*gopayd.Invoice, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create payment for paymentID %s", req.PaymentID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlPaymentCreate, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert payment for paymentID %s", req.PaymentID)
+	}
+	if err := handleNamedExec(tx, sqlInvoicePaid, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to add payment to invoice for paymentID %s", req.PaymentID)
+	}
+	var resp gopayd.Invoice
+	if err := tx.Get(&resp, sqlInvoiceByPayID, req.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get invoice with paymentID %s after payment", req.PaymentID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when creating payment for paymentID %s", req.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Payments will return all payments received for an invoice.
+func (s *postgresStore) Payments(ctx context.Context, args gopayd.InvoiceArgs) ([]gopayd.Payment, error) {
+	var resp []gopayd.Payment
+	if err := s.db.SelectContext(ctx, &resp, sqlPaymentsByPayID, args.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get payments for paymentID %s", args.PaymentID)
+	}
+	return resp, nil
+}
+
+// InvoicesExpire will expire all payable invoices past their expiry date and release the
+// txos reserved for them so their derivation paths can be reused.
+func (s *postgresStore) InvoicesExpire(ctx context.Context, args gopayd.InvoicesExpireArgs) (int64, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	params := map[string]interface{}{
+		"expired":       gopayd.InvoiceStateExpired,
+		"pending":       gopayd.InvoiceStatePending,
+		"partiallypaid": gopayd.InvoiceStatePartiallyPaid,
+		"now":           args.Now,
+	}
+	res, err := tx.NamedExec(sqlInvoicesExpire, params)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	expired, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read expired invoices")
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+	if _, err := tx.NamedExec(sqlPartialTxosExpiredDelete, params); err != nil {
+		return 0, errors.Wrap(err, "failed to delete reserved txos for expired invoices")
+	}
+	if err := commit(ctx, tx); err != nil {
+		return 0, errors.Wrap(err, "failed to commit transaction when expiring invoices")
+	}
+	return expired, nil
+}
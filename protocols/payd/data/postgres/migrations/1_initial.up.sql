@@ -0,0 +1,82 @@
+/*
+required tables:
+keys            - to store our extended private keys, only one is active
+invoices        - to store the invoices payments are requested for
+transactions    - to store transactions received and sent by the wallet
+txos            - to store our outputs and note when they have been spent
+payments        - to store each transaction received against an invoice
+proofs          - to store merkle proofs for our transactions
+
+this matches the sqlite schema after its migrations, postgres folds
+unquoted names to lower case so columns are named in lower case.
+ */
+CREATE TABLE keys (
+    name            VARCHAR NOT NULL PRIMARY KEY
+    ,xprv           VARCHAR NOT NULL
+    ,encryption     VARCHAR -- the KeyEncrypter used to encrypt xprv
+    ,active         BOOLEAN NOT NULL DEFAULT FALSE
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    ,rotatedat      TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX keys_active ON keys (active) WHERE active;
+
+CREATE TABLE invoices (
+    paymentid           VARCHAR PRIMARY KEY
+    ,satoshis           BIGINT NOT NULL CHECK (satoshis >= 0)
+    ,satoshispaid       BIGINT NOT NULL DEFAULT 0
+    ,state              VARCHAR NOT NULL DEFAULT 'pending'
+    ,expiresat          TIMESTAMPTZ
+    ,paymentreceivedat  TIMESTAMPTZ
+    ,refundto           VARCHAR
+    ,refundedat         TIMESTAMPTZ
+);
+
+CREATE INDEX invoices_state_expiresat ON invoices (state, expiresat);
+
+CREATE TABLE transactions (
+    txid            VARCHAR(64) NOT NULL PRIMARY KEY
+    ,paymentid      VARCHAR REFERENCES invoices(paymentid) -- null for transactions sent from the wallet
+    ,txhex          TEXT NOT NULL
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE txos (
+    outpoint        VARCHAR UNIQUE -- null until the txo has been paid
+    ,txid           VARCHAR(64) REFERENCES transactions(txid)
+    ,vout           BIGINT CHECK (vout >= 0 AND vout < 4294967296)
+    ,keyname        VARCHAR -- can be null on paymail payments
+    ,derivationpath VARCHAR -- can be null on paymail payments
+    ,lockingscript  TEXT NOT NULL
+    ,satoshis       BIGINT NOT NULL CHECK (satoshis >= 0)
+    ,paymentid      VARCHAR -- the invoice the txo was reserved for
+    ,spentat        TIMESTAMPTZ -- this is the date when YOU use the funds
+    ,spendingtxid   VARCHAR(64) -- the txid where you'd spent this output
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    ,modifiedat     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX txos_keyname ON txos (keyname);
+CREATE INDEX txos_derivationpath ON txos (derivationpath);
+CREATE INDEX txos_lockingscript ON txos (lockingscript);
+CREATE INDEX txos_paymentid ON txos (paymentid);
+CREATE INDEX txos_spentat ON txos (spentat);
+
+CREATE TABLE payments (
+    txid            VARCHAR(64) NOT NULL PRIMARY KEY REFERENCES transactions(txid)
+    ,paymentid      VARCHAR NOT NULL REFERENCES invoices(paymentid)
+    ,satoshis       BIGINT NOT NULL CHECK (satoshis >= 0)
+    ,refundto       VARCHAR
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX payments_paymentid ON payments (paymentid);
+
+CREATE TABLE proofs (
+    blockhash       VARCHAR(255) NOT NULL
+    ,txid           VARCHAR(64) NOT NULL REFERENCES transactions(txid)
+    ,data           TEXT NOT NULL
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    ,modifiedat     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    ,PRIMARY KEY (blockhash, txid)
+);
@@ -0,0 +1,12 @@
+-- a callback token is generated for each broadcast transaction, mAPI
+-- returns it with merkle proof callbacks. Only a hash of the token is kept.
+CREATE TABLE callback_tokens (
+    txid            VARCHAR(64) NOT NULL PRIMARY KEY REFERENCES transactions(txid)
+    ,tokenhash      VARCHAR(64) NOT NULL
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- transactions broadcast before tokens were introduced were sent without one,
+-- an empty hash marks them so their callbacks are still accepted.
+INSERT INTO callback_tokens (txid, tokenhash)
+SELECT txid, '' FROM transactions;
@@ -0,0 +1,27 @@
+-- webhooks registered by the merchant, events is a comma separated list
+-- of the events the webhook is notified of, empty for all.
+CREATE TABLE webhooks (
+    id              VARCHAR(64) NOT NULL PRIMARY KEY
+    ,url            VARCHAR NOT NULL
+    ,secret         VARCHAR(64) NOT NULL
+    ,events         VARCHAR NOT NULL DEFAULT ''
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- the outbox, events are written in the same transaction as the change they
+-- describe and removed from the due index once delivered or given up on.
+CREATE TABLE webhook_events (
+    id              VARCHAR(64) NOT NULL PRIMARY KEY
+    ,webhookid      VARCHAR(64) NOT NULL REFERENCES webhooks(id)
+    ,event          VARCHAR NOT NULL
+    ,payload        TEXT NOT NULL
+    ,attempts       INTEGER NOT NULL DEFAULT 0
+    ,nextattemptat  TIMESTAMPTZ NOT NULL
+    ,deliveredat    TIMESTAMPTZ
+    ,failedat       TIMESTAMPTZ
+    ,lasterror      TEXT
+    ,createdat      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_events_due ON webhook_events (nextattemptat)
+WHERE deliveredat IS NULL AND failedat IS NULL;
@@ -0,0 +1,47 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/config/databases"
+	"github.com/libsv/payd/data/storetest"
+)
+
+// TestPostgresStore needs a database to run against, set PAYD_TEST_POSTGRES_DSN to
+// run it. The tables in the database are truncated before each test.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("PAYD_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PAYD_TEST_POSTGRES_DSN not set")
+	}
+	storetest.Run(t, func(t *testing.T) (gopayd.Store, gopayd.Transacter) {
+		db, err := databases.NewDbSetup().SetupDb(&config.Db{
+			Type:       config.DBPostgres,
+			Dsn:        dsn,
+			SchemaPath: "migrations",
+			MigrateDb:  true,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		t.Cleanup(func() {
+			assert.NoError(t, db.Close())
+		})
+		conn, err := sqlx.Open("postgres", dsn)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer conn.Close()
+		_, err = conn.Exec(`TRUNCATE keys, invoices, transactions, txos, payments, proofs, callback_tokens, webhooks, webhook_events`)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return db, db.Transacter
+	})
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	keyByName = `
+	SELECT name, xprv, encryption, active, createdat, rotatedat
+	FROM keys
+	WHERE name = $1
+	`
+
+	keyActive = `
+	SELECT name, xprv, encryption, active, createdat, rotatedat
+	FROM keys
+	WHERE active
+	`
+
+	allKeys = `
+	SELECT name, xprv, encryption, active, createdat, rotatedat
+	FROM keys
+	ORDER BY createdat
+	`
+
+	createKey = `
+	INSERT INTO keys(name, xprv, encryption, active)
+	VALUES(:name, :xprv, :encryption, :active)
+	`
+
+	rotateKeys = `
+	UPDATE keys
+	SET active = FALSE, rotatedat = NOW()
+	WHERE active
+	`
+
+	updateKey = `
+	UPDATE keys
+	SET xprv = :xprv, encryption = :encryption
+	WHERE name = :name
+	`
+)
+
+// PrivateKey will return a key by name from the datastore.
+// If not found an error will be returned.
+func (s *postgresStore) PrivateKey(ctx context.Context, args gopayd.KeyArgs) (*gopayd.PrivateKey, error) {
+	var resp gopayd.PrivateKey
+	if err := s.db.GetContext(ctx, &resp, keyByName, args.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0004", fmt.Sprintf("key named %s not found", args.Name))
+		}
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", args.Name)
+	}
+	return &resp, nil
+}
+
+// PrivateKeyActive will return the key new outputs are derived from.
+// If there isn't an active key a not found error is returned.
+func (s *postgresStore) PrivateKeyActive(ctx context.Context) (*gopayd.PrivateKey, error) {
+	var resp gopayd.PrivateKey
+	if err := s.db.GetContext(ctx, &resp, keyActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0005", "no active key found")
+		}
+		return nil, errors.Wrap(err, "failed to get active key from datastore")
+	}
+	return &resp, nil
+}
+
+// PrivateKeys will return all keys, oldest first.
+func (s *postgresStore) PrivateKeys(ctx context.Context) ([]gopayd.PrivateKey, error) {
+	var resp []gopayd.PrivateKey
+	if err := s.db.SelectContext(ctx, &resp, allKeys); err != nil {
+		return nil, errors.Wrap(err, "failed to get keys from datastore")
+	}
+	return resp, nil
+}
+
+// PrivateKeyCreate will create and return a new key in the database.
+// If the new key is active, the current active key is rotated in the same transaction.
+// Only one key can be active, if replicas race to create an active key one will fail.
+func (s *postgresStore) PrivateKeyCreate(ctx context.Context, req gopayd.PrivateKey) (*gopayd.PrivateKey, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin tx when creating key")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if req.Active {
+		if _, err := tx.ExecContext(ctx, rotateKeys); err != nil {
+			return nil, errors.Wrap(err, "failed to rotate active key")
+		}
+	}
+	if err := handleNamedExec(tx, createKey, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to add key named '%s'", req.Name)
+	}
+	var resp gopayd.PrivateKey
+	if err := tx.GetContext(ctx, &resp, keyByName, req.Name); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", req.Name)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "failed to commit create key tx")
+	}
+	return &resp, nil
+}
+
+// PrivateKeyUpdate will update the xprv and encryption of a key.
+func (s *postgresStore) PrivateKeyUpdate(ctx context.Context, args gopayd.KeyArgs, req gopayd.PrivateKeyUpdate) (*gopayd.PrivateKey, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin tx when updating key")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, updateKey, map[string]interface{}{
+		"name":       args.Name,
+		"xprv":       req.Xprv,
+		"encryption": req.Encryption,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to update key named '%s'", args.Name)
+	}
+	var resp gopayd.PrivateKey
+	if err := tx.GetContext(ctx, &resp, keyByName, args.Name); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", args.Name)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "failed to commit update key tx")
+	}
+	return &resp, nil
+}
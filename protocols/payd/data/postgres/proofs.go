@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/libsv/go-bc"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlProofInsert = `
+	INSERT INTO proofs(blockhash, txid, data)
+	VALUES(:blockhash, :txid, :data)
+	`
+
+	sqlProofByTxID = `
+	SELECT data
+	FROM proofs
+	WHERE txid = $1
+	ORDER BY createdat DESC
+	LIMIT 1
+	`
+)
+
+// ProofCreate will insert a proof to the database.
+func (s *postgresStore) ProofCreate(ctx context.Context, req gopayd.ProofWrapper) error {
+	bb, err := json.Marshal(req.CallbackPayload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dbProof := struct {
+		Blockhash string `db:"blockhash"`
+		TxID      string `db:"txid"`
+		Data      string `db:"data"`
+	}{
+		Blockhash: req.BlockHash,
+		TxID:      req.CallbackTxID,
+		Data:      string(bb),
+	}
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin tx when creating proof")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlProofInsert, dbProof); err != nil {
+		return errors.Wrapf(err, "failed to insert proof for txid %s and blockhash '%s'", req.CallbackTxID, req.BlockHash)
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit create proof tx")
+}
+
+// MerkleProof will return the merkle proof for a transaction, nil is returned
+// if a proof hasn't been received yet.
+func (s *postgresStore) MerkleProof(ctx context.Context, txID string) (*bc.MerkleProof, error) {
+	var data string
+	if err := s.db.GetContext(ctx, &data, sqlProofByTxID, txID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get proof for txid %s", txID)
+	}
+	var mp bc.MerkleProof
+	if err := json.Unmarshal([]byte(data), &mp); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse proof for txid %s", txID)
+	}
+	return &mp, nil
+}
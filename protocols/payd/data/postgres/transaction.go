@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlTransactionCreate = `
+	INSERT INTO transactions(txid, paymentid, txhex)
+	VALUES(:txid, NULLIF(:paymentid, ''), :txhex)
+	`
+
+	sqlTransactionByID = `
+	SELECT txid, paymentid, txhex, createdat
+	FROM transactions
+	WHERE txid = $1
+	`
+
+	sqlTxHexByID = `
+	SELECT txhex
+	FROM transactions
+	WHERE txid = $1
+	`
+
+	sqlTxosByTxID = `
+	SELECT outpoint, txid, vout, keyname, derivationpath, lockingscript, satoshis,
+				spentat, spendingtxid, createdat, modifiedat
+	FROM txos
+	WHERE txid = $1
+	ORDER BY vout
+	`
+)
+
+// StoreUtxos will store a transaction along with the txos we hold keys or paymail references for.
+func (s *postgresStore) StoreUtxos(ctx context.Context, req gopayd.CreateTransaction) (*gopayd.Transaction, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start transaction when inserting transaction to db")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlTransactionCreate, req); err != nil {
+		return nil, errors.Wrap(err, "failed to insert new transaction")
+	}
+	// transactions sent from the wallet may not have change.
+	if len(req.Outputs) > 0 {
+		if err := handleNamedExec(tx, sqlTxoPaidCreate, req.Outputs); err != nil {
+			return nil, errors.Wrap(err, "failed to insert transaction outputs")
+		}
+	}
+	var resp gopayd.Transaction
+	if err := tx.Get(&resp, sqlTransactionByID, req.TxID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get stored transaction for paymentID %s", req.PaymentID)
+	}
+	if err := tx.Select(&resp.Outputs, sqlTxosByTxID, req.TxID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get stored transaction outputs for paymentID %s", req.PaymentID)
+	}
+	return &resp, errors.Wrapf(commit(ctx, tx),
+		"failed to commit transaction when adding tx and outputs for paymentID %s", req.PaymentID)
+}
+
+// Tx will return a stored transaction, nil is returned if it isn't found.
+// This is used to build spv envelopes for transactions we send.
+func (s *postgresStore) Tx(ctx context.Context, txID string) (*bt.Tx, error) {
+	var txHex string
+	if err := s.db.GetContext(ctx, &txHex, sqlTxHexByID, txID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get transaction %s", txID)
+	}
+	tx, err := bt.NewTxFromString(txHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse transaction %s", txID)
+	}
+	return tx, nil
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/errcodes"
+)
+
+const (
+	// sqlx only expands batch inserts when the query ends with the VALUES
+	// brackets, so these have no trailing whitespace.
+	sqlTxoCreate = `
+	INSERT INTO txos (keyname, derivationpath, lockingscript, satoshis, paymentid)
+	VALUES(:keyname, :derivationpath, :lockingscript, :satoshis, :paymentid)`
+
+	sqlPartialTxo = `
+	SELECT keyname, derivationpath, lockingscript, satoshis, createdat, modifiedat
+	FROM txos
+	WHERE lockingscript = $1 AND paymentid = $2 AND outpoint IS NULL
+	`
+
+	// partial txos are kept as reservations until the invoice is no longer payable
+	// so an invoice can receive many payments to the same locking script.
+	sqlTxoPaidCreate = `
+	INSERT INTO txos (outpoint, txid, vout, keyname, derivationpath, lockingscript, satoshis, paymentid)
+	VALUES(:outpoint, :txid, :vout, :keyname, :derivationpath, :lockingscript, :satoshis, :paymentid)`
+
+	sqlTxosSpendable = `
+	SELECT outpoint, txid, vout, keyname, derivationpath, lockingscript, satoshis,
+				spentat, spendingtxid, createdat, modifiedat
+	FROM txos
+	WHERE outpoint IS NOT NULL AND spentat IS NULL AND derivationpath IS NOT NULL
+	AND keyname IN (SELECT name FROM keys)
+	ORDER BY satoshis DESC
+	`
+
+	sqlTxoSpend = `
+	UPDATE txos
+	SET spentat = :spentat, spendingtxid = :spendingtxid, modifiedat = :modifiedat
+	WHERE outpoint = :outpoint AND spentat IS NULL
+	`
+
+	// a released spend is removed in this order to satisfy the foreign keys.
+	sqlTxosRelease = `
+	UPDATE txos
+	SET spentat = NULL, spendingtxid = NULL, modifiedat = :modifiedat
+	WHERE spendingtxid = :spendingtxid
+	`
+
+	sqlReleasedCallbackTokenDelete = `
+	DELETE FROM callback_tokens
+	WHERE txid = :spendingtxid
+	`
+
+	sqlReleasedTxosDelete = `
+	DELETE FROM txos
+	WHERE txid = :spendingtxid
+	`
+
+	sqlReleasedTransactionDelete = `
+	DELETE FROM transactions
+	WHERE txid = :spendingtxid
+	`
+
+	sqlPartialTxosDelete = `
+	DELETE FROM txos
+	WHERE outpoint IS NULL AND paymentid = :paymentid
+	`
+
+	sqlPartialTxosExpiredDelete = `
+	DELETE FROM txos
+	WHERE outpoint IS NULL AND paymentid IN (
+		SELECT paymentid FROM invoices WHERE state = :expired
+	)
+	`
+)
+
+// TxoCreate will store a txo created during payment requests.
+func (s *postgresStore) TxoCreate(ctx context.Context, req gopayd.TxoCreate) error {
+	return s.TxosCreate(ctx, []*gopayd.TxoCreate{
+		&req,
+	})
+}
+
+// TxosCreate will store txos created during payment requests.
+func (s *postgresStore) TxosCreate(ctx context.Context, req []*gopayd.TxoCreate) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create context when creating a txo")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlTxoCreate, req); err != nil {
+		return errors.Wrap(err, "failed to insert script keys.")
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit transaction when creating txos.")
+}
+
+// PartialTxo will return a txo that has been stored but not yet assigned to a transaction.
+func (s *postgresStore) PartialTxo(ctx context.Context, args gopayd.UnspentTxoArgs) (*gopayd.UnspentTxo, error) {
+	var txo gopayd.UnspentTxo
+	if err := s.db.GetContext(ctx, &txo, sqlPartialTxo, args.LockingScript, args.PaymentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewErrNotFound("N104",
+				fmt.Sprintf("unable to find txo with script '%s' and paymentID '%s'",
+					args.LockingScript, args.PaymentID))
+		}
+		return nil, errors.Wrap(err, "failed to read partialTxo")
+	}
+	return &txo, nil
+}
+
+// SpendableTxos will return the unspent txos we hold keys for, largest first.
+func (s *postgresStore) SpendableTxos(ctx context.Context) ([]gopayd.Txo, error) {
+	var txos []gopayd.Txo
+	if err := s.db.SelectContext(ctx, &txos, sqlTxosSpendable); err != nil {
+		return nil, errors.Wrap(err, "failed to read spendable txos")
+	}
+	return txos, nil
+}
+
+// TxosSpend will mark the txos as spent by req.SpendingTxID. If a txo has already
+// been spent a duplicate error is returned and the transaction is rolled back.
+//
+// The spentat check is re-evaluated once the row is locked, so when replicas
+// race to spend the same txo only one of them will update it.
+func (s *postgresStore) TxosSpend(ctx context.Context, args []gopayd.SpendTxoArgs, req gopayd.SpendTxo) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction when spending txos")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	now := time.Now().UTC()
+	spentAt := now
+	if req.SpentAt != nil {
+		spentAt = *req.SpentAt
+	}
+	for _, a := range args {
+		res, err := tx.NamedExec(sqlTxoSpend, map[string]interface{}{
+			"outpoint":     a.Outpoint,
+			"spentat":      spentAt,
+			"spendingtxid": req.SpendingTxID,
+			"modifiedat":   now,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to spend txo %s", a.Outpoint)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "failed to read rows affected when spending txo")
+		}
+		if n != 1 {
+			return errs.NewErrDuplicate(errcodes.ErrTxoSpent, fmt.Sprintf("txo %s has already been spent", a.Outpoint))
+		}
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit transaction when spending txos")
+}
+
+// TxosRelease will mark the txos spent by args.SpendingTxID as unspent and remove
+// the spending transaction along with its outputs and callback token.
+func (s *postgresStore) TxosRelease(ctx context.Context, args gopayd.ReleaseTxosArgs) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction when releasing txos")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	params := map[string]interface{}{
+		"spendingtxid": args.SpendingTxID,
+		"modifiedat":   time.Now().UTC(),
+	}
+	for _, q := range []string{sqlTxosRelease, sqlReleasedCallbackTokenDelete, sqlReleasedTxosDelete, sqlReleasedTransactionDelete} {
+		if _, err := tx.NamedExec(q, params); err != nil {
+			return errors.Wrapf(err, "failed to release txos spent by %s", args.SpendingTxID)
+		}
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit transaction when releasing txos")
+}
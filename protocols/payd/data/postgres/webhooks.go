@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+)
+
+const (
+	sqlWebhookByID = `
+	SELECT id, url, secret, events, createdat
+	FROM webhooks
+	WHERE id = $1
+	`
+
+	sqlWebhooks = `
+	SELECT id, url, secret, events, createdat
+	FROM webhooks
+	ORDER BY createdat, id
+	`
+
+	sqlWebhookCreate = `
+	INSERT INTO webhooks(id, url, secret, events)
+	VALUES(:id, :url, :secret, :events)
+	`
+
+	sqlWebhookDelete = `
+	DELETE FROM webhooks
+	WHERE id = :id
+	`
+
+	sqlWebhookEventsDelete = `
+	DELETE FROM webhook_events
+	WHERE webhookid = :id
+	`
+
+	sqlWebhookEventCreate = `
+	INSERT INTO webhook_events(id, webhookid, event, payload, nextattemptat)
+	VALUES(:id, :webhookid, :event, :payload, :nextattemptat)
+	`
+
+	// sqlWebhookEventsDue leases the due events by moving their next attempt,
+	// rows locked by another deliverer are skipped rather than waited on.
+	sqlWebhookEventsDue = `
+	UPDATE webhook_events
+	SET nextattemptat = $3
+	WHERE id IN (
+		SELECT id
+		FROM webhook_events
+		WHERE deliveredat IS NULL AND failedat IS NULL AND nextattemptat <= $1
+		ORDER BY createdat, nextattemptat
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, webhookid, event, payload, attempts, nextattemptat, deliveredat, failedat, lasterror, createdat
+	`
+
+	sqlWebhookEventUpdate = `
+	UPDATE webhook_events
+	SET attempts = :attempts, nextattemptat = :nextattemptat, deliveredat = :deliveredat,
+		failedat = :failedat, lasterror = :lasterror
+	WHERE id = :id
+	`
+)
+
+// Webhook will return the webhook matching args.
+func (s *postgresStore) Webhook(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+	var resp gopayd.Webhook
+	if err := s.db.GetContext(ctx, &resp, sqlWebhookByID, args.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0007", fmt.Sprintf("webhook with ID %s not found", args.ID))
+		}
+		return nil, errors.Wrapf(err, "failed to get webhook with ID %s", args.ID)
+	}
+	return &resp, nil
+}
+
+// Webhooks will return all webhooks, oldest first.
+func (s *postgresStore) Webhooks(ctx context.Context) ([]gopayd.Webhook, error) {
+	var resp []gopayd.Webhook
+	if err := s.db.SelectContext(ctx, &resp, sqlWebhooks); err != nil {
+		return nil, errors.Wrap(err, "failed to get webhooks")
+	}
+	return resp, nil
+}
+
+// WebhookCreate will store a new webhook and return it.
+func (s *postgresStore) WebhookCreate(ctx context.Context, req gopayd.WebhookCreate) (*gopayd.Webhook, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin tx when creating webhook")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlWebhookCreate, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert webhook with ID %s", req.ID)
+	}
+	var resp gopayd.Webhook
+	if err := tx.Get(&resp, sqlWebhookByID, req.ID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get webhook with ID %s after create", req.ID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "failed to commit create webhook tx")
+	}
+	return &resp, nil
+}
+
+// WebhookDelete will remove a webhook and all of its outbox events.
+func (s *postgresStore) WebhookDelete(ctx context.Context, args gopayd.WebhookArgs) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete webhook with ID %s", args.ID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if _, err := s.Webhook(ctx, args); err != nil {
+		return errors.WithMessagef(err, "failed to find webhook with ID %s to delete", args.ID)
+	}
+	if _, err := tx.NamedExec(sqlWebhookEventsDelete, args); err != nil {
+		return errors.Wrapf(err, "failed to delete events for webhook with ID %s", args.ID)
+	}
+	if err := handleNamedExec(tx, sqlWebhookDelete, args); err != nil {
+		return errors.Wrapf(err, "failed to delete webhook with ID %s", args.ID)
+	}
+	return errors.Wrapf(commit(ctx, tx), "failed to commit transaction when deleting webhook with ID %s", args.ID)
+}
+
+// WebhookEventsCreate will add the events to the outbox.
+func (s *postgresStore) WebhookEventsCreate(ctx context.Context, req []gopayd.WebhookEventCreate) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin tx when creating webhook events")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	for _, e := range req {
+		if err := handleNamedExec(tx, sqlWebhookEventCreate, e); err != nil {
+			return errors.Wrapf(err, "failed to insert webhook event %s", e.ID)
+		}
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit create webhook events tx")
+}
+
+// WebhookEventsDue will return the events that are due to be sent, oldest first.
+func (s *postgresStore) WebhookEventsDue(ctx context.Context, args gopayd.WebhookEventsDueArgs) ([]gopayd.WebhookEvent, error) {
+	var resp []gopayd.WebhookEvent
+	if err := s.db.SelectContext(ctx, &resp, sqlWebhookEventsDue, args.Now, args.Limit, args.LeaseUntil); err != nil {
+		return nil, errors.Wrap(err, "failed to get due webhook events")
+	}
+	// RETURNING doesn't keep the order of the sub query.
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].CreatedAt.Before(resp[j].CreatedAt)
+	})
+	return resp, nil
+}
+
+// WebhookEventUpdate will record an attempt to send an event.
+func (s *postgresStore) WebhookEventUpdate(ctx context.Context, args gopayd.WebhookEventArgs, req gopayd.WebhookEventUpdate) error {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin tx when updating webhook event %s", args.ID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlWebhookEventUpdate, map[string]interface{}{
+		"attempts":      req.Attempts,
+		"nextattemptat": req.NextAttemptAt,
+		"deliveredat":   req.DeliveredAt,
+		"failedat":      req.FailedAt,
+		"lasterror":     req.LastError,
+		"id":            args.ID,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to update webhook event %s", args.ID)
+	}
+	return errors.Wrapf(commit(ctx, tx), "failed to commit transaction when updating webhook event %s", args.ID)
+}
@@ -11,9 +11,9 @@ import (
 
 const (
 	sqlBalance = `
-	SELECT SUM(satoshis) as satoshis
+	SELECT COALESCE(SUM(satoshis), 0) as satoshis
 	FROM txos
-	WHERE spentat IS NULL 
+	WHERE spentat IS NULL AND outpoint IS NOT NULL
 	`
 )
 
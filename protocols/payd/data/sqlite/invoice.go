@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
 	gopayd "github.com/libsv/payd"
 	"github.com/pkg/errors"
@@ -13,24 +12,24 @@ import (
 
 const (
 	sqlCreateInvoice = `
-	INSERT INTO invoices(paymentID, satoshis)
-	VALUES(:paymentID, :satoshis)
+	INSERT INTO invoices(paymentID, satoshis, expiresAt)
+	VALUES(:paymentID, :satoshis, :expiresAt)
 	`
 
 	sqlInvoiceByPayID = `
-	SELECT paymentId,satoshis,paymentReceivedAt
+	SELECT paymentID, satoshis, satoshisPaid, state, expiresAt, paymentReceivedAt, refundTo, refundedAt
 	FROM invoices
-	WHERE paymentId = :paymentID
+	WHERE paymentID = :paymentID
 	`
 
 	sqlInvoices = `
-	SELECT paymentId,satoshis,paymentReceivedAt
+	SELECT paymentID, satoshis, satoshisPaid, state, expiresAt, paymentReceivedAt, refundTo, refundedAt
 	FROM invoices
 	`
 
 	sqlInvoiceUpdate = `
 		UPDATE invoices 
-		SET paymentReceivedAt = :paymentReceivedAt, refundTo = :refundTo
+		SET state = :state, paymentReceivedAt = :paymentReceivedAt, refundTo = :refundTo, refundedAt = :refundedAt
 		WHERE paymentID = :paymentID
 	`
 
@@ -38,6 +37,30 @@ const (
 	DELETE FROM invoices 
 	WHERE paymentID = :paymentID
 	`
+
+	sqlInvoicePaid = `
+	UPDATE invoices
+	SET satoshisPaid = satoshisPaid + :satoshis
+	WHERE paymentID = :paymentID
+	`
+
+	sqlInvoicesExpire = `
+	UPDATE invoices
+	SET state = :expired
+	WHERE state IN (:pending, :partiallyPaid) AND expiresAt <= :now
+	`
+
+	sqlPaymentCreate = `
+	INSERT INTO payments(txid, paymentID, satoshis, refundTo)
+	VALUES(:txid, :paymentID, :satoshis, :refundTo)
+	`
+
+	sqlPaymentsByPayID = `
+	SELECT txid, paymentID, satoshis, refundTo, createdAt
+	FROM payments
+	WHERE paymentID = :paymentID
+	ORDER BY createdAt
+	`
 )
 
 // Invoice will return an invoice that matches the provided args.
@@ -52,7 +75,7 @@ func (s *sqliteStore) Invoice(ctx context.Context, args gopayd.InvoiceArgs) (*go
 	return &resp, nil
 }
 
-// Invoice will return an invoice that matches the provided args.
+// Invoices will return all currently stored invoices.
 func (s *sqliteStore) Invoices(ctx context.Context) ([]gopayd.Invoice, error) {
 	var resp []gopayd.Invoice
 	if err := s.db.SelectContext(ctx, &resp, sqlInvoices); err != nil {
@@ -86,7 +109,7 @@ func (s *sqliteStore) Create(ctx context.Context, req gopayd.InvoiceCreate) (*go
 	return &resp, nil
 }
 
-// Update will update an invoice to mark it paid and return the result.
+// Update will update the state of an invoice and return the result.
 func (s *sqliteStore) Update(ctx context.Context, args gopayd.InvoiceUpdateArgs, req gopayd.InvoiceUpdate) (*gopayd.Invoice, error) {
 	tx, err := s.newTx(ctx)
 	if err != nil {
@@ -95,8 +118,7 @@ func (s *sqliteStore) Update(ctx context.Context, args gopayd.InvoiceUpdateArgs,
 	defer func() {
 		_ = rollback(ctx, tx)
 	}()
-	req.PaymentReceivedAt = time.Now().UTC()
-	resp, err := s.txUpdateInvoicePaid(tx, args, req)
+	resp, err := s.txUpdateInvoice(tx, args, req)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to update invoice")
 	}
@@ -117,6 +139,10 @@ func (s *sqliteStore) Delete(ctx context.Context, args gopayd.InvoiceArgs) error
 	if _, err := s.Invoice(ctx, args); err != nil {
 		return errors.WithMessagef(err, "failed to find key with id %s to delete", args.PaymentID)
 	}
+	// release any outputs still reserved for the invoice.
+	if _, err := tx.NamedExec(sqlPartialTxosDelete, args); err != nil {
+		return errors.Wrapf(err, "failed to delete reserved txos for paymentID %s", args.PaymentID)
+	}
 	if err := handleNamedExec(tx, sqlInvoiceDelete, args); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return lathos.NewErrNotFound("N0003", fmt.Sprintf("invoice with ID %s not found", args.PaymentID))
@@ -129,15 +155,86 @@ func (s *sqliteStore) Delete(ctx context.Context, args gopayd.InvoiceArgs) error
 	return nil
 }
 
-// txUpdateInvoicePaid takes a db object / transaction and adds a transaction to the data store
-// along with utxos, returning the updated invoice.
+// PaymentCreate will store a payment and add its satoshis to the invoice, returning the updated invoice.
+func (s *sqliteStore) PaymentCreate(ctx context.Context, req gopayd.PaymentCreate) (*gopayd.Invoice, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create payment for paymentID %s", req.PaymentID)
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlPaymentCreate, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert payment for paymentID %s", req.PaymentID)
+	}
+	if err := handleNamedExec(tx, sqlInvoicePaid, req); err != nil {
+		return nil, errors.Wrapf(err, "failed to add payment to invoice for paymentID %s", req.PaymentID)
+	}
+	var resp gopayd.Invoice
+	if err := tx.Get(&resp, sqlInvoiceByPayID, req.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get invoice with paymentID %s after payment", req.PaymentID)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit transaction when creating payment for paymentID %s", req.PaymentID)
+	}
+	return &resp, nil
+}
+
+// Payments will return all payments received for an invoice.
+func (s *sqliteStore) Payments(ctx context.Context, args gopayd.InvoiceArgs) ([]gopayd.Payment, error) {
+	var resp []gopayd.Payment
+	if err := s.db.SelectContext(ctx, &resp, sqlPaymentsByPayID, args.PaymentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to get payments for paymentID %s", args.PaymentID)
+	}
+	return resp, nil
+}
+
+// InvoicesExpire will expire all payable invoices past their expiry date and release the
+// txos reserved for them so their derivation paths can be reused.
+func (s *sqliteStore) InvoicesExpire(ctx context.Context, args gopayd.InvoicesExpireArgs) (int64, error) {
+	tx, err := s.newTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	params := map[string]interface{}{
+		"expired":       gopayd.InvoiceStateExpired,
+		"pending":       gopayd.InvoiceStatePending,
+		"partiallyPaid": gopayd.InvoiceStatePartiallyPaid,
+		"now":           args.Now,
+	}
+	res, err := tx.NamedExec(sqlInvoicesExpire, params)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	expired, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read expired invoices")
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+	if _, err := tx.NamedExec(sqlPartialTxosExpiredDelete, params); err != nil {
+		return 0, errors.Wrap(err, "failed to delete reserved txos for expired invoices")
+	}
+	if err := commit(ctx, tx); err != nil {
+		return 0, errors.Wrap(err, "failed to commit transaction when expiring invoices")
+	}
+	return expired, nil
+}
+
+// txUpdateInvoice takes a db object / transaction and updates an invoice in the data store,
+// returning the updated invoice.
 // This method can be used with other methods in the store allowing
 // multiple methods to be ran in the same db transaction.
-func (s *sqliteStore) txUpdateInvoicePaid(tx db, args gopayd.InvoiceUpdateArgs, req gopayd.InvoiceUpdate) (*gopayd.Invoice, error) {
-	req.PaymentReceivedAt = time.Now().UTC()
+func (s *sqliteStore) txUpdateInvoice(tx db, args gopayd.InvoiceUpdateArgs, req gopayd.InvoiceUpdate) (*gopayd.Invoice, error) {
 	if err := handleNamedExec(tx, sqlInvoiceUpdate, map[string]interface{}{
+		"state":             req.State,
 		"paymentReceivedAt": req.PaymentReceivedAt,
 		"refundTo":          req.RefundTo,
+		"refundedAt":        req.RefundedAt,
 		"paymentID":         args.PaymentID,
 	}); err != nil {
 		return nil, errors.Wrapf(err, "failed to update invoice for paymentID %s", args.PaymentID)
@@ -0,0 +1,42 @@
+/*
+invoices can now receive many payments:
+payments    - to store each transaction received against an invoice
+txos        - outputs are linked to their invoice so those reserved in
+              PaymentRequests can be released when an invoice expires
+
+invoices created before this migration have no expiry.
+ */
+ALTER TABLE invoices ADD COLUMN state VARCHAR NOT NULL DEFAULT 'pending';
+ALTER TABLE invoices ADD COLUMN satoshisPaid INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN expiresAt TIMESTAMP;
+ALTER TABLE invoices ADD COLUMN refundedAt TIMESTAMP;
+
+ALTER TABLE txos ADD COLUMN paymentid VARCHAR; -- null for outputs reserved before this migration
+
+CREATE TABLE payments (
+    txid            CHAR(64) NOT NULL PRIMARY KEY
+    ,paymentID      VARCHAR NOT NULL
+    ,satoshis       BIGINT NOT NULL CHECK (satoshis >= 0)
+    ,refundTo       VARCHAR
+    ,createdAt      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    ,FOREIGN KEY (txid) REFERENCES transactions(txid)
+    ,FOREIGN KEY (paymentID) REFERENCES invoices(paymentID)
+);
+
+CREATE INDEX payments_paymentid ON payments (paymentID);
+CREATE INDEX txos_paymentid ON txos (paymentid);
+CREATE INDEX invoices_state_expiresat ON invoices (state, expiresAt);
+
+-- previously an invoice could only be paid by a single transaction.
+INSERT INTO payments(txid, paymentID, satoshis, refundTo, createdAt)
+SELECT t.txid, t.paymentid, COALESCE((SELECT SUM(o.satoshis) FROM txos o WHERE o.txid = t.txid), 0), i.refundTo, t.createdat
+FROM transactions t
+INNER JOIN invoices i ON i.paymentID = t.paymentid;
+
+UPDATE txos
+SET paymentid = (SELECT t.paymentid FROM transactions t WHERE t.txid = txos.txid)
+WHERE txid IS NOT NULL;
+
+UPDATE invoices
+SET state = 'paid', satoshisPaid = (SELECT COALESCE(SUM(p.satoshis), 0) FROM payments p WHERE p.paymentID = invoices.paymentID)
+WHERE paymentReceivedAt IS NOT NULL;
@@ -0,0 +1,26 @@
+-- keys are now encrypted at rest, encryption names the KeyEncrypter used,
+-- keys stored before this migration are plain text until payd next starts.
+ALTER TABLE keys ADD COLUMN encryption VARCHAR;
+-- only the active key is used to derive new outputs, previous keys are
+-- kept so the outputs derived from them can still be spent.
+ALTER TABLE keys ADD COLUMN active BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE keys ADD COLUMN rotatedAt TIMESTAMP;
+
+CREATE UNIQUE INDEX keys_active ON keys (active) WHERE active = 1;
+
+-- remove the well known key seeded by the initial migration unless
+-- it has already been used to derive outputs, in which case it is kept
+-- inactive so those outputs can be swept. payd creates a new active key
+-- when it next starts.
+DELETE FROM keys
+WHERE name = 'keyname'
+AND xprv = '11111111111112xVQYuzHSiJmG55ahUXStc73UpffdMqgy4GTd4B5TXbn1ZY16Derh4uaoVyK4ZkCbn8GcDvV8GzLAcsDbdzUkgafnKPW6Nj'
+AND NOT EXISTS (SELECT 1 FROM txos WHERE keyname = 'keyname');
+
+UPDATE keys SET rotatedAt = CURRENT_TIMESTAMP
+WHERE name = 'keyname'
+AND xprv = '11111111111112xVQYuzHSiJmG55ahUXStc73UpffdMqgy4GTd4B5TXbn1ZY16Derh4uaoVyK4ZkCbn8GcDvV8GzLAcsDbdzUkgafnKPW6Nj';
+
+UPDATE keys SET active = 1
+WHERE name = 'keyname'
+AND xprv <> '11111111111112xVQYuzHSiJmG55ahUXStc73UpffdMqgy4GTd4B5TXbn1ZY16Derh4uaoVyK4ZkCbn8GcDvV8GzLAcsDbdzUkgafnKPW6Nj';
@@ -0,0 +1,23 @@
+-- transactions can now be sent from the wallet, these aren't payments
+-- against an invoice so paymentid becomes optional. sqlite can't alter
+-- a column so the table is rebuilt, foreign keys from txos, payments and
+-- proofs are deferred until the new table is in place.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE transactions_new (
+    txid            CHAR(64) NOT NULL PRIMARY KEY
+    ,paymentid      VARCHAR -- null for transactions sent from the wallet
+    ,txhex          TEXT NOT NULL
+    ,createdat      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    ,FOREIGN KEY (paymentID) REFERENCES invoices(paymentID)
+);
+
+INSERT INTO transactions_new(txid, paymentid, txhex, createdat)
+SELECT txid, paymentid, txhex, createdat FROM transactions;
+
+DROP TABLE transactions;
+
+ALTER TABLE transactions_new RENAME TO transactions;
+
+-- unspent outputs are selected when sending.
+CREATE INDEX txos_spentat ON txos (spentat);
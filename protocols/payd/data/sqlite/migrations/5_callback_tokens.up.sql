@@ -0,0 +1,13 @@
+-- a callback token is generated for each broadcast transaction, mAPI
+-- returns it with merkle proof callbacks. Only a hash of the token is kept.
+CREATE TABLE callback_tokens (
+    txid            CHAR(64) NOT NULL PRIMARY KEY
+    ,tokenhash      CHAR(64) NOT NULL
+    ,createdat      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    ,FOREIGN KEY (txid) REFERENCES transactions(txid)
+);
+
+-- transactions broadcast before tokens were introduced were sent without one,
+-- an empty hash marks them so their callbacks are still accepted.
+INSERT INTO callback_tokens (txid, tokenhash)
+SELECT txid, '' FROM transactions;
@@ -0,0 +1,28 @@
+-- webhooks registered by the merchant, events is a comma separated list
+-- of the events the webhook is notified of, empty for all.
+CREATE TABLE webhooks (
+    id              VARCHAR NOT NULL PRIMARY KEY
+    ,url            VARCHAR NOT NULL
+    ,secret         VARCHAR NOT NULL
+    ,events         VARCHAR NOT NULL DEFAULT ''
+    ,createdat      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the outbox, events are written in the same transaction as the change they
+-- describe and removed from the due index once delivered or given up on.
+CREATE TABLE webhook_events (
+    id              VARCHAR NOT NULL PRIMARY KEY
+    ,webhookid      VARCHAR NOT NULL
+    ,event          VARCHAR NOT NULL
+    ,payload        TEXT NOT NULL
+    ,attempts       INTEGER NOT NULL DEFAULT 0
+    ,nextattemptat  TIMESTAMP NOT NULL
+    ,deliveredat    TIMESTAMP
+    ,failedat       TIMESTAMP
+    ,lasterror      TEXT
+    ,createdat      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    ,FOREIGN KEY (webhookid) REFERENCES webhooks(id)
+);
+
+CREATE INDEX idx_webhook_events_due ON webhook_events (nextattemptat)
+WHERE deliveredat IS NULL AND failedat IS NULL;
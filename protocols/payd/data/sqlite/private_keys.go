@@ -2,24 +2,51 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
 	gopayd "github.com/libsv/payd"
 
 	// test here.
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
+	lathos "github.com/theflyingcodr/lathos/errs"
 )
 
 const (
 	keyByName = `
-	SELECT name, xprv, createdAt
+	SELECT name, xprv, encryption, active, createdAt, rotatedAt
 	FROM keys
 	WHERE name = :name
 	`
 
+	keyActive = `
+	SELECT name, xprv, encryption, active, createdAt, rotatedAt
+	FROM keys
+	WHERE active = 1
+	`
+
+	allKeys = `
+	SELECT name, xprv, encryption, active, createdAt, rotatedAt
+	FROM keys
+	ORDER BY createdAt
+	`
+
 	createKey = `
-	INSERT INTO keys(name, xprv)
-	VALUES(:name, :xprv)
+	INSERT INTO keys(name, xprv, encryption, active)
+	VALUES(:name, :xprv, :encryption, :active)
+	`
+
+	rotateKeys = `
+	UPDATE keys
+	SET active = 0, rotatedAt = CURRENT_TIMESTAMP
+	WHERE active = 1
+	`
+
+	updateKey = `
+	UPDATE keys
+	SET xprv = :xprv, encryption = :encryption
+	WHERE name = :name
 	`
 )
 
@@ -27,33 +54,87 @@ const (
 // If not found an error will be returned.
 func (s *sqliteStore) PrivateKey(ctx context.Context, args gopayd.KeyArgs) (*gopayd.PrivateKey, error) {
 	var resp gopayd.PrivateKey
-	if err := s.db.Get(&resp, keyByName, args.Name); err != nil {
+	if err := s.db.GetContext(ctx, &resp, keyByName, args.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0004", fmt.Sprintf("key named %s not found", args.Name))
+		}
 		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", args.Name)
 	}
 	return &resp, nil
 }
 
+// PrivateKeyActive will return the key new outputs are derived from.
+// If there isn't an active key a not found error is returned.
+func (s *sqliteStore) PrivateKeyActive(ctx context.Context) (*gopayd.PrivateKey, error) {
+	var resp gopayd.PrivateKey
+	if err := s.db.GetContext(ctx, &resp, keyActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, lathos.NewErrNotFound("N0005", "no active key found")
+		}
+		return nil, errors.Wrap(err, "failed to get active key from datastore")
+	}
+	return &resp, nil
+}
+
+// PrivateKeys will return all keys, oldest first.
+func (s *sqliteStore) PrivateKeys(ctx context.Context) ([]gopayd.PrivateKey, error) {
+	var resp []gopayd.PrivateKey
+	if err := s.db.SelectContext(ctx, &resp, allKeys); err != nil {
+		return nil, errors.Wrap(err, "failed to get keys from datastore")
+	}
+	return resp, nil
+}
+
 // PrivateKeyCreate will create and return a new key in the database.
+// If the new key is active, the current active key is rotated in the same transaction.
 func (s *sqliteStore) PrivateKeyCreate(ctx context.Context, req gopayd.PrivateKey) (*gopayd.PrivateKey, error) {
-	tx, err := s.db.BeginTxx(ctx, nil)
+	tx, err := s.newTx(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to begin tx when creating key")
 	}
-	defer tx.Rollback()
-	res, err := tx.NamedExec(createKey, req)
-	if err != nil {
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if req.Active {
+		if _, err := tx.ExecContext(ctx, rotateKeys); err != nil {
+			return nil, errors.Wrap(err, "failed to rotate active key")
+		}
+	}
+	if err := handleNamedExec(tx, createKey, req); err != nil {
 		return nil, errors.Wrapf(err, "failed to add key named '%s'", req.Name)
 	}
-	rows, err := res.RowsAffected()
+	var resp gopayd.PrivateKey
+	if err := tx.GetContext(ctx, &resp, keyByName, req.Name); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", req.Name)
+	}
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "failed to commit create key tx")
+	}
+	return &resp, nil
+}
+
+// PrivateKeyUpdate will update the xprv and encryption of a key.
+func (s *sqliteStore) PrivateKeyUpdate(ctx context.Context, args gopayd.KeyArgs, req gopayd.PrivateKeyUpdate) (*gopayd.PrivateKey, error) {
+	tx, err := s.newTx(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get rows affected when creating private key")
+		return nil, errors.Wrap(err, "failed to begin tx when updating key")
 	}
-	if rows <= 0 {
-		return nil, errors.Wrap(err, "no rows affected when creating private key")
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, updateKey, map[string]interface{}{
+		"name":       args.Name,
+		"xprv":       req.Xprv,
+		"encryption": req.Encryption,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to update key named '%s'", args.Name)
 	}
-	var resp *gopayd.PrivateKey
-	if err := tx.Get(resp, keyByName, req); err != nil {
-		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", req.Name)
+	var resp gopayd.PrivateKey
+	if err := tx.GetContext(ctx, &resp, keyByName, args.Name); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key named %s from datastore", args.Name)
 	}
-	return nil, errors.Wrap(tx.Commit(), "failed to commit create key tx")
+	if err := commit(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "failed to commit update key tx")
+	}
+	return &resp, nil
 }
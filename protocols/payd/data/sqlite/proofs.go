@@ -2,8 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 
+	"github.com/libsv/go-bc"
 	"github.com/pkg/errors"
 
 	gopayd "github.com/libsv/payd"
@@ -14,14 +16,18 @@ const (
 	INSERT INTO proofs(blockhash, txid, data)
 	VALUES(:blockhash, :txid, :data)
 	`
+
+	sqlProofByTxID = `
+	SELECT data
+	FROM proofs
+	WHERE txid = $1
+	ORDER BY createdAt DESC
+	LIMIT 1
+	`
 )
 
 // ProofsCreate will insert a proof to the database.
 func (s *sqliteStore) ProofCreate(ctx context.Context, req gopayd.ProofWrapper) error {
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return errors.WithStack(err)
-	}
 	bb, err := json.Marshal(req.CallbackPayload)
 	if err != nil {
 		return errors.WithStack(err)
@@ -35,16 +41,32 @@ func (s *sqliteStore) ProofCreate(ctx context.Context, req gopayd.ProofWrapper)
 		TxID:      req.CallbackTxID,
 		Data:      string(bb),
 	}
-	res, err := tx.NamedExecContext(ctx, sqlProofInsert, dbProof)
+	tx, err := s.newTx(ctx)
 	if err != nil {
-		return errors.Wrapf(err, "failed to proof for txid %s and blockhash '%s'", req.CallbackTxID, req.BlockHash)
+		return errors.Wrap(err, "failed to begin tx when creating proof")
 	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return errors.Wrap(err, "failed to get rows affected when creating proof")
+	defer func() {
+		_ = rollback(ctx, tx)
+	}()
+	if err := handleNamedExec(tx, sqlProofInsert, dbProof); err != nil {
+		return errors.Wrapf(err, "failed to insert proof for txid %s and blockhash '%s'", req.CallbackTxID, req.BlockHash)
+	}
+	return errors.Wrap(commit(ctx, tx), "failed to commit create proof tx")
+}
+
+// MerkleProof will return the merkle proof for a transaction, nil is returned
+// if a proof hasn't been received yet.
+func (s *sqliteStore) MerkleProof(ctx context.Context, txID string) (*bc.MerkleProof, error) {
+	var data string
+	if err := s.db.GetContext(ctx, &data, sqlProofByTxID, txID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get proof for txid %s", txID)
 	}
-	if rows <= 0 {
-		return errors.Wrap(err, "no rows affected when creating proof")
+	var mp bc.MerkleProof
+	if err := json.Unmarshal([]byte(data), &mp); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse proof for txid %s", txID)
 	}
-	return errors.WithStack(tx.Commit())
+	return &mp, nil
 }
@@ -3,7 +3,7 @@ package sqlite
 const (
 	sqlTransactionCreate = `
 		INSERT INTO transactions(txid, paymentID, txhex)
-		VALUES(:txid, :paymentID, :txhex)
+		VALUES(:txid, NULLIF(:paymentID, ''), :txhex)
 	`
 
 	sqlTransactionByID = `
@@ -0,0 +1,106 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/config/databases"
+	paydSQL "github.com/libsv/payd/data/sqlite"
+	"github.com/libsv/payd/data/storetest"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (gopayd.Store, gopayd.Transacter) {
+		db, _ := setupDb(t)
+		return db, db.Transacter
+	})
+}
+
+func TestSQLiteStore_PartialTxoBeforePaymentID(t *testing.T) {
+	db, dsn := setupDb(t)
+	conn, err := sqlx.Open("sqlite3", dsn)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, conn.Close())
+	})
+
+	// reserved before txos were linked to their invoice.
+	_, err = conn.Exec(`INSERT INTO txos (keyname, derivationpath, lockingscript, satoshis)
+	VALUES('keyname', '0', 'abc', 100), ('keyname', '1', 'def', 200)`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.TxoCreate(context.Background(), gopayd.TxoCreate{
+		KeyName:        "keyname",
+		DerivationPath: "2",
+		LockingScript:  "def",
+		Satoshis:       300,
+		PaymentID:      "inv1",
+	}))
+
+	txo, err := db.PartialTxo(context.Background(), gopayd.UnspentTxoArgs{PaymentID: "inv1", LockingScript: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", txo.DerivationPath)
+
+	// a txo linked to the invoice is preferred.
+	txo, err = db.PartialTxo(context.Background(), gopayd.UnspentTxoArgs{PaymentID: "inv1", LockingScript: "def"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", txo.DerivationPath)
+}
+
+func TestSQLiteStore_CallbackTokensBeforeUpgrade(t *testing.T) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=true", filepath.Join(t.TempDir(), "wallet.db"))
+	conn, err := sqlx.Open("sqlite3", dsn)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, conn.Close())
+	})
+	driver, err := sqlite3.WithInstance(conn.DB, &sqlite3.Config{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://migrations", "sqlite3", driver)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// broadcast before callback tokens were introduced.
+	assert.NoError(t, m.Steps(4))
+	_, err = conn.Exec(`INSERT INTO transactions (txid, txhex) VALUES('abc', '00')`)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Up())
+
+	store := paydSQL.NewSQLiteStore(conn)
+	token, err := store.CallbackToken(context.Background(), gopayd.CallbackTokenArgs{TxID: "abc"})
+	if assert.NoError(t, err) {
+		assert.True(t, token.Legacy())
+	}
+}
+
+func setupDb(t *testing.T) (*databases.Db, string) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=true", filepath.Join(t.TempDir(), "wallet.db"))
+	db, err := databases.NewDbSetup().SetupDb(&config.Db{
+		Type:       config.DBSqlite,
+		Dsn:        dsn,
+		SchemaPath: "migrations",
+		MigrateDb:  true,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	return db, dsn
+}
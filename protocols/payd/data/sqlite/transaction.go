@@ -2,11 +2,21 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 
+	"github.com/libsv/go-bt/v2"
 	gopayd "github.com/libsv/payd"
 	"github.com/pkg/errors"
 )
 
+const (
+	sqlTxHexByID = `
+	SELECT txhex
+	FROM transactions
+	WHERE txid = $1
+	`
+)
+
 func (s *sqliteStore) StoreUtxos(ctx context.Context, req gopayd.CreateTransaction) (*gopayd.Transaction, error) {
 	tx, err := s.newTx(ctx)
 	if err != nil {
@@ -32,8 +42,11 @@ func (s *sqliteStore) txCreateTransaction(tx db, req gopayd.CreateTransaction) (
 	if err := handleNamedExec(tx, sqlTransactionCreate, req); err != nil {
 		return nil, errors.Wrap(err, "failed to insert new transaction")
 	}
-	if err := handleNamedExec(tx, sqlTxoUpdate, req.Outputs); err != nil {
-		return nil, errors.Wrap(err, "failed to insert transaction outputs")
+	// transactions sent from the wallet may not have change.
+	if len(req.Outputs) > 0 {
+		if err := handleNamedExec(tx, sqlTxoPaidCreate, req.Outputs); err != nil {
+			return nil, errors.Wrap(err, "failed to insert transaction outputs")
+		}
 	}
 	var outTx gopayd.Transaction
 	if err := tx.Get(&outTx, sqlTransactionByID, req.TxID); err != nil {
@@ -46,3 +59,20 @@ func (s *sqliteStore) txCreateTransaction(tx db, req gopayd.CreateTransaction) (
 	outTx.Outputs = outTxos
 	return &outTx, nil
 }
+
+// Tx will return a stored transaction, nil is returned if it isn't found.
+// This is used to build spv envelopes for transactions we send.
+func (s *sqliteStore) Tx(ctx context.Context, txID string) (*bt.Tx, error) {
+	var txHex string
+	if err := s.db.GetContext(ctx, &txHex, sqlTxHexByID, txID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get transaction %s", txID)
+	}
+	tx, err := bt.NewTxFromString(txHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse transaction %s", txID)
+	}
+	return tx, nil
+}
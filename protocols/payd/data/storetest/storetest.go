@@ -0,0 +1,917 @@
+// Package storetest contains the conformance tests each payd data store
+// must pass, so the stores can be swapped without changing behaviour.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bc"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/theflyingcodr/lathos"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/mocks"
+	"github.com/libsv/payd/service"
+)
+
+// SetupFunc returns an empty, migrated data store along with the
+// Transacter used to run its methods in a single transaction.
+type SetupFunc func(t *testing.T) (gopayd.Store, gopayd.Transacter)
+
+// Run will run the conformance tests against the stores returned by setup,
+// setup is called once per test.
+func Run(t *testing.T, setup SetupFunc) {
+	tests := map[string]func(t *testing.T, s gopayd.Store, tr gopayd.Transacter){
+		"invoices":                       testInvoices,
+		"invoice update":                 testInvoiceUpdate,
+		"invoice delete releases txos":   testInvoiceDelete,
+		"payments":                       testPayments,
+		"invoices expire":                testInvoicesExpire,
+		"partial txos":                   testPartialTxos,
+		"store utxos":                    testStoreUtxos,
+		"spendable txos and balance":     testSpendableTxos,
+		"txos spend":                     testTxosSpend,
+		"txos release":                   testTxosRelease,
+		"private keys":                   testPrivateKeys,
+		"proofs":                         testProofs,
+		"callback tokens":                testCallbackTokens,
+		"webhooks":                       testWebhooks,
+		"webhook events":                 testWebhookEvents,
+		"webhook events rollback":        testWebhookEventsRollback,
+		"webhook events delivered once":  testWebhookEventsDeliveredOnce,
+		"transacter rollback":            testTransacterRollback,
+		"transacter commit":              testTransacterCommit,
+		"transacter shares transactions": testTransacterShared,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			s, tr := setup(t)
+			test(t, s, tr)
+		})
+	}
+}
+
+const (
+	keyName = "testkey"
+	// xprv isn't validated by the stores, it is normally encrypted.
+	xprv = "xprv9s21ZrQH143K2beTKhLXFRWWFwH8jkwUssjk3SVTiApgmge7kNC3jhVc4NgHW8PhW2y7BCDErqnKpKuyQMjqSePPJooPJowAz5BVLThsv6c"
+	// pubKeyHash is used to build the locking scripts of test transactions.
+	pubKeyHash = "8fe80c75c9560e8b56ed64ea3c26e18d2c52211b"
+)
+
+func createInvoice(t *testing.T, s gopayd.Store, paymentID string, satoshis uint64, expiresAt null.Time) *gopayd.Invoice {
+	inv, err := s.Create(context.Background(), gopayd.InvoiceCreate{
+		PaymentID: paymentID,
+		Satoshis:  satoshis,
+		ExpiresAt: expiresAt,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return inv
+}
+
+func createKey(t *testing.T, s gopayd.Store, name string, active bool) *gopayd.PrivateKey {
+	k, err := s.PrivateKeyCreate(context.Background(), gopayd.PrivateKey{
+		Name:       name,
+		Xprv:       xprv,
+		Encryption: null.StringFrom("passphrase"),
+		Active:     active,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return k
+}
+
+// newTx builds a transaction paying satoshis to each output, the locking
+// scripts are returned in output order.
+func newTx(t *testing.T, satoshis ...uint64) (*bt.Tx, []string) {
+	tx := bt.NewTx()
+	scripts := make([]string, 0, len(satoshis))
+	for _, sats := range satoshis {
+		if !assert.NoError(t, tx.AddP2PKHOutputFromPubKeyHashStr(pubKeyHash, sats)) {
+			t.FailNow()
+		}
+		scripts = append(scripts, tx.Outputs[len(tx.Outputs)-1].LockingScriptHexString())
+	}
+	return tx, scripts
+}
+
+// storeTx will store tx as a payment for paymentID, each output is
+// derived from the path in paths with the same index.
+func storeTx(t *testing.T, s gopayd.Store, paymentID string, tx *bt.Tx, paths ...string) *gopayd.Transaction {
+	req := gopayd.CreateTransaction{
+		PaymentID: paymentID,
+		TxID:      tx.TxID(),
+		TxHex:     tx.String(),
+	}
+	for i, o := range tx.Outputs {
+		req.Outputs = append(req.Outputs, &gopayd.UpdateTxo{
+			Outpoint:       outpoint(tx, i),
+			TxID:           tx.TxID(),
+			Vout:           i,
+			KeyName:        null.StringFrom(keyName),
+			DerivationPath: null.StringFrom(paths[i]),
+			LockingScript:  o.LockingScriptHexString(),
+			Satoshis:       o.Satoshis,
+			PaymentID:      null.NewString(paymentID, paymentID != ""),
+		})
+	}
+	stored, err := s.StoreUtxos(context.Background(), req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return stored
+}
+
+func outpoint(tx *bt.Tx, vout int) string {
+	return fmt.Sprintf("%s%d", tx.TxID(), vout)
+}
+
+func testInvoices(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	invs, err := s.Invoices(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, invs)
+
+	expiresAt := null.TimeFrom(time.Now().UTC().Add(time.Hour).Truncate(time.Second))
+	inv := createInvoice(t, s, "inv1", 1000, expiresAt)
+	assert.Equal(t, "inv1", inv.PaymentID)
+	assert.Equal(t, uint64(1000), inv.Satoshis)
+	assert.Equal(t, uint64(0), inv.SatoshisPaid)
+	assert.Equal(t, gopayd.InvoiceStatePending, inv.State)
+	assert.True(t, inv.ExpiresAt.Time.Equal(expiresAt.Time))
+	assert.False(t, inv.PaymentReceivedAt.Valid)
+	createInvoice(t, s, "inv2", 2000, null.Time{})
+
+	inv, err = s.Invoice(ctx, gopayd.InvoiceArgs{PaymentID: "inv2"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2000), inv.Satoshis)
+	assert.False(t, inv.ExpiresAt.Valid)
+
+	invs, err = s.Invoices(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, invs, 2)
+
+	_, err = s.Invoice(ctx, gopayd.InvoiceArgs{PaymentID: "missing"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+
+	_, err = s.Create(ctx, gopayd.InvoiceCreate{PaymentID: "inv1", Satoshis: 1000})
+	assert.Error(t, err, "duplicate paymentID should error")
+}
+
+func testInvoiceUpdate(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	received := time.Now().UTC().Truncate(time.Second)
+	inv, err := s.Update(context.Background(), gopayd.InvoiceUpdateArgs{PaymentID: "inv1"}, gopayd.InvoiceUpdate{
+		State:             gopayd.InvoiceStatePaid,
+		PaymentReceivedAt: null.TimeFrom(received),
+		RefundTo:          null.StringFrom("refund@paymail.com"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, gopayd.InvoiceStatePaid, inv.State)
+	assert.True(t, inv.PaymentReceivedAt.Time.Equal(received))
+	assert.Equal(t, null.StringFrom("refund@paymail.com"), inv.RefundTo)
+	assert.False(t, inv.RefundedAt.Valid)
+}
+
+func testInvoiceDelete(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	assert.NoError(t, s.TxoCreate(ctx, gopayd.TxoCreate{
+		PaymentID:      "inv1",
+		KeyName:        keyName,
+		DerivationPath: "0/0/1",
+		LockingScript:  "76a9148fe80c75c9560e8b56ed64ea3c26e18d2c52211b88ac",
+		Satoshis:       1000,
+	}))
+
+	assert.NoError(t, s.Delete(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"}))
+	_, err := s.Invoice(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: "0/0/1"})
+	assert.NoError(t, err)
+	assert.False(t, exists, "derivation path should be released")
+
+	assert.Error(t, s.Delete(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"}), "deleting a missing invoice should error")
+}
+
+func testPayments(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	payments, err := s.Payments(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+
+	tx1, _ := newTx(t, 600)
+	storeTx(t, s, "inv1", tx1, "0/0/1")
+	inv, err := s.PaymentCreate(ctx, gopayd.PaymentCreate{
+		TxID:      tx1.TxID(),
+		PaymentID: "inv1",
+		Satoshis:  600,
+		RefundTo:  null.StringFrom("refund@paymail.com"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(600), inv.SatoshisPaid)
+
+	tx2, _ := newTx(t, 700)
+	storeTx(t, s, "inv1", tx2, "0/0/2")
+	inv, err = s.PaymentCreate(ctx, gopayd.PaymentCreate{
+		TxID:      tx2.TxID(),
+		PaymentID: "inv1",
+		Satoshis:  700,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1300), inv.SatoshisPaid)
+	assert.Equal(t, uint64(300), inv.Overpaid())
+
+	_, err = s.PaymentCreate(ctx, gopayd.PaymentCreate{TxID: tx2.TxID(), PaymentID: "inv1", Satoshis: 700})
+	assert.Error(t, err, "duplicate payment should error")
+
+	payments, err = s.Payments(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	if assert.Len(t, payments, 2) {
+		txIDs := []string{payments[0].TxID, payments[1].TxID}
+		assert.ElementsMatch(t, []string{tx1.TxID(), tx2.TxID()}, txIDs)
+		for _, p := range payments {
+			assert.Equal(t, "inv1", p.PaymentID)
+			if p.TxID == tx1.TxID() {
+				assert.Equal(t, uint64(600), p.Satoshis)
+				assert.Equal(t, null.StringFrom("refund@paymail.com"), p.RefundTo)
+			}
+		}
+	}
+	inv, err = s.Invoice(ctx, gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1300), inv.SatoshisPaid)
+}
+
+func testInvoicesExpire(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	createInvoice(t, s, "expired", 1000, null.TimeFrom(now.Add(-time.Minute)))
+	createInvoice(t, s, "partial", 1000, null.TimeFrom(now.Add(-time.Minute)))
+	createInvoice(t, s, "paid", 1000, null.TimeFrom(now.Add(-time.Minute)))
+	createInvoice(t, s, "future", 1000, null.TimeFrom(now.Add(time.Hour)))
+	createInvoice(t, s, "noexpiry", 1000, null.Time{})
+	_, err := s.Update(ctx, gopayd.InvoiceUpdateArgs{PaymentID: "partial"}, gopayd.InvoiceUpdate{State: gopayd.InvoiceStatePartiallyPaid})
+	assert.NoError(t, err)
+	_, err = s.Update(ctx, gopayd.InvoiceUpdateArgs{PaymentID: "paid"}, gopayd.InvoiceUpdate{State: gopayd.InvoiceStatePaid})
+	assert.NoError(t, err)
+	for i, paymentID := range []string{"expired", "future"} {
+		assert.NoError(t, s.TxoCreate(ctx, gopayd.TxoCreate{
+			PaymentID:      paymentID,
+			KeyName:        keyName,
+			DerivationPath: paymentID,
+			LockingScript:  "76a9148fe80c75c9560e8b56ed64ea3c26e18d2c52211b88ac",
+			Satoshis:       uint64(1000 + i),
+		}))
+	}
+
+	n, err := s.InvoicesExpire(ctx, gopayd.InvoicesExpireArgs{Now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	for paymentID, state := range map[string]gopayd.InvoiceState{
+		"expired":  gopayd.InvoiceStateExpired,
+		"partial":  gopayd.InvoiceStateExpired,
+		"paid":     gopayd.InvoiceStatePaid,
+		"future":   gopayd.InvoiceStatePending,
+		"noexpiry": gopayd.InvoiceStatePending,
+	} {
+		inv, err := s.Invoice(ctx, gopayd.InvoiceArgs{PaymentID: paymentID})
+		assert.NoError(t, err)
+		assert.Equal(t, state, inv.State, paymentID)
+	}
+	exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: "expired"})
+	assert.NoError(t, err)
+	assert.False(t, exists, "txos reserved for expired invoices should be released")
+	exists, err = s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: "future"})
+	assert.NoError(t, err)
+	assert.True(t, exists, "txos reserved for payable invoices should be kept")
+
+	n, err = s.InvoicesExpire(ctx, gopayd.InvoicesExpireArgs{Now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func testPartialTxos(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	assert.NoError(t, s.TxosCreate(ctx, []*gopayd.TxoCreate{{
+		PaymentID:      "inv1",
+		KeyName:        keyName,
+		DerivationPath: "0/0/1",
+		LockingScript:  "script1",
+		Satoshis:       600,
+	}, {
+		PaymentID:      "inv1",
+		KeyName:        keyName,
+		DerivationPath: "0/0/10",
+		LockingScript:  "script2",
+		Satoshis:       400,
+	}}))
+
+	txo, err := s.PartialTxo(ctx, gopayd.UnspentTxoArgs{PaymentID: "inv1", LockingScript: "script2"})
+	assert.NoError(t, err)
+	assert.Equal(t, keyName, txo.KeyName)
+	assert.Equal(t, "0/0/10", txo.DerivationPath)
+	assert.Equal(t, "script2", txo.LockingScript)
+	assert.Equal(t, uint64(400), txo.Satoshis)
+
+	_, err = s.PartialTxo(ctx, gopayd.UnspentTxoArgs{PaymentID: "inv2", LockingScript: "script2"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+
+	for path, exp := range map[string]bool{
+		"0/0/1":  true,
+		"0/0/10": true,
+		"0/0":    false,
+		"0/0/2":  false,
+	} {
+		exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: path})
+		assert.NoError(t, err)
+		assert.Equal(t, exp, exists, path)
+	}
+	exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: "otherkey", Path: "0/0/1"})
+	assert.NoError(t, err)
+	assert.False(t, exists, "derivation paths are per key")
+
+	// paid txos aren't partial.
+	bal, err := s.Balance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), bal.Satoshis)
+}
+
+func testStoreUtxos(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, scripts := newTx(t, 600, 400)
+	stored := storeTx(t, s, "inv1", tx, "0/0/1", "0/0/2")
+	assert.Equal(t, tx.TxID(), stored.TxID)
+	assert.Equal(t, tx.String(), stored.TxHex)
+	assert.Equal(t, null.StringFrom("inv1"), stored.PaymentID)
+	if assert.Len(t, stored.Outputs, 2) {
+		outpoints := []string{stored.Outputs[0].Outpoint, stored.Outputs[1].Outpoint}
+		assert.ElementsMatch(t, []string{outpoint(tx, 0), outpoint(tx, 1)}, outpoints)
+		for _, o := range stored.Outputs {
+			assert.Equal(t, tx.TxID(), o.TxID)
+			assert.Equal(t, scripts[o.Vout], o.LockingScript)
+			assert.Equal(t, tx.Outputs[o.Vout].Satoshis, o.Satoshis)
+			assert.Equal(t, null.StringFrom(keyName), o.KeyName)
+			assert.False(t, o.SpentAt.Valid)
+		}
+	}
+	exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: "0/0/2"})
+	assert.NoError(t, err)
+	assert.True(t, exists, "paid txos should use their derivation path")
+
+	// transactions sent from the wallet have no invoice and may not have change.
+	sent, _ := newTx(t, 500)
+	stored, err = s.StoreUtxos(ctx, gopayd.CreateTransaction{
+		TxID:  sent.TxID(),
+		TxHex: sent.String(),
+	})
+	assert.NoError(t, err)
+	assert.False(t, stored.PaymentID.Valid)
+	assert.Empty(t, stored.Outputs)
+
+	got, err := s.Tx(ctx, tx.TxID())
+	assert.NoError(t, err)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, tx.TxID(), got.TxID())
+	}
+	got, err = s.Tx(ctx, "0000000000000000000000000000000000000000000000000000000000000000")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = s.StoreUtxos(ctx, gopayd.CreateTransaction{TxID: sent.TxID(), TxHex: sent.String()})
+	assert.Error(t, err, "duplicate transaction should error")
+}
+
+func testSpendableTxos(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createKey(t, s, keyName, true)
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, _ := newTx(t, 300, 900, 500)
+	storeTx(t, s, "inv1", tx, "0/0/1", "0/0/2", "0/0/3")
+
+	// paymail payments aren't derived from our keys so can't be spent.
+	paymail, scripts := newTx(t, 2000)
+	_, err := s.StoreUtxos(ctx, gopayd.CreateTransaction{
+		PaymentID: "inv1",
+		TxID:      paymail.TxID(),
+		TxHex:     paymail.String(),
+		Outputs: []*gopayd.UpdateTxo{{
+			Outpoint:      outpoint(paymail, 0),
+			TxID:          paymail.TxID(),
+			Vout:          0,
+			LockingScript: scripts[0],
+			Satoshis:      2000,
+			PaymentID:     null.StringFrom("inv1"),
+		}},
+	})
+	assert.NoError(t, err)
+
+	txos, err := s.SpendableTxos(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, txos, 3) {
+		assert.Equal(t, uint64(900), txos[0].Satoshis)
+		assert.Equal(t, uint64(500), txos[1].Satoshis)
+		assert.Equal(t, uint64(300), txos[2].Satoshis)
+		assert.Equal(t, outpoint(tx, 1), txos[0].Outpoint)
+		assert.Equal(t, 1, txos[0].Vout)
+		assert.Equal(t, null.StringFrom("0/0/2"), txos[0].DerivationPath)
+	}
+	bal, err := s.Balance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3700), bal.Satoshis)
+}
+
+func testTxosSpend(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createKey(t, s, keyName, true)
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, _ := newTx(t, 300, 900, 500)
+	storeTx(t, s, "inv1", tx, "0/0/1", "0/0/2", "0/0/3")
+
+	spending, _ := newTx(t, 1000)
+	assert.NoError(t, s.TxosSpend(ctx, []gopayd.SpendTxoArgs{
+		{Outpoint: outpoint(tx, 0)},
+		{Outpoint: outpoint(tx, 1)},
+	}, gopayd.SpendTxo{SpendingTxID: spending.TxID()}))
+
+	txos, err := s.SpendableTxos(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, txos, 1) {
+		assert.Equal(t, outpoint(tx, 2), txos[0].Outpoint)
+	}
+	bal, err := s.Balance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(500), bal.Satoshis)
+
+	// a spent txo can't be spent again, and none of the txos are updated.
+	err = s.TxosSpend(ctx, []gopayd.SpendTxoArgs{
+		{Outpoint: outpoint(tx, 2)},
+		{Outpoint: outpoint(tx, 0)},
+	}, gopayd.SpendTxo{SpendingTxID: spending.TxID()})
+	assert.True(t, lathos.IsDuplicate(err), "expected duplicate error, got %v", err)
+	txos, err = s.SpendableTxos(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, txos, 1)
+
+	err = s.TxosSpend(ctx, []gopayd.SpendTxoArgs{{Outpoint: "missing"}}, gopayd.SpendTxo{SpendingTxID: spending.TxID()})
+	assert.True(t, lathos.IsDuplicate(err), "expected duplicate error, got %v", err)
+}
+
+func testTxosRelease(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createKey(t, s, keyName, true)
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, _ := newTx(t, 300, 900, 500)
+	storeTx(t, s, "inv1", tx, "0/0/1", "0/0/2", "0/0/3")
+
+	// spend two txos with change, as the send service reserves them.
+	spending, _ := newTx(t, 1000, 150)
+	assert.NoError(t, s.TxosSpend(ctx, []gopayd.SpendTxoArgs{
+		{Outpoint: outpoint(tx, 0)},
+		{Outpoint: outpoint(tx, 1)},
+	}, gopayd.SpendTxo{SpendingTxID: spending.TxID()}))
+	_, err := s.StoreUtxos(ctx, gopayd.CreateTransaction{
+		TxID:  spending.TxID(),
+		TxHex: spending.String(),
+		Outputs: []*gopayd.UpdateTxo{{
+			Outpoint:       outpoint(spending, 1),
+			TxID:           spending.TxID(),
+			Vout:           1,
+			KeyName:        null.StringFrom(keyName),
+			DerivationPath: null.StringFrom("0/0/4"),
+			LockingScript:  spending.Outputs[1].LockingScriptHexString(),
+			Satoshis:       150,
+		}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, s.CallbackTokenCreate(ctx, gopayd.CallbackTokenCreate{TxID: spending.TxID(), TokenHash: "hash"}))
+	bal, err := s.Balance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(650), bal.Satoshis)
+
+	assert.NoError(t, s.TxosRelease(ctx, gopayd.ReleaseTxosArgs{SpendingTxID: spending.TxID()}))
+	txos, err := s.SpendableTxos(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, txos, 3)
+	bal, err = s.Balance(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1700), bal.Satoshis)
+	stored, err := s.Tx(ctx, spending.TxID())
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+	_, err = s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: spending.TxID()})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	exists, err := s.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{KeyName: keyName, Path: "0/0/4"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the released txos can be spent again.
+	assert.NoError(t, s.TxosSpend(ctx, []gopayd.SpendTxoArgs{
+		{Outpoint: outpoint(tx, 0)},
+	}, gopayd.SpendTxo{SpendingTxID: spending.TxID()}))
+}
+
+func testPrivateKeys(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	_, err := s.PrivateKeyActive(ctx)
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	keys, err := s.PrivateKeys(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	k := createKey(t, s, "key1", true)
+	assert.Equal(t, "key1", k.Name)
+	assert.Equal(t, xprv, k.Xprv)
+	assert.Equal(t, null.StringFrom("passphrase"), k.Encryption)
+	assert.True(t, k.Active)
+	assert.False(t, k.RotatedAt.Valid)
+	assert.False(t, k.CreatedAt.IsZero())
+
+	_, err = s.PrivateKeyCreate(ctx, gopayd.PrivateKey{Name: "key1", Xprv: xprv})
+	assert.Error(t, err, "duplicate key should error")
+
+	// creating an active key rotates the current one.
+	createKey(t, s, "key2", true)
+	active, err := s.PrivateKeyActive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "key2", active.Name)
+	k, err = s.PrivateKey(ctx, gopayd.KeyArgs{Name: "key1"})
+	assert.NoError(t, err)
+	assert.False(t, k.Active)
+	assert.True(t, k.RotatedAt.Valid)
+
+	createKey(t, s, "key3", false)
+	active, err = s.PrivateKeyActive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "key2", active.Name)
+
+	keys, err = s.PrivateKeys(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	k, err = s.PrivateKeyUpdate(ctx, gopayd.KeyArgs{Name: "key1"}, gopayd.PrivateKeyUpdate{
+		Xprv:       "encrypted",
+		Encryption: null.StringFrom("kms"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "encrypted", k.Xprv)
+	assert.Equal(t, null.StringFrom("kms"), k.Encryption)
+	assert.False(t, k.Active)
+
+	_, err = s.PrivateKey(ctx, gopayd.KeyArgs{Name: "missing"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+}
+
+func testProofs(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, _ := newTx(t, 1000)
+	storeTx(t, s, "inv1", tx, "0/0/1")
+
+	mp, err := s.MerkleProof(ctx, tx.TxID())
+	assert.NoError(t, err)
+	assert.Nil(t, mp)
+
+	proof := &bc.MerkleProof{
+		Index:      1,
+		TxOrID:     tx.TxID(),
+		Target:     "b1b32c1d23c9ac1d0c4cdd7fe1a7a2b3fcd8bcef60c6bcbbd4d2c5a0d4e7c1a2",
+		Nodes:      []string{"*", "a1b2c3"},
+		TargetType: "hash",
+	}
+	assert.NoError(t, s.ProofCreate(ctx, gopayd.ProofWrapper{
+		CallbackPayload: proof,
+		BlockHash:       proof.Target,
+		BlockHeight:     100,
+		CallbackTxID:    tx.TxID(),
+		CallbackReason:  "merkleProof",
+	}))
+	mp, err = s.MerkleProof(ctx, tx.TxID())
+	assert.NoError(t, err)
+	assert.Equal(t, proof, mp)
+
+	assert.Error(t, s.ProofCreate(ctx, gopayd.ProofWrapper{
+		CallbackPayload: proof,
+		BlockHash:       proof.Target,
+		CallbackTxID:    tx.TxID(),
+	}), "duplicate proof should error")
+}
+
+func testCallbackTokens(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	tx, _ := newTx(t, 1000)
+	storeTx(t, s, "inv1", tx, "0/0/1")
+
+	_, err := s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: tx.TxID()})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+
+	assert.NoError(t, s.CallbackTokenCreate(ctx, gopayd.CallbackTokenCreate{
+		TxID:      tx.TxID(),
+		TokenHash: gopayd.CallbackTokenHash("token1"),
+	}))
+	token, err := s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: tx.TxID()})
+	assert.NoError(t, err)
+	assert.Equal(t, tx.TxID(), token.TxID)
+	assert.True(t, token.Matches("token1"))
+
+	// broadcasting again replaces the token.
+	assert.NoError(t, s.CallbackTokenCreate(ctx, gopayd.CallbackTokenCreate{
+		TxID:      tx.TxID(),
+		TokenHash: gopayd.CallbackTokenHash("token2"),
+	}))
+	token, err = s.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: tx.TxID()})
+	assert.NoError(t, err)
+	assert.False(t, token.Matches("token1"))
+	assert.True(t, token.Matches("token2"))
+}
+
+func testWebhooks(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	_, err := s.Webhook(ctx, gopayd.WebhookArgs{ID: "wh1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+
+	wh, err := s.WebhookCreate(ctx, gopayd.WebhookCreate{
+		ID:     "wh1",
+		URL:    "https://merchant.com/hooks",
+		Secret: "secret1",
+		Events: gopayd.WebhookEventTypes{gopayd.WebhookEventInvoiceCreated, gopayd.WebhookEventProofStored},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "wh1", wh.ID)
+	assert.Equal(t, "secret1", wh.Secret)
+	assert.False(t, wh.CreatedAt.IsZero())
+	_, err = s.WebhookCreate(ctx, gopayd.WebhookCreate{ID: "wh2", URL: "http://localhost:8080", Secret: "secret2"})
+	assert.NoError(t, err)
+
+	wh, err = s.Webhook(ctx, gopayd.WebhookArgs{ID: "wh1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://merchant.com/hooks", wh.URL)
+	assert.Equal(t, gopayd.WebhookEventTypes{gopayd.WebhookEventInvoiceCreated, gopayd.WebhookEventProofStored}, wh.Events)
+	ww, err := s.Webhooks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ww, 2)
+
+	assert.NoError(t, s.WebhookDelete(ctx, gopayd.WebhookArgs{ID: "wh1"}))
+	_, err = s.Webhook(ctx, gopayd.WebhookArgs{ID: "wh1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	err = s.WebhookDelete(ctx, gopayd.WebhookArgs{ID: "wh1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	ww, err = s.Webhooks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ww, 1)
+	assert.Empty(t, ww[0].Events)
+}
+
+func testWebhookEvents(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, id := range []string{"wh1", "wh2"} {
+		_, err := s.WebhookCreate(ctx, gopayd.WebhookCreate{ID: id, URL: "https://merchant.com/" + id, Secret: "secret"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, s.WebhookEventsCreate(ctx, []gopayd.WebhookEventCreate{{
+		ID:            "e1",
+		WebhookID:     "wh1",
+		Event:         gopayd.WebhookEventInvoiceCreated,
+		Payload:       `{"id":"e1"}`,
+		NextAttemptAt: now,
+	}, {
+		ID:            "e2",
+		WebhookID:     "wh2",
+		Event:         gopayd.WebhookEventInvoiceCreated,
+		Payload:       `{"id":"e2"}`,
+		NextAttemptAt: now,
+	}, {
+		ID:            "e3",
+		WebhookID:     "wh1",
+		Event:         gopayd.WebhookEventProofStored,
+		Payload:       `{"id":"e3"}`,
+		NextAttemptAt: now.Add(time.Hour),
+	}}))
+
+	// due leases the events due at now for a minute.
+	due := func(now time.Time, limit int) []gopayd.WebhookEvent {
+		ee, err := s.WebhookEventsDue(ctx, gopayd.WebhookEventsDueArgs{Now: now, Limit: limit, LeaseUntil: now.Add(time.Minute)})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return ee
+	}
+	ids := func(ee []gopayd.WebhookEvent) []string {
+		resp := make([]string, 0, len(ee))
+		for _, e := range ee {
+			resp = append(resp, e.ID)
+		}
+		return resp
+	}
+
+	// leased events aren't returned again until the lease expires.
+	first := ids(due(now, 1))
+	assert.Len(t, first, 1)
+	second := ids(due(now, 10))
+	assert.Len(t, second, 1)
+	assert.ElementsMatch(t, []string{"e1", "e2"}, append(first, second...))
+	assert.Empty(t, due(now, 10))
+
+	ee := due(now.Add(time.Minute), 10)
+	assert.ElementsMatch(t, []string{"e1", "e2"}, ids(ee))
+	for _, e := range ee {
+		assert.Equal(t, 0, e.Attempts)
+		assert.Equal(t, `{"id":"`+e.ID+`"}`, e.Payload)
+		assert.False(t, e.DeliveredAt.Valid)
+		assert.True(t, now.Add(2*time.Minute).Equal(e.NextAttemptAt))
+	}
+
+	// a failed attempt is retried later, delivered and failed events aren't due.
+	assert.NoError(t, s.WebhookEventUpdate(ctx, gopayd.WebhookEventArgs{ID: "e1"}, gopayd.WebhookEventUpdate{
+		Attempts:      1,
+		NextAttemptAt: now.Add(3 * time.Minute),
+		LastError:     null.StringFrom("unexpected status code 500"),
+	}))
+	assert.NoError(t, s.WebhookEventUpdate(ctx, gopayd.WebhookEventArgs{ID: "e2"}, gopayd.WebhookEventUpdate{
+		Attempts:      1,
+		NextAttemptAt: now,
+		DeliveredAt:   null.TimeFrom(now),
+	}))
+	assert.Empty(t, due(now.Add(2*time.Minute), 10))
+	ee = due(now.Add(3*time.Minute), 10)
+	if assert.Len(t, ee, 1) {
+		assert.Equal(t, "e1", ee[0].ID)
+		assert.Equal(t, 1, ee[0].Attempts)
+		assert.Equal(t, "unexpected status code 500", ee[0].LastError.String)
+	}
+	assert.NoError(t, s.WebhookEventUpdate(ctx, gopayd.WebhookEventArgs{ID: "e1"}, gopayd.WebhookEventUpdate{
+		Attempts:      2,
+		NextAttemptAt: now.Add(3 * time.Minute),
+		FailedAt:      null.TimeFrom(now),
+		LastError:     null.StringFrom("unexpected status code 500"),
+	}))
+	assert.Equal(t, []string{"e3"}, ids(due(now.Add(time.Hour), 10)))
+
+	// deleting a webhook discards its events.
+	assert.NoError(t, s.WebhookDelete(ctx, gopayd.WebhookArgs{ID: "wh1"}))
+	assert.Empty(t, due(now.Add(2*time.Hour), 10))
+}
+
+func testWebhookEventsDeliveredOnce(t *testing.T, s gopayd.Store, _ gopayd.Transacter) {
+	ctx := context.Background()
+	_, err := s.WebhookCreate(ctx, gopayd.WebhookCreate{ID: "wh1", URL: "https://merchant.com", Secret: "secret"})
+	assert.NoError(t, err)
+	events := make([]gopayd.WebhookEventCreate, 0, 150)
+	for i := 0; i < cap(events); i++ {
+		events = append(events, gopayd.WebhookEventCreate{
+			ID:            fmt.Sprintf("e%d", i),
+			WebhookID:     "wh1",
+			Event:         gopayd.WebhookEventInvoiceCreated,
+			Payload:       fmt.Sprintf(`{"id":"e%d"}`, i),
+			NextAttemptAt: time.Now().UTC().Add(-time.Minute),
+		})
+	}
+	assert.NoError(t, s.WebhookEventsCreate(ctx, events))
+
+	// two replicas delivering from the same store, sends are slowed so
+	// their batches overlap.
+	sender := &mocks.WebhookSenderMock{
+		WebhookSendFunc: func(ctx context.Context, args gopayd.WebhookSendArgs, payload []byte) error {
+			time.Sleep(time.Millisecond)
+			return nil
+		},
+	}
+	cfg := &config.Webhooks{Timeout: time.Second, Backoff: time.Minute, MaxAttempts: 3}
+	deliverers := []interface {
+		Deliver(ctx context.Context) (int, error)
+	}{
+		service.NewWebhookDelivery(s, s, sender, cfg),
+		service.NewWebhookDelivery(s, s, sender, cfg),
+	}
+	for i := 0; i < 20 && len(sender.WebhookSendCalls()) < len(events); i++ {
+		var wg sync.WaitGroup
+		for _, d := range deliverers {
+			wg.Add(1)
+			go func(d interface {
+				Deliver(ctx context.Context) (int, error)
+			}) {
+				defer wg.Done()
+				// a deliverer losing the race to lease events may error, it
+				// tries again next round.
+				_, _ = d.Deliver(ctx)
+			}(d)
+		}
+		wg.Wait()
+	}
+
+	sent := map[string]int{}
+	for _, call := range sender.WebhookSendCalls() {
+		sent[call.Args.EventID]++
+	}
+	assert.Len(t, sent, len(events))
+	for id, n := range sent {
+		assert.Equal(t, 1, n, "event %s sent %d times", id, n)
+	}
+}
+
+func testWebhookEventsRollback(t *testing.T, s gopayd.Store, tr gopayd.Transacter) {
+	_, err := s.WebhookCreate(context.Background(), gopayd.WebhookCreate{ID: "wh1", URL: "https://merchant.com", Secret: "secret"})
+	assert.NoError(t, err)
+	now := time.Now().UTC()
+
+	// events are written in the same tx as the change they describe.
+	ctx := tr.WithTx(context.Background())
+	_, err = s.Create(ctx, gopayd.InvoiceCreate{PaymentID: "inv1", Satoshis: 1000})
+	assert.NoError(t, err)
+	assert.NoError(t, s.WebhookEventsCreate(ctx, []gopayd.WebhookEventCreate{{
+		ID:            "e1",
+		WebhookID:     "wh1",
+		Event:         gopayd.WebhookEventInvoiceCreated,
+		Payload:       `{"id":"e1"}`,
+		NextAttemptAt: now,
+	}}))
+	assert.NoError(t, tr.Rollback(ctx))
+
+	ee, err := s.WebhookEventsDue(context.Background(), gopayd.WebhookEventsDueArgs{Now: now, Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, ee)
+}
+
+func testTransacterRollback(t *testing.T, s gopayd.Store, tr gopayd.Transacter) {
+	ctx := tr.WithTx(context.Background())
+	_, err := s.Create(ctx, gopayd.InvoiceCreate{PaymentID: "inv1", Satoshis: 1000})
+	assert.NoError(t, err)
+	assert.NoError(t, s.TxoCreate(ctx, gopayd.TxoCreate{
+		PaymentID:      "inv1",
+		KeyName:        keyName,
+		DerivationPath: "0/0/1",
+		LockingScript:  "script1",
+		Satoshis:       1000,
+	}))
+	assert.NoError(t, tr.Rollback(ctx))
+
+	_, err = s.Invoice(context.Background(), gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+	_, err = s.PartialTxo(context.Background(), gopayd.UnspentTxoArgs{PaymentID: "inv1", LockingScript: "script1"})
+	assert.True(t, lathos.IsNotFound(err), "expected not found error, got %v", err)
+}
+
+func testTransacterCommit(t *testing.T, s gopayd.Store, tr gopayd.Transacter) {
+	ctx := tr.WithTx(context.Background())
+	_, err := s.Create(ctx, gopayd.InvoiceCreate{PaymentID: "inv1", Satoshis: 1000})
+	assert.NoError(t, err)
+	assert.NoError(t, s.TxoCreate(ctx, gopayd.TxoCreate{
+		PaymentID:      "inv1",
+		KeyName:        keyName,
+		DerivationPath: "0/0/1",
+		LockingScript:  "script1",
+		Satoshis:       1000,
+	}))
+	assert.NoError(t, tr.Commit(ctx))
+
+	_, err = s.Invoice(context.Background(), gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	_, err = s.PartialTxo(context.Background(), gopayd.UnspentTxoArgs{PaymentID: "inv1", LockingScript: "script1"})
+	assert.NoError(t, err)
+}
+
+// testTransacterShared checks a payment is stored atomically, as it is when
+// ppctl stores the transaction, payment and invoice state together.
+func testTransacterShared(t *testing.T, s gopayd.Store, tr gopayd.Transacter) {
+	createInvoice(t, s, "inv1", 1000, null.Time{})
+	ctx := tr.WithTx(context.Background())
+	tx, _ := newTx(t, 1000)
+	storeTx(t, s, "inv1", tx, "0/0/1")
+	req := gopayd.CreateTransaction{PaymentID: "inv1", TxID: tx.TxID(), TxHex: tx.String()}
+	req.Outputs = []*gopayd.UpdateTxo{{
+		Outpoint:      outpoint(tx, 0),
+		TxID:          tx.TxID(),
+		LockingScript: tx.Outputs[0].LockingScriptHexString(),
+		Satoshis:      1000,
+	}}
+	_, err := s.PaymentCreate(ctx, gopayd.PaymentCreate{TxID: tx.TxID(), PaymentID: "inv1", Satoshis: 1000})
+	assert.NoError(t, err)
+	// the transaction has already been stored so the payment is rolled back.
+	_, err = s.StoreUtxos(ctx, req)
+	assert.Error(t, err)
+	assert.NoError(t, tr.Rollback(ctx))
+
+	inv, err := s.Invoice(context.Background(), gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), inv.SatoshisPaid)
+	payments, err := s.Payments(context.Background(), gopayd.InvoiceArgs{PaymentID: "inv1"})
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+}
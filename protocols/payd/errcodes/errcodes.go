@@ -2,6 +2,13 @@ package errcodes
 
 // error codes used throughout application.
 const (
-	ErrDuplicatePayment = "D1"
-	ErrExpiredPayment   = "E1"
+	ErrDuplicatePayment  = "D1"
+	ErrExpiredPayment    = "E1"
+	ErrRefundedPayment   = "R1"
+	ErrRefundInvalid     = "R2"
+	ErrTxoSpent          = "S1"
+	ErrInsufficientFunds = "S2"
+	ErrPaymentRejected   = "S3"
+	ErrCallbackToken     = "A1"
+	ErrMinerID           = "A2"
 )
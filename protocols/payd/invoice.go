@@ -8,12 +8,76 @@ import (
 	"gopkg.in/guregu/null.v3"
 )
 
+// InvoiceState is the current state of an invoice.
+type InvoiceState string
+
+// Supported invoice states.
+const (
+	// InvoiceStatePending is a new invoice that hasn't received a payment.
+	InvoiceStatePending InvoiceState = "pending"
+	// InvoiceStatePartiallyPaid is an invoice that has received payments
+	// which don't yet cover the satoshis requested.
+	InvoiceStatePartiallyPaid InvoiceState = "partiallyPaid"
+	// InvoiceStatePaid is an invoice that has been paid in full, or overpaid.
+	InvoiceStatePaid InvoiceState = "paid"
+	// InvoiceStateExpired is an invoice that wasn't paid in full before it expired.
+	InvoiceStateExpired InvoiceState = "expired"
+	// InvoiceStateRefunded is an invoice whose payments have been returned to the customer.
+	InvoiceStateRefunded InvoiceState = "refunded"
+)
+
 // Invoice stores information related to a payment.
 type Invoice struct {
-	PaymentID         string      `json:"paymentID" db:"paymentID"`
-	Satoshis          uint64      `json:"satoshis" db:"satoshis"`
+	PaymentID string `json:"paymentID" db:"paymentID"`
+	Satoshis  uint64 `json:"satoshis" db:"satoshis"`
+	// SatoshisPaid is the total of all payments received for this invoice.
+	SatoshisPaid uint64       `json:"satoshisPaid" db:"satoshisPaid"`
+	State        InvoiceState `json:"state" db:"state"`
+	// ExpiresAt is the time after which the invoice can no longer be paid, if null
+	// the invoice doesn't expire.
+	ExpiresAt null.Time `json:"expiresAt" db:"expiresAt"`
+	// PaymentReceivedAt is the time the invoice was paid in full.
 	PaymentReceivedAt null.Time   `json:"paymentReceivedAt" db:"paymentReceivedAt"`
 	RefundTo          null.String `json:"refundTo" db:"refundTo"`
+	RefundedAt        null.Time   `json:"refundedAt" db:"refundedAt"`
+}
+
+// Outstanding returns the satoshis still to be paid, this is
+// the amount the invoice is underpaid by.
+func (i Invoice) Outstanding() uint64 {
+	if i.SatoshisPaid >= i.Satoshis {
+		return 0
+	}
+	return i.Satoshis - i.SatoshisPaid
+}
+
+// Overpaid returns the satoshis paid over the invoice amount.
+func (i Invoice) Overpaid() uint64 {
+	if i.SatoshisPaid <= i.Satoshis {
+		return 0
+	}
+	return i.SatoshisPaid - i.Satoshis
+}
+
+// Expired will return true if the invoice has an expiry date that has passed.
+func (i Invoice) Expired(now time.Time) bool {
+	return i.ExpiresAt.Valid && !i.ExpiresAt.Time.After(now)
+}
+
+// Payable will return true if the invoice can still receive payments.
+func (i Invoice) Payable() bool {
+	return i.State == InvoiceStatePending || i.State == InvoiceStatePartiallyPaid
+}
+
+// PaidState returns the state of a payable invoice given the satoshis paid so far.
+func (i Invoice) PaidState() InvoiceState {
+	switch {
+	case i.SatoshisPaid >= i.Satoshis:
+		return InvoiceStatePaid
+	case i.SatoshisPaid > 0:
+		return InvoiceStatePartiallyPaid
+	}
+	return InvoiceStatePending
 }
 
 // InvoiceCreate is used to create a new invoice.
@@ -21,18 +85,26 @@ type InvoiceCreate struct {
 	// PaymentID is the unique identifier for a payment.
 	PaymentID string `json:"-" db:"paymentID"`
 	Satoshis  uint64 `json:"satoshis" db:"satoshis"`
+	// ExpiresAt is optional, if not supplied the wallet payment expiry is used.
+	ExpiresAt null.Time `json:"expiresAt" db:"expiresAt"`
 }
 
 // Validate will check that InvoiceCreate params match expectations.
 func (i InvoiceCreate) Validate() validator.ErrValidation {
-	return validator.New().
+	v := validator.New().
 		Validate("satoshis", validator.MinUInt64(i.Satoshis, 546))
+	if i.ExpiresAt.Valid {
+		v = v.Validate("expiresAt", validator.DateAfter(i.ExpiresAt.Time, time.Now().UTC()))
+	}
+	return v
 }
 
 // InvoiceUpdate can be used to update an invoice after it has been created.
 type InvoiceUpdate struct {
-	PaymentReceivedAt time.Time   `db:"paymentReceviedAt"`
-	RefundTo          null.String `db:"refundTo"`
+	State             InvoiceState `db:"state"`
+	PaymentReceivedAt null.Time    `db:"paymentReceivedAt"`
+	RefundTo          null.String  `db:"refundTo"`
+	RefundedAt        null.Time    `db:"refundedAt"`
 }
 
 // InvoiceUpdateArgs are used to identify the invoice to update.
@@ -50,12 +122,40 @@ func (i *InvoiceArgs) Validate() validator.ErrValidation {
 	return validator.New().Validate("paymentID", validator.Length(i.PaymentID, 1, 30))
 }
 
+// InvoicesExpireArgs are used to expire invoices that are past their expiry date.
+type InvoicesExpireArgs struct {
+	// Now is the current time, payable invoices that expire at or before this are expired.
+	Now time.Time
+}
+
+// Payment is a transaction received against an invoice, an invoice
+// can have many payments.
+type Payment struct {
+	TxID      string      `json:"txid" db:"txid"`
+	PaymentID string      `json:"paymentID" db:"paymentID"`
+	Satoshis  uint64      `json:"satoshis" db:"satoshis"`
+	RefundTo  null.String `json:"refundTo" db:"refundTo"`
+	CreatedAt time.Time   `json:"createdAt" db:"createdAt"`
+}
+
+// PaymentCreate is used to store a payment against an invoice.
+type PaymentCreate struct {
+	TxID      string      `db:"txid"`
+	PaymentID string      `db:"paymentID"`
+	Satoshis  uint64      `db:"satoshis"`
+	RefundTo  null.String `db:"refundTo"`
+}
+
 // InvoiceService defines a service for managing invoices.
 type InvoiceService interface {
 	Invoice(ctx context.Context, args InvoiceArgs) (*Invoice, error)
 	Invoices(ctx context.Context) ([]Invoice, error)
 	Create(ctx context.Context, req InvoiceCreate) (*Invoice, error)
 	Delete(ctx context.Context, args InvoiceArgs) error
+	// Payments returns the payments received for an invoice.
+	Payments(ctx context.Context, args InvoiceArgs) ([]Payment, error)
+	// Refund will mark an invoice as refunded once its payments have been returned.
+	Refund(ctx context.Context, args InvoiceArgs) (*Invoice, error)
 }
 
 // InvoiceReaderWriter can be implemented to support storing and retrieval of invoices.
@@ -71,6 +171,9 @@ type InvoiceWriter interface {
 	// Update will update an invoice matching the provided args with the requested changes.
 	Update(ctx context.Context, args InvoiceUpdateArgs, req InvoiceUpdate) (*Invoice, error)
 	Delete(ctx context.Context, args InvoiceArgs) error
+	// InvoicesExpire will expire all payable invoices past their expiry date and release
+	// the txos reserved for them, returning the number of invoices expired.
+	InvoicesExpire(ctx context.Context, args InvoicesExpireArgs) (int64, error)
 }
 
 // InvoiceReader defines a data store used to read invoice data.
@@ -79,4 +182,6 @@ type InvoiceReader interface {
 	Invoice(ctx context.Context, args InvoiceArgs) (*Invoice, error)
 	// Invoices returns all currently stored invoices TODO: update to support search args
 	Invoices(ctx context.Context) ([]Invoice, error)
+	// Payments returns all payments received for an invoice.
+	Payments(ctx context.Context, args InvoiceArgs) ([]Payment, error)
 }
@@ -0,0 +1,109 @@
+package gopayd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestInvoice_PaidState(t *testing.T) {
+	is := is.New(t)
+	tests := map[string]struct {
+		inv         Invoice
+		state       InvoiceState
+		outstanding uint64
+		overpaid    uint64
+	}{
+		"invoice with no payments should be pending": {
+			inv:         Invoice{Satoshis: 1000},
+			state:       InvoiceStatePending,
+			outstanding: 1000,
+		}, "underpaid invoice should be partially paid": {
+			inv:         Invoice{Satoshis: 1000, SatoshisPaid: 600},
+			state:       InvoiceStatePartiallyPaid,
+			outstanding: 400,
+		}, "invoice paid in full should be paid": {
+			inv:   Invoice{Satoshis: 1000, SatoshisPaid: 1000},
+			state: InvoiceStatePaid,
+		}, "overpaid invoice should be paid": {
+			inv:      Invoice{Satoshis: 1000, SatoshisPaid: 1200},
+			state:    InvoiceStatePaid,
+			overpaid: 200,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.NewRelaxed(t)
+			is.Equal(test.inv.PaidState(), test.state)
+			is.Equal(test.inv.Outstanding(), test.outstanding)
+			is.Equal(test.inv.Overpaid(), test.overpaid)
+		})
+	}
+}
+
+func TestInvoice_Payable(t *testing.T) {
+	is := is.New(t)
+	now := time.Now().UTC()
+	tests := map[string]struct {
+		inv     Invoice
+		payable bool
+		expired bool
+	}{
+		"pending invoice should be payable": {
+			inv:     Invoice{State: InvoiceStatePending},
+			payable: true,
+		}, "partially paid invoice should be payable": {
+			inv:     Invoice{State: InvoiceStatePartiallyPaid},
+			payable: true,
+		}, "paid invoice should not be payable": {
+			inv: Invoice{State: InvoiceStatePaid},
+		}, "refunded invoice should not be payable": {
+			inv: Invoice{State: InvoiceStateRefunded},
+		}, "expired invoice should not be payable": {
+			inv:     Invoice{State: InvoiceStateExpired, ExpiresAt: null.TimeFrom(now.Add(-time.Hour))},
+			expired: true,
+		}, "pending invoice past its expiry should be expired": {
+			inv:     Invoice{State: InvoiceStatePending, ExpiresAt: null.TimeFrom(now)},
+			payable: true,
+			expired: true,
+		}, "pending invoice before its expiry should not be expired": {
+			inv:     Invoice{State: InvoiceStatePending, ExpiresAt: null.TimeFrom(now.Add(time.Hour))},
+			payable: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.NewRelaxed(t)
+			is.Equal(test.inv.Payable(), test.payable)
+			is.Equal(test.inv.Expired(now), test.expired)
+		})
+	}
+}
+
+func TestInvoiceCreate_Validate(t *testing.T) {
+	is := is.New(t)
+	tests := map[string]struct {
+		req InvoiceCreate
+		err bool
+	}{
+		"valid request should return no errors": {
+			req: InvoiceCreate{Satoshis: 1000},
+		}, "request with future expiry should return no errors": {
+			req: InvoiceCreate{Satoshis: 1000, ExpiresAt: null.TimeFrom(time.Now().Add(time.Hour))},
+		}, "request with past expiry should error": {
+			req: InvoiceCreate{Satoshis: 1000, ExpiresAt: null.TimeFrom(time.Now().Add(-time.Hour))},
+			err: true,
+		}, "request below dust should error": {
+			req: InvoiceCreate{Satoshis: 100},
+			err: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.NewRelaxed(t)
+			is.Equal(test.req.Validate().Err() != nil, test.err)
+		})
+	}
+}
@@ -1,3 +1,18 @@
 package mocks
 
 //go:generate moq -pkg mocks -out proofs_writer.go ../ ProofsWriter
+//go:generate moq -pkg mocks -out callback_token_reader.go ../ CallbackTokenReader
+//go:generate moq -pkg mocks -out transacter.go ../ Transacter
+//go:generate moq -pkg mocks -out webhook_notifier.go ../ WebhookNotifier
+//go:generate moq -pkg mocks -out webhook_reader.go ../ WebhookReader
+//go:generate moq -pkg mocks -out webhook_event_reader_writer.go ../ WebhookEventReaderWriter
+//go:generate moq -pkg mocks -out webhook_sender.go ../ WebhookSender
+//go:generate moq -pkg mocks -out private_key_reader_writer.go ../ PrivateKeyReaderWriter
+//go:generate moq -pkg mocks -out private_key_service.go ../ PrivateKeyService
+//go:generate moq -pkg mocks -out txo_reader.go ../ TxoReader
+//go:generate moq -pkg mocks -out txo_writer.go ../ TxoWriter
+//go:generate moq -pkg mocks -out payment_writer.go ../ PaymentWriter
+//go:generate moq -pkg mocks -out derivation_reader.go ../ DerivationReader
+//go:generate moq -pkg mocks -out fee_reader.go ../ FeeReader
+//go:generate moq -pkg mocks -out payment_sender.go ../ PaymentSender
+//go:generate moq -pkg mocks -out payment_host_reader_writer.go ../ PaymentHostReaderWriter
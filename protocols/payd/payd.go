@@ -2,6 +2,8 @@ package gopayd
 
 import (
 	"context"
+
+	"github.com/libsv/go-bc/spv"
 )
 
 // Transacter can be implemented to provide context based transactions.
@@ -10,3 +12,21 @@ type Transacter interface {
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
 }
+
+// Store combines the data stores payd reads from and writes to, it is
+// implemented by each of the supported databases.
+type Store interface {
+	InvoiceReaderWriter
+	PaymentWriter
+	TxoReader
+	TxoWriter
+	ProofsWriter
+	CallbackTokenReaderWriter
+	WebhookReaderWriter
+	WebhookEventReaderWriter
+	PrivateKeyReaderWriter
+	DerivationReader
+	BalanceReader
+	spv.TxStore
+	spv.MerkleProofStore
+}
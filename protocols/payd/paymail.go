@@ -4,7 +4,8 @@ import (
 	"context"
 )
 
-// P2PTransactionArgs is used to get a transaction.
+// P2PTransactionArgs is used to send a transaction, PaymentID is
+// the reference returned with the P2PDestination.
 type P2PTransactionArgs struct {
 	Alias     string
 	Domain    string
@@ -46,6 +47,14 @@ type P2PPayment struct {
 	Satoshis uint64
 }
 
+// P2PDestination contains the outputs to pay and the reference
+// to send back with the transaction.
+// https://docs.moneybutton.com/docs/paymail-07-p2p-payment-destination.html
+type P2PDestination struct {
+	Outputs   []*Output
+	Reference string
+}
+
 // PaymailReader reads paymail information from a datastore.
 type PaymailReader interface {
 	Capability(ctx context.Context, args P2PCapabilityArgs) (string, error)
@@ -54,6 +63,10 @@ type PaymailReader interface {
 // PaymailWriter writes to a paymail datastore.
 type PaymailWriter interface {
 	OutputsCreate(ctx context.Context, args P2POutputCreateArgs, req P2PPayment) ([]*Output, error)
+	// P2PDestinationCreate will request outputs and a payment reference from a paymail receiver.
+	P2PDestinationCreate(ctx context.Context, args P2POutputCreateArgs, req P2PPayment) (*P2PDestination, error)
+	// P2PTransactionSend will send a transaction paying a P2PDestination to the paymail receiver.
+	P2PTransactionSend(ctx context.Context, args P2PTransactionArgs, req P2PTransaction) error
 }
 
 // PaymailReaderWriter combines the reader and writer interfaces.
@@ -8,6 +8,8 @@ import (
 // the required satoshis and are split into the set denomination.
 // Each output should have a different locking script.
 type OutputsCreate struct {
+	// PaymentID is the invoice the outputs are created for.
+	PaymentID    string
 	Satoshis     uint64
 	Denomination uint64
 }
@@ -72,6 +72,8 @@ type CreatePaymentArgs struct {
 // SendTransactionArgs contains params required when broadcasting a tx to the network.
 type SendTransactionArgs struct {
 	TxID string
+	// CallbackToken is sent to the miners and returned with merkle proof callbacks.
+	CallbackToken string
 }
 
 // PaymentService enforces business rules when creating payments.
@@ -83,6 +85,9 @@ type PaymentService interface {
 type PaymentWriter interface {
 	// CompletePayment when implemented can store the tx and utxos as well as update the invoice as paid.
 	StoreUtxos(ctx context.Context, req CreateTransaction) (*Transaction, error)
+	// PaymentCreate will store a payment and add its satoshis to the invoice paid,
+	// returning the updated invoice.
+	PaymentCreate(ctx context.Context, req PaymentCreate) (*Invoice, error)
 }
 
 // PaymentSender will broadcast a payment to a network.
@@ -5,16 +5,25 @@ import (
 	"time"
 
 	"github.com/libsv/go-bk/bip32"
+	"gopkg.in/guregu/null.v3"
 )
 
 // PrivateKey describes a named private key.
 type PrivateKey struct {
 	// Name of the private key.
 	Name string `db:"name"`
-	// Xprv is the private key.
+	// Xprv is the private key, encrypted by the KeyEncrypter named in Encryption.
 	Xprv string `db:"xprv"`
+	// Encryption is the name of the KeyEncrypter used to encrypt Xprv, if null
+	// the key was stored before keys were encrypted and is plain text.
+	Encryption null.String `db:"encryption"`
+	// Active is set for the key new outputs are derived from, only one key is active.
+	// Inactive keys are kept so outputs derived from them can still be spent.
+	Active bool `db:"active"`
 	// CreatedAt is the date/time when the key was stored.
 	CreatedAt time.Time `db:"createdAt"`
+	// RotatedAt is the date/time when the key was replaced as the active key.
+	RotatedAt null.Time `db:"rotatedAt"`
 }
 
 // KeyArgs defines all arguments required to get a key.
@@ -23,24 +32,48 @@ type KeyArgs struct {
 	Name string `db:"name"`
 }
 
+// PrivateKeyUpdate is used to re-encrypt a stored private key.
+type PrivateKeyUpdate struct {
+	Xprv       string      `db:"xprv"`
+	Encryption null.String `db:"encryption"`
+}
+
 // PrivateKeyService can be implemented to get and create PrivateKeys.
 type PrivateKeyService interface {
+	// Setup will encrypt any plain text keys and create a new active key named
+	// keyName if there isn't an active key already.
+	Setup(ctx context.Context, keyName string) error
 	// Create will create a new private key if it doesn't exist already.
 	Create(ctx context.Context, keyName string) error
+	// Import will store an existing extended private key and make it the active key.
+	Import(ctx context.Context, keyName, xprv string) error
+	// Rotate will create a new private key and make it the active key.
+	Rotate(ctx context.Context, keyName string) error
 	// PrivateKey will return a private key.
 	PrivateKey(ctx context.Context, keyName string) (*bip32.ExtendedKey, error)
+	// ActivePrivateKey will return the name and private key new outputs should be derived from.
+	ActivePrivateKey(ctx context.Context) (string, *bip32.ExtendedKey, error)
+	// PrivateKeys will return all stored keys, the xprv of each key is not returned.
+	PrivateKeys(ctx context.Context) ([]PrivateKey, error)
 }
 
 // PrivateKeyReader reads private info from a data store.
 type PrivateKeyReader interface {
 	// PrivateKey can be used to return an existing private key.
 	PrivateKey(ctx context.Context, args KeyArgs) (*PrivateKey, error)
+	// PrivateKeyActive will return the key new outputs are derived from.
+	PrivateKeyActive(ctx context.Context) (*PrivateKey, error)
+	// PrivateKeys will return all stored private keys.
+	PrivateKeys(ctx context.Context) ([]PrivateKey, error)
 }
 
 // PrivateKeyWriter will add private key to the datastore.
 type PrivateKeyWriter interface {
-	// PrivateKeyCreate will add a new private key to the data store.
+	// PrivateKeyCreate will add a new private key to the data store. If the key
+	// is active, the current active key is rotated.
 	PrivateKeyCreate(ctx context.Context, req PrivateKey) (*PrivateKey, error)
+	// PrivateKeyUpdate will update the encrypted xprv of a key.
+	PrivateKeyUpdate(ctx context.Context, args KeyArgs, req PrivateKeyUpdate) (*PrivateKey, error)
 }
 
 // PrivateKeyReaderWriter describes a data store that can be implemented to get and store private keys.
@@ -48,3 +81,15 @@ type PrivateKeyReaderWriter interface {
 	PrivateKeyReader
 	PrivateKeyWriter
 }
+
+// KeyEncrypter is used to encrypt private keys before they're stored and decrypt them
+// when read. This can be implemented to support a key management service, by default
+// keys are encrypted using an operator supplied passphrase.
+type KeyEncrypter interface {
+	// Name identifies the encrypter, this is stored with each key.
+	Name() string
+	// Encrypt will encrypt the plaintext.
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	// Decrypt will decrypt ciphertext returned from Encrypt.
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
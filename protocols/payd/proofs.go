@@ -2,9 +2,13 @@ package gopayd
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/libsv/go-bc"
 	"github.com/libsv/go-bk/envelope"
@@ -19,6 +23,9 @@ var reTargetType = regexp.MustCompile(`^(header|hash|merkleRoot)$`)
 type ProofCreateArgs struct {
 	// TxID will be used to validate the proof envelope.
 	TxID string `json:"txId" param:"txid"`
+	// CallbackToken is sent by the miner in the Authorization header, it must
+	// match the token generated when the transaction was broadcast.
+	CallbackToken string
 }
 
 // ProofWrapper represents a mapi callback payload for a merkleproof.
@@ -80,6 +87,30 @@ func (p ProofWrapper) Validate(args ProofCreateArgs) error {
 	return vl.Err()
 }
 
+// DoubleSpendCallback is sent by mAPI to the proofs callback url when a double spend
+// of a transaction submitted with dsCheck is detected.
+type DoubleSpendCallback struct {
+	// CallbackPayload is a json encoded DoubleSpend.
+	CallbackPayload string `json:"callbackPayload"`
+	CallbackTxID    string `json:"callbackTxID"`
+	CallbackReason  string `json:"callbackReason"`
+}
+
+// IsDoubleSpend will return true if the callback reason is for a double spend,
+// doubleSpend is sent when the double spend is mined and doubleSpendAttempt
+// when it is seen in the mempool.
+func IsDoubleSpend(callbackReason string) bool {
+	r := strings.ToLower(callbackReason)
+	return r == "doublespend" || r == "doublespendattempt"
+}
+
+// DoubleSpend is the payload of a DoubleSpendCallback.
+type DoubleSpend struct {
+	DoubleSpendTxID string `json:"doubleSpendTxId"`
+	// Payload is the double spending transaction hex.
+	Payload string `json:"payload"`
+}
+
 // ProofsService enforces business rules and validation when handling merkle proofs.
 type ProofsService interface {
 	// Create will store a JSONEnvelope that contains a merkleproof. The envelope should
@@ -93,3 +124,60 @@ type ProofsWriter interface {
 	// ProofCreate can be used to persist a merkle proof in TSC format.
 	ProofCreate(ctx context.Context, req ProofWrapper) error
 }
+
+// CallbackToken is generated for each transaction we broadcast and sent to the
+// miners with it, the miners return it with merkle proof callbacks so they can
+// be authenticated. Only a hash of the token is stored.
+type CallbackToken struct {
+	TxID      string    `db:"txid"`
+	TokenHash string    `db:"tokenhash"`
+	CreatedAt time.Time `db:"createdat"`
+}
+
+// Legacy will return true if the transaction was broadcast before callback tokens
+// were introduced, these have no token hash and their callbacks aren't authenticated.
+func (c CallbackToken) Legacy() bool {
+	return c.TokenHash == ""
+}
+
+// Matches will return true if token hashes to the stored token hash.
+func (c CallbackToken) Matches(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(CallbackTokenHash(token)), []byte(c.TokenHash)) == 1
+}
+
+// CallbackTokenHash returns the hex encoded sha256 hash of a callback token.
+func CallbackTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CallbackTokenArgs identifies a callback token.
+type CallbackTokenArgs struct {
+	TxID string
+}
+
+// CallbackTokenCreate is used to store a callback token, if the transaction already
+// has a token it is replaced.
+type CallbackTokenCreate struct {
+	TxID      string `db:"txid"`
+	TokenHash string `db:"tokenhash"`
+}
+
+// CallbackTokenReader reads callback tokens from a data store.
+type CallbackTokenReader interface {
+	// CallbackToken will return the token for a transaction, a not found error
+	// is returned if there isn't one.
+	CallbackToken(ctx context.Context, args CallbackTokenArgs) (*CallbackToken, error)
+}
+
+// CallbackTokenWriter writes callback tokens to a data store.
+type CallbackTokenWriter interface {
+	// CallbackTokenCreate will store the token hash for a transaction.
+	CallbackTokenCreate(ctx context.Context, req CallbackTokenCreate) error
+}
+
+// CallbackTokenReaderWriter combines the reader and writer interfaces.
+type CallbackTokenReaderWriter interface {
+	CallbackTokenReader
+	CallbackTokenWriter
+}
@@ -0,0 +1,106 @@
+package gopayd
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/libsv/go-bt/v2/bscript"
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+var rePaymail = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// SendCreate is used to pay a BIP-270 payment request, a paymail or an address
+// using the unspent txos held by the wallet. Only one destination can be supplied.
+type SendCreate struct {
+	// PaymentRequestURL is the location of a BIP-270 PaymentRequest to pay, the
+	// outputs and amounts are set by the payment host.
+	PaymentRequestURL string `json:"paymentRequestURL"`
+	// Paymail is a paymail address to pay.
+	Paymail string `json:"paymail"`
+	// Address is a P2PKH address to pay.
+	Address string `json:"address"`
+	// Satoshis is the amount to send to a paymail or address.
+	Satoshis uint64 `json:"satoshis"`
+	// Memo is an optional note sent to the payment host or paymail receiver.
+	Memo string `json:"memo"`
+}
+
+// Validate will ensure the send request has a single destination and an amount to pay.
+func (s SendCreate) Validate() validator.ErrValidation {
+	v := validator.New().
+		Validate("destination", func() error {
+			n := 0
+			for _, d := range []string{s.PaymentRequestURL, s.Paymail, s.Address} {
+				if d != "" {
+					n++
+				}
+			}
+			if n != 1 {
+				return errors.New("one of paymentRequestURL, paymail or address should be supplied")
+			}
+			return nil
+		})
+	if s.Paymail != "" {
+		v = v.Validate("paymail", validator.MatchString(s.Paymail, rePaymail))
+	}
+	if s.Address != "" {
+		v = v.Validate("address", func() error {
+			if _, err := bscript.NewP2PKHFromAddress(s.Address); err != nil {
+				return errors.New("not a valid address")
+			}
+			return nil
+		})
+	}
+	if s.PaymentRequestURL == "" {
+		v = v.Validate("satoshis", validator.MinUInt64(s.Satoshis, 546))
+	} else {
+		v = v.Validate("satoshis", validator.MaxUInt64(s.Satoshis, 0))
+	}
+	return v
+}
+
+// Send is returned when a transaction has been sent from the wallet.
+type Send struct {
+	// TxID of the transaction sent.
+	TxID string `json:"txid"`
+	// Satoshis paid to the destination.
+	Satoshis uint64 `json:"satoshis"`
+	// Fee paid to miners.
+	Fee uint64 `json:"fee"`
+	// Change returned to the wallet.
+	Change uint64 `json:"change"`
+	// Memo is returned from the payment host when paying a PaymentRequest.
+	Memo string `json:"memo,omitempty"`
+}
+
+// SendService enforces business rules when sending payments from the wallet.
+type SendService interface {
+	// Send will build, sign and broadcast a transaction paying the destination.
+	Send(ctx context.Context, req SendCreate) (*Send, error)
+}
+
+// PaymentHostArgs identify the payment host to read from or send to.
+type PaymentHostArgs struct {
+	// URL is the PaymentRequest or Payment url.
+	URL string
+}
+
+// PaymentHostReader reads PaymentRequests from a BIP-270 payment host.
+type PaymentHostReader interface {
+	// PaymentRequest will return the PaymentRequest found at the url.
+	PaymentRequest(ctx context.Context, args PaymentHostArgs) (*PaymentRequest, error)
+}
+
+// PaymentHostWriter sends Payments to a BIP-270 payment host.
+type PaymentHostWriter interface {
+	// PaymentSend will send the payment to the host and return its PaymentACK.
+	PaymentSend(ctx context.Context, args PaymentHostArgs, req CreatePayment) (*PaymentACK, error)
+}
+
+// PaymentHostReaderWriter combines the reader and writer interfaces.
+type PaymentHostReaderWriter interface {
+	PaymentHostReader
+	PaymentHostWriter
+}
@@ -0,0 +1,48 @@
+package gopayd
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSendCreate_Validate(t *testing.T) {
+	is := is.New(t)
+	const addr = "mtdruWYVEV1wz5yL7GvpBj4MgifCB7yhPd"
+	tests := map[string]struct {
+		req SendCreate
+		err bool
+	}{
+		"address with satoshis should return no errors": {
+			req: SendCreate{Address: addr, Satoshis: 1000},
+		}, "paymail with satoshis should return no errors": {
+			req: SendCreate{Paymail: "alias@example.com", Satoshis: 1000},
+		}, "payment request without satoshis should return no errors": {
+			req: SendCreate{PaymentRequestURL: "https://example.com/api/v1/payment/abc"},
+		}, "payment request with satoshis should error": {
+			req: SendCreate{PaymentRequestURL: "https://example.com/api/v1/payment/abc", Satoshis: 1000},
+			err: true,
+		}, "no destination should error": {
+			req: SendCreate{Satoshis: 1000},
+			err: true,
+		}, "more than one destination should error": {
+			req: SendCreate{Address: addr, Paymail: "alias@example.com", Satoshis: 1000},
+			err: true,
+		}, "invalid address should error": {
+			req: SendCreate{Address: "not an address", Satoshis: 1000},
+			err: true,
+		}, "invalid paymail should error": {
+			req: SendCreate{Paymail: "alias", Satoshis: 1000},
+			err: true,
+		}, "satoshis below dust should error": {
+			req: SendCreate{Address: addr, Satoshis: 100},
+			err: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.NewRelaxed(t)
+			is.Equal(test.req.Validate().Err() != nil, test.err)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
 
 	gopayd "github.com/libsv/payd"
 	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/errcodes"
 
 	"github.com/speps/go-hashids"
 )
@@ -20,15 +24,22 @@ import (
 // This invoicing system is separate to the protocol server itself but added here
 // as a very basic example.
 type invoice struct {
-	store gopayd.InvoiceReaderWriter
-	cfg   *config.Server
+	store     gopayd.InvoiceReaderWriter
+	cfg       *config.Server
+	walletCfg *config.Wallet
+	notifier  gopayd.WebhookNotifier
+	txrunner  gopayd.Transacter
 }
 
 // NewInvoice will setup and return a new invoice service.
-func NewInvoice(cfg *config.Server, store gopayd.InvoiceReaderWriter) *invoice {
+func NewInvoice(cfg *config.Server, walletCfg *config.Wallet, store gopayd.InvoiceReaderWriter,
+	notifier gopayd.WebhookNotifier, txrunner gopayd.Transacter) *invoice {
 	return &invoice{
-		cfg:   cfg,
-		store: store}
+		cfg:       cfg,
+		walletCfg: walletCfg,
+		store:     store,
+		notifier:  notifier,
+		txrunner:  txrunner}
 }
 
 // Invoice will return an invoice by paymentID.
@@ -69,10 +80,24 @@ func (i *invoice) Create(ctx context.Context, req gopayd.InvoiceCreate) (*gopayd
 		return nil, errors.WithStack(err)
 	}
 	req.PaymentID = id
+	// invoices don't expire if the wallet has no payment expiry set.
+	if !req.ExpiresAt.Valid && i.walletCfg.PaymentExpiryHours > 0 {
+		req.ExpiresAt = null.TimeFrom(time.Now().UTC().Add(time.Hour * time.Duration(i.walletCfg.PaymentExpiryHours)))
+	}
+	ctx = i.txrunner.WithTx(ctx)
+	defer func() {
+		_ = i.txrunner.Rollback(ctx)
+	}()
 	inv, err := i.store.Create(ctx, req)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	if err := i.notifier.Notify(ctx, gopayd.WebhookEventInvoiceCreated, inv); err != nil {
+		return nil, errors.WithMessagef(err, "failed to notify webhooks of invoice %s", inv.PaymentID)
+	}
+	if err := i.txrunner.Commit(ctx); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit invoice %s", inv.PaymentID)
+	}
 	return inv, nil
 }
 
@@ -84,3 +109,46 @@ func (i *invoice) Delete(ctx context.Context, args gopayd.InvoiceArgs) error {
 	return errors.WithMessagef(i.store.Delete(ctx, args),
 		"failed to delete invoice with ID %s", args.PaymentID)
 }
+
+// Payments will return all payments received for an invoice.
+func (i *invoice) Payments(ctx context.Context, args gopayd.InvoiceArgs) ([]gopayd.Payment, error) {
+	if err := args.Validate().Err(); err != nil {
+		return nil, err
+	}
+	if _, err := i.store.Invoice(ctx, args); err != nil {
+		return nil, errors.WithMessagef(err, "failed to get invoice with id %s", args.PaymentID)
+	}
+	pp, err := i.store.Payments(ctx, args)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get payments for invoice with id %s", args.PaymentID)
+	}
+	return pp, nil
+}
+
+// Refund will mark an invoice as refunded, this should be called once the satoshis
+// paid have been returned to the customer. Once refunded an invoice can't be paid.
+func (i *invoice) Refund(ctx context.Context, args gopayd.InvoiceArgs) (*gopayd.Invoice, error) {
+	if err := args.Validate().Err(); err != nil {
+		return nil, err
+	}
+	inv, err := i.store.Invoice(ctx, args)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get invoice with id %s", args.PaymentID)
+	}
+	if inv.State == gopayd.InvoiceStateRefunded {
+		return nil, errs.NewErrDuplicate(errcodes.ErrRefundInvalid, fmt.Sprintf("invoice '%s' has already been refunded", args.PaymentID))
+	}
+	if inv.SatoshisPaid == 0 {
+		return nil, errs.NewErrUnprocessable(errcodes.ErrRefundInvalid, fmt.Sprintf("invoice '%s' has no payments to refund", args.PaymentID))
+	}
+	inv, err = i.store.Update(ctx, gopayd.InvoiceUpdateArgs{PaymentID: args.PaymentID}, gopayd.InvoiceUpdate{
+		State:             gopayd.InvoiceStateRefunded,
+		PaymentReceivedAt: inv.PaymentReceivedAt,
+		RefundTo:          inv.RefundTo,
+		RefundedAt:        null.TimeFrom(time.Now().UTC()),
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to refund invoice with id %s", args.PaymentID)
+	}
+	return inv, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// invoiceExpiry is a background job that expires invoices which haven't been paid in full
+// before their expiry date. The outputs reserved for expired invoices are released so
+// their derivation paths can be reused.
+type invoiceExpiry struct {
+	store    gopayd.InvoiceWriter
+	interval time.Duration
+}
+
+// NewInvoiceExpiry will setup and return a new invoice expiry job that checks for
+// expired invoices every interval.
+func NewInvoiceExpiry(store gopayd.InvoiceWriter, interval time.Duration) *invoiceExpiry {
+	return &invoiceExpiry{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run will expire invoices every interval until the context is cancelled.
+func (i *invoiceExpiry) Run(ctx context.Context) {
+	if i.interval <= 0 {
+		log.Info("invoice expiry interval not set, invoices will not be expired")
+		return
+	}
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := i.Expire(ctx); err != nil {
+			log.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Expire will expire all payable invoices that are past their expiry date and
+// return the number of invoices expired.
+func (i *invoiceExpiry) Expire(ctx context.Context) (int64, error) {
+	n, err := i.store.InvoicesExpire(ctx, gopayd.InvoicesExpireArgs{Now: time.Now().UTC()})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to expire invoices")
+	}
+	if n > 0 {
+		log.Infof("expired %d invoices", n)
+	}
+	return n, nil
+}
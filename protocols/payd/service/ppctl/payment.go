@@ -30,10 +30,11 @@ type payment struct {
 	sender    gopayd.PaymentSender
 	txrunner  gopayd.Transacter
 	envVerify spv.PaymentVerifier
+	notifier  gopayd.WebhookNotifier
 }
 
 // NewPayment will create and return a new payment service.
-func NewPayment(cfg *config.Wallet, store gopayd.PaymentWriter, txoRdr gopayd.TxoReader, invStore gopayd.InvoiceReaderWriter, sender gopayd.PaymentSender, txrunner gopayd.Transacter, envVerify spv.PaymentVerifier) *payment {
+func NewPayment(cfg *config.Wallet, store gopayd.PaymentWriter, txoRdr gopayd.TxoReader, invStore gopayd.InvoiceReaderWriter, sender gopayd.PaymentSender, txrunner gopayd.Transacter, envVerify spv.PaymentVerifier, notifier gopayd.WebhookNotifier) *payment {
 	return &payment{
 		cfg:       cfg,
 		store:     store,
@@ -42,6 +43,7 @@ func NewPayment(cfg *config.Wallet, store gopayd.PaymentWriter, txoRdr gopayd.Tx
 		sender:    sender,
 		txrunner:  txrunner,
 		envVerify: envVerify,
+		notifier:  notifier,
 	}
 }
 
@@ -65,8 +67,13 @@ func (p *payment) CreatePayment(ctx context.Context, args gopayd.CreatePaymentAr
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get invoice to validate output total for paymentID %s.", args.PaymentID)
 	}
-	if !inv.PaymentReceivedAt.IsZero() {
+	switch {
+	case inv.State == gopayd.InvoiceStatePaid:
 		return nil, errs.NewErrDuplicate(errcodes.ErrDuplicatePayment, fmt.Sprintf("payment already received for paymentID '%s'", args.PaymentID))
+	case inv.State == gopayd.InvoiceStateRefunded:
+		return nil, errs.NewErrUnprocessable(errcodes.ErrRefundedPayment, fmt.Sprintf("invoice '%s' has been refunded", args.PaymentID))
+	case inv.State == gopayd.InvoiceStateExpired, inv.Expired(time.Now().UTC()):
+		return nil, errs.NewErrUnprocessable(errcodes.ErrExpiredPayment, fmt.Sprintf("paymentRequest '%s' has expired, request a new payment", args.PaymentID))
 	}
 	pa := &gopayd.PaymentACK{
 		Payment: &req,
@@ -79,12 +86,13 @@ func (p *payment) CreatePayment(ctx context.Context, args gopayd.CreatePaymentAr
 	// TODO: validate the transaction inputs
 	outputTotal := uint64(0)
 	txos := make([]*gopayd.UpdateTxo, 0)
-	// iterate outputs and gather the total satoshis for our known outputs
+	// iterate outputs and gather the total satoshis for our known outputs,
+	// the amounts don't need to match the payment request as an invoice can be
+	// partially paid or overpaid.
 	for i, o := range tx.Outputs {
 		sk, err := p.txoRdr.PartialTxo(ctx, gopayd.UnspentTxoArgs{
+			PaymentID:     inv.PaymentID,
 			LockingScript: o.LockingScript.String(),
-			Satoshis:      o.Satoshis,
-			Keyname:       keyname,
 		})
 		if err != nil {
 			// script isn't known to us, could be a change utxo, skip and carry on
@@ -93,30 +101,31 @@ func (p *payment) CreatePayment(ctx context.Context, args gopayd.CreatePaymentAr
 			}
 			return nil, errors.Wrapf(err, "failed to get store output for paymentID %s", args.PaymentID)
 		}
-		// has the payment expired - createdAt of the txo is the date the paymentRequest was received
-		// so used this as our base.
-		if sk.CreatedAt.Add(time.Hour * time.Duration(p.cfg.PaymentExpiryHours)).Before(time.Now().UTC()) {
-			return nil, errs.NewErrUnprocessable(errcodes.ErrExpiredPayment, fmt.Sprintf("paymentRequest '%s' has expired, request a new payment", args.PaymentID))
-		}
 		// push new txo onto list for persistence later
 		txos = append(txos, &gopayd.UpdateTxo{
 			Outpoint:       fmt.Sprintf("%s%d", tx.TxID(), i),
 			TxID:           tx.TxID(),
 			Vout:           i,
-			KeyName:        null.StringFrom(keyname),
+			KeyName:        null.StringFrom(sk.KeyName),
 			DerivationPath: null.StringFrom(sk.DerivationPath),
 			LockingScript:  sk.LockingScript,
 			Satoshis:       o.Satoshis,
+			PaymentID:      null.StringFrom(inv.PaymentID),
 		})
 		outputTotal += o.Satoshis
 	}
-	// if it doesn't fully pay the invoice, reject it
-	if outputTotal < inv.Satoshis {
+	// if it doesn't pay the invoice anything, reject it
+	if outputTotal == 0 {
 		pa.Error = 1
-		pa.Memo = "Outputs do not fully pay invoice for paymentID " + args.PaymentID
+		pa.Memo = "Outputs do not pay invoice for paymentID " + args.PaymentID
 		return pa, nil
 	}
+	// reqCtx is used to notify webhooks of a rejected broadcast once the transaction is rolled back.
+	reqCtx := ctx
 	ctx = p.txrunner.WithTx(ctx)
+	defer func() {
+		_ = p.txrunner.Rollback(ctx)
+	}()
 	// Store utxos and set invoice to paid.
 	if _, err = p.store.StoreUtxos(ctx, gopayd.CreateTransaction{
 		PaymentID: inv.PaymentID,
@@ -129,20 +138,69 @@ func (p *payment) CreatePayment(ctx context.Context, args gopayd.CreatePaymentAr
 		pa.Memo = err.Error()
 		return nil, errors.Wrapf(err, "failed to complete payment for paymentID %s", args.PaymentID)
 	}
-	if _, err := p.invStore.Update(ctx, gopayd.InvoiceUpdateArgs{PaymentID: args.PaymentID}, gopayd.InvoiceUpdate{
-		RefundTo: req.RefundTo,
-	}); err != nil {
+	// Store the payment and update the invoice state from the new total paid.
+	inv, err = p.store.PaymentCreate(ctx, gopayd.PaymentCreate{
+		TxID:      tx.TxID(),
+		PaymentID: inv.PaymentID,
+		Satoshis:  outputTotal,
+		RefundTo:  req.RefundTo,
+	})
+	if err != nil {
+		log.Error(err)
+		pa.Error = 1
+		pa.Memo = err.Error()
+		return nil, errors.Wrapf(err, "failed to store payment for paymentID %s", args.PaymentID)
+	}
+	update := gopayd.InvoiceUpdate{
+		State:             inv.PaidState(),
+		PaymentReceivedAt: inv.PaymentReceivedAt,
+		RefundTo:          inv.RefundTo,
+	}
+	if req.RefundTo.Valid {
+		update.RefundTo = req.RefundTo
+	}
+	if update.State == gopayd.InvoiceStatePaid {
+		update.PaymentReceivedAt = null.TimeFrom(time.Now().UTC())
+	}
+	if inv, err = p.invStore.Update(ctx, gopayd.InvoiceUpdateArgs{PaymentID: args.PaymentID}, update); err != nil {
 		log.Error(err)
 		pa.Error = 1
 		pa.Memo = err.Error()
 		return nil, errors.Wrapf(err, "failed to update invoice payment for paymentID %s", args.PaymentID)
 	}
-	// Broadcast the transaction.
+	switch {
+	case inv.Outstanding() > 0:
+		pa.Memo = fmt.Sprintf("invoice %s partially paid, %d satoshis outstanding", args.PaymentID, inv.Outstanding())
+	case inv.Overpaid() > 0:
+		pa.Memo = fmt.Sprintf("invoice %s overpaid by %d satoshis", args.PaymentID, inv.Overpaid())
+	}
+	if err := p.notifier.Notify(ctx, gopayd.WebhookEventPaymentReceived, gopayd.PaymentReceivedEvent{
+		Invoice: inv,
+		Payment: gopayd.Payment{
+			TxID:      tx.TxID(),
+			PaymentID: inv.PaymentID,
+			Satoshis:  outputTotal,
+			RefundTo:  req.RefundTo,
+			CreatedAt: time.Now().UTC(),
+		},
+	}); err != nil {
+		return nil, errors.WithMessagef(err, "failed to notify webhooks of payment for paymentID %s", args.PaymentID)
+	}
+	// Broadcast the transaction, an error means no miner accepted it.
+	txEvent := gopayd.TransactionEvent{TxID: tx.TxID(), PaymentID: inv.PaymentID}
 	if err := p.sender.Send(ctx, gopayd.SendTransactionArgs{TxID: tx.TxID()}, req); err != nil {
 		log.Error(err)
 		pa.Error = 1
 		pa.Memo = err.Error()
+		_ = p.txrunner.Rollback(ctx)
+		txEvent.Error = err.Error()
+		if err := p.notifier.Notify(reqCtx, gopayd.WebhookEventTxRejected, txEvent); err != nil {
+			log.Errorf("failed to notify webhooks of rejected transaction %s: %s", tx.TxID(), err)
+		}
 		return pa, errors.Wrapf(err, "failed to send payment for paymentID %s", args.PaymentID)
 	}
+	if err := p.notifier.Notify(ctx, gopayd.WebhookEventTxAccepted, txEvent); err != nil {
+		return nil, errors.WithMessagef(err, "failed to notify webhooks of transaction %s", tx.TxID())
+	}
 	return pa, errors.WithStack(p.txrunner.Commit(ctx))
 }
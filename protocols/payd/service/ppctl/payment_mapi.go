@@ -2,23 +2,43 @@ package ppctl
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 
 	gopayd "github.com/libsv/payd"
 	"github.com/pkg/errors"
 )
 
+// callbackTokenLen is the number of random bytes in a callback token.
+const callbackTokenLen = 32
+
 type paymentMapiService struct {
-	sender gopayd.PaymentSender
+	sender   gopayd.PaymentSender
+	tokenWtr gopayd.CallbackTokenWriter
 }
 
 // NewPaymentMapiSender will setup and return a new mapi payment service.
-func NewPaymentMapiSender(sender gopayd.PaymentSender) *paymentMapiService {
-	return &paymentMapiService{sender: sender}
+func NewPaymentMapiSender(sender gopayd.PaymentSender, tokenWtr gopayd.CallbackTokenWriter) *paymentMapiService {
+	return &paymentMapiService{sender: sender, tokenWtr: tokenWtr}
 }
 
 // CreatePayment will inform the merchant of a new payment being made,
 // this payment will then be transmitted to the network and and acknowledgement sent to the user.
+//
+// A new callback token is generated for the transaction, miners must return it
+// with the merkle proof callback.
 func (p *paymentMapiService) Send(ctx context.Context, args gopayd.SendTransactionArgs, req gopayd.CreatePayment) error {
+	bb := make([]byte, callbackTokenLen)
+	if _, err := rand.Read(bb); err != nil {
+		return errors.Wrapf(err, "failed to generate callback token for txid %s", args.TxID)
+	}
+	args.CallbackToken = hex.EncodeToString(bb)
+	if err := p.tokenWtr.CallbackTokenCreate(ctx, gopayd.CallbackTokenCreate{
+		TxID:      args.TxID,
+		TokenHash: gopayd.CallbackTokenHash(args.CallbackToken),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to store callback token for txid %s", args.TxID)
+	}
 	// Broadcast the transaction.
 	return errors.WithStack(p.sender.Send(ctx, args, req))
 }
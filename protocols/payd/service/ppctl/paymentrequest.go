@@ -11,6 +11,7 @@ import (
 
 	gopayd "github.com/libsv/payd"
 	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/errcodes"
 )
 
 type paymentRequest struct {
@@ -48,11 +49,19 @@ func (p *paymentRequest) CreatePaymentRequest(ctx context.Context, args gopayd.P
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get invoice when creating payment request")
 	}
-	if !inv.PaymentReceivedAt.IsZero() {
+	now := time.Now().UTC()
+	switch {
+	case inv.State == gopayd.InvoiceStatePaid:
 		return nil, errs.NewErrDuplicate("D103", fmt.Sprintf("payment already received for paymentId %s", args.PaymentID))
+	case inv.State == gopayd.InvoiceStateRefunded:
+		return nil, errs.NewErrUnprocessable(errcodes.ErrRefundedPayment, fmt.Sprintf("invoice '%s' has been refunded", args.PaymentID))
+	case inv.State == gopayd.InvoiceStateExpired, inv.Expired(now):
+		return nil, errs.NewErrUnprocessable(errcodes.ErrExpiredPayment, fmt.Sprintf("invoice '%s' has expired", args.PaymentID))
 	}
+	// a partially paid invoice only requests the satoshis still outstanding.
 	oo, err := p.outputter.CreateOutputs(ctx, gopayd.OutputsCreate{
-		Satoshis:     inv.Satoshis,
+		PaymentID:    inv.PaymentID,
+		Satoshis:     inv.Outstanding(),
 		Denomination: 1000,
 	})
 	if err != nil {
@@ -62,11 +71,15 @@ func (p *paymentRequest) CreatePaymentRequest(ctx context.Context, args gopayd.P
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read fees when constructing payment request")
 	}
+	expiresAt := now.Add(time.Hour * time.Duration(p.walletCfg.PaymentExpiryHours))
+	if inv.ExpiresAt.Valid {
+		expiresAt = inv.ExpiresAt.Time
+	}
 	return &gopayd.PaymentRequest{
 		Network:             p.walletCfg.Network,
 		Outputs:             oo,
-		CreationTimestamp:   time.Now().UTC().Unix(),
-		ExpirationTimestamp: time.Now().Add(24 * time.Hour).UTC().Unix(),
+		CreationTimestamp:   now.Unix(),
+		ExpirationTimestamp: expiresAt.UTC().Unix(),
 		PaymentURL:          fmt.Sprintf("http://%s/api/v1/payment/%s", p.envCfg.Hostname, args.PaymentID),
 		Memo:                fmt.Sprintf("invoice %s", args.PaymentID),
 		MerchantData: &gopayd.MerchantData{
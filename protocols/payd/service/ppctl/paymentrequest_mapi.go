@@ -15,10 +15,6 @@ import (
 	"github.com/libsv/payd/config"
 )
 
-const (
-	keyname = "keyname"
-)
-
 type mapiOutputs struct {
 	privKeySvc    gopayd.PrivateKeyService
 	txoWtr        gopayd.TxoWriter
@@ -39,8 +35,8 @@ func NewMapiOutputs(env *config.Server, privKeySvc gopayd.PrivateKeyService, txo
 // its own locking script to help with privacy when payments are broadcast.
 // This is limited however, for full privacy you'd probably want a new TX per script.
 func (p *mapiOutputs) CreateOutputs(ctx context.Context, args gopayd.OutputsCreate) ([]*gopayd.Output, error) {
-	// get our master key
-	priv, err := p.privKeySvc.PrivateKey(ctx, keyname)
+	// get our active master key, outputs are derived from it.
+	keyname, priv, err := p.privKeySvc.ActivePrivateKey(ctx)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -84,6 +80,7 @@ func (p *mapiOutputs) CreateOutputs(ctx context.Context, args gopayd.OutputsCrea
 			sats = args.Denomination
 		}*/
 		txos = append(txos, &gopayd.TxoCreate{
+			PaymentID:      args.PaymentID,
 			KeyName:        keyname,
 			DerivationPath: path,
 			LockingScript:  s.String(),
@@ -45,6 +45,7 @@ func (p *paymailOutputs) CreateOutputs(ctx context.Context, args gopayd.OutputsC
 	txos := make([]*gopayd.TxoCreate, 0, len(oo))
 	for _, o := range oo {
 		txos = append(txos, &gopayd.TxoCreate{
+			PaymentID:      args.PaymentID,
 			KeyName:        p.cfg.Address,
 			DerivationPath: "paymail",
 			LockingScript:  o.Script,
@@ -2,71 +2,254 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"regexp"
+	"time"
 
+	"github.com/labstack/gommon/log"
 	"github.com/libsv/go-bk/bip32"
 	"github.com/libsv/go-bk/chaincfg"
 	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos"
+	"gopkg.in/guregu/null.v3"
 
 	gopayd "github.com/libsv/payd"
 )
 
 type privateKey struct {
 	store           gopayd.PrivateKeyReaderWriter
+	enc             gopayd.KeyEncrypter
 	useMainNet      bool
 	numericPlusTick *regexp.Regexp
 }
 
 // NewPrivateKeys will setup and return a new PrivateKey service.
-func NewPrivateKeys(store gopayd.PrivateKeyReaderWriter, useMainNet bool) *privateKey {
+// Keys are encrypted with the KeyEncrypter before being stored.
+func NewPrivateKeys(store gopayd.PrivateKeyReaderWriter, enc gopayd.KeyEncrypter, useMainNet bool) *privateKey {
 	return &privateKey{
 		store:           store,
+		enc:             enc,
 		useMainNet:      useMainNet,
 		numericPlusTick: regexp.MustCompile(`^[0-9]+'{0,1}$`),
 	}
 }
 
-// Create creates a extended private key for a keyName.
+// Setup will encrypt any keys stored in plain text and create a new active key named
+// keyName if there isn't one. If keyName is taken by an inactive key, such as the
+// well known key retired by migrations, the new key is named after keyName and the
+// current time. The active key is then read to check it can be decrypted.
+func (svc *privateKey) Setup(ctx context.Context, keyName string) error {
+	keys, err := svc.store.PrivateKeys(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get keys")
+	}
+	for _, key := range keys {
+		if key.Encryption.Valid {
+			continue
+		}
+		xprv, err := svc.encrypt(ctx, key.Xprv)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt key %s", key.Name)
+		}
+		if _, err := svc.store.PrivateKeyUpdate(ctx, gopayd.KeyArgs{Name: key.Name}, gopayd.PrivateKeyUpdate{
+			Xprv:       xprv,
+			Encryption: null.StringFrom(svc.enc.Name()),
+		}); err != nil {
+			return errors.Wrapf(err, "failed to store encrypted key %s", key.Name)
+		}
+		log.Warnf("key %s was stored in plain text and is now encrypted, consider rotating it", key.Name)
+	}
+	if _, err := svc.store.PrivateKeyActive(ctx); err != nil {
+		if !lathos.IsNotFound(err) {
+			return errors.Wrap(err, "failed to get active key")
+		}
+		if err := svc.createActive(ctx, keyName); err != nil {
+			return err
+		}
+	}
+	name, _, err := svc.ActivePrivateKey(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to read active key")
+	}
+	log.Infof("using key %s to derive outputs", name)
+	return nil
+}
+
+// Create creates a extended private key for a keyName. The key is only
+// made active if there isn't an active key already.
 func (svc *privateKey) Create(ctx context.Context, keyName string) error { // get keyname from settings in caller
+	_, err := svc.store.PrivateKey(ctx, gopayd.KeyArgs{Name: keyName})
+	if err == nil {
+		return nil
+	}
+	if !lathos.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get key %s by name", keyName)
+	}
+	_, err = svc.store.PrivateKeyActive(ctx)
+	if err != nil && !lathos.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get active key")
+	}
+	active := err != nil
+	xprv, err := svc.newMaster()
+	if err != nil {
+		return err
+	}
+	return svc.save(ctx, keyName, xprv, active)
+}
+
+// Import will validate and store an existing extended private key, the
+// imported key becomes the active key.
+func (svc *privateKey) Import(ctx context.Context, keyName, xprv string) error {
+	xKey, err := bip32.NewKeyFromString(xprv)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse extended private key")
+	}
+	if !xKey.IsPrivate() {
+		return errors.New("extended key is not a private key")
+	}
+	if xKey.Depth() != 0 {
+		return errors.New("extended key is not a master key")
+	}
+	if !xKey.IsForNet(svc.chain()) {
+		return errors.New("extended key is for a different network")
+	}
+	if err := svc.exists(ctx, keyName); err != nil {
+		return err
+	}
+	return svc.save(ctx, keyName, xKey, true)
+}
+
+// Rotate will create a new active key, the previous keys are kept
+// so outputs derived from them can still be spent.
+func (svc *privateKey) Rotate(ctx context.Context, keyName string) error {
+	if err := svc.exists(ctx, keyName); err != nil {
+		return err
+	}
+	xprv, err := svc.newMaster()
+	if err != nil {
+		return err
+	}
+	return svc.save(ctx, keyName, xprv, true)
+}
+
+// PrivateKey returns the extended private key for a keyname.
+func (svc *privateKey) PrivateKey(ctx context.Context, keyName string) (*bip32.ExtendedKey, error) {
 	key, err := svc.store.PrivateKey(ctx, gopayd.KeyArgs{Name: keyName})
 	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get key %s by name", keyName)
+	}
+	return svc.decrypt(ctx, key)
+}
+
+// ActivePrivateKey returns the name and extended private key new outputs are derived from.
+func (svc *privateKey) ActivePrivateKey(ctx context.Context) (string, *bip32.ExtendedKey, error) {
+	key, err := svc.store.PrivateKeyActive(ctx)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get active key")
+	}
+	xKey, err := svc.decrypt(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return key.Name, xKey, nil
+}
+
+// PrivateKeys returns all stored keys without their xprv.
+func (svc *privateKey) PrivateKeys(ctx context.Context) ([]gopayd.PrivateKey, error) {
+	keys, err := svc.store.PrivateKeys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get keys")
+	}
+	for i := range keys {
+		keys[i].Xprv = ""
+	}
+	return keys, nil
+}
+
+func (svc *privateKey) createActive(ctx context.Context, keyName string) error {
+	_, err := svc.store.PrivateKey(ctx, gopayd.KeyArgs{Name: keyName})
+	switch {
+	case err == nil:
+		name := fmt.Sprintf("%s-%s", keyName, time.Now().UTC().Format("20060102150405"))
+		log.Warnf("key %s is not active, creating key %s", keyName, name)
+		keyName = name
+	case !lathos.IsNotFound(err):
 		return errors.Wrapf(err, "failed to get key %s by name", keyName)
 	}
-	if key != nil {
-		return nil
+	return svc.Rotate(ctx, keyName)
+}
+
+func (svc *privateKey) exists(ctx context.Context, keyName string) error {
+	_, err := svc.store.PrivateKey(ctx, gopayd.KeyArgs{Name: keyName})
+	switch {
+	case err == nil:
+		return errors.Errorf("key %s already exists", keyName)
+	case !lathos.IsNotFound(err):
+		return errors.Wrapf(err, "failed to get key %s by name", keyName)
 	}
+	return nil
+}
+
+func (svc *privateKey) newMaster() (*bip32.ExtendedKey, error) {
 	seed, err := bip32.GenerateSeed(bip32.RecommendedSeedLen)
 	if err != nil {
-		return errors.Wrap(err, "failed to generate seed")
+		return nil, errors.Wrap(err, "failed to generate seed")
 	}
-	chain := &chaincfg.TestNet
+	xprv, err := bip32.NewMaster(seed, svc.chain())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create master node for given seed and chain")
+	}
+	return xprv, nil
+}
+
+func (svc *privateKey) chain() *chaincfg.Params {
 	if svc.useMainNet {
-		chain = &chaincfg.MainNet
+		return &chaincfg.MainNet
 	}
-	xprv, err := bip32.NewMaster(seed, chain)
+	return &chaincfg.TestNet
+}
+
+func (svc *privateKey) save(ctx context.Context, keyName string, xKey *bip32.ExtendedKey, active bool) error {
+	xprv, err := svc.encrypt(ctx, xKey.String())
 	if err != nil {
-		return errors.Wrap(err, "failed to create master node for given seed and chain")
+		return errors.Wrapf(err, "failed to encrypt key %s", keyName)
 	}
 	if _, err := svc.store.PrivateKeyCreate(ctx, gopayd.PrivateKey{
-		Name: keyName,
-		Xprv: xprv.String(),
+		Name:       keyName,
+		Xprv:       xprv,
+		Encryption: null.StringFrom(svc.enc.Name()),
+		Active:     active,
 	}); err != nil {
 		return errors.Wrap(err, "failed to create private key")
 	}
 	return nil
 }
 
-// PrivateKey returns the extended private key for a keyname.
-func (svc *privateKey) PrivateKey(ctx context.Context, keyName string) (*bip32.ExtendedKey, error) {
-	key, err := svc.store.PrivateKey(ctx, gopayd.KeyArgs{Name: keyName})
+func (svc *privateKey) encrypt(ctx context.Context, xprv string) (string, error) {
+	b, err := svc.enc.Encrypt(ctx, []byte(xprv))
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get key %s by name", keyName)
-	}
-	if key == nil {
-		return nil, errors.Wrap(err, "key not found")
+		return "", err
 	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
 
-	xKey, err := bip32.NewKeyFromString(key.Xprv)
+func (svc *privateKey) decrypt(ctx context.Context, key *gopayd.PrivateKey) (*bip32.ExtendedKey, error) {
+	xprv := key.Xprv
+	if key.Encryption.Valid {
+		if key.Encryption.String != svc.enc.Name() {
+			return nil, errors.Errorf("key %s is encrypted with %s, not %s", key.Name, key.Encryption.String, svc.enc.Name())
+		}
+		b, err := base64.StdEncoding.DecodeString(key.Xprv)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode key %s", key.Name)
+		}
+		if b, err = svc.enc.Decrypt(ctx, b); err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt key %s", key.Name)
+		}
+		xprv = string(b)
+	}
+	xKey, err := bip32.NewKeyFromString(xprv)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get extended key from xpriv")
 	}
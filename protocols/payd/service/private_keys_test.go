@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	lathos "github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/mocks"
+)
+
+func Test_PrivateKeys_Setup(t *testing.T) {
+	t.Parallel()
+	xprv := base64.StdEncoding.EncodeToString([]byte("xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"))
+	tests := map[string]struct {
+		keys   []gopayd.PrivateKey
+		active bool
+		// expName is the prefix of the name of the key expected to be created.
+		expName string
+	}{
+		"no keys should create an active key named keyName": {
+			expName: "keyname",
+		}, "retired key named keyName should create a new active key": {
+			keys: []gopayd.PrivateKey{{
+				Name:       "keyname",
+				Xprv:       xprv,
+				Encryption: null.StringFrom("test"),
+				RotatedAt:  null.TimeFrom(time.Now()),
+			}},
+			expName: "keyname-",
+		}, "active key should not create a key": {
+			keys: []gopayd.PrivateKey{{
+				Name:       "keyname",
+				Xprv:       xprv,
+				Encryption: null.StringFrom("test"),
+				Active:     true,
+			}},
+			active: true,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			keys := test.keys
+			var created []gopayd.PrivateKey
+			find := func(fn func(k gopayd.PrivateKey) bool) (*gopayd.PrivateKey, error) {
+				for _, k := range append(keys, created...) {
+					if fn(k) {
+						return &k, nil
+					}
+				}
+				return nil, lathos.NewErrNotFound("N0001", "key not found")
+			}
+			store := &mocks.PrivateKeyReaderWriterMock{
+				PrivateKeysFunc: func(ctx context.Context) ([]gopayd.PrivateKey, error) {
+					return keys, nil
+				},
+				PrivateKeyFunc: func(ctx context.Context, args gopayd.KeyArgs) (*gopayd.PrivateKey, error) {
+					return find(func(k gopayd.PrivateKey) bool { return k.Name == args.Name })
+				},
+				PrivateKeyActiveFunc: func(ctx context.Context) (*gopayd.PrivateKey, error) {
+					if test.active {
+						return &keys[0], nil
+					}
+					return find(func(k gopayd.PrivateKey) bool { return k.Active })
+				},
+				PrivateKeyCreateFunc: func(ctx context.Context, req gopayd.PrivateKey) (*gopayd.PrivateKey, error) {
+					created = append(created, req)
+					return &req, nil
+				},
+			}
+			svc := NewPrivateKeys(store, testEncrypter{}, false)
+			assert.NoError(t, svc.Setup(context.Background(), "keyname"))
+			if test.expName == "" {
+				assert.Empty(t, created)
+				return
+			}
+			if !assert.Len(t, created, 1) {
+				return
+			}
+			assert.True(t, created[0].Active)
+			assert.True(t, strings.HasPrefix(created[0].Name, test.expName))
+			if test.expName == "keyname" {
+				assert.Equal(t, "keyname", created[0].Name)
+			}
+		})
+	}
+}
+
+// testEncrypter stores keys unencrypted.
+type testEncrypter struct{}
+
+func (testEncrypter) Name() string {
+	return "test"
+}
+
+func (testEncrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	return plaintext, nil
+}
+
+func (testEncrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return ciphertext, nil
+}
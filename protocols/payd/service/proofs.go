@@ -3,31 +3,54 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/libsv/go-bk/envelope"
 	"github.com/pkg/errors"
 	validator "github.com/theflyingcodr/govalidator"
+	"github.com/theflyingcodr/lathos"
+	"github.com/theflyingcodr/lathos/errs"
 
 	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/errcodes"
 )
 
 type proofs struct {
-	wtr gopayd.ProofsWriter
+	wtr      gopayd.ProofsWriter
+	tokenRdr gopayd.CallbackTokenReader
+	cfg      *config.MApi
+	notifier gopayd.WebhookNotifier
+	txrunner gopayd.Transacter
 }
 
 // NewProofsService will setup and return a new merkle proof service.
-func NewProofsService(wtr gopayd.ProofsWriter) *proofs {
+func NewProofsService(wtr gopayd.ProofsWriter, tokenRdr gopayd.CallbackTokenReader, cfg *config.MApi,
+	notifier gopayd.WebhookNotifier, txrunner gopayd.Transacter) *proofs {
 	return &proofs{
-		wtr: wtr,
+		wtr:      wtr,
+		tokenRdr: tokenRdr,
+		cfg:      cfg,
+		notifier: notifier,
+		txrunner: txrunner,
 	}
 }
 
 // Create will add a merkle proof to a data store for persistent storage once it has
 // been validated.
+//
+// The callback token must match the token sent when the transaction was broadcast and,
+// if minerIds are configured, the envelope must be signed by one of them.
+//
+// mAPI also reports double spends to the proofs callback, these aren't stored but
+// webhooks are notified of them.
 func (p *proofs) Create(ctx context.Context, args gopayd.ProofCreateArgs, req envelope.JSONEnvelope) error {
-	var proof *gopayd.ProofWrapper
-	if err := json.Unmarshal([]byte(req.Payload), &proof); err != nil {
-		return errors.Wrap(err, "failed to unmarshall JSONEnvelope")
+	if err := p.authenticate(ctx, args); err != nil {
+		return err
+	}
+	if err := p.verifyMinerID(req); err != nil {
+		return err
 	}
 	if err := validator.New().Validate("jsonEnvelope", func() error {
 		if ok, err := req.IsValid(); !ok || err != nil {
@@ -37,11 +60,102 @@ func (p *proofs) Create(ctx context.Context, args gopayd.ProofCreateArgs, req en
 	}).Err(); err != nil {
 		return err
 	}
+	var callback struct {
+		CallbackReason string `json:"callbackReason"`
+	}
+	if err := json.Unmarshal([]byte(req.Payload), &callback); err != nil {
+		return errors.Wrap(err, "failed to unmarshall JSONEnvelope")
+	}
+	if gopayd.IsDoubleSpend(callback.CallbackReason) {
+		return p.doubleSpend(ctx, args, req)
+	}
+	var proof *gopayd.ProofWrapper
+	if err := json.Unmarshal([]byte(req.Payload), &proof); err != nil {
+		return errors.Wrap(err, "failed to unmarshall JSONEnvelope")
+	}
 	if err := proof.Validate(args); err != nil {
 		return err
 	}
+	ctx = p.txrunner.WithTx(ctx)
+	defer func() {
+		_ = p.txrunner.Rollback(ctx)
+	}()
 	if err := p.wtr.ProofCreate(ctx, *proof); err != nil {
 		return errors.Wrap(err, "failed to save proof")
 	}
+	if err := p.notifier.Notify(ctx, gopayd.WebhookEventProofStored, gopayd.ProofStoredEvent{
+		TxID:        args.TxID,
+		BlockHash:   proof.BlockHash,
+		BlockHeight: proof.BlockHeight,
+	}); err != nil {
+		return errors.WithMessagef(err, "failed to notify webhooks of proof for txid %s", args.TxID)
+	}
+	return errors.Wrap(p.txrunner.Commit(ctx), "failed to commit proof")
+}
+
+// doubleSpend will notify webhooks of a double spend reported by a miner.
+func (p *proofs) doubleSpend(ctx context.Context, args gopayd.ProofCreateArgs, req envelope.JSONEnvelope) error {
+	var callback gopayd.DoubleSpendCallback
+	if err := json.Unmarshal([]byte(req.Payload), &callback); err != nil {
+		return errors.Wrap(err, "failed to unmarshall double spend callback")
+	}
+	var ds gopayd.DoubleSpend
+	if err := validator.New().
+		Validate("callbackTxID", func() error {
+			if callback.CallbackTxID != args.TxID {
+				return fmt.Errorf("double spend txid does not match expected txid %s", args.TxID)
+			}
+			return nil
+		}).
+		Validate("callbackPayload", func() error {
+			return errors.Wrap(json.Unmarshal([]byte(callback.CallbackPayload), &ds), "invalid double spend payload")
+		}).Err(); err != nil {
+		return err
+	}
+	return errors.WithMessagef(p.notifier.Notify(ctx, gopayd.WebhookEventDoubleSpend, gopayd.DoubleSpendEvent{
+		TxID:            args.TxID,
+		DoubleSpendTxID: ds.DoubleSpendTxID,
+		Reason:          callback.CallbackReason,
+	}), "failed to notify webhooks of double spend of txid %s", args.TxID)
+}
+
+// authenticate checks the callback token against the token stored for the transaction.
+// The same error is returned for unknown transactions so they can't be enumerated.
+//
+// Transactions broadcast before callback tokens were introduced were sent to the
+// miners without one, their callbacks are accepted without a token.
+func (p *proofs) authenticate(ctx context.Context, args gopayd.ProofCreateArgs) error {
+	token, err := p.tokenRdr.CallbackToken(ctx, gopayd.CallbackTokenArgs{TxID: args.TxID})
+	switch {
+	case err == nil && token.Legacy():
+		return nil
+	case args.CallbackToken == "":
+		return errs.NewErrNotAuthenticated(errcodes.ErrCallbackToken, "callback token required")
+	case lathos.IsNotFound(err):
+		return errs.NewErrNotAuthenticated(errcodes.ErrCallbackToken, "invalid callback token")
+	case err != nil:
+		return errors.Wrapf(err, "failed to read callback token for txid %s", args.TxID)
+	}
+	if !token.Matches(args.CallbackToken) {
+		return errs.NewErrNotAuthenticated(errcodes.ErrCallbackToken, "invalid callback token")
+	}
 	return nil
 }
+
+// verifyMinerID ensures the envelope is signed by a configured miner, envelopes
+// aren't checked if no minerIds are configured.
+func (p *proofs) verifyMinerID(req envelope.JSONEnvelope) error {
+	minerIDs := p.cfg.MinerIDs()
+	if len(minerIDs) == 0 {
+		return nil
+	}
+	if req.PublicKey == nil || req.Signature == nil {
+		return errs.NewErrNotAuthorised(errcodes.ErrMinerID, "merkle proof envelope must be signed by a miner")
+	}
+	for _, id := range minerIDs {
+		if strings.EqualFold(id, *req.PublicKey) {
+			return nil
+		}
+	}
+	return errs.NewErrNotAuthorised(errcodes.ErrMinerID, "merkle proof envelope is not signed by a known miner")
+}
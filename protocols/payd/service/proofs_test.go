@@ -8,8 +8,10 @@ import (
 	"github.com/libsv/go-bc"
 	"github.com/libsv/go-bk/envelope"
 	"github.com/stretchr/testify/assert"
+	lathos "github.com/theflyingcodr/lathos/errs"
 
 	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
 	"github.com/libsv/payd/mocks"
 )
 
@@ -19,11 +21,17 @@ func Test_Proofs_create(t *testing.T) {
 		args           gopayd.ProofCreateArgs
 		req            envelope.JSONEnvelope
 		proofsCreateFn func(ctx context.Context, req gopayd.ProofWrapper) error
-		err            error
+		minerIDs       []string
+		// minerSigned configures the key the envelope is signed with as a minerId.
+		minerSigned bool
+		// event is the webhook event expected to be sent.
+		event gopayd.WebhookEventType
+		err   error
 	}{
 		"successful run should return no errors": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -48,10 +56,12 @@ func Test_Proofs_create(t *testing.T) {
 			proofsCreateFn: func(ctx context.Context, req gopayd.ProofWrapper) error {
 				return nil
 			},
-			err: nil,
+			event: gopayd.WebhookEventProofStored,
+			err:   nil,
 		}, "mismatch txid should return error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -79,7 +89,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[callbackPayload.txOrId: txId provided in callbackPayload doesn't match expected txID 2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb], [callbackTxID: proof txid does not match expected txid 2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb]"),
 		}, "mismatch txid in proof only should return single error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -107,7 +118,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[callbackPayload.txOrId: txId provided in callbackPayload doesn't match expected txID 2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb]"),
 		}, "empty payload should return error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -126,7 +138,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[callbackPayload: value cannot be empty]"),
 		}, "invalid envelope sig should return error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -146,7 +159,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[jsonEnvelope: invalid merkleproof envelope: failed to parse json envelope signature malformed signature: too short]"),
 		}, "invalid callback reason should error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -174,7 +188,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[callbackReason: invalid callback received, should be of type merkleProof]"),
 		}, "txhex with correct txid should validate with no error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -199,9 +214,11 @@ func Test_Proofs_create(t *testing.T) {
 			proofsCreateFn: func(ctx context.Context, req gopayd.ProofWrapper) error {
 				return nil
 			},
+			event: gopayd.WebhookEventProofStored,
 		}, "invalid targetType should error": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -229,7 +246,8 @@ func Test_Proofs_create(t *testing.T) {
 			err: errors.New("[callbackPayload.targetType: value mine failed to meet requirements]"),
 		}, "error from proof create should be echoed back": {
 			args: gopayd.ProofCreateArgs{
-				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
 			},
 			req: func() envelope.JSONEnvelope {
 				e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
@@ -255,18 +273,165 @@ func Test_Proofs_create(t *testing.T) {
 				return errors.New("I failed")
 			},
 			err: errors.New("failed to save proof: I failed"),
+		}, "missing callback token should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID: "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+			},
+			req: proofEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			err: errors.New("Not authenticated: callback token required"),
+		}, "wrong callback token should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "not the token",
+			},
+			req: proofEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			err: errors.New("Not authenticated: invalid callback token"),
+		}, "callback token for unknown txid should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "3f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req: proofEnvelope(t, "3f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			err: errors.New("Not authenticated: invalid callback token"),
+		}, "transaction broadcast before callback tokens should not need a token": {
+			args: gopayd.ProofCreateArgs{
+				TxID: "4f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+			},
+			req: proofEnvelope(t, "4f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			proofsCreateFn: func(ctx context.Context, req gopayd.ProofWrapper) error {
+				return nil
+			},
+			event: gopayd.WebhookEventProofStored,
+		}, "missing callback token for unknown txid should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID: "3f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+			},
+			req: proofEnvelope(t, "3f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			err: errors.New("Not authenticated: callback token required"),
+		}, "envelope signed by unknown miner should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req:      proofEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			minerIDs: []string{"03fcfcfcd0841b0a6ed2057fa8ed404788de47ceb3390c53e79c4ecd1e05819031"},
+			err:      errors.New("Permission denied: merkle proof envelope is not signed by a known miner"),
+		}, "envelope signed by configured miner should return no errors": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req: proofEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			proofsCreateFn: func(ctx context.Context, req gopayd.ProofWrapper) error {
+				return nil
+			},
+			minerSigned: true,
+			event:       gopayd.WebhookEventProofStored,
+		}, "unsigned envelope should error when minerIds are configured": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req: func() envelope.JSONEnvelope {
+				e := proofEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca")
+				e.PublicKey = nil
+				e.Signature = nil
+				return e
+			}(),
+			minerIDs: []string{"03fcfcfcd0841b0a6ed2057fa8ed404788de47ceb3390c53e79c4ecd1e05819031"},
+			err:      errors.New("Permission denied: merkle proof envelope must be signed by a miner"),
+		}, "double spend should notify webhooks and not store a proof": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req:   doubleSpendEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca"),
+			event: gopayd.WebhookEventDoubleSpend,
+		}, "double spend for another txid should error": {
+			args: gopayd.ProofCreateArgs{
+				TxID:          "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca",
+				CallbackToken: "token",
+			},
+			req: doubleSpendEnvelope(t, "2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70cb"),
+			err: errors.New("[callbackTxID: double spend txid does not match expected txid 2f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca]"),
 		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			mockProofWrtr := &mocks.ProofsWriterMock{ProofCreateFunc: test.proofsCreateFn}
-			err := NewProofsService(mockProofWrtr).Create(context.Background(), test.args, test.req)
+			mockTokenRdr := &mocks.CallbackTokenReaderMock{
+				CallbackTokenFunc: func(ctx context.Context, args gopayd.CallbackTokenArgs) (*gopayd.CallbackToken, error) {
+					switch args.TxID {
+					case "3f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca":
+						return nil, lathos.NewErrNotFound("N0006", "not found")
+					case "4f8d0ac044aa2fd8fc7675809f5d17acac4e9bf63dd0ea4eb58f43b66ccc70ca":
+						return &gopayd.CallbackToken{TxID: args.TxID}, nil
+					}
+					return &gopayd.CallbackToken{TxID: args.TxID, TokenHash: gopayd.CallbackTokenHash("token")}, nil
+				},
+			}
+			cfg := &config.MApi{}
+			for _, id := range test.minerIDs {
+				cfg.Miners = append(cfg.Miners, &config.Miner{MinerID: id})
+			}
+			if test.minerSigned {
+				cfg.Miners = append(cfg.Miners, &config.Miner{MinerID: *test.req.PublicKey})
+			}
+			mockNotifier := &mocks.WebhookNotifierMock{
+				NotifyFunc: func(ctx context.Context, event gopayd.WebhookEventType, data interface{}) error {
+					return nil
+				},
+			}
+			mockTxRunner := &mocks.TransacterMock{
+				WithTxFunc: func(ctx context.Context) context.Context {
+					return ctx
+				},
+				CommitFunc: func(ctx context.Context) error {
+					return nil
+				},
+				RollbackFunc: func(ctx context.Context) error {
+					return nil
+				},
+			}
+			err := NewProofsService(mockProofWrtr, mockTokenRdr, cfg, mockNotifier, mockTxRunner).
+				Create(context.Background(), test.args, test.req)
 			if test.err != nil {
 				assert.Error(t, err)
 				assert.EqualError(t, err, test.err.Error())
+				assert.Empty(t, mockNotifier.NotifyCalls())
 				return
 			}
 			assert.NoError(t, err)
+			if assert.Len(t, mockNotifier.NotifyCalls(), 1) {
+				assert.Equal(t, test.event, mockNotifier.NotifyCalls()[0].Event)
+			}
 		})
 	}
 }
+
+// proofEnvelope returns a signed envelope containing a valid proof for txID.
+func proofEnvelope(t *testing.T, txID string) envelope.JSONEnvelope {
+	e, err := envelope.NewJSONEnvelope(gopayd.ProofWrapper{
+		CallbackPayload: &bc.MerkleProof{
+			TxOrID:     txID,
+			Target:     "abc123",
+			TargetType: "header",
+		},
+		BlockHash:      "abc123",
+		CallbackTxID:   txID,
+		CallbackReason: "merkleProof",
+	})
+	assert.NoError(t, err)
+	return *e
+}
+
+// doubleSpendEnvelope returns a signed envelope reporting a double spend of txID.
+func doubleSpendEnvelope(t *testing.T, txID string) envelope.JSONEnvelope {
+	e, err := envelope.NewJSONEnvelope(gopayd.DoubleSpendCallback{
+		CallbackPayload: `{"doubleSpendTxId":"8e3a9e0b8ebd0ab1dc4a8e8bcbd3c3ea0e4a8d7a7c3ab5d0a6d0c5b2e5d1a3f4"}`,
+		CallbackTxID:    txID,
+		CallbackReason:  "doubleSpend",
+	})
+	assert.NoError(t, err)
+	return *e
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/libsv/go-bc/spv"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/errcodes"
+)
+
+const (
+	// p2pkhUnlockingLen is the size of a signed p2pkh input script.
+	p2pkhUnlockingLen = 107
+	// p2pkhOutputLen is the size of a p2pkh change output.
+	p2pkhOutputLen = 34
+)
+
+// send pays BIP-270 payment requests, paymails and addresses from the txos
+// received by the wallet.
+type send struct {
+	cfg           *config.Wallet
+	privKeySvc    gopayd.PrivateKeyService
+	txoRdr        gopayd.TxoReader
+	txoWtr        gopayd.TxoWriter
+	store         gopayd.PaymentWriter
+	derivationRdr gopayd.DerivationReader
+	feeRdr        gopayd.FeeReader
+	sender        gopayd.PaymentSender
+	paymailWtr    gopayd.PaymailWriter
+	hostStore     gopayd.PaymentHostReaderWriter
+	envCreator    spv.EnvelopeCreator
+	txrunner      gopayd.Transacter
+	notifier      gopayd.WebhookNotifier
+}
+
+// NewSend will setup and return a new send service.
+func NewSend(cfg *config.Wallet, privKeySvc gopayd.PrivateKeyService, txoRdr gopayd.TxoReader, txoWtr gopayd.TxoWriter, store gopayd.PaymentWriter,
+	derivationRdr gopayd.DerivationReader, feeRdr gopayd.FeeReader, sender gopayd.PaymentSender, paymailWtr gopayd.PaymailWriter,
+	hostStore gopayd.PaymentHostReaderWriter, envCreator spv.EnvelopeCreator, txrunner gopayd.Transacter, notifier gopayd.WebhookNotifier) *send {
+	return &send{
+		cfg:           cfg,
+		privKeySvc:    privKeySvc,
+		txoRdr:        txoRdr,
+		txoWtr:        txoWtr,
+		store:         store,
+		derivationRdr: derivationRdr,
+		feeRdr:        feeRdr,
+		sender:        sender,
+		paymailWtr:    paymailWtr,
+		hostStore:     hostStore,
+		envCreator:    envCreator,
+		txrunner:      txrunner,
+		notifier:      notifier,
+	}
+}
+
+// Send will build a transaction paying the destination, spending the largest txos first
+// and returning change to a new output derived from the active key.
+//
+// The txos are reserved by marking them as spent and storing the new transaction before
+// it is sent, so no db transaction is held open during the network calls. The reservation
+// is released if the payment host rejects the payment or, for paymails and addresses,
+// if the broadcast fails.
+func (s *send) Send(ctx context.Context, req gopayd.SendCreate) (*gopayd.Send, error) {
+	if err := req.Validate().Err(); err != nil {
+		return nil, err
+	}
+	var (
+		outputs []*gopayd.Output
+		fq      *bt.FeeQuote
+		pr      *gopayd.PaymentRequest
+		dest    *gopayd.P2PDestination
+		alias   string
+		domain  string
+		err     error
+	)
+	switch {
+	case req.PaymentRequestURL != "":
+		if pr, err = s.paymentRequest(ctx, req.PaymentRequestURL); err != nil {
+			return nil, err
+		}
+		outputs, fq = pr.Outputs, pr.FeeRate
+	case req.Paymail != "":
+		parts := strings.SplitN(req.Paymail, "@", 2)
+		alias, domain = parts[0], parts[1]
+		if dest, err = s.paymailWtr.P2PDestinationCreate(ctx, gopayd.P2POutputCreateArgs{
+			Domain: domain,
+			Alias:  alias,
+		}, gopayd.P2PPayment{Satoshis: req.Satoshis}); err != nil {
+			return nil, errors.Wrapf(err, "failed to get payment destination for %s", req.Paymail)
+		}
+		outputs = dest.Outputs
+	default:
+		script, err := bscript.NewP2PKHFromAddress(req.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create script for address %s", req.Address)
+		}
+		outputs = []*gopayd.Output{{Amount: req.Satoshis, Script: script.String()}}
+	}
+	if fq == nil {
+		if fq, err = s.feeRdr.Fees(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to get fees")
+		}
+	}
+	tx, spent, err := s.fund(ctx, outputs, fq)
+	if err != nil {
+		return nil, err
+	}
+	change, err := s.change(ctx, tx, len(outputs), fq)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, tx, spent); err != nil {
+		return nil, err
+	}
+	resp := &gopayd.Send{
+		TxID:     tx.TxID(),
+		Satoshis: tx.TotalOutputSatoshis(),
+		Fee:      tx.TotalInputSatoshis() - tx.TotalOutputSatoshis(),
+	}
+	payment := gopayd.CreatePayment{
+		Transaction: tx.String(),
+		Memo:        req.Memo,
+	}
+	if pr != nil {
+		if pr.MerchantData != nil {
+			payment.MerchantData = *pr.MerchantData
+		}
+		if payment.SPVEnvelope, err = s.envCreator.CreateEnvelope(ctx, tx); err != nil {
+			return nil, errors.Wrap(err, "failed to create spv envelope, the txos spent may not have merkle proofs yet")
+		}
+	}
+	txos := make([]*gopayd.UpdateTxo, 0, 1)
+	if change != nil {
+		change.Outpoint = fmt.Sprintf("%s%d", resp.TxID, change.Vout)
+		change.TxID = resp.TxID
+		txos = append(txos, change)
+		resp.Change = change.Satoshis
+		resp.Satoshis -= change.Satoshis
+	}
+	args := make([]gopayd.SpendTxoArgs, 0, len(spent))
+	for _, txo := range spent {
+		args = append(args, gopayd.SpendTxoArgs{Outpoint: txo.Outpoint})
+	}
+
+	if err := s.reserve(ctx, args, gopayd.CreateTransaction{
+		TxID:    resp.TxID,
+		TxHex:   payment.Transaction,
+		Outputs: txos,
+	}); err != nil {
+		return nil, err
+	}
+	sendArgs := gopayd.SendTransactionArgs{TxID: resp.TxID}
+	if pr != nil {
+		// the payment host broadcasts the transaction.
+		ack, err := s.hostStore.PaymentSend(ctx, gopayd.PaymentHostArgs{URL: pr.PaymentURL}, payment)
+		if err != nil {
+			s.release(ctx, resp.TxID)
+			return nil, errors.Wrapf(err, "failed to send payment to %s", pr.PaymentURL)
+		}
+		if ack.Error > 0 {
+			s.release(ctx, resp.TxID)
+			return nil, errs.NewErrUnprocessable(errcodes.ErrPaymentRejected,
+				fmt.Sprintf("payment rejected by payment host: %s", ack.Memo))
+		}
+		resp.Memo = ack.Memo
+		// we also send it to our miner so a merkle proof is received for the change,
+		// the payment has been accepted so the txos stay spent if this fails.
+		event, txEvent := gopayd.WebhookEventTxAccepted, gopayd.TransactionEvent{TxID: resp.TxID}
+		if err := s.sender.Send(ctx, sendArgs, payment); err != nil {
+			log.Warnf("transaction %s accepted by payment host failed to broadcast: %s", resp.TxID, err)
+			event, txEvent.Error = gopayd.WebhookEventTxRejected, err.Error()
+		}
+		if err := s.notifier.Notify(ctx, event, txEvent); err != nil {
+			log.Errorf("failed to notify webhooks of transaction %s: %s", resp.TxID, err)
+		}
+		return resp, nil
+	}
+	if err := s.sender.Send(ctx, sendArgs, payment); err != nil {
+		s.release(ctx, resp.TxID)
+		if err := s.notifier.Notify(ctx, gopayd.WebhookEventTxRejected, gopayd.TransactionEvent{
+			TxID:  resp.TxID,
+			Error: err.Error(),
+		}); err != nil {
+			log.Errorf("failed to notify webhooks of rejected transaction %s: %s", resp.TxID, err)
+		}
+		return nil, errors.Wrapf(err, "failed to broadcast transaction %s", resp.TxID)
+	}
+	if err := s.notifier.Notify(ctx, gopayd.WebhookEventTxAccepted, gopayd.TransactionEvent{TxID: resp.TxID}); err != nil {
+		log.Errorf("failed to notify webhooks of transaction %s: %s", resp.TxID, err)
+	}
+	if dest != nil {
+		if err := s.paymailWtr.P2PTransactionSend(ctx, gopayd.P2PTransactionArgs{
+			Alias:     alias,
+			Domain:    domain,
+			PaymentID: dest.Reference,
+			TxHex:     payment.Transaction,
+		}, gopayd.P2PTransaction{
+			TxHex:    payment.Transaction,
+			Metadata: gopayd.P2PTransactionMetadata{Note: req.Memo},
+		}); err != nil {
+			log.Warnf("transaction %s was broadcast but %s was not notified: %s", resp.TxID, req.Paymail, err)
+		}
+	}
+	return resp, nil
+}
+
+// reserve will mark the txos spent by req as spent and store req in a single db transaction.
+func (s *send) reserve(ctx context.Context, args []gopayd.SpendTxoArgs, req gopayd.CreateTransaction) error {
+	ctx = s.txrunner.WithTx(ctx)
+	defer func() {
+		_ = s.txrunner.Rollback(ctx)
+	}()
+	now := time.Now().UTC()
+	if err := s.txoWtr.TxosSpend(ctx, args, gopayd.SpendTxo{
+		SpentAt:      &now,
+		SpendingTxID: req.TxID,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to spend txos for transaction %s", req.TxID)
+	}
+	if _, err := s.store.StoreUtxos(ctx, req); err != nil {
+		return errors.Wrapf(err, "failed to store transaction %s", req.TxID)
+	}
+	return errors.Wrapf(s.txrunner.Commit(ctx), "failed to commit transaction %s", req.TxID)
+}
+
+// release will undo reserve for a transaction that wasn't accepted, if this fails
+// the txos stay spent and are logged so they can be released manually.
+func (s *send) release(ctx context.Context, txID string) {
+	if err := s.txoWtr.TxosRelease(ctx, gopayd.ReleaseTxosArgs{SpendingTxID: txID}); err != nil {
+		log.Errorf("failed to release txos reserved by transaction %s: %s", txID, err)
+	}
+}
+
+// paymentRequest gets and checks the payment request can be paid.
+func (s *send) paymentRequest(ctx context.Context, url string) (*gopayd.PaymentRequest, error) {
+	pr, err := s.hostStore.PaymentRequest(ctx, gopayd.PaymentHostArgs{URL: url})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get payment request from %s", url)
+	}
+	switch {
+	case pr.ExpirationTimestamp > 0 && time.Now().UTC().Unix() > pr.ExpirationTimestamp:
+		return nil, errs.NewErrUnprocessable(errcodes.ErrExpiredPayment, fmt.Sprintf("payment request %s has expired", url))
+	case pr.PaymentURL == "":
+		return nil, errs.NewErrUnprocessable(errcodes.ErrPaymentRejected, fmt.Sprintf("payment request %s has no paymentUrl", url))
+	case len(pr.Outputs) == 0:
+		return nil, errs.NewErrUnprocessable(errcodes.ErrPaymentRejected, fmt.Sprintf("payment request %s has no outputs", url))
+	}
+	if pr.FeeRate != nil {
+		// fees are divided by the bytes charged for, the payment host could send zero.
+		fee, err := pr.FeeRate.Fee(bt.FeeTypeStandard)
+		if err != nil || fee.MiningFee.Bytes <= 0 {
+			return nil, errs.NewErrUnprocessable(errcodes.ErrPaymentRejected, fmt.Sprintf("payment request %s has an invalid fee rate", url))
+		}
+	}
+	return pr, nil
+}
+
+// fund will add the outputs to a new transaction and add the largest spendable
+// txos as inputs until the outputs and fee are covered.
+func (s *send) fund(ctx context.Context, outputs []*gopayd.Output, fq *bt.FeeQuote) (*bt.Tx, []gopayd.Txo, error) {
+	tx := bt.NewTx()
+	for _, o := range outputs {
+		script, err := bscript.NewFromHexString(o.Script)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to parse output script %s", o.Script)
+		}
+		tx.AddOutput(&bt.Output{Satoshis: o.Amount, LockingScript: script})
+	}
+	txos, err := s.txoRdr.SpendableTxos(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get spendable txos")
+	}
+	spent := make([]gopayd.Txo, 0)
+	for _, txo := range txos {
+		if err := tx.From(txo.TxID, uint32(txo.Vout), txo.LockingScript, txo.Satoshis); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to add txo %s to transaction", txo.Outpoint)
+		}
+		spent = append(spent, txo)
+		fee, err := estimateFee(tx, fq)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tx.TotalInputSatoshis() >= tx.TotalOutputSatoshis()+fee {
+			return tx, spent, nil
+		}
+	}
+	return nil, nil, errs.NewErrUnprocessable(errcodes.ErrInsufficientFunds,
+		fmt.Sprintf("insufficient funds to send %d satoshis", tx.TotalOutputSatoshis()))
+}
+
+// change will add a change output derived from the active key, nil is returned
+// if the change would be dust.
+func (s *send) change(ctx context.Context, tx *bt.Tx, outputs int, fq *bt.FeeQuote) (*gopayd.UpdateTxo, error) {
+	keyName, priv, err := s.privKeySvc.ActivePrivateKey(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	path, err := s.derivationPath(ctx, keyName)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := priv.DerivePublicKeyFromPath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key for change output")
+	}
+	script, err := bscript.NewP2PKHFromPubKeyBytes(pubKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create change script")
+	}
+	if err := tx.Change(script, fq); err != nil {
+		return nil, errors.Wrap(err, "failed to add change output")
+	}
+	if tx.OutputCount() == outputs {
+		return nil, nil
+	}
+	if tx.Outputs[outputs].Satoshis < s.cfg.DustLimit {
+		tx.Outputs = tx.Outputs[:outputs]
+		return nil, nil
+	}
+	return &gopayd.UpdateTxo{
+		Vout:           outputs,
+		KeyName:        null.StringFrom(keyName),
+		DerivationPath: null.StringFrom(path),
+		LockingScript:  script.String(),
+		Satoshis:       tx.Outputs[outputs].Satoshis,
+	}, nil
+}
+
+// sign will sign each input with the key derived from the spent txo's derivation path.
+func (s *send) sign(ctx context.Context, tx *bt.Tx, spent []gopayd.Txo) error {
+	keys := map[string]*bip32.ExtendedKey{}
+	for i, txo := range spent {
+		key, ok := keys[txo.KeyName.String]
+		if !ok {
+			var err error
+			if key, err = s.privKeySvc.PrivateKey(ctx, txo.KeyName.String); err != nil {
+				return errors.Wrapf(err, "failed to get key for txo %s", txo.Outpoint)
+			}
+			keys[txo.KeyName.String] = key
+		}
+		child, err := key.DeriveChildFromPath(txo.DerivationPath.String)
+		if err != nil {
+			return errors.Wrapf(err, "failed to derive key for txo %s", txo.Outpoint)
+		}
+		privKey, err := child.ECPrivKey()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get private key for txo %s", txo.Outpoint)
+		}
+		if err := tx.Sign(ctx, &bt.LocalSigner{PrivateKey: privKey}, uint32(i), sighash.AllForkID); err != nil {
+			return errors.Wrapf(err, "failed to sign txo %s", txo.Outpoint)
+		}
+	}
+	return nil
+}
+
+// derivationPath will return a derivation path not yet used with the key.
+func (s *send) derivationPath(ctx context.Context, keyName string) (string, error) {
+	for {
+		var b [8]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", errors.Wrap(err, "failed to create seed for derivation path")
+		}
+		path := bip32.DerivePath(binary.LittleEndian.Uint64(b[:]))
+		exists, err := s.derivationRdr.DerivationPathExists(ctx, gopayd.DerivationExistsArgs{
+			KeyName: keyName,
+			Path:    path,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to check derivation path exists when creating change output")
+		}
+		if !exists {
+			return path, nil
+		}
+	}
+}
+
+// estimateFee returns the fee for tx once its inputs are signed and change is added.
+func estimateFee(tx *bt.Tx, fq *bt.FeeQuote) (uint64, error) {
+	fee, err := fq.Fee(bt.FeeTypeStandard)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get standard fee")
+	}
+	if fee.MiningFee.Bytes <= 0 {
+		return 0, errors.New("fee quote must charge for a positive number of bytes")
+	}
+	size := len(tx.Bytes()) + tx.InputCount()*p2pkhUnlockingLen + p2pkhOutputLen
+	return uint64(size * fee.MiningFee.Satoshis / fee.MiningFee.Bytes), nil
+}
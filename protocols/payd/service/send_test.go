@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/libsv/go-bc/spv"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+	"github.com/stretchr/testify/assert"
+	lathos "github.com/theflyingcodr/lathos"
+	"github.com/theflyingcodr/lathos/errs"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/errcodes"
+	"github.com/libsv/payd/mocks"
+)
+
+const (
+	sendKey1 = "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	sendKey2 = "xprv9s21ZrQH143K2beTKhLXFRWWFwH8jkwUssjk3SVTiApgmge7kNC3jhVc4NgHW8PhW2y7BCDErqnKpKuyQMjqSePPJooPJowAz5BVLThsv6c"
+)
+
+// sendMocks are the dependencies of the send service, each is set up to succeed
+// apart from the txo reader which is set by each test.
+type sendMocks struct {
+	keys     map[string]*bip32.ExtendedKey
+	keySvc   *mocks.PrivateKeyServiceMock
+	txoRdr   *mocks.TxoReaderMock
+	txoWtr   *mocks.TxoWriterMock
+	store    *mocks.PaymentWriterMock
+	fees     *mocks.FeeReaderMock
+	sender   *mocks.PaymentSenderMock
+	host     *mocks.PaymentHostReaderWriterMock
+	txrunner *mocks.TransacterMock
+	notifier *mocks.WebhookNotifierMock
+}
+
+func newSendMocks(t *testing.T) *sendMocks {
+	keys := map[string]*bip32.ExtendedKey{}
+	for name, xprv := range map[string]string{"key1": sendKey1, "key2": sendKey2} {
+		k, err := bip32.NewKeyFromString(xprv)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		keys[name] = k
+	}
+	return &sendMocks{
+		keys: keys,
+		keySvc: &mocks.PrivateKeyServiceMock{
+			ActivePrivateKeyFunc: func(ctx context.Context) (string, *bip32.ExtendedKey, error) {
+				return "key1", keys["key1"], nil
+			},
+			PrivateKeyFunc: func(ctx context.Context, keyName string) (*bip32.ExtendedKey, error) {
+				return keys[keyName], nil
+			},
+		},
+		txoRdr: &mocks.TxoReaderMock{},
+		txoWtr: &mocks.TxoWriterMock{
+			TxosSpendFunc: func(ctx context.Context, args []gopayd.SpendTxoArgs, req gopayd.SpendTxo) error {
+				return nil
+			},
+			TxosReleaseFunc: func(ctx context.Context, args gopayd.ReleaseTxosArgs) error {
+				return nil
+			},
+		},
+		store: &mocks.PaymentWriterMock{
+			StoreUtxosFunc: func(ctx context.Context, req gopayd.CreateTransaction) (*gopayd.Transaction, error) {
+				return &gopayd.Transaction{TxID: req.TxID, TxHex: req.TxHex}, nil
+			},
+		},
+		fees: &mocks.FeeReaderMock{
+			FeesFunc: func(ctx context.Context) (*bt.FeeQuote, error) {
+				return bt.NewFeeQuote(), nil
+			},
+		},
+		sender: &mocks.PaymentSenderMock{
+			SendFunc: func(ctx context.Context, args gopayd.SendTransactionArgs, req gopayd.CreatePayment) error {
+				return nil
+			},
+		},
+		host: &mocks.PaymentHostReaderWriterMock{
+			PaymentRequestFunc: func(ctx context.Context, args gopayd.PaymentHostArgs) (*gopayd.PaymentRequest, error) {
+				return &gopayd.PaymentRequest{
+					Outputs:    []*gopayd.Output{{Amount: 6000, Script: sendScript(t, keys["key2"], "1/1")}},
+					PaymentURL: "https://merchant.com/pay",
+					FeeRate:    bt.NewFeeQuote(),
+				}, nil
+			},
+			PaymentSendFunc: func(ctx context.Context, args gopayd.PaymentHostArgs, req gopayd.CreatePayment) (*gopayd.PaymentACK, error) {
+				return &gopayd.PaymentACK{Memo: "thanks"}, nil
+			},
+		},
+		txrunner: &mocks.TransacterMock{
+			WithTxFunc: func(ctx context.Context) context.Context {
+				return ctx
+			},
+			CommitFunc: func(ctx context.Context) error {
+				return nil
+			},
+			RollbackFunc: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		notifier: &mocks.WebhookNotifierMock{
+			NotifyFunc: func(ctx context.Context, event gopayd.WebhookEventType, data interface{}) error {
+				return nil
+			},
+		},
+	}
+}
+
+func (m *sendMocks) svc() *send {
+	return NewSend(&config.Wallet{DustLimit: 136}, m.keySvc, m.txoRdr, m.txoWtr, m.store, &mocks.DerivationReaderMock{
+		DerivationPathExistsFunc: func(ctx context.Context, args gopayd.DerivationExistsArgs) (bool, error) {
+			return false, nil
+		},
+	}, m.fees, m.sender, nil, m.host, envelopeCreatorFunc(func(ctx context.Context, tx *bt.Tx) (*spv.Envelope, error) {
+		return &spv.Envelope{TxID: tx.TxID(), RawTx: tx.String()}, nil
+	}), m.txrunner, m.notifier)
+}
+
+// envelopeCreatorFunc creates spv envelopes with a function.
+type envelopeCreatorFunc func(ctx context.Context, tx *bt.Tx) (*spv.Envelope, error)
+
+func (fn envelopeCreatorFunc) CreateEnvelope(ctx context.Context, tx *bt.Tx) (*spv.Envelope, error) {
+	return fn(ctx, tx)
+}
+
+// sendScript returns the p2pkh locking script for key derived at path.
+func sendScript(t *testing.T, key *bip32.ExtendedKey, path string) string {
+	pubKey, err := key.DerivePublicKeyFromPath(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	script, err := bscript.NewP2PKHFromPubKeyBytes(pubKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return script.String()
+}
+
+// sendTxo returns a txo of satoshis spendable by keyName derived at path.
+func sendTxo(t *testing.T, m *sendMocks, n int, keyName, path string, satoshis uint64) gopayd.Txo {
+	txID := fmt.Sprintf("%064x", n)
+	return gopayd.Txo{
+		Outpoint:       txID + "0",
+		TxID:           txID,
+		KeyName:        null.StringFrom(keyName),
+		DerivationPath: null.StringFrom(path),
+		LockingScript:  sendScript(t, m.keys[keyName], path),
+		Satoshis:       satoshis,
+	}
+}
+
+func sendAddress(t *testing.T) string {
+	addr, err := bscript.NewAddressFromPublicKeyHash(make([]byte, 20), true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return addr.AddressString
+}
+
+func Test_Send(t *testing.T) {
+	t.Parallel()
+	rejected := errors.New("rejected")
+	tests := map[string]struct {
+		// txos are the satoshis of the spendable txos, largest first.
+		txos  []uint64
+		req   func(t *testing.T) gopayd.SendCreate
+		setup func(m *sendMocks)
+		// spent is the number of txos expected to be spent.
+		spent int
+		// change is true if a change output is expected.
+		change bool
+		// released is true if the reserved txos should be released.
+		released bool
+		sent     bool
+		event    gopayd.WebhookEventType
+		err      func(t *testing.T, err error)
+	}{
+		"largest txos should be spent until the amount and fee are covered": {
+			txos: []uint64{5000, 3000, 1000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{Address: sendAddress(t), Satoshis: 6000}
+			},
+			spent:  2,
+			change: true,
+			sent:   true,
+			event:  gopayd.WebhookEventTxAccepted,
+		}, "dust change should be added to the fee": {
+			txos: []uint64{800},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{Address: sendAddress(t), Satoshis: 600}
+			},
+			spent: 1,
+			sent:  true,
+			event: gopayd.WebhookEventTxAccepted,
+		}, "insufficient funds should return error": {
+			txos: []uint64{1000, 500},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{Address: sendAddress(t), Satoshis: 1500}
+			},
+			err: func(t *testing.T, err error) {
+				var e errs.ErrUnprocessable
+				assert.True(t, errors.As(err, &e), "expected unprocessable error, got %v", err)
+				assert.Equal(t, errcodes.ErrInsufficientFunds, e.Code())
+			},
+		}, "txo spent by another send should return error": {
+			txos: []uint64{5000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{Address: sendAddress(t), Satoshis: 1000}
+			},
+			setup: func(m *sendMocks) {
+				m.txoWtr.TxosSpendFunc = func(ctx context.Context, args []gopayd.SpendTxoArgs, req gopayd.SpendTxo) error {
+					return errs.NewErrDuplicate(errcodes.ErrTxoSpent, "txo has already been spent")
+				}
+			},
+			spent: 1,
+			err: func(t *testing.T, err error) {
+				assert.True(t, lathos.IsDuplicate(err), "expected duplicate error, got %v", err)
+			},
+		}, "failed broadcast should release the txos": {
+			txos: []uint64{5000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{Address: sendAddress(t), Satoshis: 1000}
+			},
+			setup: func(m *sendMocks) {
+				m.sender.SendFunc = func(ctx context.Context, args gopayd.SendTransactionArgs, req gopayd.CreatePayment) error {
+					return rejected
+				}
+			},
+			spent:    1,
+			change:   true,
+			released: true,
+			sent:     true,
+			event:    gopayd.WebhookEventTxRejected,
+			err: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, rejected), "expected rejected error, got %v", err)
+			},
+		}, "payment accepted by payment host should be broadcast": {
+			txos: []uint64{5000, 3000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{PaymentRequestURL: "https://merchant.com/pr"}
+			},
+			spent:  2,
+			change: true,
+			sent:   true,
+			event:  gopayd.WebhookEventTxAccepted,
+		}, "payment rejected by payment host should release the txos": {
+			txos: []uint64{5000, 3000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{PaymentRequestURL: "https://merchant.com/pr"}
+			},
+			setup: func(m *sendMocks) {
+				m.host.PaymentSendFunc = func(ctx context.Context, args gopayd.PaymentHostArgs, req gopayd.CreatePayment) (*gopayd.PaymentACK, error) {
+					return &gopayd.PaymentACK{Error: 1, Memo: "no thanks"}, nil
+				}
+			},
+			spent:    2,
+			change:   true,
+			released: true,
+			err: func(t *testing.T, err error) {
+				var e errs.ErrUnprocessable
+				assert.True(t, errors.As(err, &e), "expected unprocessable error, got %v", err)
+				assert.Equal(t, errcodes.ErrPaymentRejected, e.Code())
+			},
+		}, "payment host error should release the txos": {
+			txos: []uint64{5000, 3000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{PaymentRequestURL: "https://merchant.com/pr"}
+			},
+			setup: func(m *sendMocks) {
+				m.host.PaymentSendFunc = func(ctx context.Context, args gopayd.PaymentHostArgs, req gopayd.CreatePayment) (*gopayd.PaymentACK, error) {
+					return nil, rejected
+				}
+			},
+			spent:    2,
+			change:   true,
+			released: true,
+			err: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, rejected), "expected rejected error, got %v", err)
+			},
+		}, "failed broadcast of payment accepted by payment host should keep the txos spent": {
+			txos: []uint64{5000, 3000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{PaymentRequestURL: "https://merchant.com/pr"}
+			},
+			setup: func(m *sendMocks) {
+				m.sender.SendFunc = func(ctx context.Context, args gopayd.SendTransactionArgs, req gopayd.CreatePayment) error {
+					return rejected
+				}
+			},
+			spent:  2,
+			change: true,
+			sent:   true,
+			event:  gopayd.WebhookEventTxRejected,
+		}, "payment request charging for zero bytes should return error": {
+			txos: []uint64{5000},
+			req: func(t *testing.T) gopayd.SendCreate {
+				return gopayd.SendCreate{PaymentRequestURL: "https://merchant.com/pr"}
+			},
+			setup: func(m *sendMocks) {
+				m.host.PaymentRequestFunc = func(ctx context.Context, args gopayd.PaymentHostArgs) (*gopayd.PaymentRequest, error) {
+					fq := bt.NewFeeQuote()
+					fq.AddQuote(bt.FeeTypeStandard, &bt.Fee{
+						FeeType:   bt.FeeTypeStandard,
+						MiningFee: bt.FeeUnit{Satoshis: 1, Bytes: 0},
+						RelayFee:  bt.FeeUnit{Satoshis: 1, Bytes: 0},
+					})
+					return &gopayd.PaymentRequest{
+						Outputs:    []*gopayd.Output{{Amount: 1000, Script: sendScript(t, m.keys["key2"], "1/1")}},
+						PaymentURL: "https://merchant.com/pay",
+						FeeRate:    fq,
+					}, nil
+				}
+			},
+			err: func(t *testing.T, err error) {
+				var e errs.ErrUnprocessable
+				assert.True(t, errors.As(err, &e), "expected unprocessable error, got %v", err)
+				assert.Equal(t, errcodes.ErrPaymentRejected, e.Code())
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			m := newSendMocks(t)
+			txos := make([]gopayd.Txo, 0, len(test.txos))
+			for i, sats := range test.txos {
+				txos = append(txos, sendTxo(t, m, i, "key1", fmt.Sprintf("0/%d", i), sats))
+			}
+			m.txoRdr.SpendableTxosFunc = func(ctx context.Context) ([]gopayd.Txo, error) {
+				return txos, nil
+			}
+			if test.setup != nil {
+				test.setup(m)
+			}
+			resp, err := m.svc().Send(context.Background(), test.req(t))
+			if test.err != nil {
+				test.err(t, err)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			spends := m.txoWtr.TxosSpendCalls()
+			if test.spent == 0 {
+				assert.Empty(t, spends)
+				return
+			}
+			if !assert.Len(t, spends, 1) {
+				return
+			}
+			assert.Len(t, spends[0].Args, test.spent)
+			for i, a := range spends[0].Args {
+				assert.Equal(t, txos[i].Outpoint, a.Outpoint)
+			}
+			txID := spends[0].Req.SpendingTxID
+
+			// the reservation is committed before any network calls are made.
+			stored := m.store.StoreUtxosCalls()
+			if len(stored) == 1 {
+				assert.Equal(t, txID, stored[0].Req.TxID)
+				assert.Equal(t, test.change, len(stored[0].Req.Outputs) == 1)
+				assert.Len(t, m.txrunner.CommitCalls(), 1)
+			}
+			if resp != nil {
+				assert.Equal(t, txID, resp.TxID)
+				assert.Equal(t, test.change, resp.Change > 0)
+				var total uint64
+				for _, txo := range txos[:test.spent] {
+					total += txo.Satoshis
+				}
+				assert.Equal(t, total, resp.Satoshis+resp.Change+resp.Fee)
+			}
+
+			released := m.txoWtr.TxosReleaseCalls()
+			if test.released {
+				if assert.Len(t, released, 1) {
+					assert.Equal(t, txID, released[0].Args.SpendingTxID)
+				}
+			} else {
+				assert.Empty(t, released)
+			}
+			assert.Equal(t, test.sent, len(m.sender.SendCalls()) == 1)
+			if test.event == "" {
+				assert.Empty(t, m.notifier.NotifyCalls())
+				return
+			}
+			if assert.Len(t, m.notifier.NotifyCalls(), 1) {
+				assert.Equal(t, test.event, m.notifier.NotifyCalls()[0].Event)
+			}
+		})
+	}
+}
+
+func Test_Send_Sign(t *testing.T) {
+	t.Parallel()
+	m := newSendMocks(t)
+	txos := []gopayd.Txo{
+		sendTxo(t, m, 1, "key1", "0/1", 3000),
+		sendTxo(t, m, 2, "key2", "5/7", 2000),
+		sendTxo(t, m, 3, "key1", "2147483647/3", 1000),
+	}
+	m.txoRdr.SpendableTxosFunc = func(ctx context.Context) ([]gopayd.Txo, error) {
+		return txos, nil
+	}
+	resp, err := m.svc().Send(context.Background(), gopayd.SendCreate{Address: sendAddress(t), Satoshis: 5500})
+	if !assert.NoError(t, err) {
+		return
+	}
+	sent := m.sender.SendCalls()
+	if !assert.Len(t, sent, 1) {
+		return
+	}
+	tx, err := bt.NewTxFromString(sent[0].Req.Transaction)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, resp.TxID, tx.TxID())
+	if !assert.Equal(t, len(txos), tx.InputCount()) {
+		return
+	}
+	// each key is only read once.
+	assert.Len(t, m.keySvc.PrivateKeyCalls(), 2)
+
+	for i, txo := range txos {
+		in := tx.Inputs[i]
+		assert.Equal(t, txo.TxID, in.PreviousTxIDStr())
+		in.PreviousTxSatoshis = txo.Satoshis
+		in.PreviousTxScript, err = bscript.NewFromHexString(txo.LockingScript)
+		assert.NoError(t, err)
+
+		parts, err := bscript.DecodeParts(*in.UnlockingScript)
+		if !assert.NoError(t, err) || !assert.Len(t, parts, 2) {
+			continue
+		}
+		want, err := m.keys[txo.KeyName.String].DerivePublicKeyFromPath(txo.DerivationPath.String)
+		assert.NoError(t, err)
+		assert.Equal(t, want, parts[1], "input %d should be signed by %s/%s", i, txo.KeyName.String, txo.DerivationPath.String)
+
+		pubKey, err := bec.ParsePubKey(parts[1], bec.S256())
+		assert.NoError(t, err)
+		sig, err := bec.ParseDERSignature(parts[0][:len(parts[0])-1], bec.S256())
+		assert.NoError(t, err)
+		hash, err := tx.CalcInputSignatureHash(uint32(i), sighash.AllForkID)
+		assert.NoError(t, err)
+		assert.True(t, sig.Verify(hash, pubKey), "input %d signature should be valid", i)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/pkg/errors"
+	"github.com/theflyingcodr/lathos"
+	"gopkg.in/guregu/null.v3"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+)
+
+const (
+	// webhookBatchSize is the most events sent each interval.
+	webhookBatchSize = 100
+	// webhookMaxBackoff caps the delay between attempts to send an event.
+	webhookMaxBackoff = 6 * time.Hour
+)
+
+// webhookDelivery is a background job that sends the events in the outbox to
+// their webhooks. Failed events are retried with an exponential backoff until
+// the configured max attempts is reached.
+//
+// Events are sent at least once, webhooks can use the X-Payd-Delivery header
+// to ignore events they have already received.
+type webhookDelivery struct {
+	whRdr    gopayd.WebhookReader
+	eventRdw gopayd.WebhookEventReaderWriter
+	sender   gopayd.WebhookSender
+	cfg      *config.Webhooks
+}
+
+// NewWebhookDelivery will setup and return a new webhook delivery job.
+func NewWebhookDelivery(whRdr gopayd.WebhookReader, eventRdw gopayd.WebhookEventReaderWriter,
+	sender gopayd.WebhookSender, cfg *config.Webhooks) *webhookDelivery {
+	return &webhookDelivery{
+		whRdr:    whRdr,
+		eventRdw: eventRdw,
+		sender:   sender,
+		cfg:      cfg,
+	}
+}
+
+// Run will send due events every interval until the context is cancelled.
+func (w *webhookDelivery) Run(ctx context.Context) {
+	if w.cfg.Interval <= 0 {
+		log.Info("webhook interval not set, webhook events will not be sent")
+		return
+	}
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Deliver(ctx); err != nil {
+			log.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver will send the events that are due and return the number delivered.
+func (w *webhookDelivery) Deliver(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	// the lease covers every event in the batch timing out, so other
+	// replicas don't send them again while this one is still working.
+	events, err := w.eventRdw.WebhookEventsDue(ctx, gopayd.WebhookEventsDueArgs{
+		Now:        now,
+		Limit:      webhookBatchSize,
+		LeaseUntil: now.Add(webhookBatchSize * w.cfg.Timeout),
+	})
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to read webhook events")
+	}
+	delivered := 0
+	for _, e := range events {
+		update, err := w.send(ctx, e, now)
+		if err != nil {
+			return delivered, err
+		}
+		if err := w.eventRdw.WebhookEventUpdate(ctx, gopayd.WebhookEventArgs{ID: e.ID}, *update); err != nil {
+			return delivered, errors.WithMessagef(err, "failed to update webhook event %s", e.ID)
+		}
+		if update.DeliveredAt.Valid {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send will post the event to its webhook and return the result of the attempt.
+func (w *webhookDelivery) send(ctx context.Context, e gopayd.WebhookEvent, now time.Time) (*gopayd.WebhookEventUpdate, error) {
+	update := &gopayd.WebhookEventUpdate{
+		Attempts:      e.Attempts + 1,
+		NextAttemptAt: e.NextAttemptAt,
+	}
+	wh, err := w.whRdr.Webhook(ctx, gopayd.WebhookArgs{ID: e.WebhookID})
+	if err != nil {
+		if !lathos.IsNotFound(err) {
+			return nil, errors.WithMessagef(err, "failed to get webhook for event %s", e.ID)
+		}
+		// the webhook has been deleted since the event was created.
+		update.FailedAt = null.TimeFrom(now)
+		update.LastError = null.StringFrom("webhook not found")
+		return update, nil
+	}
+	mac := hmac.New(sha256.New, []byte(wh.Secret))
+	_, _ = mac.Write([]byte(e.Payload))
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+	if err := w.sender.WebhookSend(ctx, gopayd.WebhookSendArgs{
+		URL:       wh.URL,
+		EventID:   e.ID,
+		Event:     e.Event,
+		Signature: hex.EncodeToString(mac.Sum(nil)),
+	}, []byte(e.Payload)); err != nil {
+		update.LastError = null.StringFrom(err.Error())
+		if update.Attempts >= w.cfg.MaxAttempts {
+			log.Warnf("giving up sending webhook event %s to %s after %d attempts: %s", e.ID, wh.URL, update.Attempts, err)
+			update.FailedAt = null.TimeFrom(now)
+			return update, nil
+		}
+		update.NextAttemptAt = now.Add(w.backoff(update.Attempts))
+		return update, nil
+	}
+	update.DeliveredAt = null.TimeFrom(now)
+	return update, nil
+}
+
+// backoff returns the delay before the next attempt, it doubles after
+// each attempt up to webhookMaxBackoff.
+func (w *webhookDelivery) backoff(attempts int) time.Duration {
+	d := w.cfg.Backoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return d
+}
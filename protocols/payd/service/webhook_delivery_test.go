@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	lathos "github.com/theflyingcodr/lathos/errs"
+
+	gopayd "github.com/libsv/payd"
+	"github.com/libsv/payd/config"
+	"github.com/libsv/payd/mocks"
+)
+
+func Test_WebhookDelivery_Deliver(t *testing.T) {
+	t.Parallel()
+	const payload = `{"id":"e1","event":"invoice.created"}`
+	tests := map[string]struct {
+		event     gopayd.WebhookEvent
+		webhookFn func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error)
+		sendErr   error
+		delivered int
+		// check asserts the update recorded for the event.
+		check func(t *testing.T, now time.Time, u gopayd.WebhookEventUpdate)
+		err   error
+	}{
+		"successful send should mark event delivered": {
+			event: gopayd.WebhookEvent{ID: "e1", WebhookID: "wh1", Payload: payload},
+			webhookFn: func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+				return &gopayd.Webhook{ID: args.ID, URL: "https://merchant.com", Secret: "secret"}, nil
+			},
+			delivered: 1,
+			check: func(t *testing.T, now time.Time, u gopayd.WebhookEventUpdate) {
+				assert.Equal(t, 1, u.Attempts)
+				assert.True(t, u.DeliveredAt.Valid)
+				assert.False(t, u.FailedAt.Valid)
+			},
+		}, "failed send should be retried with backoff": {
+			event: gopayd.WebhookEvent{ID: "e1", WebhookID: "wh1", Payload: payload, Attempts: 2},
+			webhookFn: func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+				return &gopayd.Webhook{ID: args.ID, URL: "https://merchant.com", Secret: "secret"}, nil
+			},
+			sendErr: errors.New("unexpected status code 500"),
+			check: func(t *testing.T, now time.Time, u gopayd.WebhookEventUpdate) {
+				assert.Equal(t, 3, u.Attempts)
+				assert.False(t, u.DeliveredAt.Valid)
+				assert.False(t, u.FailedAt.Valid)
+				assert.Equal(t, "unexpected status code 500", u.LastError.String)
+				// 30s doubled after each of the first 2 attempts.
+				assert.Equal(t, 2*time.Minute, u.NextAttemptAt.Sub(now))
+			},
+		}, "failed send on last attempt should mark event failed": {
+			event: gopayd.WebhookEvent{ID: "e1", WebhookID: "wh1", Payload: payload, Attempts: 4},
+			webhookFn: func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+				return &gopayd.Webhook{ID: args.ID, URL: "https://merchant.com", Secret: "secret"}, nil
+			},
+			sendErr: errors.New("unexpected status code 500"),
+			check: func(t *testing.T, now time.Time, u gopayd.WebhookEventUpdate) {
+				assert.Equal(t, 5, u.Attempts)
+				assert.True(t, u.FailedAt.Valid)
+				assert.Equal(t, "unexpected status code 500", u.LastError.String)
+			},
+		}, "event for deleted webhook should be marked failed": {
+			event: gopayd.WebhookEvent{ID: "e1", WebhookID: "wh1", Payload: payload},
+			webhookFn: func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+				return nil, lathos.NewErrNotFound("N0007", "webhook not found")
+			},
+			check: func(t *testing.T, now time.Time, u gopayd.WebhookEventUpdate) {
+				assert.True(t, u.FailedAt.Valid)
+				assert.Equal(t, "webhook not found", u.LastError.String)
+			},
+		}, "error reading webhook should be returned": {
+			event: gopayd.WebhookEvent{ID: "e1", WebhookID: "wh1", Payload: payload},
+			webhookFn: func(ctx context.Context, args gopayd.WebhookArgs) (*gopayd.Webhook, error) {
+				return nil, errors.New("db down")
+			},
+			err: errors.New("failed to get webhook for event e1: db down"),
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mockEvents := &mocks.WebhookEventReaderWriterMock{
+				WebhookEventsDueFunc: func(ctx context.Context, args gopayd.WebhookEventsDueArgs) ([]gopayd.WebhookEvent, error) {
+					return []gopayd.WebhookEvent{test.event}, nil
+				},
+				WebhookEventUpdateFunc: func(ctx context.Context, args gopayd.WebhookEventArgs, req gopayd.WebhookEventUpdate) error {
+					return nil
+				},
+			}
+			mockSender := &mocks.WebhookSenderMock{
+				WebhookSendFunc: func(ctx context.Context, args gopayd.WebhookSendArgs, payload []byte) error {
+					return test.sendErr
+				},
+			}
+			svc := NewWebhookDelivery(&mocks.WebhookReaderMock{WebhookFunc: test.webhookFn}, mockEvents, mockSender, &config.Webhooks{
+				MaxAttempts: 5,
+				Backoff:     30 * time.Second,
+				Timeout:     time.Second,
+			})
+			delivered, err := svc.Deliver(context.Background())
+			if test.err != nil {
+				assert.EqualError(t, err, test.err.Error())
+				assert.Empty(t, mockEvents.WebhookEventUpdateCalls())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.delivered, delivered)
+			now := mockEvents.WebhookEventsDueCalls()[0].Args.Now
+			if assert.Len(t, mockEvents.WebhookEventUpdateCalls(), 1) {
+				call := mockEvents.WebhookEventUpdateCalls()[0]
+				assert.Equal(t, test.event.ID, call.Args.ID)
+				test.check(t, now, call.Req)
+			}
+			for _, call := range mockSender.WebhookSendCalls() {
+				mac := hmac.New(sha256.New, []byte("secret"))
+				_, _ = mac.Write([]byte(payload))
+				assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), call.Args.Signature)
+				assert.Equal(t, test.event.ID, call.Args.EventID)
+				assert.Equal(t, payload, string(call.Payload))
+			}
+		})
+	}
+}
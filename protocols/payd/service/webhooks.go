@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// webhookSecretLen is the number of random bytes in a webhook secret.
+const webhookSecretLen = 32
+
+// webhooks manages the webhooks a merchant has registered and adds events
+// for them to the outbox, the events are sent by webhookDelivery.
+type webhooks struct {
+	store    gopayd.WebhookReaderWriter
+	eventWtr gopayd.WebhookEventWriter
+}
+
+// NewWebhooks will setup and return a new webhook service.
+func NewWebhooks(store gopayd.WebhookReaderWriter, eventWtr gopayd.WebhookEventWriter) *webhooks {
+	return &webhooks{
+		store:    store,
+		eventWtr: eventWtr,
+	}
+}
+
+// Webhooks will return all registered webhooks, secrets aren't returned.
+func (w *webhooks) Webhooks(ctx context.Context) ([]gopayd.Webhook, error) {
+	ww, err := w.store.Webhooks(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get webhooks")
+	}
+	for i := range ww {
+		ww[i].Secret = ""
+	}
+	return ww, nil
+}
+
+// Create will register a new webhook with a generated secret, the secret
+// is only returned here.
+func (w *webhooks) Create(ctx context.Context, req gopayd.WebhookCreate) (*gopayd.Webhook, error) {
+	if err := req.Validate().Err(); err != nil {
+		return nil, err
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate webhook id")
+	}
+	secret, err := randomHex(webhookSecretLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate webhook secret")
+	}
+	req.ID = id
+	req.Secret = secret
+	wh, err := w.store.WebhookCreate(ctx, req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create webhook")
+	}
+	return wh, nil
+}
+
+// Delete will remove a webhook, events waiting to be sent to it are discarded.
+func (w *webhooks) Delete(ctx context.Context, args gopayd.WebhookArgs) error {
+	if err := args.Validate().Err(); err != nil {
+		return err
+	}
+	return errors.WithMessagef(w.store.WebhookDelete(ctx, args),
+		"failed to delete webhook with ID %s", args.ID)
+}
+
+// Notify will add an event to the outbox for each webhook subscribed to it. If ctx
+// has a transaction the events are written in it, so they are only sent if the
+// change they describe is committed.
+func (w *webhooks) Notify(ctx context.Context, event gopayd.WebhookEventType, data interface{}) error {
+	ww, err := w.store.Webhooks(ctx)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get webhooks to notify of %s", event)
+	}
+	now := time.Now().UTC()
+	var events []gopayd.WebhookEventCreate
+	for _, wh := range ww {
+		if !wh.Subscribed(event) {
+			continue
+		}
+		id, err := randomHex(16)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate webhook event id")
+		}
+		bb, err := json.Marshal(gopayd.WebhookPayload{
+			ID:        id,
+			Event:     event,
+			CreatedAt: now,
+			Data:      data,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode %s event", event)
+		}
+		events = append(events, gopayd.WebhookEventCreate{
+			ID:            id,
+			WebhookID:     wh.ID,
+			Event:         event,
+			Payload:       string(bb),
+			NextAttemptAt: now,
+		})
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return errors.WithMessagef(w.eventWtr.WebhookEventsCreate(ctx, events),
+		"failed to add %s events to outbox", event)
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	bb := make([]byte, n)
+	if _, err := rand.Read(bb); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bb), nil
+}
@@ -8,11 +8,12 @@ import (
 
 // Transaction defines a single transaction.
 type Transaction struct {
-	PaymentID string    `db:"paymentid"`
-	TxID      string    `db:"txid"`
-	TxHex     string    `db:"txhex"`
-	CreatedAt time.Time `db:"createdat"`
-	Outputs   []Txo     `db:"-"`
+	// PaymentID is null for transactions sent from the wallet.
+	PaymentID null.String `db:"paymentid"`
+	TxID      string      `db:"txid"`
+	TxHex     string      `db:"txhex"`
+	CreatedAt time.Time   `db:"createdat"`
+	Outputs   []Txo       `db:"-"`
 }
 
 // Txo defines a single txo and can be returned from the data store.
@@ -32,13 +33,14 @@ type Txo struct {
 
 // CreateTransaction is used to insert a tx into the data store.
 type CreateTransaction struct {
+	// PaymentID is left empty for transactions sent from the wallet.
 	PaymentID string       `db:"paymentID"`
 	TxID      string       `db:"txid"`
 	TxHex     string       `db:"txhex"`
 	Outputs   []*UpdateTxo `db:"-"`
 }
 
-// UpdateTxo is used to update a single txo in the data store.
+// UpdateTxo is used to store a single txo received in a payment.
 type UpdateTxo struct {
 	Outpoint       string      `db:"outpoint"`
 	TxID           string      `db:"txid"`
@@ -47,6 +49,7 @@ type UpdateTxo struct {
 	DerivationPath null.String `db:"derivationpath"`
 	LockingScript  string      `db:"lockingscript"`
 	Satoshis       uint64      `db:"satoshis"`
+	PaymentID      null.String `db:"paymentid"`
 }
 
 // SpendTxo can be used to update a transaction out with information
@@ -58,7 +61,12 @@ type SpendTxo struct {
 
 // SpendTxoArgs are used to identify the transaction output to mark as spent.
 type SpendTxoArgs struct {
-	Outpoint string
+	Outpoint string `db:"outpoint"`
+}
+
+// ReleaseTxosArgs are used to identify a spend that was reserved but not broadcast.
+type ReleaseTxosArgs struct {
+	SpendingTxID string `db:"spendingtxid"`
 }
 
 // TxoArgs is used to get a single txo.
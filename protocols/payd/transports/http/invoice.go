@@ -24,6 +24,8 @@ func (i *invoice) RegisterRoutes(g *echo.Group) {
 	g.GET(RouteInvoice, i.invoice)
 	g.POST(RouteInvoices, i.create)
 	g.DELETE(RouteInvoice, i.delete)
+	g.GET(RouteInvoicePayments, i.payments)
+	g.POST(RouteInvoiceRefund, i.refund)
 }
 
 // invoices returns all invoices currently stored.
@@ -69,3 +71,29 @@ func (i *invoice) delete(e echo.Context) error {
 	}
 	return e.NoContent(http.StatusNoContent)
 }
+
+// payments returns all payments received for an invoice.
+func (i *invoice) payments(e echo.Context) error {
+	var args gopayd.InvoiceArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to parse invoice args")
+	}
+	pp, err := i.svc.Payments(e.Request().Context(), args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, pp)
+}
+
+// refund marks an invoice as refunded.
+func (i *invoice) refund(e echo.Context) error {
+	var args gopayd.InvoiceArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to parse invoice args")
+	}
+	inv, err := i.svc.Refund(e.Request().Context(), args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, inv)
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/libsv/go-bk/envelope"
@@ -31,7 +32,11 @@ func (p *proofs) create(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return errors.WithStack(err)
 	}
-	args := gopayd.ProofCreateArgs{TxID: c.Param("txid")}
+	args := gopayd.ProofCreateArgs{
+		TxID: c.Param("txid"),
+		// mAPI sends the callback token as the Authorization header.
+		CallbackToken: strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "),
+	}
 	if err := p.svc.Create(c.Request().Context(), args, req); err != nil {
 		return errors.WithStack(err)
 	}
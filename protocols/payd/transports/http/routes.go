@@ -5,10 +5,16 @@ const (
 	RoutePaymentRequest = "api/v1/payment/:paymentID"
 	RoutePayment        = "api/v1/payment/:paymentID"
 
-	RouteInvoice  = "api/v1/invoices/:paymentID"
-	RouteInvoices = "api/v1/invoices"
-	RouteBalance  = "api/v1/balance"
+	RouteInvoice         = "api/v1/invoices/:paymentID"
+	RouteInvoices        = "api/v1/invoices"
+	RouteInvoicePayments = "api/v1/invoices/:paymentID/payments"
+	RouteInvoiceRefund   = "api/v1/invoices/:paymentID/refund"
+	RouteBalance         = "api/v1/balance"
+	RouteSend            = "api/v1/send"
 
 	RouteProofs   = "api/v1/proofs/:txid"
 	RouteTxStatus = "api/v1/txstatus/:txid"
+
+	RouteWebhooks = "api/v1/webhooks"
+	RouteWebhook  = "api/v1/webhooks/:webhookID"
 )
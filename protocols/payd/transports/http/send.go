@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+type send struct {
+	svc gopayd.SendService
+}
+
+// NewSend will setup and return a send handler.
+func NewSend(svc gopayd.SendService) *send {
+	return &send{svc: svc}
+}
+
+// RegisterRoutes will hook up the routes to the echo group.
+func (s *send) RegisterRoutes(g *echo.Group) {
+	g.POST(RouteSend, s.send)
+}
+
+func (s *send) send(e echo.Context) error {
+	var req gopayd.SendCreate
+	if err := e.Bind(&req); err != nil {
+		return errors.Wrap(err, "failed to parse send req")
+	}
+	resp, err := s.svc.Send(e.Request().Context(), req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusCreated, resp)
+}
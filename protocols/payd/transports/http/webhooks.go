@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	gopayd "github.com/libsv/payd"
+)
+
+// webhooks is used by the merchant to manage the urls payd sends events to.
+type webhooks struct {
+	svc gopayd.WebhookService
+}
+
+// NewWebhooks will setup and return a new webhooks handler.
+func NewWebhooks(svc gopayd.WebhookService) *webhooks {
+	return &webhooks{svc: svc}
+}
+
+// RegisterRoutes will hook up the routes to the echo group.
+func (w *webhooks) RegisterRoutes(g *echo.Group) {
+	g.GET(RouteWebhooks, w.webhooks)
+	g.POST(RouteWebhooks, w.create)
+	g.DELETE(RouteWebhook, w.delete)
+}
+
+// webhooks returns all registered webhooks.
+func (w *webhooks) webhooks(e echo.Context) error {
+	ww, err := w.svc.Webhooks(e.Request().Context())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusOK, ww)
+}
+
+// create registers a webhook, the response contains the secret used to sign events.
+func (w *webhooks) create(e echo.Context) error {
+	var req gopayd.WebhookCreate
+	if err := e.Bind(&req); err != nil {
+		return errors.Wrap(err, "failed to parse webhook create req")
+	}
+	wh, err := w.svc.Create(e.Request().Context(), req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return e.JSON(http.StatusCreated, wh)
+}
+
+func (w *webhooks) delete(e echo.Context) error {
+	var args gopayd.WebhookArgs
+	if err := e.Bind(&args); err != nil {
+		return errors.Wrap(err, "failed to parse webhook args")
+	}
+	if err := w.svc.Delete(e.Request().Context(), args); err != nil {
+		return errors.WithStack(err)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
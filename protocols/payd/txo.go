@@ -12,17 +12,18 @@ import (
 // These are partial txos and will be further hydrated when a transaction
 // is sent spending them.
 type TxoCreate struct {
+	// PaymentID is the invoice the txo has been reserved for.
+	PaymentID      string
 	KeyName        string
 	DerivationPath string
 	LockingScript  string
 	Satoshis       uint64
 }
 
-// UnspentTxoArgs are used to located an unfulfilled txo.
+// UnspentTxoArgs are used to located an unfulfilled txo reserved for an invoice.
 type UnspentTxoArgs struct {
-	Keyname       string `db:"keyname"`
+	PaymentID     string `db:"paymentid"`
 	LockingScript string `db:"lockingscript"`
-	Satoshis      uint64 `db:"satoshis"`
 }
 
 // UnspentTxo is an unfulfilled txo not yet linked to a transaction.
@@ -41,10 +42,21 @@ type TxoWriter interface {
 	TxoCreate(ctx context.Context, req TxoCreate) error
 	// TxosCreate will add an array of partial txos to a data store.
 	TxosCreate(ctx context.Context, req []*TxoCreate) error
+	// TxosSpend will mark the txos as spent by a transaction, if any of the
+	// txos have already been spent none are updated and an error is returned.
+	TxosSpend(ctx context.Context, args []SpendTxoArgs, req SpendTxo) error
+	// TxosRelease will undo a spend that was never broadcast, the txos spent by
+	// args.SpendingTxID are marked as unspent and the spending transaction, its
+	// outputs and callback token are removed. It is only used for transactions
+	// sent from the wallet.
+	TxosRelease(ctx context.Context, args ReleaseTxosArgs) error
 }
 
 // TxoReader is used to read tx information from a data store.
 type TxoReader interface {
 	// PartialTxo will return a txo that has not tet been assigned to a transaction.
 	PartialTxo(ctx context.Context, args UnspentTxoArgs) (*UnspentTxo, error)
+	// SpendableTxos will return the txos received by the wallet that haven't been
+	// spent and were derived from a stored key.
+	SpendableTxos(ctx context.Context) ([]Txo, error)
 }
@@ -0,0 +1,307 @@
+package gopayd
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	validator "github.com/theflyingcodr/govalidator"
+	"gopkg.in/guregu/null.v3"
+)
+
+// WebhookEventType is a lifecycle event that webhooks can be notified of.
+type WebhookEventType string
+
+// Supported webhook events.
+const (
+	// WebhookEventInvoiceCreated is sent with the Invoice when it is created.
+	WebhookEventInvoiceCreated WebhookEventType = "invoice.created"
+	// WebhookEventPaymentReceived is sent with a PaymentReceivedEvent when a payment
+	// is stored against an invoice.
+	WebhookEventPaymentReceived WebhookEventType = "payment.received"
+	// WebhookEventTxAccepted is sent with a TransactionEvent when a transaction is
+	// accepted by mAPI.
+	WebhookEventTxAccepted WebhookEventType = "transaction.accepted"
+	// WebhookEventTxRejected is sent with a TransactionEvent when a transaction is
+	// rejected by mAPI.
+	WebhookEventTxRejected WebhookEventType = "transaction.rejected"
+	// WebhookEventProofStored is sent with a ProofStoredEvent when a merkle proof is stored.
+	WebhookEventProofStored WebhookEventType = "proof.stored"
+	// WebhookEventDoubleSpend is sent with a DoubleSpendEvent when a miner reports a
+	// double spend of a transaction.
+	WebhookEventDoubleSpend WebhookEventType = "transaction.doubleSpend"
+)
+
+// nolint:gochecknoglobals // read only list of events used in validation.
+var webhookEvents = map[WebhookEventType]struct{}{
+	WebhookEventInvoiceCreated:  {},
+	WebhookEventPaymentReceived: {},
+	WebhookEventTxAccepted:      {},
+	WebhookEventTxRejected:      {},
+	WebhookEventProofStored:     {},
+	WebhookEventDoubleSpend:     {},
+}
+
+// WebhookEventTypes is a list of events, it is stored as a comma separated string.
+type WebhookEventTypes []WebhookEventType
+
+// Value implements driver.Valuer.
+func (w WebhookEventTypes) Value() (driver.Value, error) {
+	ss := make([]string, 0, len(w))
+	for _, e := range w {
+		ss = append(ss, string(e))
+	}
+	return strings.Join(ss, ","), nil
+}
+
+// Scan implements sql.Scanner.
+func (w *WebhookEventTypes) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unable to scan %T into WebhookEventTypes", value)
+	}
+	*w = WebhookEventTypes{}
+	for _, e := range strings.Split(s, ",") {
+		if e != "" {
+			*w = append(*w, WebhookEventType(e))
+		}
+	}
+	return nil
+}
+
+// Webhook is a url the merchant has registered to be notified of events.
+type Webhook struct {
+	ID  string `json:"id" db:"id"`
+	URL string `json:"url" db:"url"`
+	// Secret is used to sign the events sent to the webhook, it is only
+	// returned when the webhook is created.
+	Secret string `json:"secret,omitempty" db:"secret"`
+	// Events the webhook is notified of, if empty it is notified of all events.
+	Events    WebhookEventTypes `json:"events" db:"events"`
+	CreatedAt time.Time         `json:"createdAt" db:"createdat"`
+}
+
+// Subscribed will return true if the webhook should be notified of event.
+func (w Webhook) Subscribed(event WebhookEventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookCreate is used to register a new webhook.
+type WebhookCreate struct {
+	ID     string            `json:"-" db:"id"`
+	URL    string            `json:"url" db:"url"`
+	Secret string            `json:"-" db:"secret"`
+	Events WebhookEventTypes `json:"events" db:"events"`
+}
+
+// Validate will check that WebhookCreate params match expectations.
+func (w WebhookCreate) Validate() validator.ErrValidation {
+	return validator.New().
+		Validate("url", func() error {
+			u, err := url.Parse(w.URL)
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				return errors.New("url must be an absolute http or https url")
+			}
+			return nil
+		}).
+		Validate("events", func() error {
+			for _, e := range w.Events {
+				if _, ok := webhookEvents[e]; !ok {
+					return fmt.Errorf("unknown event %s", e)
+				}
+			}
+			return nil
+		})
+}
+
+// WebhookArgs identify a webhook.
+type WebhookArgs struct {
+	ID string `param:"webhookID" db:"id"`
+}
+
+// Validate will check that webhook arguments match expectations.
+func (w *WebhookArgs) Validate() validator.ErrValidation {
+	return validator.New().Validate("webhookID", validator.NotEmpty(w.ID))
+}
+
+// WebhookPayload is the body sent to webhooks, Data depends on the event type.
+type WebhookPayload struct {
+	ID        string           `json:"id"`
+	Event     WebhookEventType `json:"event"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Data      interface{}      `json:"data"`
+}
+
+// PaymentReceivedEvent is sent when a payment is received for an invoice,
+// the invoice has the new total paid and state.
+type PaymentReceivedEvent struct {
+	Invoice *Invoice `json:"invoice"`
+	Payment Payment  `json:"payment"`
+}
+
+// TransactionEvent is sent when a transaction is broadcast.
+type TransactionEvent struct {
+	TxID string `json:"txid"`
+	// PaymentID is set when the transaction pays an invoice.
+	PaymentID string `json:"paymentID,omitempty"`
+	// Error is the reason a transaction was rejected.
+	Error string `json:"error,omitempty"`
+}
+
+// ProofStoredEvent is sent when a merkle proof is stored for a transaction.
+type ProofStoredEvent struct {
+	TxID        string `json:"txid"`
+	BlockHash   string `json:"blockHash"`
+	BlockHeight uint32 `json:"blockHeight"`
+}
+
+// DoubleSpendEvent is sent when a miner reports a double spend of a transaction.
+type DoubleSpendEvent struct {
+	TxID            string `json:"txid"`
+	DoubleSpendTxID string `json:"doubleSpendTxId"`
+	// Reason is doubleSpend if the double spend was mined and
+	// doubleSpendAttempt if it was only seen.
+	Reason string `json:"reason"`
+}
+
+// WebhookEvent is an event waiting in the outbox to be delivered to a webhook.
+type WebhookEvent struct {
+	ID        string           `db:"id"`
+	WebhookID string           `db:"webhookid"`
+	Event     WebhookEventType `db:"event"`
+	// Payload is the json encoded WebhookPayload, it is encoded once so
+	// the signature is the same each time the event is sent.
+	Payload       string      `db:"payload"`
+	Attempts      int         `db:"attempts"`
+	NextAttemptAt time.Time   `db:"nextattemptat"`
+	DeliveredAt   null.Time   `db:"deliveredat"`
+	FailedAt      null.Time   `db:"failedat"`
+	LastError     null.String `db:"lasterror"`
+	CreatedAt     time.Time   `db:"createdat"`
+}
+
+// WebhookEventCreate is used to add an event to the outbox.
+type WebhookEventCreate struct {
+	ID            string           `db:"id"`
+	WebhookID     string           `db:"webhookid"`
+	Event         WebhookEventType `db:"event"`
+	Payload       string           `db:"payload"`
+	NextAttemptAt time.Time        `db:"nextattemptat"`
+}
+
+// WebhookEventArgs identify an event in the outbox.
+type WebhookEventArgs struct {
+	ID string `db:"id"`
+}
+
+// WebhookEventsDueArgs are used to read the events waiting to be delivered.
+type WebhookEventsDueArgs struct {
+	// Now is the current time, undelivered events with a next attempt at or
+	// before this are returned.
+	Now time.Time
+	// Limit is the maximum number of events returned.
+	Limit int
+	// LeaseUntil is the next attempt at set on the returned events, so
+	// other deliverers skip them until the lease expires.
+	LeaseUntil time.Time
+}
+
+// WebhookEventUpdate records an attempt to deliver an event.
+type WebhookEventUpdate struct {
+	Attempts      int         `db:"attempts"`
+	NextAttemptAt time.Time   `db:"nextattemptat"`
+	DeliveredAt   null.Time   `db:"deliveredat"`
+	FailedAt      null.Time   `db:"failedat"`
+	LastError     null.String `db:"lasterror"`
+}
+
+// WebhookSendArgs contain the headers sent with an event.
+type WebhookSendArgs struct {
+	URL     string
+	EventID string
+	Event   WebhookEventType
+	// Signature is the hex encoded HMAC-SHA256 of the payload using the webhook secret.
+	Signature string
+}
+
+// WebhookService is used to manage the webhooks events are sent to.
+type WebhookService interface {
+	Webhooks(ctx context.Context) ([]Webhook, error)
+	// Create will register a webhook, the returned webhook contains the secret events are signed with.
+	Create(ctx context.Context, req WebhookCreate) (*Webhook, error)
+	// Delete will remove a webhook and any events waiting to be sent to it.
+	Delete(ctx context.Context, args WebhookArgs) error
+}
+
+// WebhookNotifier adds events to the outbox for each webhook subscribed to them.
+type WebhookNotifier interface {
+	// Notify will add the event to the outbox, if ctx has a transaction the event
+	// is only sent if the transaction commits.
+	Notify(ctx context.Context, event WebhookEventType, data interface{}) error
+}
+
+// WebhookReaderWriter combines the reader and writer interfaces.
+type WebhookReaderWriter interface {
+	WebhookReader
+	WebhookWriter
+}
+
+// WebhookReader reads webhooks from a data store.
+type WebhookReader interface {
+	// Webhook will return a webhook, a not found error is returned if it doesn't exist.
+	Webhook(ctx context.Context, args WebhookArgs) (*Webhook, error)
+	// Webhooks will return all webhooks, oldest first.
+	Webhooks(ctx context.Context) ([]Webhook, error)
+}
+
+// WebhookWriter writes webhooks to a data store.
+type WebhookWriter interface {
+	WebhookCreate(ctx context.Context, req WebhookCreate) (*Webhook, error)
+	// WebhookDelete will remove a webhook and its outbox events.
+	WebhookDelete(ctx context.Context, args WebhookArgs) error
+}
+
+// WebhookEventReaderWriter combines the reader and writer interfaces.
+type WebhookEventReaderWriter interface {
+	WebhookEventReader
+	WebhookEventWriter
+}
+
+// WebhookEventReader reads events from the outbox.
+type WebhookEventReader interface {
+	// WebhookEventsDue will claim and return the events that are due to be sent, oldest first.
+	// Each event is only returned to one caller until args.LeaseUntil.
+	WebhookEventsDue(ctx context.Context, args WebhookEventsDueArgs) ([]WebhookEvent, error)
+}
+
+// WebhookEventWriter writes events to the outbox.
+type WebhookEventWriter interface {
+	WebhookEventsCreate(ctx context.Context, req []WebhookEventCreate) error
+	// WebhookEventUpdate will record an attempt to send an event.
+	WebhookEventUpdate(ctx context.Context, args WebhookEventArgs, req WebhookEventUpdate) error
+}
+
+// WebhookSender sends events to webhooks.
+type WebhookSender interface {
+	// WebhookSend will post the payload to args.URL, an error is returned
+	// if the webhook doesn't respond with a 2xx status.
+	WebhookSend(ctx context.Context, args WebhookSendArgs, payload []byte) error
+}